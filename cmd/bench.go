@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/bench"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchLevels    []int
+	benchQueries   int
+	benchRequests  int
+	benchResolvers []string
+	benchDomain    string
+	benchURL       string
+	benchTimeout   int
+	benchSkipDNS   bool
+	benchSkipHTTP  bool
+)
+
+// benchRateHeadroom is the share of the measured query rate suggested for
+// --resolve-rate, leaving room for retries and slower real-world answers
+const benchRateHeadroom = 0.8
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure achievable DNS and HTTP throughput and suggest scan settings",
+	Long: `Runs synthetic resolution and HTTP probing at increasing concurrency levels and reports the queries/requests per second this machine sustains, then suggests concurrency and rate settings for real scans.
+
+By default only local servers are used, which measures the machine itself. Pass --resolvers and --url to include the network path, but only point them at resolvers and hosts you are allowed to load test.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		resolvers, err := resolver.ParseResolvers(benchResolvers)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+
+		options := bench.DefaultOptions()
+		options.Levels = benchLevels
+		options.Queries = benchQueries
+		options.Requests = benchRequests
+		options.Resolvers = resolvers
+		options.Domain = benchDomain
+		options.URL = benchURL
+		options.Timeout = time.Duration(benchTimeout) * time.Second
+
+		var suggestions []string
+
+		if !benchSkipDNS {
+			target := "local DNS server"
+			if len(resolvers) > 0 {
+				target = fmt.Sprintf("%d resolvers", len(resolvers))
+			}
+			logger.Infof("⏱  Benchmarking DNS resolution against %s...", target)
+			measurements, err := bench.Resolve(options)
+			if err != nil {
+				logger.Errorf("%v", err)
+				os.Exit(1)
+			}
+			fmt.Println(bench.FormatMeasurements("DNS Resolution", "Queries/s", measurements))
+
+			if best, ok := bench.Best(measurements); ok {
+				suggestions = append(suggestions,
+					fmt.Sprintf("--resolve-concurrency %d", best.Concurrency),
+					fmt.Sprintf("--resolve-rate %.0f", best.Rate()*benchRateHeadroom))
+			} else {
+				logger.Warnf("every DNS level exceeded the error budget; try lower --levels")
+			}
+		}
+
+		if !benchSkipHTTP {
+			target := "local HTTP server"
+			if benchURL != "" {
+				target = benchURL
+			}
+			logger.Infof("⏱  Benchmarking HTTP probing against %s...", target)
+			measurements, err := bench.HTTP(options)
+			if err != nil {
+				logger.Errorf("%v", err)
+				os.Exit(1)
+			}
+			fmt.Println(bench.FormatMeasurements("HTTP Probing", "Requests/s", measurements))
+
+			if best, ok := bench.Best(measurements); ok {
+				suggestions = append(suggestions,
+					fmt.Sprintf("--score-concurrency %d", best.Concurrency),
+					fmt.Sprintf("--probe-concurrency %d", best.Concurrency))
+			} else {
+				logger.Warnf("every HTTP level exceeded the error budget; try lower --levels")
+			}
+		}
+
+		if len(suggestions) > 0 {
+			fmt.Println("💡 Suggested settings (fastest level with under 1% errors):")
+			for _, suggestion := range suggestions {
+				fmt.Printf("   %s\n", suggestion)
+			}
+		}
+	},
+}
+
+func init() {
+	defaults := bench.DefaultOptions()
+	benchCmd.Flags().IntSliceVar(&benchLevels, "levels", defaults.Levels, "Concurrency levels to measure")
+	benchCmd.Flags().IntVar(&benchQueries, "queries", defaults.Queries, "DNS queries sent at each level")
+	benchCmd.Flags().IntVar(&benchRequests, "requests", defaults.Requests, "HTTP requests sent at each level")
+	benchCmd.Flags().StringSliceVar(&benchResolvers, "resolvers", nil, "Benchmark these resolvers instead of a local DNS server (comma-separated IPs or a file)")
+	benchCmd.Flags().StringVar(&benchDomain, "domain", defaults.Domain, "Domain under which unique names are queried from real resolvers")
+	benchCmd.Flags().StringVar(&benchURL, "url", "", "Benchmark this URL instead of a local HTTP server")
+	benchCmd.Flags().IntVar(&benchTimeout, "timeout", int(defaults.Timeout/time.Second), "Timeout in seconds for each query or request")
+	benchCmd.Flags().BoolVar(&benchSkipDNS, "skip-dns", false, "Skip the DNS benchmark")
+	benchCmd.Flags().BoolVar(&benchSkipHTTP, "skip-http", false, "Skip the HTTP benchmark")
+
+	rootCmd.AddCommand(benchCmd)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/omerimzali/subscan/pkg/diff"
+	"github.com/omerimzali/subscan/pkg/formatter"
+	"github.com/omerimzali/subscan/pkg/input"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/spf13/cobra"
+)
+
+// noColor disables colored terminal output
+var noColor bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Compare the results of two scans",
+	Long:  `Compares two scan outputs (score or probe reports in JSON, JSON Lines or CSV, resolved DNS records or plain host lists) and reports new and removed subdomains and changed attributes: status, CNAME, addresses, score and vulnerabilities.`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat
+		if format == "" {
+			format = formatter.FormatPlain
+		}
+
+		previous := readDiffHosts(args[0])
+		current := readDiffHosts(args[1])
+		d := diff.Compare(previous, current)
+
+		// Color only helps when a person reads the terminal
+		if outputFile == "" && format == formatter.FormatPlain {
+			fmt.Print(diff.FormatDiff(d, !noColor && isTerminal(os.Stdout)))
+			return
+		}
+
+		formattedOutput, err := formatter.FormatDiff(d, format)
+		if err != nil {
+			logger.Errorf("could not format diff: %v", err)
+			os.Exit(1)
+		}
+		if outputFile == "" {
+			fmt.Println(formattedOutput)
+			return
+		}
+		logger.Infof("%d added, %d removed, %d changed", len(d.Added), len(d.Removed), len(d.Changed))
+		writeFormattedToFile(formattedOutput, outputFile)
+	},
+}
+
+// readDiffHosts reads the hosts of a scan output file, exiting on failure
+func readDiffHosts(path string) []model.Host {
+	data, err := input.Read(path)
+	if err != nil {
+		logger.Errorf("could not read %s: %v", path, err)
+		os.Exit(1)
+	}
+	hosts, err := formatter.ReadHosts(data)
+	if err != nil {
+		logger.Errorf("could not parse %s: %v", path, err)
+		os.Exit(1)
+	}
+	return hosts
+}
+
+// isTerminal reports whether f is an interactive terminal, honoring NO_COLOR
+func isTerminal(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Path to output file (prints to stdout if omitted)")
+	diffCmd.Flags().StringVarP(&outputFormat, "format", "f", "", "Output format: plain, json, markdown (default plain)")
+	diffCmd.Flags().BoolVar(&noColor, "no-color", false, "Disable colored output")
+
+	rootCmd.AddCommand(diffCmd)
+}
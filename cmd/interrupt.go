@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+// interruptContext returns a context canceled on the first Ctrl-C or SIGTERM,
+// so the stages stop their work in flight and the results so far are still
+// written. A second Ctrl-C exits immediately. The returned function stops
+// listening for the signals once the command is done.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			logger.Warnf("interrupted; stopping and writing the results so far (press Ctrl-C again to quit immediately)")
+			cancel()
+		case <-done:
+		}
+	}()
+	return ctx, func() { close(done) }
+}
+
+// exitIfInterrupted exits with the conventional status of a run stopped by
+// Ctrl-C once the command has written its partial results
+func exitIfInterrupted(ctx context.Context) {
+	if ctx.Err() != nil {
+		os.Exit(130)
+	}
+}
+
+// scanProgress counts what a domain's scan completed, reported when the scan
+// is interrupted
+type scanProgress struct {
+	candidates int
+	alive      int
+	scored     int
+	probed     int
+}
+
+// report prints the summary of an interrupted scan
+func (p scanProgress) report(target string, output string) {
+	completed := []string{fmt.Sprintf("%d candidates", p.candidates), fmt.Sprintf("%d alive", p.alive)}
+	if enableProbe {
+		completed = append(completed, fmt.Sprintf("%d probed", p.probed))
+	} else if enableScoring {
+		completed = append(completed, fmt.Sprintf("%d scored", p.scored))
+	}
+	destination := "printed"
+	if output != "" {
+		destination = "saved to " + output
+	}
+	logger.Warnf("scan of %s interrupted after %s; partial results %s", describeTarget(target), strings.Join(completed, ", "), destination)
+}
+
+// describeTarget names the scanned domain, or the subdomain list of list scans
+func describeTarget(target string) string {
+	if target == "" {
+		return describeList(listFile)
+	}
+	return target
+}
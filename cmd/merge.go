@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/omerimzali/subscan/pkg/formatter"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/spf13/cobra"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <results.json>...",
+	Short: "Merge probe result files from multiple runs",
+	Long:  `Merges probe JSON reports from multiple runs or machines into a single report. The most recently probed result wins per host, while tags, vulnerabilities and findings are combined.`,
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat
+		if format == "" {
+			format = formatter.FormatJSON
+		}
+
+		var sets [][]probe.ProbeResult
+		for _, path := range args {
+			results, err := probe.ReadProbeResultsFromFile(path)
+			if err != nil {
+				logger.Errorf("could not read results file %s: %v", path, err)
+				os.Exit(1)
+			}
+			sets = append(sets, results)
+		}
+
+		merged := probe.MergeResults(sets...)
+
+		formattedOutput, err := formatter.FormatProbeResults(merged, format)
+		if err != nil {
+			logger.Errorf("could not format merged results: %v", err)
+			os.Exit(1)
+		}
+
+		if outputFile == "" {
+			fmt.Println(formattedOutput)
+			return
+		}
+		logger.Infof("Merged %d files into %d hosts", len(args), len(merged))
+		writeFormattedToFile(formattedOutput, outputFile)
+	},
+}
+
+func init() {
+	mergeCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Path to output file (prints to stdout if omitted)")
+	mergeCmd.Flags().StringVarP(&outputFormat, "format", "f", "", "Output format: json, jsonl, csv, html, markdown (default json)")
+
+	rootCmd.AddCommand(mergeCmd)
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/diff"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/omerimzali/subscan/pkg/monitor"
+	"github.com/omerimzali/subscan/pkg/notify"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scan"
+	"github.com/spf13/cobra"
+)
+
+var (
+	monitorInterval    time.Duration
+	monitorOnce        bool
+	monitorSnapshotDir string
+)
+
+// minMonitorInterval keeps monitor from re-scanning, and re-querying the
+// passive sources, in a tight loop
+const minMonitorInterval = time.Minute
+
+var monitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Re-scan domains on a schedule and alert on changes",
+	Long: `Re-runs enumeration and resolution of the target domains every interval, optionally probing the alive hosts, and keeps a snapshot of each run. Subdomains missing from the previous snapshot and findings never seen before are sent to the configured notification destinations.
+
+The first run of a domain records the baseline and sends no notifications. Snapshots are report files, so any two can be compared with subscan diff.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		targets, err := collectDomains(domains, domainsFile)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if len(targets) == 0 {
+			logger.Errorf("at least one domain is required")
+			cmd.Help()
+			os.Exit(1)
+		}
+		if !monitorOnce && monitorInterval < minMonitorInterval {
+			logger.Errorf("--interval must be at least %s", minMonitorInterval)
+			os.Exit(1)
+		}
+
+		store, err := monitor.Open(monitorSnapshotDir)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+
+		settings := loadScanSettings(cmd)
+		notifier := notifierFromFlags()
+		if notifier == nil {
+			logger.Warnf("no notification destination configured; changes are only printed")
+		}
+
+		// Stop cleanly on Ctrl-C, also in the middle of a run
+		ctx, stop := interruptContext()
+		defer stop()
+
+		logger.Infof("👀 Monitoring %d domains every %s", len(targets), monitorInterval)
+		for {
+			if settings.windows.Wait(ctx) == nil {
+				for _, target := range targets {
+					if ctx.Err() != nil {
+						break
+					}
+					monitorDomain(ctx, target, settings, store, notifier)
+				}
+			}
+			if ctx.Err() != nil {
+				logger.Infof("Stopped monitoring")
+				return
+			}
+			if monitorOnce {
+				return
+			}
+
+			logger.Infof("Next run at %s", time.Now().Add(monitorInterval).Format("2006-01-02 15:04"))
+			select {
+			case <-time.After(monitorInterval):
+			case <-ctx.Done():
+				logger.Infof("Stopped monitoring")
+				return
+			}
+		}
+	},
+}
+
+// monitorDomain scans a domain once, stores the snapshot and notifies about
+// subdomains and findings that are new since the previous run. A run
+// interrupted through ctx stores nothing, so the next one compares against a
+// complete snapshot.
+func monitorDomain(ctx context.Context, target string, settings scanSettings, store *monitor.Store, notifier notify.Notifier) {
+	logger.Infof("Scanning %s...", target)
+	enumerated, _ := enumerateDomain(ctx, target, settings)
+	candidates, _ := scan.Dedupe(enumerated)
+	candidates = applyScope(settings.scope, candidates)
+	records := resolver.ResolveSubdomains(ctx, candidates, resolveOptionsFor(target, settings))
+
+	var report model.Report
+	var findings []probe.Finding
+	if enableProbe && len(records) > 0 {
+		results := probe.RunProbes(ctx, resolver.Names(records), probeOptionsFor(target, settings, resolver.RecordMap(records)))
+		settings.annotations.ApplyToProbes(results)
+		report = probe.NewReport(results, target)
+		findings = report.Findings
+	} else {
+		report = model.NewReport(model.KindResolve, target)
+		for _, record := range records {
+			report.Hosts = append(report.Hosts, model.Host{Domain: record.Name, CNAME: record.CNAME, IPs: record.IPs()})
+		}
+		report.Scan.HostCount = len(report.Hosts)
+	}
+
+	if ctx.Err() != nil {
+		logger.Warnf("scan of %s interrupted; no snapshot saved", target)
+		return
+	}
+
+	previous, err := store.Latest(target)
+	if err != nil {
+		logger.Warnf("could not read the previous snapshot of %s: %v", target, err)
+	}
+	path, err := store.Save(report)
+	if err != nil {
+		logger.Errorf("could not save snapshot of %s: %v", target, err)
+		return
+	}
+
+	// Findings are remembered across runs, so each is only alerted on once
+	seen, err := store.Findings(target)
+	if err != nil {
+		logger.Warnf("could not load findings state of %s: %v", target, err)
+		return
+	}
+	fresh := seen.Observe(findings, time.Now())
+	if err := seen.Save(); err != nil {
+		logger.Warnf("could not save findings state of %s: %v", target, err)
+	}
+
+	if previous == nil {
+		logger.Infof("Baseline of %s recorded: %d alive subdomains, %d findings (%s)", target, len(report.Hosts), len(findings), path)
+		return
+	}
+
+	event := notify.Event{Target: target, Time: time.Now().UTC(), NewFindings: fresh}
+	for _, host := range diff.Compare(previous.Hosts, report.Hosts).Added {
+		event.NewSubdomains = append(event.NewSubdomains, host.Domain)
+	}
+	if event.Empty() {
+		logger.Infof("No changes in %s (%d alive subdomains)", target, len(report.Hosts))
+		return
+	}
+
+	fmt.Print(event.Text())
+	if notifier != nil {
+		if err := notifier.Notify(event); err != nil {
+			logger.Warnf("%v", err)
+		}
+	}
+}
+
+func init() {
+	flags := monitorCmd.Flags()
+	flags.StringSliceVarP(&domains, "domain", "d", nil, "Target domains to monitor (e.g., example.com or example.com,example.org)")
+	flags.StringVar(&domainsFile, "domains-file", "", "File with target domains to monitor, one per line")
+	flags.DurationVar(&monitorInterval, "interval", 6*time.Hour, "Time between two runs (at least 1m)")
+	flags.BoolVar(&monitorOnce, "once", false, "Run once and exit (for cron)")
+	flags.StringVar(&monitorSnapshotDir, "snapshot-dir", monitor.DefaultDir, "Directory keeping the snapshots of every run")
+	flags.BoolVar(&enableProbe, "probe", false, "Probe alive subdomains and alert on new findings")
+	addNotifyFlags(flags)
+	addEnumFlags(flags)
+	addScopeFlags(flags)
+	addResolveFlags(flags)
+	addProbeFlags(flags)
+	addHTTPFlags(flags)
+	addProxyFlags(flags)
+	addInternalFlags(flags)
+	flags.StringSliceVar(&scanWindows, "scan-window", nil, "Only scan inside these local time windows, e.g. 01:00-05:00 (pauses outside them)")
+
+	rootCmd.AddCommand(monitorCmd)
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/notify"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/spf13/pflag"
+)
+
+// When a scan notifies about its findings
+const (
+	notifyOnFinding = "finding"
+	notifyOnSummary = "summary"
+)
+
+var (
+	// Notification destinations
+	notifySlack   string
+	notifyDiscord string
+	notifyWebhook string
+	smtpServer    string
+	smtpUsername  string
+	smtpPassword  string
+	emailFrom     string
+	emailTo       []string
+	// Notification of scan findings
+	notifyOn       string
+	notifySeverity string
+)
+
+// notifierFromFlags returns the notifier of the configured destinations, or
+// nil when none is configured
+func notifierFromFlags() notify.Notifier {
+	options := notify.DefaultOptions()
+	options.SlackWebhook = notifySlack
+	options.DiscordWebhook = notifyDiscord
+	options.Webhook = notifyWebhook
+	options.Email = notify.EmailOptions{
+		Server:   smtpServer,
+		Username: smtpUsername,
+		Password: smtpPassword,
+		From:     emailFrom,
+		To:       emailTo,
+	}
+	if options.Email.Password == "" {
+		options.Email.Password = os.Getenv("SUBSCAN_SMTP_PASSWORD")
+	}
+	return notify.New(options)
+}
+
+// addNotifyFlags registers the notification destination flags
+func addNotifyFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&notifySlack, "notify-slack", "", "Slack incoming webhook URL to notify")
+	flags.StringVar(&notifyDiscord, "notify-discord", "", "Discord webhook URL to notify")
+	flags.StringVar(&notifyWebhook, "notify-webhook", "", "URL receiving each notification as a JSON POST")
+	flags.StringVar(&smtpServer, "smtp-server", "", "SMTP server (host:port) for email notifications")
+	flags.StringVar(&smtpUsername, "smtp-username", "", "SMTP username")
+	flags.StringVar(&smtpPassword, "smtp-password", "", "SMTP password (or set SUBSCAN_SMTP_PASSWORD)")
+	flags.StringVar(&emailFrom, "email-from", "", "Sender address of email notifications (default: the SMTP username)")
+	flags.StringSliceVar(&emailTo, "email-to", nil, "Recipients of email notifications (comma-separated)")
+}
+
+// findingNotifier sends the probe findings of a scan to the notification
+// destinations, each one as soon as it is found or as a summary per domain
+type findingNotifier struct {
+	notifier notify.Notifier
+	minimum  probe.Severity
+	summary  bool
+}
+
+// findingNotifierFromFlags validates the notification flags of the scan and
+// returns its notifier, or nil when no destination is configured
+func findingNotifierFromFlags() *findingNotifier {
+	minimum, err := probe.ParseSeverity(notifySeverity)
+	if err != nil {
+		logger.Errorf("--notify-severity: %v", err)
+		os.Exit(1)
+	}
+	if notifyOn != notifyOnFinding && notifyOn != notifyOnSummary {
+		logger.Errorf("--notify-on must be %s or %s", notifyOnFinding, notifyOnSummary)
+		os.Exit(1)
+	}
+
+	notifier := notifierFromFlags()
+	if notifier == nil {
+		return nil
+	}
+	return &findingNotifier{notifier: notifier, minimum: minimum, summary: notifyOn == notifyOnSummary}
+}
+
+// notable returns the findings at or above the minimum severity
+func (n *findingNotifier) notable(findings []probe.Finding) []probe.Finding {
+	var notable []probe.Finding
+	for _, finding := range findings {
+		if probe.VulnerabilitySeverity(finding.Check) >= n.minimum {
+			notable = append(notable, finding)
+		}
+	}
+	return notable
+}
+
+// send notifies about the findings of a target, if there are any
+func (n *findingNotifier) send(target string, findings []probe.Finding) {
+	event := notify.Event{Target: target, Time: time.Now().UTC(), NewFindings: n.notable(findings)}
+	if event.Empty() {
+		return
+	}
+	if err := n.notifier.Notify(event); err != nil {
+		logger.Warnf("%v", err)
+	}
+}
+
+// Probed notifies about a host's findings as soon as it is probed, unless
+// only a summary is sent
+func (n *findingNotifier) Probed(target string, result probe.ProbeResult) {
+	if n == nil || n.summary {
+		return
+	}
+	if target == "" {
+		target = result.Domain
+	}
+	n.send(target, result.Findings)
+}
+
+// Finished sends the summary of a target's findings once it is probed. Scans
+// of a subdomain list have no target and are named after the list.
+func (n *findingNotifier) Finished(target string, findings []probe.Finding) {
+	if n == nil || !n.summary {
+		return
+	}
+	if target == "" {
+		target = describeList(listFile)
+	}
+	n.send(target, findings)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"net/url"
+	"os"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/spf13/pflag"
+)
+
+var (
+	// proxyURL routes all HTTP traffic; the per-stage proxies override it
+	proxyURL      string
+	enumProxyURL  string
+	scoreProxyURL string
+	probeProxyURL string
+)
+
+// stageProxy returns the proxy of a stage: its own when set, else --proxy,
+// else nil to use the environment's proxy
+func stageProxy(override string) *url.URL {
+	raw := override
+	if raw == "" {
+		raw = proxyURL
+	}
+	if raw == "" {
+		return nil
+	}
+	proxy, err := httpclient.ParseProxy(raw)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	return proxy
+}
+
+// addProxyFlags registers the proxy flag shared by every command sending HTTP requests
+func addProxyFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&proxyURL, "proxy", "", "Send all HTTP traffic through this proxy: http://, https:// or socks5://host:port (default: HTTP_PROXY/HTTPS_PROXY)")
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/formatter"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/spf13/cobra"
+)
+
+var recheckCmd = &cobra.Command{
+	Use:   "recheck <findings.json>",
+	Short: "Re-test previously reported probe findings",
+	Long:  `Re-tests only the hosts with takeover/exposure findings from a previous probe JSON report and marks each finding as fixed or still vulnerable.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := outputFormat
+		if format == "" {
+			format = formatter.FormatPlain
+		}
+
+		previous, err := probe.ReadProbeResultsFromFile(args[0])
+		if err != nil {
+			logger.Errorf("could not read findings file: %v", err)
+			os.Exit(1)
+		}
+
+		options := probe.ProbeOptions{
+			Concurrency:  probeConcurrency,
+			Timeout:      time.Duration(probeTimeout) * time.Second,
+			UserAgent:    "Subscan/1.0",
+			Verbose:      probeVerbose,
+			MaxRedirects: maxRedirects,
+		}
+
+		ctx, stop := interruptContext()
+		defer exitIfInterrupted(ctx)
+		defer stop()
+
+		logger.Infof("Re-checking findings from %s...", args[0])
+		results := probe.RecheckFindings(ctx, previous, options)
+		if ctx.Err() != nil {
+			logger.Warnf("interrupted; %d hosts were re-checked", len(results))
+		}
+
+		// Always show the remediation summary
+		fmt.Println(probe.FormatRecheckResults(results))
+
+		if outputFile != "" {
+			formattedOutput, err := formatter.FormatRecheckResults(results, format)
+			if err != nil {
+				logger.Errorf("could not format recheck results: %v", err)
+				os.Exit(1)
+			}
+			writeFormattedToFile(formattedOutput, outputFile)
+		}
+	},
+}
+
+func init() {
+	recheckCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Path to output file")
+	recheckCmd.Flags().StringVarP(&outputFormat, "format", "f", "", "Output format: plain, json, jsonl, csv, markdown")
+	recheckCmd.Flags().IntVar(&probeTimeout, "probe-timeout", 10, "Timeout in seconds for probe requests")
+	recheckCmd.Flags().IntVar(&probeConcurrency, "probe-concurrency", 10, "Number of concurrent probes")
+	recheckCmd.Flags().BoolVar(&probeVerbose, "probe-verbose", false, "Show detailed output during probing")
+
+	rootCmd.AddCommand(recheckCmd)
+}
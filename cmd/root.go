@@ -1,243 +1,1878 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/omerimzali/subscan/pkg/annotate"
+	"github.com/omerimzali/subscan/pkg/attribution"
+	"github.com/omerimzali/subscan/pkg/buckets"
+	"github.com/omerimzali/subscan/pkg/checkpoint"
+	"github.com/omerimzali/subscan/pkg/config"
+	"github.com/omerimzali/subscan/pkg/coverage"
+	"github.com/omerimzali/subscan/pkg/db"
 	"github.com/omerimzali/subscan/pkg/enumeration"
 	"github.com/omerimzali/subscan/pkg/expander"
+	"github.com/omerimzali/subscan/pkg/filter"
 	"github.com/omerimzali/subscan/pkg/formatter"
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/input"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/omerimzali/subscan/pkg/portscan"
 	"github.com/omerimzali/subscan/pkg/probe"
 	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scan"
+	"github.com/omerimzali/subscan/pkg/schedule"
 	"github.com/omerimzali/subscan/pkg/scorer"
+	"github.com/omerimzali/subscan/pkg/sink"
+	"github.com/omerimzali/subscan/pkg/store"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	domain           string
+	domains          []string
+	domainsFile      string
+	// Number of domains scanned in parallel
+	domainConcurrency int
+	// Pre-existing subdomain list to scan instead of enumerating
+	listFile  string
+	readStdin bool
 	outputFile       string
 	passiveOnly      bool
 	activeOnly       bool
-	wordlist         string
+	wordlists        []string
+	wordlistLimit    int
 	smartBruteforce  bool
 	commonspeakPath  string
 	useDNSTwist      bool
+	twistLimit       int
+	twistPerName     int
 	verboseExpansion bool
+	// Permutation engine
+	permutationLevel int
+	scanSeed         int64
+	permutationFile  string
+	feedbackRound    bool
+	feedbackLimit    int
+	iterative        bool
+	maxIterations    int
+	reverseSweep     bool
+	sweepMaxRanges   int
 	enableScoring    bool
 	scoreConcurrency int
 	scoreTimeout     int
 	verboseScoring   bool
+	scoreRulesFile   string
+	scoreKeywordFile string
+	certExpiryDays   int
+	sanHarvest       bool
+	weakTLSChecks    bool
+	portScan         bool
+	portList         string
+	portTimeout      int
+	portConcurrency  int
 	outputFormat     string
 	// Probe related flags
 	enableProbe        bool
 	probeTimeout       int
 	probeConcurrency   int
 	probeVerbose       bool
+	// Redirect related flags
+	followRedirects bool
+	maxRedirects    int
+	httpPortList    string
+	// Resolver related flags
+	queryAuthoritative bool
+	// Ask the authoritative nameservers for their software version
+	nsFingerprint bool
+	// Try zone transfers from the authoritative nameservers
+	zoneTransfer bool
+	// Look up the NS, MX, TXT, SOA and SRV records of the target domain
+	apexRecords bool
+	// Walk the NSEC or NSEC3 chain of a DNSSEC-signed zone
+	nsecWalk       bool
+	nsecMaxQueries int
+	nsec3HashFile  string
+	// Check cloud storage buckets named after the target
+	bucketScan      bool
+	bucketWordsFile string
+	// Passive source selection
+	passiveSources []string
+	// Scope rules every host must pass before it is resolved or probed
+	includePatterns []string
+	excludePatterns []string
+	excludeFile     string
+	// Passive source API keys
+	securityTrailsKey string
+	virusTotalKey     string
+	shodanKey         string
+	githubToken       string
+	chaosKey          string
+	// Query crt.sh's database instead of its HTTP endpoint
+	crtShPostgres bool
+	// Requests per minute of the rate-limited passive sources, by name
+	sourceRates map[string]int
+	// Seconds a passive source may take before it is abandoned, 0 for no limit
+	sourceTimeout int
+	// Retries of passive source requests failing with a transient error,
+	// and the seconds before the first one
+	sourceRetries    int
+	sourceRetryDelay int
+	// Save the URLs archive sources found, for content discovery
+	urlsFile string
+	// Ownership annotations file
+	annotationsFile string
+	// Polite mode
+	politeMode bool
+	// Scan windows
+	scanWindows []string
+	// Findings seen in previous runs
+	findingsState string
+	// Content encodings advertised to probed hosts
+	acceptEncoding string
+	// Custom DNS resolvers
+	customResolvers []string
+	// Resolution tuning
+	fastResolve        bool
+	resolveConcurrency int
+	resolveRate        float64
+	resolveTimeout     int
+	// Second resolution pass confirming alive subdomains
+	reverify          bool
+	reverifyDelay     int
+	reverifyResolvers []string
+	// Per-resolver history kept across runs
+	resolverStatsFile string
+	noResolverStats   bool
+	// Probe check selection
+	probeChecks      []string
+	noOpenRedirect   bool
+	noSensitiveFiles bool
+	// Seconds to back off once from a host that starts blocking probes
+	blockCooldown int
+	// Resolve and handshake with the next probe batch ahead of time
+	probeWarmup bool
+	// Encrypted DNS transports
+	dohEndpoint string
+	dotServers  []string
+	// Stream results as they are produced
+	streamOutput bool
+	// Directory for the on-disk store holding results of very large scans
+	spillDir string
+	// Output filters restricting the hosts shown
+	matchStatuses []int
+	minScore      float64
+	onlyCloud     bool
+	matchTags     []string
+	// State file for checkpointing and resuming interrupted scans
+	resumeFile string
+	// SQLite database recording every run
+	dbFile string
+	// Config file with API keys and flag defaults
+	configFile string
+	// Logging
+	logLevel   string
+	quietLogs  bool
+	silentLogs bool
+	logJSON    bool
+	// Internal network scans
+	internalScan     bool
+	internalSuffixes []string
+	// Generated candidate depth limit
+	maxDepth int
+	// Recursive passive enumeration
+	recursiveEnum        bool
+	recursiveDepth       int
+	recursiveMaxTargets  int
+	recursiveConcurrency int
+)
+
+// Polite mode presets
+const (
+	politeConcurrency  = 2
+	politeRequestDelay = time.Second
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "subscan",
 	Short: "Subscan - A subdomain enumeration tool",
 	Long:  `Subscan is a CLI tool that performs both passive and active subdomain enumeration.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		loadConfig(cmd)
+		configureLogging()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if domain == "" {
-			fmt.Println("Error: domain is required")
+		if listFile != "" && readStdin {
+			logger.Errorf("--list and --stdin cannot be combined")
+			os.Exit(1)
+		}
+		if readStdin {
+			listFile = "-"
+		}
+		
+		targets, err := collectDomains(domains, domainsFile)
+		if err != nil {
+			logger.Errorf("could not read domains file: %v", err)
+			os.Exit(1)
+		}
+		if len(targets) == 0 && listFile == "" {
+			logger.Errorf("domain is required (or pass subdomains with --list/--stdin)")
 			cmd.Help()
 			os.Exit(1)
 		}
+		if len(targets) > 1 && listFile != "" {
+			logger.Errorf("--list/--stdin accept at most one --domain, used as the scope")
+			os.Exit(1)
+		}
+		if len(targets) == 0 {
+			// A list without a domain is scanned without a scope
+			targets = []string{""}
+		}
+		if targets[0] == "" && queryAuthoritative {
+			logger.Errorf("--authoritative needs --domain to find the nameservers")
+			os.Exit(1)
+		}
+		if targets[0] == "" && nsFingerprint {
+			logger.Errorf("--ns-fingerprint needs --domain to find the nameservers")
+			os.Exit(1)
+		}
+		if targets[0] == "" && nsecWalk {
+			logger.Errorf("--nsec-walk needs --domain to find the zone")
+			os.Exit(1)
+		}
+		if targets[0] == "" && bucketScan {
+			logger.Errorf("--buckets needs --domain to name the buckets after")
+			os.Exit(1)
+		}
+		for _, target := range targets {
+			if isInternal(target) && (cmd.Flags().Changed("doh") || cmd.Flags().Changed("dot")) {
+				logger.Errorf("%s is an internal domain that public DoH/DoT resolvers cannot answer; use --resolvers with your internal DNS servers", target)
+				os.Exit(1)
+			}
+		}
+
+		// Validate output format if specified
+		if outputFormat != "" && !formatter.IsValidFormat(outputFormat) {
+			logger.Errorf("invalid output format '%s'. Supported formats: plain, json, jsonl, csv, html, markdown, nmap, masscan, urls, defectdojo", outputFormat)
+			os.Exit(1)
+		}
+		if outputFormat == formatter.FormatDefectDojo && !enableProbe {
+			logger.Errorf("the defectdojo format exports probe findings; add --probe")
+			os.Exit(1)
+		}
+
+		settings := loadScanSettings(cmd)
+		
+		// Streaming writes each result as soon as it's ready, to the output file
+		// or stdout and to the --stream-webhook destination
+		if streamOutput && formatter.IsTargetFormat(outputFormat) {
+			logger.Errorf("the %s target list is built from all results and cannot be streamed", outputFormat)
+			os.Exit(1)
+		}
+		if streamOutput && outputFormat == formatter.FormatDefectDojo {
+			logger.Errorf("the defectdojo import file is built from all findings and cannot be streamed")
+			os.Exit(1)
+		}
+		if spillDir != "" && outputFormat != "" && outputFormat != formatter.FormatPlain && outputFormat != formatter.FormatJSONL {
+			logger.Errorf("--spill-dir writes results one per line and only supports the plain and jsonl formats")
+			os.Exit(1)
+		}
+		if resumeFile != "" && spillDir != "" {
+			logger.Errorf("--resume keeps results in its state file and cannot be combined with --spill-dir")
+			os.Exit(1)
+		}
+		if dbFile != "" && spillDir != "" {
+			logger.Errorf("--db records results kept in memory and cannot be combined with --spill-dir")
+			os.Exit(1)
+		}
+		if summaryFile != "" && spillDir != "" {
+			logger.Errorf("--summary counts results kept in memory and cannot be combined with --spill-dir")
+			os.Exit(1)
+		}
+		// One document per domain printed back to back would not parse
+		if len(targets) > 1 && outputFile == "" && !streamOutput && outputFormat != "" && outputFormat != formatter.FormatPlain {
+			logger.Errorf("%s results of several domains need -o, which writes one file per domain, or --stream, which writes them all as one stream", outputFormat)
+			os.Exit(1)
+		}
+		
+		// A domain that failed exits with an error once the others finished
+		// and the outputs were flushed and closed
+		var failed int32
+		defer func() {
+			if atomic.LoadInt32(&failed) > 0 {
+				os.Exit(1)
+			}
+		}()
+		
+		// Ctrl-C stops the scan gracefully, still writing the results so far
+		ctx, stop := interruptContext()
+		defer exitIfInterrupted(ctx)
+		defer stop()
+		var sinks []sink.OutputSink
+		if streamOutput && outputFile != "" {
+			sinks = append(sinks, sink.NewFile(outputFile, outputFormat))
+		} else if streamOutput {
+			sinks = append(sinks, sink.NewStream(os.Stdout, outputFormat))
+		}
+		if settings.sink = settings.filter.Sink(outputSinks(sinks...)); settings.sink != nil {
+			defer startSinks(settings.sink)()
+		}
+		settings.streamed = streamOutput
+		settings.multi = len(targets) > 1
+		if summaryFile != "" {
+			settings.summaries = &domainSummaries{}
+		}
+		settings.notifier = findingNotifierFromFlags()
+		if settings.notifier != nil && !enableProbe {
+			logger.Warnf("notifications are sent for probe findings; use --probe to enable them")
+		}
+		if dbFile != "" {
+			settings.results, err = db.Open(dbFile)
+			if err != nil {
+				logger.Errorf("%v", err)
+				os.Exit(1)
+			}
+			defer settings.results.Close()
+		}
+		
+		// Always score if format other than plain is requested; port scanner
+		// target lists only need the resolved addresses
+		if !enableScoring && outputFormat != "" && outputFormat != formatter.FormatPlain && !formatter.IsTargetFormat(outputFormat) {
+			enableScoring = true
+		}
+		// Status codes, scores and tags come from scoring as well
+		if !enableScoring && !enableProbe && settings.filter.NeedsHTTP() {
+			enableScoring = true
+		}
+		if minScore != 0 && enableProbe {
+			logger.Errorf("--min-score filters scored hosts; probe results carry no score")
+			os.Exit(1)
+		}
+		
+		// Domains are scanned in parallel up to --domain-concurrency, each with its own outputs
+		if domainConcurrency < 1 {
+			domainConcurrency = 1
+		}
+		jobs := make(chan string, len(targets))
+		for _, target := range targets {
+			jobs <- target
+		}
+		close(jobs)
+		
+		var wg sync.WaitGroup
+		for i := 0; i < domainConcurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for target := range jobs {
+					if ctx.Err() != nil {
+						continue
+					}
+					if err := scanDomain(ctx, target, domainOutputFile(outputFile, target, settings.multi), settings); err != nil {
+						logger.Errorf("%s: %v", target, err)
+						atomic.AddInt32(&failed, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		
+		if settings.summaries != nil {
+			settings.summaries.write(summaryFile)
+		}
+	},
+}
+
+// scanSettings holds the run-wide configuration shared by every scanned domain
+type scanSettings struct {
+	sources         []enumeration.Source
+	annotations     annotate.Annotations
+	windows         schedule.Windows
+	parsedWordlists []enumeration.Wordlist
+	nameservers     []string
+	// permuteWords replace the built-in permutation words when set
+	permuteWords []string
+	// bucketWords replace the built-in bucket name words when set
+	bucketWords []string
+	// scoreRules replace the built-in scoring rules when set
+	scoreRules []scorer.Rule
+	// scoreKeywords extend the built-in name keywords when set
+	scoreKeywords []scorer.Keyword
+	// portScan port-scans the scored hosts when set
+	portScan *portscan.Options
+	// httpPorts are tried for HTTP(S) besides 80 and 443 when scoring and probing
+	httpPorts []int
+	// reverifyServers answer the --reverify pass instead of nameservers when set
+	reverifyServers []string
+	checks          []string
+	dohURL          string
+	dotResolvers    []string
+	userAgent       string
+	requestDelay    time.Duration
+	// scoreProxy and probeProxy route the stages' requests; nil uses the environment's proxy
+	scoreProxy *url.URL
+	probeProxy *url.URL
+	// sink receives every result as it is produced; streamed is set when it
+	// replaces the final output
+	sink     sink.OutputSink
+	streamed bool
+	// results records every run when --db is set
+	results *db.DB
+	// notifier sends probe findings to the --notify-* destinations
+	notifier *findingNotifier
+	// multi is set when several domains are scanned, so outputs are split per domain
+	multi bool
+	// summaries collects each domain's roll-up when --summary is set
+	summaries *domainSummaries
+	// resolverStats tracks how the nameservers perform across runs; nil when disabled
+	resolverStats *resolver.Stats
+	// scope drops the hosts the scope rules exclude; nil keeps every host
+	scope *enumeration.ScopeFilter
+	// filter restricts the hosts the outputs show; nil shows every host
+	filter *filter.Filter
+	// recursion bounds --recursive passive enumeration
+	recursion enumeration.RecursionOptions
+}
+
+// loadScanSettings validates the flags shared by the scan and the pipeline
+// stage commands and builds the settings they describe
+func loadScanSettings(cmd *cobra.Command) scanSettings {
+	applySourceKeys()
+
+	for name, perMinute := range sourceRates {
+		if err := enumeration.SetRateLimit(name, perMinute); err != nil {
+			logger.Errorf("invalid --source-rate: %v", err)
+			os.Exit(1)
+		}
+	}
+	if sourceTimeout < 0 {
+		logger.Errorf("--source-timeout cannot be negative")
+		os.Exit(1)
+	}
+	enumeration.SetSourceTimeout(time.Duration(sourceTimeout) * time.Second)
+	if sourceRetries < 0 || sourceRetryDelay < 0 {
+		logger.Errorf("--source-retries and --source-retry-delay cannot be negative")
+		os.Exit(1)
+	}
+	retry := enumeration.DefaultRetryOptions()
+	retry.Retries = sourceRetries
+	retry.BaseDelay = time.Duration(sourceRetryDelay) * time.Second
+	enumeration.SetRetry(retry)
+	if recursiveEnum && (recursiveDepth < 0 || recursiveMaxTargets < 0) {
+		logger.Errorf("--depth and --recursive-max-targets cannot be negative")
+		os.Exit(1)
+	}
+	if recursiveEnum && recursiveConcurrency <= 0 {
+		logger.Errorf("--recursive-concurrency must be positive")
+		os.Exit(1)
+	}
+
+	enumeration.UseCrtShPostgres(crtShPostgres)
+	enumProxy := stageProxy(enumProxyURL)
+	enumeration.SetProxy(enumProxy)
+	if crtShPostgres && enumProxy != nil && enumProxy != httpclient.Direct {
+		logger.Warnf("--crtsh-postgres connects to crt.sh's database directly, bypassing the proxy")
+	}
+	sources, err := enumeration.SelectSources(passiveSources)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+
+	var annotations annotate.Annotations
+	if annotationsFile != "" {
+		annotations, err = annotate.Load(annotationsFile)
+		if err != nil {
+			logger.Errorf("could not load annotations: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	windows, err := schedule.ParseWindows(scanWindows)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+
+	var parsedWordlists []enumeration.Wordlist
+	for _, spec := range wordlists {
+		list, err := enumeration.ParseWordlist(spec)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		list.Limit = wordlistLimit
+		parsedWordlists = append(parsedWordlists, list)
+	}
+
+	var permutationWords []string
+	if permutationFile != "" {
+		permutationWords, err = expander.LoadPermutationWords(permutationFile)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	var bucketWords []string
+	if bucketWordsFile != "" {
+		bucketWords, err = buckets.LoadWords(bucketWordsFile)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	var scoreRules []scorer.Rule
+	if scoreRulesFile != "" {
+		scoreRules, err = scorer.LoadRules(scoreRulesFile)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	var scoreKeywords []scorer.Keyword
+	if scoreKeywordFile != "" {
+		scoreKeywords, err = scorer.LoadKeywords(scoreKeywordFile)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+
+	var portScanOptions *portscan.Options
+	if portScan {
+		ports, err := portscan.ParsePorts(portList)
+		if err != nil {
+			logger.Errorf("invalid --ports: %v", err)
+			os.Exit(1)
+		}
+		if portTimeout <= 0 {
+			logger.Errorf("--port-timeout must be at least 1 second")
+			os.Exit(1)
+		}
+		portScanOptions = &portscan.Options{
+			Ports:       ports,
+			Concurrency: portConcurrency,
+			Timeout:     time.Duration(portTimeout) * time.Second,
+		}
+	}
+
+	var httpPorts []int
+	if httpPortList != "" {
+		httpPorts, err = portscan.ParsePorts(httpPortList)
+		if err != nil {
+			logger.Errorf("invalid --http-ports: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	nameservers, err := resolver.ParseResolvers(customResolvers)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	reverifyServers, err := resolver.ParseResolvers(reverifyResolvers)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	var resolverStats *resolver.Stats
+	if !noResolverStats {
+		statsFile := resolverStatsFile
+		if statsFile == "" {
+			statsFile = resolver.DefaultStatsPath()
+		}
+		if statsFile != "" {
+			resolverStats, err = resolver.LoadStats(statsFile)
+			if err != nil {
+				logger.Warnf("resolver statistics unavailable, every resolver will be used: %v", err)
+			}
+		}
+	}
+
+	var disabledChecks []string
+	if noOpenRedirect {
+		disabledChecks = append(disabledChecks, probe.CheckOpenRedirect)
+	}
+	if noSensitiveFiles {
+		disabledChecks = append(disabledChecks, probe.CheckSensitiveFiles)
+	}
+	checks, err := probe.SelectChecks(probeChecks, disabledChecks)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if enableProbe && len(checks) == 0 {
+		logger.Errorf("every selected probe check is disabled; select at least one with --checks")
+		os.Exit(1)
+	}
+
+	var dohURL string
+	if cmd.Flags().Changed("doh") {
+		if cmd.Flags().Changed("dot") {
+			logger.Errorf("--doh and --dot cannot be combined")
+			os.Exit(1)
+		}
+		dohURL, err = resolver.DoHURL(dohEndpoint)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+	var dotResolvers []string
+	if cmd.Flags().Changed("dot") {
+		dotResolvers = resolver.ParseDoTServers(dotServers)
+	}
+	if fastResolve && (dohURL != "" || len(dotResolvers) > 0) {
+		logger.Errorf("--fast-resolve cannot be combined with --doh or --dot")
+		os.Exit(1)
+	}
+	if queryAuthoritative && (dohURL != "" || len(dotResolvers) > 0) {
+		logger.Errorf("--authoritative cannot be combined with --doh or --dot")
+		os.Exit(1)
+	}
+
+	// Polite mode trades speed for a light footprint on production estates
+	userAgent := "Subscan/1.0"
+	var requestDelay time.Duration
+	if politeMode {
+		logger.Infof("🐢 Polite mode: honoring robots.txt, low concurrency and per-host delays")
+		userAgent = httpclient.PoliteUserAgent
+		requestDelay = politeRequestDelay
+		if scoreConcurrency > politeConcurrency {
+			scoreConcurrency = politeConcurrency
+		}
+		if probeConcurrency > politeConcurrency {
+			probeConcurrency = politeConcurrency
+		}
+		if recursiveConcurrency > politeConcurrency {
+			recursiveConcurrency = politeConcurrency
+		}
+		if portScanOptions != nil && portScanOptions.Concurrency > politeConcurrency {
+			portScanOptions.Concurrency = politeConcurrency
+		}
+	}
+
+	return scanSettings{
+		sources:         sources,
+		annotations:     annotations,
+		windows:         windows,
+		parsedWordlists: parsedWordlists,
+		permuteWords:    permutationWords,
+		bucketWords:     bucketWords,
+		scoreRules:      scoreRules,
+		scoreKeywords:   scoreKeywords,
+		portScan:        portScanOptions,
+		httpPorts:       httpPorts,
+		nameservers:     nameservers,
+		reverifyServers: reverifyServers,
+		resolverStats:   resolverStats,
+		checks:          checks,
+		dohURL:          dohURL,
+		dotResolvers:    dotResolvers,
+		userAgent:       userAgent,
+		requestDelay:    requestDelay,
+		scoreProxy:      stageProxy(scoreProxyURL),
+		probeProxy:      stageProxy(probeProxyURL),
+		scope:           loadScope(),
+		filter:          loadFilter(),
+		recursion: enumeration.RecursionOptions{
+			Depth:       recursiveDepth,
+			MaxTargets:  recursiveMaxTargets,
+			Concurrency: recursiveConcurrency,
+		},
+	}
+}
+
+// loadScope builds the scope filter from --include-pattern, --exclude-pattern
+// and the patterns and names of --exclude-subdomain-file
+func loadScope() *enumeration.ScopeFilter {
+	exclude := append([]string(nil), excludePatterns...)
+	if excludeFile != "" {
+		data, err := os.ReadFile(excludeFile)
+		if err != nil {
+			logger.Errorf("could not read exclusion list: %v", err)
+			os.Exit(1)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				exclude = append(exclude, line)
+			}
+		}
+	}
+
+	scope, err := enumeration.NewScopeFilter(includePatterns, exclude)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	return scope
+}
+
+// applySourceKeys hands the API keys given by flag to their sources, over
+// the ones from the environment and the config file
+func applySourceKeys() {
+	if securityTrailsKey != "" {
+		enumeration.SetAPIKey("securitytrails", securityTrailsKey)
+	}
+	if virusTotalKey != "" {
+		enumeration.SetAPIKey("virustotal", virusTotalKey)
+	}
+	if shodanKey != "" {
+		enumeration.SetAPIKey("shodan", shodanKey)
+	}
+	if githubToken != "" {
+		enumeration.SetAPIKey("github", githubToken)
+	}
+	if chaosKey != "" {
+		enumeration.SetAPIKey("chaos", chaosKey)
+	}
+}
+
+// collectDomains merges the --domain values with the domains file, dropping duplicates
+func collectDomains(values []string, path string) ([]string, error) {
+	if path != "" {
+		listed, err := input.ReadHosts(path)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, listed...)
+	}
+	
+	seen := make(map[string]bool)
+	var targets []string
+	for _, value := range values {
+		value = input.NormalizeHost(value)
+		if value != "" && !seen[value] {
+			seen[value] = true
+			targets = append(targets, value)
+		}
+	}
+	return targets, nil
+}
+
+// domainOutputFile returns the output path of one domain. Multi-domain runs
+// write a file per domain by inserting it before the extension, so
+// results.json becomes results-example.com.json.
+func domainOutputFile(path string, target string, multi bool) string {
+	if path == "" || !multi {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + target + ext
+}
+
+// outputMu keeps the printed results of parallel domains from interleaving
+var outputMu sync.Mutex
+
+// printResults prints a domain's results, headed by the domain in multi-domain runs
+func printResults(target string, multi bool, content string) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	
+	if multi {
+		fmt.Printf("\n=== %s ===\n", target)
+	}
+	fmt.Println(content)
+}
+
+// scanDomain runs enumeration, resolution, scoring and probing for one target
+// domain and writes its results to output, or prints them when output is
+// empty. Canceling ctx cuts the stages short and writes what they completed.
+// Errors are returned rather than exiting, since other domains may be
+// scanning in parallel.
+func scanDomain(ctx context.Context, target string, output string, settings scanSettings) error {
+	var subdomains []string
+	var knownPorts map[string][]int
+	
+	var completed scanProgress
+	defer func() {
+		if ctx.Err() != nil {
+			completed.report(target, output)
+		}
+	}()
+	
+	// Stages note what they skipped or left partial for the reports
+	caveats := &coverage.Caveats{}
+	ctx = coverage.With(ctx, caveats)
+	
+	// Stages record the names they find, so every host lists its sources
+	attributed := &attribution.Sources{}
+	ctx = attribution.With(ctx, attributed)
+	
+	run := startRun(target, settings)
+	if run != nil {
+		defer func() {
+			if ctx.Err() == nil {
+				recordRun(run.Finish())
+			}
+		}()
+	}
+	
+	// Resumed scans pick up the candidates and results of the interrupted run,
+	// and a list from other tools replaces enumeration entirely
+	var cp *checkpoint.Checkpoint
+	if resumeFile != "" {
+		var err error
+		cp, err = checkpoint.Load(domainOutputFile(resumeFile, target, settings.multi), target)
+		if err != nil {
+			return err
+		}
+		defer finishCheckpoint(ctx, cp)
+	}
+	
+	if cp != nil && cp.Resumed() {
+		subdomains, knownPorts = cp.Candidates()
+		logger.Infof("Resuming scan of %s with %d candidates from %s", target, len(subdomains), resumeFile)
+	} else if listFile != "" {
+		listed, err := input.ReadHosts(listFile)
+		if err != nil {
+			return fmt.Errorf("could not read subdomain list: %v", err)
+		}
+		logger.Infof("Read %d subdomains from %s, skipping enumeration", len(listed), describeList(listFile))
+		subdomains = listed
+		attributed.Add(attribution.List, listed...)
+	} else {
+		logger.Infof("Starting subdomain enumeration for: %s", target)
+	}
+	
+	if listFile == "" && (cp == nil || !cp.Resumed()) {
+		enumerated, ports := enumerateDomain(ctx, target, settings)
+		subdomains = append(subdomains, enumerated...)
+		knownPorts = ports
+	}
+	
+	// Nameservers handing out the zone give away every name in it
+	var openNameservers []resolver.Nameserver
+	if zoneTransfer && !passiveOnly && target != "" && listFile == "" && (cp == nil || !cp.Resumed()) && ctx.Err() == nil {
+		var zone []string
+		zone, openNameservers = transferZone(target)
+		attributed.Add(attribution.ZoneTransfer, zone...)
+		subdomains = append(subdomains, zone...)
+	}
+	
+	// The apex's mail exchangers, SRV targets and SPF mechanisms name hosts
+	// sources may not know about
+	var apex *model.ApexRecords
+	if apexRecords && !passiveOnly && target != "" && ctx.Err() == nil {
+		apex = lookupApex(target, settings)
+		if apex != nil && listFile == "" && (cp == nil || !cp.Resumed()) {
+			named := resolver.ApexCandidates(*apex)
+			attributed.Add(attribution.Apex, named...)
+			subdomains = append(subdomains, named...)
+		}
+	}
+	
+	// A signed zone's NSEC records chain its names together
+	var zoneWalk *resolver.ZoneWalk
+	if nsecWalk && !passiveOnly && target != "" && listFile == "" && (cp == nil || !cp.Resumed()) && ctx.Err() == nil {
+		zoneWalk = walkZone(ctx, target)
+		if zoneWalk != nil {
+			attributed.Add(attribution.ZoneWalk, zoneWalk.Names...)
+			subdomains = append(subdomains, zoneWalk.Names...)
+		}
+	}
+	
+	// Deduplicate subdomains
+	uniqueSubdomains, uniqueMap := scan.Dedupe(subdomains)
+	uniqueSubdomains = applyScope(settings.scope, uniqueSubdomains)
+	
+	logger.Infof("Total unique subdomains found: %d", len(uniqueSubdomains))
+	if zoneWalk != nil && zoneWalk.NSEC3 {
+		known := zoneWalk.Crack(uniqueSubdomains)
+		logger.Infof("%d of the zone's %d NSEC3 hashes match a candidate", len(known), len(zoneWalk.Hashes))
+	}
+	completed.candidates = len(uniqueSubdomains)
+	if run != nil {
+		recordRun(run.AddCandidates(uniqueSubdomains))
+	}
+	
+	pending := uniqueSubdomains
+	var restoredRecords []resolver.DNSRecord
+	if cp != nil {
+		if !cp.Resumed() && ctx.Err() == nil {
+			if err := cp.SetCandidates(uniqueSubdomains, knownPorts); err != nil {
+				logger.Warnf("could not save checkpoint: %v", err)
+			}
+		}
+		pending = cp.Unresolved(uniqueSubdomains)
+		restoredRecords = cp.Alive()
+		if len(pending) < len(uniqueSubdomains) {
+			logger.Infof("%d subdomains were resolved before the interruption", len(uniqueSubdomains)-len(pending))
+		}
+	}
+	
+	// Target-level data reported alongside the hosts
+	var info formatter.ScanInfo
+	if nsFingerprint {
+		info.Nameservers = fingerprintNameservers(target)
+	}
+	info.Nameservers = markZoneTransfers(info.Nameservers, openNameservers)
+	info.Apex = apex
+	
+	// The apex and www hosts are the reference for the hosts analyzed later
+	var baselines []scorer.Baseline
+	if target != "" && (enableScoring || enableProbe) {
+		baselines = profileBaselines(ctx, target, settings)
+		info.Baseline = scorer.Profiles(baselines)
+	}
+	if bucketScan && target != "" {
+		info.Buckets = scanBuckets(ctx, target, settings)
+	}
+	
+	logger.Infof("Resolving subdomains...")
+	resolveOptions := resolveOptionsFor(target, settings)
+	streamRecords := settings.sink != nil && !enableProbe && !enableScoring && (outputFormat == "" || outputFormat == formatter.FormatPlain)
+	if streamRecords && !reverify {
+		resolveOptions.OnResolved = func(record resolver.DNSRecord) {
+			record.Sources = attributed.Of(record.Name)
+			writeResult(settings.sink, sink.Result{Target: target, Record: &record})
+		}
+		for i := range restoredRecords {
+			restoredRecords[i].Sources = attributed.Of(restoredRecords[i].Name)
+			writeResult(settings.sink, sink.Result{Target: target, Record: &restoredRecords[i]})
+		}
+	}
+	if cp != nil {
+		resolveOptions.OnAttempted = cp.Attempted
+	}
+	dnsRecords := append(restoredRecords, resolver.ResolveSubdomains(ctx, pending, resolveOptions)...)
+	
+	// Confirmed names are permuted along their own naming patterns, once with
+	// --feedback and until nothing new resolves with --iterative
+	if (feedbackRound || iterative) && !passiveOnly && target != "" {
+		rounds := 1
+		if iterative {
+			rounds = maxIterations
+		}
+		found, tried := resolveFeedback(ctx, target, settings.scope, dnsRecords, uniqueMap, rounds, resolveOptions, run)
+		completed.candidates += tried
+		dnsRecords = append(dnsRecords, found...)
+	}
+	
+	// Reverse DNS of the ranges the target resolves into names hosts no
+	// source or wordlist knows about
+	if reverseSweep && !passiveOnly && target != "" && ctx.Err() == nil {
+		found, tried := resolveReverseSweep(ctx, target, settings.scope, dnsRecords, uniqueMap, resolveOptions, run)
+		completed.candidates += tried
+		dnsRecords = append(dnsRecords, found...)
+	}
+	attributeRecords(dnsRecords, attributed)
+	if reverify {
+		dnsRecords = reverifyRecords(ctx, target, dnsRecords, settings)
+		if streamRecords {
+			for i := range dnsRecords {
+				writeResult(settings.sink, sink.Result{Target: target, Record: &dnsRecords[i]})
+			}
+		}
+	}
+	aliveSubdomains := resolver.Names(dnsRecords)
+	recordsByName := resolver.RecordMap(dnsRecords)
+	logger.Infof("Found %d alive subdomains", len(aliveSubdomains))
+	completed.alive = len(aliveSubdomains)
+	if run != nil {
+		recordRun(run.AddRecords(dnsRecords))
+	}
+	
+	// Hosts harvested from certificate SANs count towards the tls-san source
+	var sanAlive []string
+	
+	// Probing for misconfigurations if enabled
+	var probeResults []probe.ProbeResult
+	if enableProbe && len(aliveSubdomains) > 0 {
+		logger.Infof("🔍 Probing for misconfigurations and security issues...")
+		
+		options := probeOptionsFor(target, settings, recordsByName)
+		
+		// Very large scans keep results on disk and only count them in memory
+		var spill *store.Spill
+		var summary probe.Summary
+		var findings []probe.Finding
+		var spillMu sync.Mutex
+		// Spilled findings are only kept for the findings state and summary notifications
+		keepFindings := findingsState != "" || (settings.notifier != nil && settings.notifier.summary)
+		if spillDir != "" {
+			var err error
+			spill, err = store.Open(spillDir)
+			if err != nil {
+				return err
+			}
+			defer spill.Close()
+			options.DiscardResults = true
+		}
+		
+		if settings.sink != nil || spill != nil {
+			options.OnResult = func(result probe.ProbeResult) {
+				annotated := []probe.ProbeResult{result}
+				settings.annotations.ApplyToProbes(annotated)
+				if settings.sink != nil {
+					writeResult(settings.sink, sink.Result{Target: target, Probe: &annotated[0]})
+				}
+				if spill != nil {
+					spillMu.Lock()
+					summary.Add(annotated[0])
+					if keepFindings {
+						findings = append(findings, annotated[0].Findings...)
+					}
+					spillMu.Unlock()
+					if !settings.filter.Probe(annotated[0]) {
+						return
+					}
+					if err := spill.Put(annotated[0].Domain, annotated[0]); err != nil {
+						logger.Warnf("could not store result for %s: %v", annotated[0].Domain, err)
+					}
+				}
+			}
+		}
+		
+		// Hosts probed before an interruption keep their results
+		toProbe := aliveSubdomains
+		var restored []probe.ProbeResult
+		if cp != nil {
+			toProbe = cp.Unprobed(aliveSubdomains)
+			restored = cp.Probed()
+			onResult := options.OnResult
+			options.OnResult = func(result probe.ProbeResult) {
+				cp.AddProbed(result)
+				if onResult != nil {
+					onResult(result)
+				}
+			}
+			if onResult != nil {
+				for _, result := range restored {
+					onResult(result)
+				}
+			}
+		}
+		
+		// Findings are sent as soon as their host is probed
+		if settings.notifier != nil {
+			onResult := options.OnResult
+			options.OnResult = func(result probe.ProbeResult) {
+				settings.notifier.Probed(target, result)
+				if onResult != nil {
+					onResult(result)
+				}
+			}
+		}
+		
+		// Run probes
+		probeResults = append(restored, probe.RunProbes(ctx, toProbe, options)...)
+		
+		// Nameservers handing out the zone are findings of their own
+		for _, result := range probe.ZoneTransferResults(target, openNameservers, options) {
+			if options.OnResult != nil {
+				options.OnResult(result)
+			}
+			if !options.DiscardResults {
+				probeResults = append(probeResults, result)
+			}
+		}
+		completed.probed = len(probeResults)
+		settings.annotations.ApplyToProbes(probeResults)
+		if run != nil {
+			recordRun(run.AddProbeResults(probeResults))
+		}
+		if spill == nil {
+			for _, result := range probeResults {
+				findings = append(findings, result.Findings...)
+			}
+		}
+		
+		// Only report findings that weren't seen in earlier runs
+		if findingsState != "" {
+			findings = reportNewFindings(findings)
+		}
+		settings.notifier.Finished(target, findings)
+		
+		// Display probe summary
+		if spill != nil {
+			printResults(target, settings.multi, summary.String())
+			if !settings.streamed {
+				writeSpilled(target, settings.multi, spill, output, func(w *formatter.StreamWriter, data []byte) error {
+					var result probe.ProbeResult
+					if err := json.Unmarshal(data, &result); err != nil {
+						return err
+					}
+					return w.WriteProbeResult(result)
+				})
+			}
+		} else {
+			printResults(target, settings.multi, probe.FormatProbeResults(settings.filter.Probes(probeResults), false))
+		}
+		
+		// Write probe results to file if requested (streaming and spilling already did)
+		if output != "" && !settings.streamed && spill == nil {
+			// If format is specified, use the formatter package
+			if outputFormat != "" {
+				info.Caveats = caveats.List()
+				formattedOutput, err := formatter.FormatProbeScan(settings.filter.Probes(probeResults), outputFormat, info)
+				if err != nil {
+					logger.Errorf("could not format probe results: %v", err)
+				} else {
+					err = os.WriteFile(output, []byte(formattedOutput), 0644)
+					if err != nil {
+						logger.Errorf("could not write probe results to file: %v", err)
+					} else {
+						logger.Infof("Probe results saved to %s in %s format", output, outputFormat)
+					}
+				}
+			} else {
+				// For plain text format, use the probe package's formatter
+				formattedOutput := probe.FormatProbeResults(settings.filter.Probes(probeResults), true)
+				writeFormattedToFile(formattedOutput, output)
+			}
+		}
+	}
+	
+	// Analyze and score subdomains if enabled
+	if enableScoring && len(aliveSubdomains) > 0 && !enableProbe {
+		logger.Infof("🔍 Analyzing and scoring alive subdomains...")
+		
+		options := scoreOptionsFor(target, settings, recordsByName, knownPorts)
+		options.Baselines = baselines
+		
+		// Very large scans keep results on disk, holding only certificate SANs in memory
+		var spill *store.Spill
+		var sanSources []scorer.SubdomainInfo
+		var spillMu sync.Mutex
+		if spillDir != "" {
+			var err error
+			spill, err = store.Open(spillDir)
+			if err != nil {
+				return err
+			}
+			defer spill.Close()
+			options.DiscardResults = true
+		}
+		
+		if settings.sink != nil || spill != nil {
+			options.OnResult = func(info scorer.SubdomainInfo) {
+				annotated := []scorer.SubdomainInfo{info}
+				settings.annotations.ApplyToScores(annotated)
+				if settings.sink != nil {
+					writeResult(settings.sink, sink.Result{Target: target, Subdomain: &annotated[0]})
+				}
+				if spill != nil {
+					if len(info.SANs) > 0 {
+						spillMu.Lock()
+						sanSources = append(sanSources, scorer.SubdomainInfo{SANs: info.SANs})
+						spillMu.Unlock()
+					}
+					if !settings.filter.Subdomain(annotated[0]) {
+						return
+					}
+					if err := spill.Put(scorer.SortKey(annotated[0]), annotated[0]); err != nil {
+						logger.Warnf("could not store result for %s: %v", info.Subdomain, err)
+					}
+				}
+			}
+		}
+		
+		// Hosts scored before an interruption keep their results
+		toScore := aliveSubdomains
+		var restored []scorer.SubdomainInfo
+		if cp != nil {
+			toScore = cp.Unscored(aliveSubdomains)
+			restored = cp.Scored()
+			onResult := options.OnResult
+			options.OnResult = func(info scorer.SubdomainInfo) {
+				cp.AddScored(info)
+				if onResult != nil {
+					onResult(info)
+				}
+			}
+			if onResult != nil {
+				for _, info := range restored {
+					onResult(info)
+				}
+			}
+		}
+		
+		// Run analysis
+		results := append(restored, scorer.AnalyzeSubdomains(ctx, toScore, options)...)
+		if spill != nil {
+			results = sanSources
+		}
+		if len(restored) > 0 {
+			scorer.SortByScore(results)
+		}
+		
+		// Certificates often name hosts enumeration missed; resolve and score
+		// those too, and then the hosts named by their certificates in turn
+		if sanHarvest {
+			known := make(map[string]bool, len(uniqueMap))
+			for name := range uniqueMap {
+				known[name] = true
+			}
+			sanOptions := scan.SANOptions{Rounds: scan.DefaultSANRounds, Scope: settings.scope}
+			if cp != nil {
+				sanOptions.Pending = cp.Unscored
+			}
+			sanResults, sanRecords := scan.HarvestSANs(ctx, target, results, known, resolveOptions, options, sanOptions)
+			sanAlive = resolver.Names(sanRecords)
+			results = append(results, sanResults...)
+			if len(sanRecords) > 0 {
+				logger.Infof("Harvested %d live subdomains from certificate SANs", len(sanRecords))
+				scorer.SortByScore(results)
+			}
+		}
+		settings.annotations.ApplyToScores(results)
+		if spill == nil {
+			captureScreenshots(ctx, results, settings)
+		}
+		if run != nil {
+			recordRun(run.AddScores(results))
+		}
+		completed.scored = len(results)
+		if spill != nil {
+			completed.scored = spill.Len()
+		}
+		
+		// Format results based on the requested format
+		shown := settings.filter.Subdomains(results)
+		if spill != nil {
+			if settings.streamed {
+				logger.Infof("Streamed %d results", spill.Len())
+			} else {
+				writeSpilled(target, settings.multi, spill, output, func(w *formatter.StreamWriter, data []byte) error {
+					var info scorer.SubdomainInfo
+					if err := json.Unmarshal(data, &info); err != nil {
+						return err
+					}
+					return w.WriteSubdomain(info)
+				})
+			}
+		} else if settings.streamed {
+			logger.Infof("Streamed %d results", len(results))
+		} else if outputFormat != "" {
+			info.Caveats = caveats.List()
+			formattedOutput, err := formatter.FormatScan(shown, outputFormat, target, info)
+			if err != nil {
+				return fmt.Errorf("could not format results: %v", err)
+			}
+			
+			// Write to file if specified, otherwise print to stdout
+			if output != "" {
+				err = os.WriteFile(output, []byte(formattedOutput), 0644)
+				if err != nil {
+					return fmt.Errorf("could not write to file: %v", err)
+				}
+				logger.Infof("Results saved to %s in %s format", output, outputFormat)
+			} else {
+				printResults(target, settings.multi, formattedOutput)
+			}
+		} else {
+			// Use default formatting
+			printResults(target, settings.multi, "\n📊 Subdomain Analysis Results (Sorted by Score):\n"+scorer.FormatResults(shown))
+			
+			// Write results to file if requested
+			if output != "" {
+				writeFormattedToFile(scorer.FormatResults(shown), output)
+			}
+		}
+	} else if !enableProbe && formatter.IsTargetFormat(outputFormat) {
+		formattedOutput, err := formatter.FormatTargets(settings.filter.Records(dnsRecords), outputFormat)
+		if err != nil {
+			return fmt.Errorf("could not format targets: %v", err)
+		}
+		if output != "" {
+			writeFormattedToFile(formattedOutput, output)
+		} else {
+			printResults(target, settings.multi, formattedOutput)
+		}
+	} else if !enableProbe {
+		// Output basic results without scoring
+		if outputFormat != "" && outputFormat != formatter.FormatPlain {
+			return fmt.Errorf("scoring is required for the %s format; use --score", outputFormat)
+		}
+		
+		if !settings.streamed {
+			shown := resolver.Names(settings.filter.Records(dnsRecords))
+			printResults(target, settings.multi, strings.Join(shown, "\n"))
+			
+			if output != "" && !enableProbe {
+				writeToFile(shown, output)
+			}
+		}
+	}
+	
+	logSourceStats(target, attributed, append(resolver.Names(dnsRecords), sanAlive...))
+	settings.summaries.add(target, dnsRecords, probeResults)
+	return nil
+}
+
+// attributeRecords sets the sources of each record from those recorded
+func attributeRecords(records []resolver.DNSRecord, attributed *attribution.Sources) {
+	for i := range records {
+		records[i].Sources = attributed.Of(records[i].Name)
+	}
+}
+
+// logSourceStats prints how many names each source found, how many no other
+// source found and how many of them resolved
+func logSourceStats(target string, attributed *attribution.Sources, alive []string) {
+	stats := attributed.Stats(alive)
+	if len(stats) == 0 {
+		return
+	}
+	if target != "" {
+		logger.Infof("📈 Sources of %s:", target)
+	} else {
+		logger.Infof("📈 Sources:")
+	}
+	for _, stat := range stats {
+		logger.Infof("  %s", stat)
+	}
+}
+
+// fingerprintNameservers asks the target's authoritative nameservers for their
+// software version and hostname, and prints what they disclose
+func fingerprintNameservers(target string) []resolver.Nameserver {
+	nameservers, err := resolver.FingerprintNameservers(target, time.Duration(resolveTimeout)*time.Second)
+	if err != nil {
+		logger.Warnf("could not fingerprint the nameservers of %s: %v", target, err)
+		return nil
+	}
+	logger.Infof("🧬 Nameservers of %s:", target)
+	for _, ns := range nameservers {
+		logger.Infof("  %s", ns)
+	}
+	return nameservers
+}
+
+// transferZone attempts a zone transfer from each authoritative nameserver of
+// the target, returning the names in the zone and the nameservers that
+// handed it out
+func transferZone(target string) ([]string, []resolver.Nameserver) {
+	zone, open, err := scan.TransferZone(target, time.Duration(resolveTimeout)*time.Second)
+	if err != nil {
+		logger.Debugf("could not look up the nameservers of %s for a zone transfer: %v", target, err)
+		return nil, nil
+	}
+	for _, ns := range open {
+		logger.Warnf("🚨 %s allows zone transfers of %s", ns.Name, target)
+	}
+	if len(open) > 0 {
+		logger.Infof("Zone transfer listed %d subdomains", len(zone))
+	}
+	return zone, open
+}
+
+// walkZone walks the NSEC chain of the target's zone, or collects its NSEC3
+// hashes, and saves the hashes for offline cracking when asked to
+func walkZone(ctx context.Context, target string) *resolver.ZoneWalk {
+	nameservers, err := resolver.LookupNameservers(target)
+	if err != nil {
+		logger.Warnf("could not look up the nameservers of %s for a zone walk: %v", target, err)
+		return nil
+	}
+	options := resolver.DefaultWalkOptions()
+	options.MaxQueries = nsecMaxQueries
+	options.Timeout = time.Duration(resolveTimeout) * time.Second
+	logger.Infof("🔗 Walking the DNSSEC chain of %s...", target)
+	walk, err := resolver.WalkZone(ctx, target, nameservers, options)
+	if err != nil {
+		logger.Warnf("could not walk the zone of %s: %v", target, err)
+		return nil
+	}
+
+	extent := "partial"
+	if walk.Complete {
+		extent = "complete"
+	}
+	if !walk.NSEC3 {
+		logger.Infof("NSEC walk listed %d subdomains in %d queries (%s)", len(walk.Names), walk.Queries, extent)
+		return &walk
+	}
+	logger.Infof("Collected %d NSEC3 hashes in %d queries (%s, %d iterations)", len(walk.Hashes), walk.Queries, extent, walk.Iterations)
+	if nsec3HashFile != "" {
+		content := strings.Join(walk.HashcatLines(), "\n") + "\n"
+		if err := os.WriteFile(nsec3HashFile, []byte(content), 0644); err != nil {
+			logger.Warnf("could not save NSEC3 hashes: %v", err)
+		} else {
+			logger.Infof("NSEC3 hashes saved to %s (hashcat mode 8300)", nsec3HashFile)
+		}
+	}
+	return &walk
+}
 
-		// Validate output format if specified
-		if outputFormat != "" && !formatter.IsValidFormat(outputFormat) {
-			fmt.Printf("Error: invalid output format '%s'. Supported formats: plain, json, csv, html, markdown\n", outputFormat)
-			os.Exit(1)
+// lookupApex looks up the DNS records of the target domain itself, and
+// prints what they reveal
+func lookupApex(target string, settings scanSettings) *model.ApexRecords {
+	records := resolver.LookupApex(target, settings.nameservers, time.Duration(resolveTimeout)*time.Second)
+	if records.Empty() {
+		logger.Warnf("could not find any apex records of %s", target)
+		return nil
+	}
+	logger.Infof("📮 Apex records of %s: %d NS, %d MX, %d TXT, %d SRV", target, len(records.NS), len(records.MX), len(records.TXT), len(records.SRV))
+	if records.SPF() == "" {
+		logger.Infof("  No SPF record")
+	}
+	if records.DMARC == "" {
+		logger.Infof("  No DMARC record")
+	}
+	if services := records.Verifications(); len(services) > 0 {
+		logger.Infof("  Verified with %s", strings.Join(services, ", "))
+	}
+	if candidates := resolver.ApexCandidates(records); len(candidates) > 0 {
+		logger.Infof("  %d hosts named by MX, SRV and SPF records", len(candidates))
+	}
+	return &records
+}
+
+// markZoneTransfers marks the nameservers that handed out the zone, adding
+// the ones not already listed
+func markZoneTransfers(nameservers []resolver.Nameserver, open []resolver.Nameserver) []resolver.Nameserver {
+	for _, transferred := range open {
+		found := false
+		for i := range nameservers {
+			if nameservers[i].Name == transferred.Name {
+				nameservers[i].ZoneTransfer = true
+				found = true
+			}
 		}
+		if !found {
+			nameservers = append(nameservers, transferred)
+		}
+	}
+	return nameservers
+}
 
-		fmt.Printf("Starting subdomain enumeration for: %s\n", domain)
-		
-		var passiveResults []string
-		var subdomains []string
-		
-		if !activeOnly {
-			fmt.Println("Performing passive enumeration...")
-			passiveResults = enumeration.FetchPassive(domain)
-			fmt.Printf("Found %d subdomains through passive enumeration\n", len(passiveResults))
-			subdomains = append(subdomains, passiveResults...)
+// scanBuckets checks the cloud storage buckets named after the target, and
+// prints the ones that exist
+func scanBuckets(ctx context.Context, target string, settings scanSettings) []model.Bucket {
+	options := buckets.DefaultOptions()
+	options.Concurrency = probeConcurrency
+	options.Timeout = time.Duration(probeTimeout) * time.Second
+	options.UserAgent = settings.userAgent
+	options.Proxy = settings.probeProxy
+	names := buckets.Names(target, settings.bucketWords)
+	logger.Infof("🪣 Checking %d bucket names on %d providers...", len(names), len(options.Providers))
+	found := buckets.Check(ctx, names, options)
+	if len(found) == 0 {
+		logger.Infof("No buckets named after %s found", target)
+		return nil
+	}
+	logger.Infof("🪣 Buckets of %s:", target)
+	for _, bucket := range found {
+		if bucket.Access == buckets.AccessPublic {
+			logger.Warnf("  %s", bucket)
+		} else {
+			logger.Infof("  %s", bucket)
 		}
-		
-		var bruteResults []string
-		if !passiveOnly {
-			var wordlistSubdomains []string
-			
-			if smartBruteforce && len(passiveResults) > 0 {
-				fmt.Println("🧠 Using smart wordlist expansion...")
-				
-				// Configure expansion options
-				options := expander.ExpandOptions{
-					PassiveSubdomains: passiveResults,
-					CommonspeakPath:   commonspeakPath,
-					UseDNSTwist:       useDNSTwist,
-					VerboseOutput:     verboseExpansion,
-				}
-				
-				// Run the expansion
-				expandedWords := expander.ExpandWordlist(options)
-				
-				// Append domain to each expanded word to create potential subdomains
-				for _, word := range expandedWords {
-					if !strings.Contains(word, ".") {
-						// It's a prefix, not a full subdomain
-						wordlistSubdomains = append(wordlistSubdomains, fmt.Sprintf("%s.%s", word, domain))
-					} else {
-						// It's already a full subdomain
-						wordlistSubdomains = append(wordlistSubdomains, word)
-					}
-				}
-				
-				fmt.Printf("🔍 Smart expansion generated %d potential subdomains\n", len(wordlistSubdomains))
-			}
-			
-			// If a traditional wordlist is provided, use it too
-			if wordlist != "" {
-				fmt.Println("Performing brute force with wordlist...")
-				wordlistResults := enumeration.BruteForce(domain, wordlist)
-				fmt.Printf("Found %d potential subdomains through wordlist\n", len(wordlistResults))
-				
-				// Add wordlist results to the brute force candidates
-				wordlistSubdomains = append(wordlistSubdomains, wordlistResults...)
-			}
-			
-			// Just adding the results without having done resolution yet
-			bruteResults = wordlistSubdomains
-			subdomains = append(subdomains, bruteResults...)
+	}
+	return found
+}
+
+// profileBaselines profiles the target's apex and www hosts, and prints
+// what they serve
+func profileBaselines(ctx context.Context, target string, settings scanSettings) []scorer.Baseline {
+	options := scoreOptionsFor(target, settings, nil, nil)
+	if !enableScoring {
+		options.Proxy = settings.probeProxy
+	}
+	baselines := scorer.ProfileBaselines(ctx, target, options)
+	logger.Infof("📌 Baseline of %s:", target)
+	for _, baseline := range baselines {
+		logger.Infof("  %s", baseline.Profile)
+	}
+	return baselines
+}
+
+// startRun records the start of a domain's scan in the results database, and
+// its completion once scanDomain returns
+func startRun(target string, settings scanSettings) *db.Run {
+	if settings.results == nil {
+		return nil
+	}
+	run, err := settings.results.StartRun(target)
+	if err != nil {
+		logger.Warnf("%v", err)
+		return nil
+	}
+	return run
+}
+
+// recordRun warns when results could not be written to the database; the
+// scan's own output is unaffected
+func recordRun(err error) {
+	if err != nil {
+		logger.Warnf("could not record results in database: %v", err)
+	}
+}
+
+// finishCheckpoint removes the state file of a completed scan, or saves it
+// when the scan was interrupted so it can continue with the same --resume file
+func finishCheckpoint(ctx context.Context, cp *checkpoint.Checkpoint) {
+	if ctx.Err() == nil {
+		if err := cp.Remove(); err != nil {
+			logger.Warnf("could not remove state file: %v", err)
 		}
-		
-		// Deduplicate subdomains
-		uniqueMap := make(map[string]bool)
-		var uniqueSubdomains []string
-		
-		for _, subdomain := range subdomains {
-			subdomain = strings.ToLower(strings.TrimSpace(subdomain))
-			if subdomain != "" && !uniqueMap[subdomain] {
-				uniqueMap[subdomain] = true
-				uniqueSubdomains = append(uniqueSubdomains, subdomain)
-			}
+		return
+	}
+	if !cp.Resumed() {
+		logger.Warnf("interrupted before enumeration finished; nothing to resume")
+		return
+	}
+	if err := cp.Save(); err != nil {
+		logger.Errorf("could not save checkpoint: %v", err)
+		return
+	}
+	logger.Warnf("progress saved, rerun with --resume %s to continue", resumeFile)
+}
+
+// enumerateDomain runs passive enumeration and brute forcing for a domain and
+// returns the candidates found, with the ports passive sources reported
+func enumerateDomain(ctx context.Context, target string, settings scanSettings) ([]string, map[string][]int) {
+	var passiveResults []string
+	var subdomains []string
+	var knownPorts map[string][]int
+	
+	// Public sources know nothing about private zones and must not learn their names
+	skipPassive := activeOnly
+	if !activeOnly && isInternal(target) {
+		logger.Infof("%s is an internal domain, skipping passive sources", target)
+		if len(settings.parsedWordlists) == 0 {
+			logger.Warnf("no wordlist given; use --wordlist or --list to supply candidates")
 		}
-		
-		fmt.Printf("Total unique subdomains found: %d\n", len(uniqueSubdomains))
-		
-		fmt.Println("Resolving subdomains...")
-		aliveSubdomains := resolver.ResolveSubdomains(uniqueSubdomains)
-		fmt.Printf("Found %d alive subdomains\n", len(aliveSubdomains))
-		
-		// Always score if format other than plain is requested
-		if !enableScoring && outputFormat != "" && outputFormat != formatter.FormatPlain {
-			enableScoring = true
+		skipPassive = true
+	}
+	
+	if !skipPassive {
+		settings.windows.Wait(ctx)
+		logger.Infof("Performing passive enumeration...")
+		var recursion *enumeration.RecursionOptions
+		if recursiveEnum {
+			recursion = &settings.recursion
 		}
 		
-		// Probing for misconfigurations if enabled
-		var probeResults []probe.ProbeResult
-		if enableProbe && len(aliveSubdomains) > 0 {
-			fmt.Println("🔍 Probing for misconfigurations and security issues...")
-			
-			// Configure probe options
-			options := probe.ProbeOptions{
-				Concurrency: probeConcurrency,
-				Timeout:     time.Duration(probeTimeout) * time.Second,
-				UserAgent:   "Subscan/1.0",
-				Verbose:     probeVerbose,
-			}
-			
-			// Run probes
-			probeResults = probe.RunProbes(aliveSubdomains, options)
-			
-			// Display probe summary
-			fmt.Println(probe.FormatProbeResults(probeResults, false))
-			
-			// Write probe results to file if requested
-			if outputFile != "" {
-				// If format is specified, use the formatter package
-				if outputFormat != "" {
-					formattedOutput, err := formatter.FormatProbeResults(probeResults, outputFormat)
-					if err != nil {
-						fmt.Printf("Error formatting probe results: %v\n", err)
-					} else {
-						err = os.WriteFile(outputFile, []byte(formattedOutput), 0644)
-						if err != nil {
-							fmt.Printf("Error writing probe results to file: %v\n", err)
-						} else {
-							fmt.Printf("Probe results saved to %s in %s format\n", outputFile, outputFormat)
-						}
-					}
-				} else {
-					// For plain text format, use the probe package's formatter
-					formattedOutput := probe.FormatProbeResults(probeResults, true)
-					writeFormattedToFile(formattedOutput, outputFile)
-				}
-			}
+		// Passive data occasionally contains odd entries outside the target
+		var dropped int
+		passiveResults, dropped, knownPorts = scan.EnumeratePassive(ctx, target, settings.sources, recursion)
+		if urlsFile != "" {
+			writeURLs(enumeration.CollectURLs(settings.sources, target), domainOutputFile(urlsFile, target, settings.multi))
+		}
+		if dropped > 0 {
+			logger.Infof("Dropped %d out-of-scope passive results", dropped)
 		}
+		logger.Infof("Found %d subdomains through passive enumeration", len(passiveResults))
+		subdomains = append(subdomains, passiveResults...)
+	}
+	
+	var bruteResults []string
+	if !passiveOnly {
+		var wordlistSubdomains []string
 		
-		// Analyze and score subdomains if enabled
-		if enableScoring && len(aliveSubdomains) > 0 && !enableProbe {
-			fmt.Println("🔍 Analyzing and scoring alive subdomains...")
+		if smartBruteforce && len(passiveResults) > 0 {
+			logger.Infof("🧠 Using smart wordlist expansion...")
 			
-			// Configure analysis options
-			options := scorer.AnalysisOptions{
-				Concurrency:    scoreConcurrency,
-				Timeout:        time.Duration(scoreTimeout) * time.Second,
-				VerboseOutput:  verboseScoring,
-				ExcludeHeaders: true,
+			// Configure expansion options
+			options := expander.ExpandOptions{
+				PassiveSubdomains: passiveResults,
+				CommonspeakPath:   commonspeakPath,
+				UseDNSTwist:       useDNSTwist,
+				VerboseOutput:     verboseExpansion,
+				Twist:             expander.DefaultTwistOptions(),
 			}
+			options.Twist.Domain = target
+			options.Twist.Limit = twistLimit
+			options.Twist.PerSubdomain = twistPerName
+			options.Twist.Seed = scanSeed
+			options.Seed = scanSeed
 			
-			// Run analysis
-			results := scorer.AnalyzeSubdomains(aliveSubdomains, options)
+			// Run the expansion
+			expandedWords := expander.ExpandWordlist(options)
 			
-			// Format results based on the requested format
-			if outputFormat != "" {
-				formattedOutput, err := formatter.Format(results, outputFormat, domain)
-				if err != nil {
-					fmt.Printf("Error formatting results: %v\n", err)
-					os.Exit(1)
-				}
-				
-				// Write to file if specified, otherwise print to stdout
-				if outputFile != "" {
-					err = os.WriteFile(outputFile, []byte(formattedOutput), 0644)
-					if err != nil {
-						fmt.Printf("Error writing to file: %v\n", err)
-						os.Exit(1)
-					}
-					fmt.Printf("Results saved to %s in %s format\n", outputFile, outputFormat)
+			// Append domain to each expanded word to create potential subdomains
+			for _, word := range expandedWords {
+				if !strings.Contains(word, ".") {
+					// It's a prefix, not a full subdomain
+					wordlistSubdomains = append(wordlistSubdomains, fmt.Sprintf("%s.%s", word, target))
 				} else {
-					fmt.Println(formattedOutput)
-				}
-			} else {
-				// Use default formatting
-				fmt.Println("\n📊 Subdomain Analysis Results (Sorted by Score):")
-				fmt.Println(scorer.FormatResults(results))
-				
-				// Write results to file if requested
-				if outputFile != "" {
-					writeFormattedToFile(scorer.FormatResults(results), outputFile)
+					// It's already a full subdomain
+					wordlistSubdomains = append(wordlistSubdomains, word)
 				}
 			}
-		} else if !enableProbe {
-			// Output basic results without scoring
-			if outputFormat != "" && outputFormat != formatter.FormatPlain {
-				fmt.Println("Warning: scoring is required for the requested format. Please use --score flag.")
-				os.Exit(1)
-			}
 			
-			for _, sub := range aliveSubdomains {
-				fmt.Println(sub)
+			logger.Infof("🔍 Smart expansion generated %d potential subdomains", len(wordlistSubdomains))
+			attribution.Record(ctx, attribution.SmartBruteforce, wordlistSubdomains...)
+		}
+		
+		// Permute the known subdomains into names following their patterns
+		if permutationLevel > 0 && len(passiveResults) > 0 {
+			options := expander.DefaultPermutationOptions()
+			options.Domain = target
+			options.Level = permutationLevel
+			options.Words = settings.permuteWords
+			options.Seed = scanSeed
+			permutations := expander.Permute(passiveResults, options)
+			logger.Infof("🔀 Permutation engine generated %d potential subdomains (level %d)", len(permutations), permutationLevel)
+			attribution.Record(ctx, attribution.Permutation, permutations...)
+			wordlistSubdomains = append(wordlistSubdomains, permutations...)
+		}
+		
+		// If traditional wordlists are provided, use them too
+		for _, list := range settings.parsedWordlists {
+			logger.Infof("Performing brute force with wordlist %s (%s)...", list.Path, list.Mode)
+			wordlistResults := enumeration.BruteForceWordlist(target, list, passiveResults)
+			logger.Infof("Found %d potential subdomains through wordlist", len(wordlistResults))
+			if list.Mode != enumeration.WordlistPrefix && list.Limit > 0 && len(wordlistResults) >= list.Limit {
+				coverage.Note(ctx, "wordlist %s stopped at %d %s candidates before combining its words with every known subdomain", list.Path, list.Limit, list.Mode)
 			}
+			attribution.Record(ctx, attribution.Bruteforce, wordlistResults...)
 			
-			if outputFile != "" && !enableProbe {
-				writeToFile(aliveSubdomains, outputFile)
-			}
+			// Add wordlist results to the brute force candidates
+			wordlistSubdomains = append(wordlistSubdomains, wordlistResults...)
 		}
-	},
+		
+		// Never let generated candidates leave the target or exceed the depth limit
+		var dropped int
+		wordlistSubdomains, dropped = enumeration.ScopeCandidates(wordlistSubdomains, target, maxDepth)
+		if dropped > 0 {
+			logger.Infof("Dropped %d generated candidates outside the domain or deeper than --max-depth", dropped)
+		}
+		if scanSeed != 0 {
+			wordlistSubdomains = expander.Order(wordlistSubdomains, scanSeed)
+		}
+		
+		// Just adding the results without having done resolution yet
+		bruteResults = wordlistSubdomains
+		subdomains = append(subdomains, bruteResults...)
+	}
+	
+	return subdomains, knownPorts
+}
+
+// loadFilter builds the output filter from --match-status, --min-score,
+// --only-cloud and --tag, or returns nil when none is given
+func loadFilter() *filter.Filter {
+	if len(matchStatuses) == 0 && minScore == 0 && !onlyCloud && len(matchTags) == 0 {
+		return nil
+	}
+	return &filter.Filter{
+		Statuses:  matchStatuses,
+		MinScore:  minScore,
+		OnlyCloud: onlyCloud,
+		Tags:      matchTags,
+	}
+}
+
+// applyScope drops the names the scope rules exclude, logging how many
+func applyScope(scope *enumeration.ScopeFilter, names []string) []string {
+	kept, dropped := scope.Filter(names)
+	if dropped > 0 {
+		logger.Infof("Dropped %d hosts excluded by the scope rules", dropped)
+	}
+	return kept
+}
+
+// resolveOptionsFor builds the resolution options for a domain from the flags
+func resolveOptionsFor(target string, settings scanSettings) resolver.ResolveOptions {
+	resolveOptions := resolver.DefaultResolveOptions()
+	resolveOptions.Windows = settings.windows
+	resolveOptions.Fast = fastResolve
+	resolveOptions.Concurrency = resolveConcurrency
+	resolveOptions.Rate = resolveRate
+	resolveOptions.Timeout = time.Duration(resolveTimeout) * time.Second
+	resolveOptions.Nameservers = settings.nameservers
+	resolveOptions.DoHURL = settings.dohURL
+	resolveOptions.DoTServers = settings.dotResolvers
+	resolveOptions.Stats = settings.resolverStats
+	// The fast engine falls back to public resolvers, which can't see private zones
+	if isInternal(target) && len(resolveOptions.Nameservers) == 0 && resolveOptions.Fast {
+		resolveOptions.Nameservers = resolver.SystemNameservers()
+	}
+	if queryAuthoritative {
+		authoritative, err := resolver.AuthoritativeNameservers(target)
+		if err != nil {
+			logger.Warnf("authoritative nameservers unavailable, using default resolvers: %v", err)
+		} else {
+			resolveOptions.Nameservers = authoritative
+		}
+	}
+	return resolveOptions
+}
+
+// resolveFeedback runs up to rounds feedback rounds: the subdomains found
+// alive in the previous round are permuted with the words of every alive
+// subdomain and the names not tried yet are resolved, until a round finds
+// nothing new. It returns the records found and the number of names tried.
+func resolveFeedback(ctx context.Context, target string, scope *enumeration.ScopeFilter, records []resolver.DNSRecord, tried map[string]bool, rounds int, options resolver.ResolveOptions, run *db.Run) ([]resolver.DNSRecord, int) {
+	permutations := expander.DefaultPermutationOptions()
+	permutations.Domain = target
+	permutations.Limit = feedbackLimit
+	permutations.Seed = scanSeed
+
+	confirmed := resolver.Names(records)
+	seeds := confirmed
+	var found []resolver.DNSRecord
+	total := 0
+	for round := 1; round <= rounds && len(seeds) > 0 && ctx.Err() == nil; round++ {
+		candidates := expander.Feedback(seeds, confirmed, tried, permutations)
+		candidates, _ = enumeration.ScopeCandidates(candidates, target, maxDepth)
+		candidates, _ = scope.Filter(candidates)
+		if len(candidates) == 0 {
+			break
+		}
+		logger.Infof("🔁 Feedback round %d: resolving %d permutations of %d subdomains", round, len(candidates), len(seeds))
+		for _, name := range candidates {
+			tried[name] = true
+		}
+		attribution.Record(ctx, attribution.Feedback, candidates...)
+		total += len(candidates)
+		if run != nil {
+			recordRun(run.AddCandidates(candidates))
+		}
+
+		alive := resolver.ResolveSubdomains(ctx, candidates, options)
+		logger.Infof("Feedback round %d found %d new subdomains", round, len(alive))
+		found = append(found, alive...)
+		seeds = resolver.Names(alive)
+		confirmed = append(confirmed, seeds...)
+	}
+	return found, total
+}
+
+// resolveReverseSweep PTR-scans the /24 ranges of the resolved addresses and
+// resolves the in-scope hostnames found that weren't tried yet
+func resolveReverseSweep(ctx context.Context, target string, scope *enumeration.ScopeFilter, records []resolver.DNSRecord, tried map[string]bool, options resolver.ResolveOptions, run *db.Run) ([]resolver.DNSRecord, int) {
+	// CDN and cloud addresses are shared with every other customer of the
+	// provider, so their ranges name hosts of other organizations
+	var owned []resolver.DNSRecord
+	for _, record := range records {
+		if record.CNAME == "" || scorer.CloudProvider(record.CNAME) == "" {
+			owned = append(owned, record)
+		}
+	}
+	ranges := resolver.SweepRanges(owned)
+	if len(ranges) == 0 {
+		return nil, 0
+	}
+	if sweepMaxRanges > 0 && len(ranges) > sweepMaxRanges {
+		logger.Warnf("Reverse sweeping the %d of %d /24 ranges holding the most addresses", sweepMaxRanges, len(ranges))
+		coverage.Note(ctx, "reverse sweep skipped %d of %d /24 ranges", len(ranges)-sweepMaxRanges, len(ranges))
+		ranges = ranges[:sweepMaxRanges]
+	}
+	logger.Infof("🔄 Reverse sweeping %d /24 ranges of the resolved addresses...", len(ranges))
+	names := resolver.ReverseSweep(ctx, ranges, target, options)
+	names, _ = enumeration.ScopeCandidates(names, target, 0)
+	names, _ = scope.Filter(names)
+	attribution.Record(ctx, attribution.ReverseDNS, names...)
+
+	var candidates []string
+	for _, name := range names {
+		if !tried[name] {
+			tried[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 || ctx.Err() != nil {
+		logger.Infof("Reverse sweep found no new subdomains")
+		return nil, 0
+	}
+	if run != nil {
+		recordRun(run.AddCandidates(candidates))
+	}
+
+	alive := resolver.ResolveSubdomains(ctx, candidates, options)
+	logger.Infof("Reverse sweep found %d new subdomains", len(alive))
+	return alive, len(candidates)
+}
+
+// reverifyRecords resolves the alive subdomains a second time, through
+// --reverify-resolvers when given, and drops those that don't resolve again
+func reverifyRecords(ctx context.Context, target string, records []resolver.DNSRecord, settings scanSettings) []resolver.DNSRecord {
+	if len(records) == 0 || ctx.Err() != nil {
+		return records
+	}
+	options := resolveOptionsFor(target, settings)
+	if len(settings.reverifyServers) > 0 {
+		options.Nameservers = settings.reverifyServers
+		options.DoHURL = ""
+		options.DoTServers = nil
+	}
+	if reverifyDelay > 0 {
+		logger.Infof("Re-verifying %d alive subdomains in %ds...", len(records), reverifyDelay)
+	} else {
+		logger.Infof("Re-verifying %d alive subdomains...", len(records))
+	}
+
+	confirmed, dropped := resolver.Reverify(ctx, records, time.Duration(reverifyDelay)*time.Second, options)
+	for _, name := range dropped {
+		logger.Debugf("Dropped %s: did not resolve again", name)
+	}
+	if len(dropped) > 0 {
+		logger.Infof("Dropped %d subdomains that did not resolve again", len(dropped))
+	}
+	return confirmed
+}
+
+// scoreOptionsFor builds the scoring options for a domain from the flags
+func scoreOptionsFor(target string, settings scanSettings, records map[string]resolver.DNSRecord, knownPorts map[string][]int) scorer.AnalysisOptions {
+	return scorer.AnalysisOptions{
+		Concurrency:     scoreConcurrency,
+		Timeout:         time.Duration(scoreTimeout) * time.Second,
+		VerboseOutput:   verboseScoring,
+		ExcludeHeaders:  true,
+		FollowRedirects: followRedirects,
+		MaxRedirects:    maxRedirects,
+		Scope:           target,
+		KnownPorts:      knownPorts,
+		ProbeOpenPorts:  outputFormat == formatter.FormatURLs,
+		HTTPPorts:       settings.httpPorts,
+		UserAgent:       settings.userAgent,
+		RespectRobots:   politeMode,
+		RequestDelay:    settings.requestDelay,
+		AcceptEncoding:  acceptEncoding,
+		Records:         records,
+		Windows:         settings.windows,
+		Proxy:           settings.scoreProxy,
+		Rules:           settings.scoreRules,
+		Keywords:        settings.scoreKeywords,
+
+		CertExpiryWarning: time.Duration(certExpiryDays) * 24 * time.Hour,
+		WeakTLSChecks:     weakTLSChecks,
+		PortScan:          settings.portScan,
+	}
+}
+
+// probeOptionsFor builds the probe options for a domain from the flags
+func probeOptionsFor(target string, settings scanSettings, records map[string]resolver.DNSRecord) probe.ProbeOptions {
+	return probe.ProbeOptions{
+		Concurrency:     probeConcurrency,
+		Timeout:         time.Duration(probeTimeout) * time.Second,
+		UserAgent:       settings.userAgent,
+		Verbose:         probeVerbose,
+		FollowRedirects: followRedirects,
+		MaxRedirects:    maxRedirects,
+		Scope:           target,
+		RespectRobots:   politeMode,
+		RequestDelay:    settings.requestDelay,
+		AcceptEncoding:  acceptEncoding,
+		Checks:          settings.checks,
+		Records:         records,
+		Windows:         settings.windows,
+		BlockCooldown:   time.Duration(blockCooldown) * time.Second,
+		Proxy:           settings.probeProxy,
+		Warmup:          probeWarmup,
+		HTTPPorts:       settings.httpPorts,
+	}
 }
 
 func Execute() error {
@@ -245,39 +1880,292 @@ func Execute() error {
 }
 
 func init() {
+	flags := rootCmd.Flags()
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file with API keys and flag defaults (default ~/.subscan.yaml)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Level of progress messages on standard error: debug, info, warn, error, silent")
+	rootCmd.PersistentFlags().BoolVar(&quietLogs, "quiet", false, "Only log warnings and errors")
+	rootCmd.PersistentFlags().BoolVar(&silentLogs, "silent", false, "Only write results to standard output; errors are still logged")
+	rootCmd.PersistentFlags().BoolVar(&logJSON, "log-json", false, "Write log messages as JSON lines")
+
 	// Basic options
-	rootCmd.Flags().StringVarP(&domain, "domain", "d", "", "Target domain to scan (e.g., example.com)")
-	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Path to output file")
-	rootCmd.Flags().BoolVar(&passiveOnly, "passive-only", false, "Only perform passive enumeration")
-	rootCmd.Flags().BoolVar(&activeOnly, "active-only", false, "Only perform DNS resolution from wordlist")
-	rootCmd.Flags().StringVarP(&wordlist, "wordlist", "w", "", "Path to wordlist for brute-force")
-	
-	// Smart brute-force options
-	rootCmd.Flags().BoolVar(&smartBruteforce, "smart-bruteforce", false, "Enable intelligent wordlist expansion")
-	rootCmd.Flags().StringVar(&commonspeakPath, "commonspeak", "", "Path to Commonspeak2 wordlist file")
-	rootCmd.Flags().BoolVar(&useDNSTwist, "dnstwist", false, "Generate typo-based variations of discovered subdomains")
-	rootCmd.Flags().BoolVar(&verboseExpansion, "verbose-expansion", false, "Show detailed output during wordlist expansion")
-	
+	flags.StringSliceVarP(&domains, "domain", "d", nil, "Target domains to scan (e.g., example.com or example.com,example.org)")
+	flags.StringVar(&domainsFile, "domains-file", "", "File with target domains to scan, one per line")
+	flags.IntVar(&domainConcurrency, "domain-concurrency", 1, "Number of domains scanned in parallel")
+	flags.StringVarP(&listFile, "list", "l", "", "Skip enumeration and scan the subdomains in this file (plain, JSON, JSONL or CSV)")
+	flags.BoolVar(&readStdin, "stdin", false, "Skip enumeration and scan subdomains read from standard input")
+	flags.StringVarP(&outputFile, "output", "o", "", "Path to output file")
+	flags.BoolVar(&streamOutput, "stream", false, "Write each result as soon as it is processed (JSON Lines for non-plain formats)")
+	addStreamFlags(flags)
+	addFilterFlags(flags)
+	flags.StringVar(&summaryFile, "summary", "", "Write an executive summary comparing the scanned domains by assets, findings per severity and cloud distribution to this file (HTML, or Markdown for .md)")
+	flags.StringVar(&spillDir, "spill-dir", "", "Keep scoring and probe results in a temporary on-disk store in this directory instead of memory, for very large scans (plain and jsonl formats; names and DNS records stay in memory)")
+	flags.StringVar(&dbFile, "db", "", "Record every run (subdomains, DNS records, scores, findings) in this SQLite database")
+	flags.StringVar(&resumeFile, "resume", "", "Checkpoint progress to this state file and resume an interrupted scan from it")
+	flags.BoolVar(&nsFingerprint, "ns-fingerprint", false, "Query version.bind and hostname.bind (CHAOS class) on the target's authoritative nameservers and report their software")
+	flags.BoolVar(&apexRecords, "apex-records", true, "Look up the NS, MX, TXT, SOA, DMARC and common SRV records of the target domain, report them and add the hosts MX, SRV and SPF records name to the candidates (--apex-records=false to skip)")
+	flags.BoolVar(&nsecWalk, "nsec-walk", false, "Walk the NSEC chain of a DNSSEC-signed zone to list its names, or collect its NSEC3 hashes")
+	flags.IntVar(&nsecMaxQueries, "nsec-max-queries", resolver.DefaultWalkQueries, "Maximum number of queries a --nsec-walk sends")
+	flags.StringVar(&nsec3HashFile, "nsec3-hashes", "", "Save the NSEC3 hashes a --nsec-walk collects to this file, in hashcat mode 8300 format")
+	flags.BoolVar(&zoneTransfer, "axfr", true, "Attempt a zone transfer from each authoritative nameserver, adding the zone's names to the candidates and reporting open nameservers (--axfr=false to skip)")
+	flags.BoolVar(&bucketScan, "buckets", false, "Check S3 and GCS buckets named after the target (e.g. example-backups, prod-example) for existence and public listing")
+	flags.StringVar(&bucketWordsFile, "bucket-words", "", "File of words joined to the company name for --buckets, one per line (default: built-in list)")
+	addEnumFlags(flags)
+	addScopeFlags(flags)
+
 	// Scoring options
-	rootCmd.Flags().BoolVar(&enableScoring, "score", false, "Enable subdomain analysis and scoring")
-	rootCmd.Flags().IntVar(&scoreConcurrency, "score-concurrency", 10, "Number of concurrent requests during scoring")
-	rootCmd.Flags().IntVar(&scoreTimeout, "score-timeout", 5, "Timeout in seconds for HTTP requests during scoring")
-	rootCmd.Flags().BoolVar(&verboseScoring, "verbose-scoring", false, "Show detailed output during scoring")
-	
+	flags.BoolVar(&enableScoring, "score", false, "Enable subdomain analysis and scoring")
+	addScoreFlags(flags)
+	addScreenshotFlags(flags)
+
 	// Output format options
-	rootCmd.Flags().StringVarP(&outputFormat, "format", "f", "", "Output format: plain, json, csv, html, markdown")
-	
+	flags.StringVarP(&outputFormat, "format", "f", "", "Output format: plain, json, jsonl, csv, html, markdown, nmap, masscan, urls, defectdojo (with --probe)")
+
+	// Annotation options
+	flags.StringVar(&annotationsFile, "annotations", "", "Path to a JSON/CSV file mapping subdomain patterns to owner/team/notes")
+
 	// Probe options
-	rootCmd.Flags().BoolVar(&enableProbe, "probe", false, "Enable probing for common misconfigurations and security issues")
-	rootCmd.Flags().IntVar(&probeTimeout, "probe-timeout", 10, "Timeout in seconds for probe requests")
-	rootCmd.Flags().IntVar(&probeConcurrency, "probe-concurrency", 10, "Number of concurrent probes")
-	rootCmd.Flags().BoolVar(&probeVerbose, "probe-verbose", false, "Show detailed output during probing")
+	flags.BoolVar(&enableProbe, "probe", false, "Enable probing for common misconfigurations and security issues")
+	addProbeFlags(flags)
+	addHTTPFlags(flags)
+	addProxyFlags(flags)
+
+	// Scan window options
+	flags.StringSliceVar(&scanWindows, "scan-window", nil, "Only scan inside these local time windows, e.g. 01:00-05:00 (pauses outside them)")
+
+	// Finding state options
+	flags.StringVar(&findingsState, "findings-state", "", "File tracking findings across runs; only new findings are reported, last-seen times are updated")
+
+	// Notification options
+	addNotifyFlags(flags)
+	flags.StringVar(&notifyOn, "notify-on", notifyOnFinding, "When to notify: finding (each finding as soon as its host is probed) or summary (once per domain)")
+	flags.StringVar(&notifySeverity, "notify-severity", "high", "Minimum severity of notified findings: low, medium, high, critical")
+
+	addInternalFlags(flags)
+	addResolveFlags(flags)
+}
+
+// addInternalFlags registers the flags for scanning internal domains
+func addInternalFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&internalScan, "internal", false, "Treat the domains as internal: skip passive sources and resolve through --resolvers or the system's DNS servers")
+	flags.StringSliceVar(&internalSuffixes, "internal-suffix", nil, "Extra suffixes of internal domains, besides .local, .internal, .corp, .lan and other private names")
+}
+
+// addSourceKeyFlags registers the API key flags of the keyed passive sources
+func addSourceKeyFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&securityTrailsKey, "securitytrails-key", "", "SecurityTrails API key (or set SECURITYTRAILS_API_KEY)")
+	flags.StringVar(&virusTotalKey, "virustotal-key", "", "VirusTotal API key (or set VIRUSTOTAL_API_KEY)")
+	flags.StringVar(&shodanKey, "shodan-key", "", "Shodan API key (or set SHODAN_API_KEY)")
+	flags.StringVar(&chaosKey, "chaos-key", "", "ProjectDiscovery Chaos API key (or set CHAOS_KEY)")
+	flags.StringVar(&githubToken, "github-token", "", "GitHub personal access token for code search (or set GITHUB_TOKEN)")
+}
+
+// addScopeFlags registers the flags dropping out-of-scope hosts before they
+// are resolved or probed
+func addScopeFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&includePatterns, "include-pattern", nil, "Only keep hosts matching one of these globs (*.api.example.com) or /regular expressions/; repeat the flag for several")
+	flags.StringArrayVar(&excludePatterns, "exclude-pattern", nil, "Drop hosts matching any of these globs (*.marketing.example.com) or /regular expressions/; repeat the flag for several")
+	flags.StringVar(&excludeFile, "exclude-subdomain-file", "", "File of out-of-scope hosts and patterns to drop, one per line")
+}
+
+// addFilterFlags registers the flags restricting the hosts the outputs show
+func addFilterFlags(flags *pflag.FlagSet) {
+	flags.IntSliceVar(&matchStatuses, "match-status", nil, "Only output hosts that answered with one of these HTTP status codes, e.g. 200,403")
+	flags.Float64Var(&minScore, "min-score", 0, "Only output scored hosts scoring at least this much")
+	flags.BoolVar(&onlyCloud, "only-cloud", false, "Only output hosts served by a cloud provider")
+	flags.StringSliceVar(&matchTags, "tag", nil, "Only output hosts carrying one of these tags, e.g. TAKEOVER-CANDIDATE or PORT-*")
+}
+
+// addEnumFlags registers the passive enumeration and brute force flags
+func addEnumFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&passiveOnly, "passive-only", false, "Only perform passive enumeration")
+	flags.BoolVar(&activeOnly, "active-only", false, "Only perform DNS resolution from wordlist")
+	flags.StringSliceVarP(&wordlists, "wordlist", "w", nil, "Wordlists for brute-force as path[:mode], mode prefix (default), suffix or infix")
+	flags.IntVar(&wordlistLimit, "wordlist-limit", enumeration.DefaultCombinationLimit, "Maximum candidates of each suffix or infix wordlist, combining words with the apex first (0 = unlimited)")
+	flags.StringSliceVar(&passiveSources, "sources", nil, "Comma-separated passive sources to use (default: all). Available: "+strings.Join(enumeration.SourceNames(), ", "))
+
+	addSourceKeyFlags(flags)
+	flags.BoolVar(&crtShPostgres, "crtsh-postgres", false, "Query crt.sh's public PostgreSQL database instead of its HTTP endpoint (better for large domains)")
+	flags.StringToIntVar(&sourceRates, "source-rate", nil, "Requests per minute of rate-limited passive sources as name=N, e.g. rapiddns=5,hackertarget=2 (0 = unlimited)")
+	flags.IntVar(&sourceTimeout, "source-timeout", int(enumeration.DefaultSourceTimeout/time.Second), "Seconds a passive source may take before it is abandoned and the others are used alone (0 = no limit)")
+	flags.IntVar(&sourceRetries, "source-retries", enumeration.DefaultRetryOptions().Retries, "Times a passive source request failing with a connection error, timeout or 5xx is retried, with exponential backoff")
+	flags.IntVar(&sourceRetryDelay, "source-retry-delay", int(enumeration.DefaultRetryOptions().BaseDelay/time.Second), "Seconds before the first retry of a passive source request, doubled for each further one")
+	flags.StringVar(&urlsFile, "save-urls", "", "Save the URLs the wayback and commoncrawl sources find to this file, for content discovery")
+	flags.StringVar(&enumProxyURL, "enum-proxy", "", "Proxy for passive source requests only, overriding --proxy (direct for none)")
+
+	// Smart brute-force options
+	flags.BoolVar(&smartBruteforce, "smart-bruteforce", false, "Enable intelligent wordlist expansion")
+	flags.StringVar(&commonspeakPath, "commonspeak", "", "Path to Commonspeak2 wordlist file")
+	flags.BoolVar(&useDNSTwist, "dnstwist", false, "Generate typo-based variations of discovered subdomains")
+	flags.IntVar(&twistLimit, "dnstwist-limit", expander.DefaultTwistOptions().Limit, "Maximum names generated by --dnstwist, twisting names with high-value words and short labels first (0 = unlimited)")
+	flags.IntVar(&twistPerName, "dnstwist-per-subdomain", expander.DefaultTwistOptions().PerSubdomain, "Maximum --dnstwist variations of each subdomain, sampled when it has more (0 = unlimited)")
+	flags.BoolVar(&verboseExpansion, "verbose-expansion", false, "Show detailed output during wordlist expansion")
+	flags.IntVar(&permutationLevel, "permutation-level", 0, "Permute passive results altdns-style: inserted and joined words, swapped parts, number ranges; rounds of permutation (0 = off)")
+	flags.BoolVar(&feedbackRound, "feedback", false, "After resolution, permute the alive subdomains with the words of their own labels and resolve the new names")
+	flags.IntVar(&feedbackLimit, "feedback-limit", 10000, "Maximum names generated by each --feedback or --iterative round")
+	flags.BoolVar(&iterative, "iterative", false, "Repeat the --feedback round on the subdomains each round finds until none are new")
+	flags.IntVar(&maxIterations, "max-iterations", 5, "Maximum rounds of --iterative")
+	flags.BoolVar(&reverseSweep, "reverse-sweep", false, "After resolution, look up the PTR records of the /24 ranges of the resolved addresses and resolve the in-scope hostnames found")
+	flags.IntVar(&sweepMaxRanges, "reverse-sweep-max-ranges", 16, "Maximum /24 ranges PTR-scanned by --reverse-sweep, those holding the most resolved addresses first (0 = unlimited)")
+	flags.StringVar(&permutationFile, "permutation-words", "", "File of words for --permutation-level, one per line (default: built-in environment, region and service words)")
+	flags.Int64Var(&scanSeed, "seed", 0, "Make candidate generation, sampling and ordering reproducible: scans with the same seed and inputs try the same names in the same order (0 = off)")
+
+	flags.IntVar(&maxDepth, "max-depth", 0, "Maximum labels below the domain for generated candidates (0 = unlimited)")
+
+	// Recursive enumeration options
+	flags.BoolVar(&recursiveEnum, "recursive", false, "Feed discovered subdomains back into passive enumeration to find nested levels")
+	flags.IntVar(&recursiveDepth, "depth", enumeration.DefaultRecursionOptions().Depth, "Maximum recursion depth for --recursive")
+	flags.IntVar(&recursiveMaxTargets, "recursive-max-targets", enumeration.DefaultRecursionOptions().MaxTargets, "Maximum subdomains re-enumerated per level by --recursive, those with names found below them first (0 = unlimited)")
+	flags.IntVar(&recursiveConcurrency, "recursive-concurrency", enumeration.DefaultRecursionOptions().Concurrency, "Subdomains re-enumerated at once by --recursive")
+}
+
+// addResolveFlags registers the DNS resolution flags
+func addResolveFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&fastResolve, "fast-resolve", false, "Use the raw UDP DNS engine for very large candidate lists (A records only)")
+	flags.IntVar(&resolveConcurrency, "resolve-concurrency", resolver.DefaultConcurrency, "Number of concurrent DNS resolution workers")
+	flags.Float64Var(&resolveRate, "resolve-rate", 0, "Maximum DNS queries per second (0 = unlimited)")
+	flags.IntVar(&resolveTimeout, "resolve-timeout", int(resolver.DefaultTimeout/time.Second), "Timeout in seconds for each subdomain's DNS lookups")
+	flags.StringSliceVar(&customResolvers, "resolvers", nil, "DNS resolvers to rotate across (comma-separated IPs or a file with one per line)")
+	flags.StringVar(&dohEndpoint, "doh", "", "Resolve over DNS-over-HTTPS: cloudflare, google or an https:// endpoint (--doh=google)")
+	flags.Lookup("doh").NoOptDefVal = "cloudflare"
+	flags.StringSliceVar(&dotServers, "dot", nil, "Resolve over DNS-over-TLS via these servers (--dot=9.9.9.9)")
+	flags.Lookup("dot").NoOptDefVal = resolver.DefaultDoTServer
+	flags.BoolVar(&reverify, "reverify", false, "Resolve alive subdomains a second time and drop those that don't resolve again")
+	flags.IntVar(&reverifyDelay, "reverify-delay", 0, "Seconds to wait before the --reverify pass")
+	flags.StringSliceVar(&reverifyResolvers, "reverify-resolvers", nil, "DNS resolvers for the --reverify pass (default: the --resolvers)")
+	flags.BoolVar(&queryAuthoritative, "authoritative", false, "Query the target's authoritative nameservers directly instead of recursive resolvers")
+	flags.StringVar(&resolverStatsFile, "resolver-stats", "", "File tracking each resolver's success rate and latency across runs (default ~/"+resolver.DefaultStatsFile+")")
+	flags.BoolVar(&noResolverStats, "no-resolver-stats", false, "Neither track resolvers across runs nor skip the ones that performed poorly")
+}
+
+// addScoreFlags registers the scoring tuning flags
+func addScoreFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&scoreConcurrency, "score-concurrency", 10, "Number of concurrent requests during scoring")
+	flags.IntVar(&scoreTimeout, "score-timeout", 5, "Timeout in seconds for HTTP requests during scoring")
+	flags.BoolVar(&verboseScoring, "verbose-scoring", false, "Show detailed output during scoring")
+	flags.StringVar(&scoreProxyURL, "score-proxy", "", "Proxy for scoring requests only, overriding --proxy (direct for none)")
+	flags.StringVar(&scoreRulesFile, "score-rules", "", "YAML or JSON file of scoring rules replacing the built-in weights")
+	flags.StringVar(&scoreKeywordFile, "score-keywords", "", "File of \"word score [tag]\" lines adding to or overriding the built-in name keywords")
+	flags.BoolVar(&sanHarvest, "san-harvest", true, "Resolve and score in-scope names found in certificate SANs, repeating for the certificates of the hosts found (--san-harvest=false to skip)")
+	flags.IntVar(&certExpiryDays, "cert-expiry-days", int(scorer.DefaultCertExpiryWarning/(24*time.Hour)), "Tag certificates expiring within this many days CERT-EXPIRING-SOON")
+	flags.BoolVar(&weakTLSChecks, "tls-weak-checks", false, "Open extra handshakes to HTTPS hosts to detect TLS 1.0/1.1 and insecure cipher suites (direct connections; skipped behind a proxy)")
+	flags.BoolVar(&portScan, "port-scan", false, "TCP connect scan the addresses of the scored hosts and boost hosts exposing interesting ports (direct connections; skipped behind a proxy)")
+	flags.StringVar(&portList, "ports", "common", "Ports for --port-scan: common, top-100, or a list like 80,443,8000-8100")
+	flags.IntVar(&portTimeout, "port-timeout", int(portscan.DefaultOptions().Timeout/time.Second), "Timeout in seconds for each --port-scan connection")
+	flags.IntVar(&portConcurrency, "port-concurrency", portscan.DefaultOptions().Concurrency, "Number of concurrent --port-scan connections")
+}
+
+// addProbeFlags registers the probe tuning and check selection flags
+func addProbeFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&probeTimeout, "probe-timeout", 10, "Timeout in seconds for probe requests")
+	flags.IntVar(&probeConcurrency, "probe-concurrency", 10, "Number of concurrent probes")
+	flags.BoolVar(&probeVerbose, "probe-verbose", false, "Show detailed output during probing")
+	flags.StringSliceVar(&probeChecks, "checks", nil, "Only run these probe checks: takeover, s3, sensitive-files, open-redirect, zone-transfer (default all)")
+	flags.BoolVar(&noOpenRedirect, "no-open-redirect", false, "Skip the active open redirect check")
+	flags.BoolVar(&noSensitiveFiles, "no-sensitive-files", false, "Skip requesting sensitive file paths")
+	flags.StringVar(&probeProxyURL, "probe-proxy", "", "Proxy for probe requests only, overriding --proxy (direct for none)")
+	flags.BoolVar(&probeWarmup, "warmup", false, "Resolve the next batch of hosts and complete their TLS handshakes while the current batch is probed")
+	flags.IntVar(&blockCooldown, "block-cooldown", 0, "Seconds to back off once when a host returns 429 or a challenge page before retrying (0 = skip its remaining checks)")
+}
+
+// addHTTPFlags registers the flags shared by scoring and probing requests
+func addHTTPFlags(flags *pflag.FlagSet) {
+	// Redirect options
+	flags.BoolVar(&followRedirects, "follow-redirects", false, "Follow redirects during scoring/probing and record the chain")
+	flags.IntVar(&maxRedirects, "max-redirects", 10, "Maximum number of redirects to follow")
+	flags.StringVar(&httpPortList, "http-ports", "", "Also try HTTP(S) on these ports when scoring/probing, e.g. 8080,8443,8000,3000 or ranges like 8000-8100")
+
+	// Polite mode
+	flags.BoolVar(&politeMode, "polite", false, "Honor robots.txt, cap concurrency, add per-host delays and use an identifying User-Agent")
+
+	flags.StringVar(&acceptEncoding, "accept-encoding", httpclient.DefaultAcceptEncoding, "Accept-Encoding sent when scoring/probing; responses are decoded before signature matching (use identity to disable compression)")
+}
+
+// loadConfig applies the config file to the flags of the command being run.
+// Flags given on the command line win over the file, which in turn wins over
+// API keys from environment variables.
+func loadConfig(cmd *cobra.Command) {
+	path := configFile
+	if path == "" {
+		path = config.DefaultPath()
+		if _, err := os.Stat(path); err != nil {
+			return
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		logger.Errorf("could not load config: %v", err)
+		os.Exit(1)
+	}
+	cfg.ResolveToggles("sources", enumeration.SourceNames())
+
+	for _, key := range cfg.Keys() {
+		if !knownFlag(cmd.Root(), key) {
+			logger.Warnf("unknown setting '%s' in %s", key, path)
+		}
+	}
+	for source, key := range cfg.APIKeys {
+		if err := enumeration.SetAPIKey(source, key); err != nil {
+			logger.Warnf("%v in %s", err, path)
+		}
+	}
+	if err := cfg.Apply(cmd.Flags()); err != nil {
+		logger.Errorf("in %s: %v", path, err)
+		os.Exit(1)
+	}
+}
+
+// configureLogging sets the level and format of progress messages from the
+// logging flags; --silent and --quiet win over --log-level
+func configureLogging() {
+	level, err := logger.ParseLevel(logLevel)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if quietLogs {
+		level = logger.LevelWarn
+	}
+	if silentLogs {
+		level = logger.LevelError
+	}
+	logger.Configure(level, logJSON)
+}
+
+// isInternal reports whether a target is scanned as an internal domain, either
+// by its suffix or because --internal was given
+func isInternal(target string) bool {
+	if target == "" {
+		return false
+	}
+	return internalScan || resolver.IsInternalDomain(target, internalSuffixes)
+}
+
+// knownFlag reports whether a command or any of its subcommands has the flag
+func knownFlag(cmd *cobra.Command, name string) bool {
+	if cmd.Flags().Lookup(name) != nil {
+		return true
+	}
+	for _, sub := range cmd.Commands() {
+		if knownFlag(sub, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeList names the source of a subdomain list for progress messages
+func describeList(path string) string {
+	if path == "-" {
+		return "standard input"
+	}
+	return path
 }
 
 func writeToFile(subdomains []string, filepath string) {
 	f, err := os.Create(filepath)
 	if err != nil {
-		fmt.Printf("Error creating output file: %v\n", err)
+		logger.Errorf("could not create output file: %v", err)
 		return
 	}
 	defer f.Close()
@@ -286,18 +2174,97 @@ func writeToFile(subdomains []string, filepath string) {
 		f.WriteString(subdomain + "\n")
 	}
 	
-	fmt.Printf("Results saved to %s\n", filepath)
+	logger.Infof("Results saved to %s", filepath)
+}
+
+// writeURLs saves the URLs archive sources found, one per line
+func writeURLs(urls []string, path string) {
+	if len(urls) == 0 {
+		logger.Infof("No archived URLs to save to %s", path)
+		return
+	}
+	content := strings.Join(urls, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		logger.Errorf("could not save URLs: %v", err)
+		return
+	}
+	logger.Infof("%d archived URLs saved to %s", len(urls), path)
 }
 
 func writeFormattedToFile(content string, filepath string) {
 	f, err := os.Create(filepath)
 	if err != nil {
-		fmt.Printf("Error creating output file: %v\n", err)
+		logger.Errorf("could not create output file: %v", err)
 		return
 	}
 	defer f.Close()
 	
 	f.WriteString(content)
 	
-	fmt.Printf("Results saved to %s\n", filepath)
-} 
\ No newline at end of file
+	logger.Infof("Results saved to %s", filepath)
+} 
+// writeSpilled writes every result of a spill store, in order, to the output
+// file or stdout, one per line. Errors are reported without exiting so the
+// caller still removes the store.
+func writeSpilled(target string, multi bool, spill *store.Spill, output string, write func(*formatter.StreamWriter, []byte) error) {
+	var buf strings.Builder
+	var dest io.Writer = &buf
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			logger.Errorf("could not create output file: %v", err)
+			return
+		}
+		defer f.Close()
+		bw := bufio.NewWriter(f)
+		defer bw.Flush()
+		dest = bw
+	} else {
+		// Take the lock up front so other domains' output can't interleave
+		outputMu.Lock()
+		defer outputMu.Unlock()
+		if multi {
+			fmt.Printf("\n=== %s ===\n", target)
+		}
+		dest = os.Stdout
+	}
+
+	w := formatter.NewStreamWriter(dest, outputFormat)
+	err := spill.Each(func(data []byte) error {
+		return write(w, data)
+	})
+	if err != nil {
+		logger.Errorf("could not write results: %v", err)
+		return
+	}
+	if output != "" {
+		logger.Infof("%d results saved to %s", spill.Len(), output)
+	}
+}
+
+// findingsMu serializes updates of the findings state file between domains scanned in parallel
+var findingsMu sync.Mutex
+
+// reportNewFindings records probe findings in the state file, prints only
+// those not seen before and returns them
+func reportNewFindings(findings []probe.Finding) []probe.Finding {
+	findingsMu.Lock()
+	defer findingsMu.Unlock()
+
+	store, err := probe.LoadFindingStore(findingsState)
+	if err != nil {
+		logger.Warnf("failed to load findings state: %v", err)
+		return findings
+	}
+
+	fresh := store.Observe(findings, time.Now())
+	logger.Infof("Findings: %d new, %d previously seen", len(fresh), len(findings)-len(fresh))
+	for _, finding := range fresh {
+		logger.Infof("  [NEW] %s %s: %s", finding.ID, finding.Host, finding.Check)
+	}
+
+	if err := store.Save(); err != nil {
+		logger.Warnf("failed to save findings state: %v", err)
+	}
+	return fresh
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/coverage"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/scorer"
+	"github.com/omerimzali/subscan/pkg/screenshot"
+	"github.com/spf13/pflag"
+)
+
+var (
+	// Screenshot capture of the scored web services
+	takeScreenshots       bool
+	screenshotDir         string
+	chromePath            string
+	screenshotTimeout     int
+	screenshotConcurrency int
+)
+
+// captureScreenshots renders the web services of the scored hosts in headless
+// Chrome and records the screenshots on the results
+func captureScreenshots(ctx context.Context, results []scorer.SubdomainInfo, settings scanSettings) {
+	if !takeScreenshots {
+		return
+	}
+	var urls []string
+	for _, info := range results {
+		urls = append(urls, info.URLs...)
+	}
+	if len(urls) == 0 {
+		return
+	}
+
+	options := screenshot.DefaultOptions()
+	options.Browser = chromePath
+	options.Dir = screenshotDir
+	options.Timeout = time.Duration(screenshotTimeout) * time.Second
+	options.Concurrency = screenshotConcurrency
+	options.UserAgent = settings.userAgent
+	options.Proxy = settings.scoreProxy
+
+	logger.Infof("📸 Capturing screenshots of %d web services...", len(urls))
+	shots, err := screenshot.CaptureAll(ctx, urls, options)
+	if err != nil {
+		logger.Warnf("Skipping screenshots: %v", err)
+		coverage.Note(ctx, "screenshots skipped: %v", err)
+		return
+	}
+	for i := range results {
+		for _, url := range results[i].URLs {
+			if shot, ok := shots[url]; ok {
+				results[i].Screenshots = append(results[i].Screenshots, shot.Path)
+			}
+		}
+	}
+	if failed := len(urls) - len(shots); failed > 0 {
+		coverage.Note(ctx, "%d web services could not be rendered for screenshots", failed)
+	}
+	logger.Infof("Saved %d screenshots to %s", len(shots), options.Dir)
+}
+
+// addScreenshotFlags registers the screenshot capture flags
+func addScreenshotFlags(flags *pflag.FlagSet) {
+	defaults := screenshot.DefaultOptions()
+	flags.BoolVar(&takeScreenshots, "screenshot", false, "Screenshot each scored web service in headless Chrome and show thumbnails in the HTML report")
+	flags.StringVar(&screenshotDir, "screenshot-dir", defaults.Dir, "Directory the screenshots and their thumbnails are saved to")
+	flags.StringVar(&chromePath, "chrome-path", "", "Chrome or Chromium executable used for screenshots (default: found on the PATH)")
+	flags.IntVar(&screenshotTimeout, "screenshot-timeout", int(defaults.Timeout/time.Second), "Timeout in seconds for rendering each page")
+	flags.IntVar(&screenshotConcurrency, "screenshot-concurrency", defaults.Concurrency, "Number of pages rendered in parallel")
+}
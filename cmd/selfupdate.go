@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/selfupdate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// version and releasePublicKey are set when building releases:
+	//   go build -ldflags "-X github.com/omerimzali/subscan/cmd.version=1.2.0
+	//     -X github.com/omerimzali/subscan/cmd.releasePublicKey=<base64 ed25519 key>"
+	version          = "dev"
+	releasePublicKey = ""
+
+	updateCheckOnly     bool
+	updateForce         bool
+	updatePublicKey     string
+	updateOverrideKey   bool
+	updateAllowUnsigned bool
+	updateTimeout       int
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Replace this binary with the latest release",
+	Long: `Downloads the latest release binary for this platform, verifies it against the release's SHA-256 checksums and replaces the running binary with it. No Go toolchain is needed.
+
+The checksums file must also carry a valid ed25519 signature by the release signing key built into this binary, or the one given with --public-key. Replacing a built-in key takes --insecure-override-key, and a binary built without one only installs releases verified against their checksums with --allow-unsigned, since a checksums file from the same release proves no authenticity. Releases that can't be verified are never installed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		options := selfupdate.DefaultOptions()
+		options.Timeout = time.Duration(updateTimeout) * time.Second
+		options.PublicKey = releasePublicKey
+		if updatePublicKey != "" && updatePublicKey != releasePublicKey {
+			if releasePublicKey != "" && !updateOverrideKey {
+				logger.Errorf("this binary verifies releases with its built-in signing key; replacing it with --public-key requires --insecure-override-key")
+				os.Exit(1)
+			}
+			options.PublicKey = updatePublicKey
+		}
+		options.AllowUnsigned = updateAllowUnsigned
+		if options.PublicKey == "" && !options.AllowUnsigned && !updateCheckOnly {
+			logger.Errorf("this binary has no release signing key; pass --public-key, or --allow-unsigned to trust the release checksums alone")
+			os.Exit(1)
+		}
+		options.Proxy = stageProxy("")
+
+		ctx := context.Background()
+		release, err := selfupdate.Latest(ctx, options)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+
+		newer := selfupdate.Newer(version, release.Tag)
+		if updateCheckOnly {
+			if newer {
+				fmt.Printf("Subscan %s is available (running %s): %s\n", release.Version(), version, release.URL)
+			} else {
+				fmt.Printf("Subscan %s is the latest release\n", version)
+			}
+			return
+		}
+		if !newer && !updateForce {
+			logger.Infof("Subscan %s is already the latest release", version)
+			return
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			logger.Errorf("could not locate the running binary: %v", err)
+			os.Exit(1)
+		}
+		logger.Infof("Downloading Subscan %s...", release.Version())
+		binary, err := selfupdate.Download(ctx, release, options)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if options.PublicKey == "" {
+			logger.Warnf("Verified the checksum only, as --allow-unsigned permits; the release's authenticity is unverified")
+		}
+		if err := selfupdate.Replace(executable, binary); err != nil {
+			logger.Errorf("could not replace %s: %v", executable, err)
+			os.Exit(1)
+		}
+		logger.Infof("Updated Subscan %s to %s", version, release.Version())
+	},
+}
+
+func init() {
+	rootCmd.Version = version
+
+	selfUpdateCmd.Flags().BoolVar(&updateCheckOnly, "check", false, "Only report whether a newer release is available")
+	selfUpdateCmd.Flags().BoolVar(&updateForce, "force", false, "Reinstall the latest release even if it isn't newer")
+	selfUpdateCmd.Flags().StringVar(&updatePublicKey, "public-key", "", "Base64 ed25519 key the release checksums must be signed with (default: the key built into this binary)")
+	selfUpdateCmd.Flags().BoolVar(&updateOverrideKey, "insecure-override-key", false, "Let --public-key replace the signing key built into this binary")
+	selfUpdateCmd.Flags().BoolVar(&updateAllowUnsigned, "allow-unsigned", false, "Install a release verified against its checksums only when no signing key is available")
+	selfUpdateCmd.Flags().IntVar(&updateTimeout, "timeout", int(selfupdate.DefaultOptions().Timeout/time.Second), "Timeout in seconds for each download")
+	addProxyFlags(selfUpdateCmd.Flags())
+
+	rootCmd.AddCommand(selfUpdateCmd)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/sink"
+	"github.com/spf13/pflag"
+)
+
+// streamWebhook is the URL every result is posted to as it is produced
+var streamWebhook string
+
+// outputSinks returns the sinks results are streamed to: the given ones and
+// the --stream-webhook destination. Nil is returned when there are none.
+func outputSinks(sinks ...sink.OutputSink) sink.OutputSink {
+	if streamWebhook != "" {
+		sinks = append(sinks, sink.NewWebhook(streamWebhook))
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+	return sink.Multi(sinks)
+}
+
+// startSinks starts the sinks and returns the function closing them
+func startSinks(s sink.OutputSink) func() {
+	if err := s.Start(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	return func() {
+		if err := s.Close(); err != nil {
+			logger.Warnf("could not close output: %v", err)
+		}
+	}
+}
+
+// streamSink returns the sink writing streamed results to out in format
+func streamSink(out io.Writer, format string) sink.OutputSink {
+	return outputSinks(sink.NewStream(out, format))
+}
+
+// writeResult sends a result to the sinks, warning when one fails
+func writeResult(s sink.OutputSink, result sink.Result) {
+	if err := s.WriteResult(result); err != nil {
+		logger.Warnf("%v", err)
+	}
+}
+
+// addStreamFlags registers the flags adding sinks for streamed results
+func addStreamFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&streamWebhook, "stream-webhook", "", "POST each result as JSON to this URL as soon as it is processed")
+}
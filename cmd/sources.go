@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/enumeration"
+	"github.com/omerimzali/subscan/pkg/formatter"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sourcesDomain  string
+	sourcesSelect  []string
+	sourcesTimeout int
+)
+
+var sourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Check that each passive source is reachable and accepts its API key",
+	Long: `Runs a sample query against every passive source, or the ones named with --sources, and reports whether it answered. A keyed source without a key is reported as such rather than queried; a rejected key, a rate limit, a timeout and a connection failure are told apart, so a dead API or an expired key shows up before a scan quietly finds less.
+
+API keys are read as for a scan: from the environment, the config file and the key flags. The exit status is 1 when a source that was queried failed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := stageFormat(formatter.FormatPlain, formatter.FormatPlain, formatter.FormatJSON)
+		applySourceKeys()
+		enumeration.SetProxy(stageProxy(enumProxyURL))
+
+		sources, err := enumeration.SelectSources(sourcesSelect)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		if sourcesTimeout <= 0 {
+			logger.Errorf("--timeout must be positive")
+			os.Exit(1)
+		}
+		timeout := time.Duration(sourcesTimeout) * time.Second
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		logger.Infof("🩺 Checking %d passive sources with a sample query for %s", len(sources), sourcesDomain)
+		results := make([]enumeration.SourceHealth, len(sources))
+		var wg sync.WaitGroup
+		for i, source := range sources {
+			wg.Add(1)
+			go func(i int, source enumeration.Source) {
+				defer wg.Done()
+				results[i] = enumeration.CheckSource(ctx, source, sourcesDomain, timeout)
+			}(i, source)
+		}
+		wg.Wait()
+		exitIfInterrupted(ctx)
+
+		if format == formatter.FormatJSON {
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				logger.Errorf("could not format results: %v", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		} else {
+			printSourceHealth(results)
+		}
+
+		failed := 0
+		for _, result := range results {
+			if !result.Healthy() && result.Status != enumeration.StatusNoKey {
+				failed++
+			}
+		}
+		if failed > 0 {
+			logger.Warnf("%d of %d passive sources failed", failed, len(results))
+			os.Exit(1)
+		}
+	},
+}
+
+// printSourceHealth writes the results of a source check as a table
+func printSourceHealth(results []enumeration.SourceHealth) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tSTATUS\tRESULTS\tTIME\tDETAIL")
+	for _, result := range results {
+		status := strings.ToUpper(string(result.Status))
+		detail := result.Error
+		if result.Status == enumeration.StatusNoKey {
+			detail = "set a key to use this source"
+		} else if result.Healthy() && result.Results == 0 {
+			detail = "answered, but with no results"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", result.Source, status, result.Results, result.Elapsed.Round(10*time.Millisecond), detail)
+	}
+	w.Flush()
+}
+
+func init() {
+	flags := sourcesCmd.Flags()
+	flags.StringVarP(&sourcesDomain, "domain", "d", "example.com", "Domain to run the sample query for")
+	flags.StringSliceVar(&sourcesSelect, "sources", nil, "Comma-separated passive sources to check (default: all)")
+	flags.IntVar(&sourcesTimeout, "timeout", 60, "Seconds each source may take to answer")
+	flags.StringVarP(&outputFormat, "format", "f", "", "Output format: plain, json (default plain)")
+	flags.StringVar(&enumProxyURL, "enum-proxy", "", "Proxy for passive source requests only, overriding --proxy (direct for none)")
+	addSourceKeyFlags(flags)
+	addProxyFlags(flags)
+
+	rootCmd.AddCommand(sourcesCmd)
+}
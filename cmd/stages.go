@@ -0,0 +1,443 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/coverage"
+	"github.com/omerimzali/subscan/pkg/formatter"
+	"github.com/omerimzali/subscan/pkg/input"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scan"
+	"github.com/omerimzali/subscan/pkg/scorer"
+	"github.com/omerimzali/subscan/pkg/sink"
+	"github.com/spf13/cobra"
+)
+
+// stageDomain scopes the resolve, score, probe and report stages
+var stageDomain string
+
+var enumCmd = &cobra.Command{
+	Use:   "enum",
+	Short: "Enumerate candidate subdomains without resolving them",
+	Long: `Runs passive enumeration and brute forcing for the target domains and writes the unique candidates, one per line, for the resolve stage:
+
+  subscan enum -d example.com -w words.txt | subscan resolve | subscan score | subscan report -f html -o report.html`,
+	Run: func(cmd *cobra.Command, args []string) {
+		targets, err := collectDomains(domains, domainsFile)
+		if err != nil {
+			logger.Errorf("could not read domains file: %v", err)
+			os.Exit(1)
+		}
+		if len(targets) == 0 {
+			logger.Errorf("domain is required")
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		ctx, stop := interruptContext()
+		defer exitIfInterrupted(ctx)
+		defer stop()
+		out, done := stageOutput()
+		defer done()
+		settings := loadScanSettings(cmd)
+
+		var candidates []string
+		for _, target := range targets {
+			logger.Infof("Starting subdomain enumeration for: %s", target)
+			found, _ := enumerateDomain(ctx, target, settings)
+			candidates = append(candidates, found...)
+		}
+		candidates, _ = scan.Dedupe(candidates)
+		candidates = applyScope(settings.scope, candidates)
+		logger.Infof("Total unique subdomains found: %d", len(candidates))
+
+		for _, candidate := range candidates {
+			fmt.Fprintln(out, candidate)
+		}
+	},
+}
+
+var resolveCmd = &cobra.Command{
+	Use:   "resolve [subdomains]",
+	Short: "Resolve subdomains and keep the alive ones",
+	Long:  `Resolves the subdomains in a file, or read from standard input, in any format the --list flag accepts and writes the DNS records of the alive ones as JSON Lines (or their names with -f plain) as they resolve.`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := stageFormat(formatter.FormatJSONL, formatter.FormatPlain, formatter.FormatJSON, formatter.FormatJSONL)
+		if queryAuthoritative && stageDomain == "" {
+			logger.Errorf("--authoritative needs --domain to find the nameservers")
+			os.Exit(1)
+		}
+
+		hosts, _ := stageHosts(args)
+		ctx, stop := interruptContext()
+		defer exitIfInterrupted(ctx)
+		defer stop()
+		out, done := stageOutput()
+		defer done()
+		settings := loadScanSettings(cmd)
+		hosts = applyScope(settings.scope, hosts)
+
+		logger.Infof("Resolving %d subdomains...", len(hosts))
+		options := resolveOptionsFor(stageDomain, settings)
+		var results sink.OutputSink
+		if format != formatter.FormatJSON {
+			results = streamSink(out, format)
+			defer startSinks(results)()
+		}
+		if results != nil && !reverify {
+			options.OnResolved = func(record resolver.DNSRecord) {
+				writeResult(results, sink.Result{Target: stageDomain, Record: &record})
+			}
+		}
+		records := resolver.ResolveSubdomains(ctx, hosts, options)
+		if reverify {
+			records = reverifyRecords(ctx, stageDomain, records, settings)
+			if results != nil {
+				for i := range records {
+					writeResult(results, sink.Result{Target: stageDomain, Record: &records[i]})
+				}
+			}
+		}
+		logger.Infof("Found %d alive subdomains", len(records))
+
+		if format == formatter.FormatJSON {
+			data, err := json.MarshalIndent(records, "", "  ")
+			if err != nil {
+				logger.Errorf("could not format records: %v", err)
+				os.Exit(1)
+			}
+			fmt.Fprintln(out, string(data))
+		}
+	},
+}
+
+var scoreCmd = &cobra.Command{
+	Use:   "score [subdomains]",
+	Short: "Score alive subdomains",
+	Long:  `Analyzes and scores the subdomains in a file, or read from standard input. DNS records written by the resolve stage are reused instead of querying again. Results are written as JSON Lines as they are scored, or in another format once all are done.`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := stageFormat(formatter.FormatJSONL, formatter.FormatPlain, formatter.FormatJSON, formatter.FormatJSONL,
+			formatter.FormatCSV, formatter.FormatHTML, formatter.FormatMarkdown, formatter.FormatNmap, formatter.FormatMasscan, formatter.FormatURLs)
+
+		hosts, records := stageHosts(args)
+		ctx, stop := interruptContext()
+		defer exitIfInterrupted(ctx)
+		defer stop()
+		out, done := stageOutput()
+		defer done()
+		settings := loadScanSettings(cmd)
+		hosts = applyScope(settings.scope, hosts)
+		caveats := &coverage.Caveats{}
+		ctx = coverage.With(ctx, caveats)
+
+		logger.Infof("🔍 Analyzing and scoring subdomains...")
+		options := scoreOptionsFor(stageDomain, settings, records, nil)
+		streamed := format == formatter.FormatJSONL
+		if streamed {
+			results := settings.filter.Sink(streamSink(out, format))
+			defer startSinks(results)()
+			options.OnResult = func(info scorer.SubdomainInfo) {
+				annotated := []scorer.SubdomainInfo{info}
+				settings.annotations.ApplyToScores(annotated)
+				writeResult(results, sink.Result{Target: stageDomain, Subdomain: &annotated[0]})
+			}
+		}
+		results := scorer.AnalyzeSubdomains(ctx, hosts, options)
+		settings.annotations.ApplyToScores(results)
+		logger.Infof("Scored %d subdomains", len(results))
+		if streamed {
+			return
+		}
+		results = settings.filter.Subdomains(results)
+		captureScreenshots(ctx, results, settings)
+
+		formattedOutput := scorer.FormatResults(results)
+		if format != formatter.FormatPlain {
+			var err error
+			formattedOutput, err = formatter.FormatScan(results, format, stageDomain, formatter.ScanInfo{Caveats: caveats.List()})
+			if err != nil {
+				logger.Errorf("could not format results: %v", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Fprint(out, formattedOutput)
+	},
+}
+
+var probeCmd = &cobra.Command{
+	Use:   "probe [subdomains]",
+	Short: "Probe subdomains for misconfigurations",
+	Long:  `Probes the subdomains in a file, or read from standard input, for takeovers, exposed buckets and files and open redirects. DNS records written by the resolve stage are reused instead of querying again. Results are written as JSON Lines as they are probed, or in another format once all are done.`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := stageFormat(formatter.FormatJSONL, formatter.FormatPlain, formatter.FormatJSON, formatter.FormatJSONL,
+			formatter.FormatCSV, formatter.FormatHTML, formatter.FormatMarkdown, formatter.FormatNmap, formatter.FormatMasscan, formatter.FormatURLs,
+			formatter.FormatDefectDojo)
+
+		hosts, records := stageHosts(args)
+		ctx, stop := interruptContext()
+		defer exitIfInterrupted(ctx)
+		defer stop()
+		out, done := stageOutput()
+		defer done()
+		settings := loadScanSettings(cmd)
+		if minScore != 0 {
+			logger.Errorf("--min-score filters scored hosts; probe results carry no score")
+			os.Exit(1)
+		}
+		if len(settings.checks) == 0 {
+			logger.Errorf("every selected probe check is disabled; select at least one with --checks")
+			os.Exit(1)
+		}
+		hosts = applyScope(settings.scope, hosts)
+		caveats := &coverage.Caveats{}
+		ctx = coverage.With(ctx, caveats)
+
+		logger.Infof("🔍 Probing for misconfigurations and security issues...")
+		options := probeOptionsFor(stageDomain, settings, records)
+		streamed := format == formatter.FormatJSONL
+		if streamed {
+			results := settings.filter.Sink(streamSink(out, format))
+			defer startSinks(results)()
+			options.OnResult = func(result probe.ProbeResult) {
+				annotated := []probe.ProbeResult{result}
+				settings.annotations.ApplyToProbes(annotated)
+				writeResult(results, sink.Result{Target: stageDomain, Probe: &annotated[0]})
+			}
+		}
+		results := probe.RunProbes(ctx, hosts, options)
+		settings.annotations.ApplyToProbes(results)
+
+		if findingsState != "" {
+			var findings []probe.Finding
+			for _, result := range results {
+				findings = append(findings, result.Findings...)
+			}
+			reportNewFindings(findings)
+		}
+
+		// The summary is progress output; the full results go to the next stage
+		fmt.Println(probe.FormatProbeResults(results, false))
+		if streamed {
+			return
+		}
+
+		results = settings.filter.Probes(results)
+		formattedOutput := probe.FormatProbeResults(results, true)
+		if format != formatter.FormatPlain {
+			var err error
+			formattedOutput, err = formatter.FormatProbeScan(results, format, formatter.ScanInfo{Caveats: caveats.List()})
+			if err != nil {
+				logger.Errorf("could not format probe results: %v", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Fprint(out, formattedOutput)
+	},
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report [results]",
+	Short: "Render the output of a score or probe stage",
+	Long:  `Reads the results of the score or probe stage (JSON, JSON Lines or CSV) from a file, or from standard input, and renders them in any output format. DNS records from the resolve stage can be rendered as nmap or masscan target lists.`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := stageFormat(formatter.FormatPlain, formatter.FormatPlain, formatter.FormatJSON, formatter.FormatJSONL,
+			formatter.FormatCSV, formatter.FormatHTML, formatter.FormatMarkdown, formatter.FormatNmap, formatter.FormatMasscan, formatter.FormatURLs,
+			formatter.FormatDefectDojo)
+
+		data := stageInput(args)
+		out, done := stageOutput()
+		defer done()
+		outputFilter := loadFilter()
+
+		var formattedOutput string
+		var err error
+		switch {
+		case formatter.IsProbeReport(data):
+			var results []probe.ProbeResult
+			results, err = probe.ParseProbeResults(data)
+			if err == nil && minScore != 0 {
+				err = fmt.Errorf("--min-score filters scored hosts; probe results carry no score")
+			}
+			results = outputFilter.Probes(results)
+			if err == nil && format == formatter.FormatPlain {
+				formattedOutput = probe.FormatProbeResults(results, true)
+			} else if err == nil {
+				formattedOutput, err = formatter.FormatProbeResults(results, format)
+			}
+		case formatter.IsScoredReport(data):
+			var results []scorer.SubdomainInfo
+			results, err = formatter.ParseScoredResults(data)
+			results = outputFilter.Subdomains(results)
+			if err == nil && format == formatter.FormatPlain {
+				formattedOutput = scorer.FormatResults(results)
+			} else if err == nil {
+				scorer.SortByScore(results)
+				formattedOutput, err = formatter.Format(results, format, stageDomain)
+			}
+		case resolver.IsRecordList(data):
+			var records []resolver.DNSRecord
+			records, err = resolver.ParseRecords(data)
+			if err == nil && outputFilter.NeedsHTTP() {
+				err = fmt.Errorf("DNS records can only be filtered with --only-cloud")
+			}
+			records = outputFilter.Records(records)
+			if err == nil && format == formatter.FormatPlain {
+				formattedOutput = strings.Join(resolver.Names(records), "\n") + "\n"
+			} else if err == nil && formatter.IsTargetFormat(format) {
+				formattedOutput, err = formatter.FormatTargets(records, format)
+			} else if err == nil {
+				err = fmt.Errorf("DNS records can only be reported as plain, nmap or masscan")
+			}
+		default:
+			err = fmt.Errorf("input is neither a score nor a probe report; run it through the score or probe stage first")
+		}
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+
+		fmt.Fprint(out, formattedOutput)
+	},
+}
+
+// stageFormat returns the requested output format, or fallback when none was
+// requested, and exits unless the stage supports it
+func stageFormat(fallback string, supported ...string) string {
+	if outputFormat == "" {
+		return fallback
+	}
+	for _, format := range supported {
+		if outputFormat == format {
+			return format
+		}
+	}
+	logger.Errorf("unsupported output format '%s'. Supported formats: %s", outputFormat, strings.Join(supported, ", "))
+	os.Exit(1)
+	return ""
+}
+
+// stageInput reads the previous stage's output from the file argument, or
+// from standard input when no file or "-" is given
+func stageInput(args []string) []byte {
+	path := "-"
+	if len(args) > 0 {
+		path = args[0]
+	}
+	data, err := input.Read(path)
+	if err != nil {
+		logger.Errorf("could not read %s: %v", describeList(path), err)
+		os.Exit(1)
+	}
+	return data
+}
+
+// stageHosts reads the subdomains handed to a stage, along with their DNS
+// records when the input was written by the resolve stage
+func stageHosts(args []string) ([]string, map[string]resolver.DNSRecord) {
+	data := stageInput(args)
+	records := make(map[string]resolver.DNSRecord)
+
+	if resolver.IsRecordList(data) {
+		parsed, err := resolver.ParseRecords(data)
+		if err != nil {
+			logger.Errorf("could not read DNS records: %v", err)
+			os.Exit(1)
+		}
+		for name, record := range resolver.RecordMap(parsed) {
+			records[name] = record
+		}
+		return resolver.Names(parsed), records
+	}
+
+	hosts, err := input.ParseHosts(data)
+	if err != nil {
+		logger.Errorf("could not read subdomains: %v", err)
+		os.Exit(1)
+	}
+	return hosts, records
+}
+
+// stageOutput returns where a stage writes its results: the output file, or
+// standard output. In the latter case summaries printed alongside are moved to
+// standard error with the log, so the results can be piped into the next
+// stage. The returned function closes the output.
+func stageOutput() (io.Writer, func()) {
+	if outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			logger.Errorf("could not create output file: %v", err)
+			os.Exit(1)
+		}
+		return f, func() {
+			f.Close()
+			logger.Infof("Results saved to %s", outputFile)
+		}
+	}
+
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	return stdout, func() {}
+}
+
+// addStageFlags registers the flags shared by the stage commands reading a
+// previous stage's output
+func addStageFlags(cmd *cobra.Command, formats string) {
+	cmd.Flags().StringVarP(&stageDomain, "domain", "d", "", "Target domain, used as the scope for redirects and in report titles")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Path to output file (writes to stdout if omitted)")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "", "Output format: "+formats)
+}
+
+func init() {
+	enumCmd.Flags().StringSliceVarP(&domains, "domain", "d", nil, "Target domains to enumerate (e.g., example.com or example.com,example.org)")
+	enumCmd.Flags().StringVar(&domainsFile, "domains-file", "", "File with target domains to enumerate, one per line")
+	enumCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Path to output file (writes to stdout if omitted)")
+	addEnumFlags(enumCmd.Flags())
+	addScopeFlags(enumCmd.Flags())
+	addProxyFlags(enumCmd.Flags())
+	addInternalFlags(enumCmd.Flags())
+	enumCmd.Flags().StringSliceVar(&scanWindows, "scan-window", nil, "Only scan inside these local time windows, e.g. 01:00-05:00 (pauses outside them)")
+
+	addStageFlags(resolveCmd, "jsonl (default), json, plain")
+	addResolveFlags(resolveCmd.Flags())
+	addScopeFlags(resolveCmd.Flags())
+	addStreamFlags(resolveCmd.Flags())
+	addInternalFlags(resolveCmd.Flags())
+	resolveCmd.Flags().StringSliceVar(&scanWindows, "scan-window", nil, "Only scan inside these local time windows, e.g. 01:00-05:00 (pauses outside them)")
+
+	addStageFlags(scoreCmd, "jsonl (default), json, csv, html, markdown, plain, nmap, masscan, urls")
+	addScoreFlags(scoreCmd.Flags())
+	addFilterFlags(scoreCmd.Flags())
+	addScopeFlags(scoreCmd.Flags())
+	addScreenshotFlags(scoreCmd.Flags())
+	addStreamFlags(scoreCmd.Flags())
+	addHTTPFlags(scoreCmd.Flags())
+	addProxyFlags(scoreCmd.Flags())
+	scoreCmd.Flags().StringVar(&annotationsFile, "annotations", "", "Path to a JSON/CSV file mapping subdomain patterns to owner/team/notes")
+	scoreCmd.Flags().StringSliceVar(&scanWindows, "scan-window", nil, "Only scan inside these local time windows, e.g. 01:00-05:00 (pauses outside them)")
+
+	addStageFlags(probeCmd, "jsonl (default), json, csv, html, markdown, plain, nmap, masscan, urls, defectdojo")
+	addProbeFlags(probeCmd.Flags())
+	addFilterFlags(probeCmd.Flags())
+	addScopeFlags(probeCmd.Flags())
+	addStreamFlags(probeCmd.Flags())
+	addHTTPFlags(probeCmd.Flags())
+	addProxyFlags(probeCmd.Flags())
+	probeCmd.Flags().StringVar(&annotationsFile, "annotations", "", "Path to a JSON/CSV file mapping subdomain patterns to owner/team/notes")
+	probeCmd.Flags().StringVar(&findingsState, "findings-state", "", "File tracking findings across runs; only new findings are reported, last-seen times are updated")
+	probeCmd.Flags().StringSliceVar(&scanWindows, "scan-window", nil, "Only scan inside these local time windows, e.g. 01:00-05:00 (pauses outside them)")
+
+	addStageFlags(reportCmd, "plain (default), json, jsonl, csv, html, markdown, nmap, masscan, urls, defectdojo (probe reports)")
+	addFilterFlags(reportCmd.Flags())
+
+	rootCmd.AddCommand(enumCmd, resolveCmd, scoreCmd, probeCmd, reportCmd)
+}
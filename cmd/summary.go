@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/omerimzali/subscan/pkg/formatter"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+)
+
+// Executive summary of a multi-domain scan
+var summaryFile string
+
+// domainSummaries collects the roll-up of each scanned domain for --summary
+type domainSummaries struct {
+	mu        sync.Mutex
+	summaries []formatter.DomainSummary
+}
+
+// add rolls up a scanned domain; list scans without a domain are named
+// after their list
+func (d *domainSummaries) add(target string, records []resolver.DNSRecord, results []probe.ProbeResult) {
+	if d == nil {
+		return
+	}
+	if target == "" {
+		target = describeList(listFile)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.summaries = append(d.summaries, formatter.SummarizeDomain(target, records, results))
+}
+
+// write saves the executive summary to path, as Markdown for .md files and
+// HTML otherwise
+func (d *domainSummaries) write(path string) {
+	format := formatter.FormatHTML
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".md" || ext == ".markdown" {
+		format = formatter.FormatMarkdown
+	}
+	d.mu.Lock()
+	content, err := formatter.FormatSummary(d.summaries, format)
+	d.mu.Unlock()
+	if err != nil {
+		logger.Errorf("could not format the summary: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		logger.Errorf("could not write the summary: %v", err)
+		return
+	}
+	logger.Infof("Executive summary of %d domains saved to %s", len(d.summaries), path)
+}
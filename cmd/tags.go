@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/omerimzali/subscan/pkg/formatter"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/scorer"
+	"github.com/omerimzali/subscan/pkg/tags"
+	"github.com/spf13/cobra"
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "List the tags Subscan attaches to hosts",
+	Long:  `Lists every tag Subscan can attach to hosts with its category, severity and description. Names with wildcards, such as PORT-*, stand for families of tags. Tags added by custom score rules and keywords are listed when their files are given.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := stageFormat(formatter.FormatPlain, formatter.FormatPlain, formatter.FormatJSON)
+
+		// Loading the files registers the tags they add
+		if scoreRulesFile != "" {
+			if _, err := scorer.LoadRules(scoreRulesFile); err != nil {
+				logger.Errorf("%v", err)
+				os.Exit(1)
+			}
+		}
+		if scoreKeywordFile != "" {
+			if _, err := scorer.LoadKeywords(scoreKeywordFile); err != nil {
+				logger.Errorf("%v", err)
+				os.Exit(1)
+			}
+		}
+
+		all := tags.All()
+		if format == formatter.FormatJSON {
+			data, err := json.MarshalIndent(all, "", "  ")
+			if err != nil {
+				logger.Errorf("could not format tags: %v", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+			return
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "TAG\tSEVERITY\tCATEGORY\tDESCRIPTION")
+		for _, tag := range all {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", tag.Name, tag.Severity, tag.Category, tag.Description)
+		}
+		writer.Flush()
+	},
+}
+
+func init() {
+	tagsCmd.Flags().StringVarP(&outputFormat, "format", "f", "", "Output format: plain, json (default plain)")
+	tagsCmd.Flags().StringVar(&scoreRulesFile, "score-rules", "", "YAML or JSON file of scoring rules whose tags to list")
+	tagsCmd.Flags().StringVar(&scoreKeywordFile, "score-keywords", "", "File of \"word score [tag]\" lines whose tags to list")
+
+	rootCmd.AddCommand(tagsCmd)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/input"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	takeoverWatchInterval    time.Duration
+	takeoverWatchOnce        bool
+	takeoverWatchResolvers   []string
+	takeoverWatchConcurrency int
+	takeoverWatchTimeout     int
+	takeoverWatchVerbose     bool
+)
+
+var takeoverWatchCmd = &cobra.Command{
+	Use:   "takeover-watch <watchlist>",
+	Short: "Monitor dangling CNAMEs and alert when they become claimable",
+	Long: `Re-resolves only the hosts of a watchlist every interval and alerts as soon as a CNAME target stops existing or its provider serves an unclaimed resource page.
+
+The watchlist is a probe report (JSON, JSON Lines or CSV), from which takeover candidates and hosts pointing at takeover-prone providers are picked, or a plain list of hosts.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := loadWatchlist(args[0])
+		if err != nil {
+			logger.Errorf("could not read watchlist: %v", err)
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			logger.Infof("Watchlist has no hosts with takeover-prone CNAMEs")
+			return
+		}
+
+		nameservers, err := resolver.ParseResolvers(takeoverWatchResolvers)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+
+		var out *os.File
+		if outputFile != "" {
+			out, err = os.OpenFile(outputFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				logger.Errorf("could not open output file: %v", err)
+				os.Exit(1)
+			}
+			defer out.Close()
+		}
+
+		options := probe.DefaultTakeoverWatchOptions()
+		options.Interval = takeoverWatchInterval
+		options.Nameservers = nameservers
+		options.Concurrency = takeoverWatchConcurrency
+		options.Timeout = time.Duration(takeoverWatchTimeout) * time.Second
+		options.Verbose = takeoverWatchVerbose
+		watcher := probe.NewTakeoverWatcher(entries, options)
+
+		// Stop cleanly on Ctrl-C
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+
+		logger.Infof("👀 Watching %d hosts for claimable CNAMEs", len(entries))
+		for {
+			for _, status := range watcher.Check() {
+				reportTakeoverStatus(status, out)
+			}
+			if takeoverWatchOnce {
+				return
+			}
+
+			if takeoverWatchVerbose {
+				logger.Infof("Next check at %s", time.Now().Add(options.Interval).Format("15:04:05"))
+			}
+			select {
+			case <-time.After(options.Interval):
+			case <-interrupt:
+				logger.Infof("Stopped watching")
+				return
+			}
+		}
+	},
+}
+
+// loadWatchlist reads watch entries from a probe report, or from a host list
+// when the file holds no probe results
+func loadWatchlist(path string) ([]probe.WatchEntry, error) {
+	results, err := probe.ReadProbeResultsFromFile(path)
+	if err == nil {
+		return probe.BuildWatchlist(results), nil
+	}
+
+	hosts, hostErr := input.ReadHosts(path)
+	if hostErr != nil {
+		return nil, err
+	}
+	entries := make([]probe.WatchEntry, 0, len(hosts))
+	for _, host := range hosts {
+		entries = append(entries, probe.WatchEntry{Host: host})
+	}
+	return entries, nil
+}
+
+// reportTakeoverStatus prints a claimability change and appends it to the alert log
+func reportTakeoverStatus(status probe.TakeoverStatus, out *os.File) {
+	if status.Claimable {
+		fmt.Printf("🚨 [CLAIMABLE] %s -> %s: %s\n", status.Host, status.CNAME, status.Reason)
+	} else {
+		fmt.Printf("✅ [no longer claimable] %s: %s\n", status.Host, status.Reason)
+	}
+
+	if out != nil {
+		data, err := json.Marshal(status)
+		if err != nil {
+			logger.Warnf("could not record alert: %v", err)
+			return
+		}
+		out.Write(append(data, '\n'))
+	}
+}
+
+func init() {
+	takeoverWatchCmd.Flags().DurationVar(&takeoverWatchInterval, "interval", probe.DefaultTakeoverWatchOptions().Interval, "Time between two checks of the watchlist")
+	takeoverWatchCmd.Flags().BoolVar(&takeoverWatchOnce, "once", false, "Check the watchlist once and exit (for cron)")
+	takeoverWatchCmd.Flags().StringSliceVar(&takeoverWatchResolvers, "resolvers", nil, "DNS resolvers to query (comma-separated IPs or a file with one per line)")
+	takeoverWatchCmd.Flags().IntVar(&takeoverWatchConcurrency, "concurrency", 10, "Number of hosts checked concurrently")
+	takeoverWatchCmd.Flags().IntVar(&takeoverWatchTimeout, "timeout", 10, "Timeout in seconds for DNS and HTTP requests")
+	takeoverWatchCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Append alerts as JSON Lines to this file")
+	takeoverWatchCmd.Flags().BoolVar(&takeoverWatchVerbose, "verbose", false, "Show hosts that could not be checked and the next check time")
+
+	rootCmd.AddCommand(takeoverWatchCmd)
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/enumeration"
+	"github.com/omerimzali/subscan/pkg/input"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/scan"
+	"github.com/omerimzali/subscan/pkg/vhost"
+	"github.com/spf13/cobra"
+)
+
+var (
+	vhostDomain      string
+	vhostAddresses   []string
+	vhostWordlists   []string
+	vhostList        string
+	vhostScheme      string
+	vhostPort        int
+	vhostConcurrency int
+	vhostTimeout     int
+	vhostTolerance   int
+)
+
+var vhostCmd = &cobra.Command{
+	Use:   "vhost",
+	Short: "Discover virtual hosts by brute forcing the Host header",
+	Long: `Requests candidate names from a server directly, by Host header and TLS server name, and reports the names it answers differently for than for a random name. Shared hosting and CDN origins often serve virtual hosts that have no DNS records, which DNS-based enumeration never finds.
+
+Candidates are the words of the wordlists under --domain and the names of --list. The servers are the --ip addresses, or the addresses of the domain itself when none are given. Names that also resolve are marked, so the ones missing from DNS stand out.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if vhostDomain == "" {
+			logger.Errorf("--domain is required")
+			os.Exit(1)
+		}
+
+		var candidates []string
+		for _, spec := range vhostWordlists {
+			list, err := enumeration.ParseWordlist(spec)
+			if err != nil {
+				logger.Errorf("%v", err)
+				os.Exit(1)
+			}
+			candidates = append(candidates, enumeration.BruteForceWordlist(vhostDomain, list, nil)...)
+		}
+		if vhostList != "" {
+			listed, err := input.ReadHosts(vhostList)
+			if err != nil {
+				logger.Errorf("could not read subdomain list: %v", err)
+				os.Exit(1)
+			}
+			candidates = append(candidates, listed...)
+		}
+		candidates, _ = scan.Dedupe(candidates)
+		candidates, _ = enumeration.ScopeCandidates(candidates, vhostDomain, 0)
+		if len(candidates) == 0 {
+			logger.Errorf("no candidates; use --wordlist or --list to supply them")
+			os.Exit(1)
+		}
+
+		addresses := vhostAddresses
+		if len(addresses) == 0 {
+			resolved, err := net.LookupHost(vhostDomain)
+			if err != nil {
+				logger.Errorf("could not resolve %s; give the servers with --ip: %v", vhostDomain, err)
+				os.Exit(1)
+			}
+			addresses = resolved
+		}
+
+		options := vhost.DefaultOptions()
+		options.Scheme = vhostScheme
+		options.Port = vhostPort
+		options.Concurrency = vhostConcurrency
+		options.Timeout = time.Duration(vhostTimeout) * time.Second
+		options.Tolerance = vhostTolerance
+		options.Proxy = stageProxy("")
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		var found []vhost.Host
+		for _, address := range addresses {
+			logger.Infof("🏠 Trying %d virtual hosts on %s", len(candidates), address)
+			hosts, err := vhost.Discover(ctx, address, candidates, options)
+			if err != nil {
+				logger.Warnf("%v", err)
+				continue
+			}
+			found = append(found, hosts...)
+		}
+		sort.Slice(found, func(i, j int) bool {
+			if found[i].Address != found[j].Address {
+				return found[i].Address < found[j].Address
+			}
+			return found[i].Name < found[j].Name
+		})
+
+		hidden := 0
+		for _, host := range found {
+			dns := "not in DNS"
+			if host.InDNS {
+				dns = "in DNS"
+			} else {
+				hidden++
+			}
+			title := ""
+			if host.Title != "" {
+				title = fmt.Sprintf(" %q", host.Title)
+			}
+			fmt.Printf("%s [%s] [%d] [%d bytes] [%s]%s\n", host.URL, host.Address, host.Status, host.ContentLength, dns, title)
+		}
+		logger.Infof("Found %d virtual hosts, %d of them not in DNS", len(found), hidden)
+
+		if outputFile != "" {
+			writeVhosts(found, outputFile)
+		}
+		exitIfInterrupted(ctx)
+	},
+}
+
+// writeVhosts saves the virtual hosts found as JSON Lines
+func writeVhosts(hosts []vhost.Host, path string) {
+	out, err := os.Create(path)
+	if err != nil {
+		logger.Errorf("could not create output file: %v", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+
+	encoder := json.NewEncoder(out)
+	for _, host := range hosts {
+		if err := encoder.Encode(host); err != nil {
+			logger.Errorf("could not write virtual hosts: %v", err)
+			os.Exit(1)
+		}
+	}
+	logger.Infof("Virtual hosts saved to %s", path)
+}
+
+func init() {
+	defaults := vhost.DefaultOptions()
+	vhostCmd.Flags().StringVarP(&vhostDomain, "domain", "d", "", "Domain the candidate virtual hosts are under")
+	vhostCmd.Flags().StringSliceVar(&vhostAddresses, "ip", nil, "Addresses of the servers to query (default: the domain's own addresses)")
+	vhostCmd.Flags().StringSliceVarP(&vhostWordlists, "wordlist", "w", nil, "Wordlists of candidate names as path[:mode], mode prefix (default), suffix or infix")
+	vhostCmd.Flags().StringVarP(&vhostList, "list", "l", "", "File of candidate names, such as known subdomains (plain, JSON, JSONL or CSV)")
+	vhostCmd.Flags().StringVar(&vhostScheme, "scheme", defaults.Scheme, "Scheme to request: https or http")
+	vhostCmd.Flags().IntVar(&vhostPort, "port", 0, "Port to request (default: 443 for https, 80 for http)")
+	vhostCmd.Flags().IntVar(&vhostConcurrency, "concurrency", defaults.Concurrency, "Number of concurrent requests")
+	vhostCmd.Flags().IntVar(&vhostTimeout, "timeout", int(defaults.Timeout/time.Second), "Timeout in seconds for each request")
+	vhostCmd.Flags().IntVar(&vhostTolerance, "tolerance", defaults.Tolerance, "Bytes a response may differ from the server's default page and still match it")
+	addProxyFlags(vhostCmd.Flags())
+	vhostCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Save the virtual hosts found as JSON Lines to this file")
+
+	rootCmd.AddCommand(vhostCmd)
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/ctstream"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchDomains       []string
+	certstreamURL      string
+	watchResolve       bool
+	watchScore         bool
+	watchBatchInterval time.Duration
+	watchVerbose       bool
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream newly issued certificates and emit new subdomains",
+	Long:  `Connects to a certstream-compatible certificate transparency stream and continuously emits newly observed subdomains of the target domains, optionally resolving and scoring them as they appear.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(watchDomains) == 0 {
+			logger.Errorf("at least one domain is required")
+			cmd.Help()
+			os.Exit(1)
+		}
+
+		var out *os.File
+		if outputFile != "" {
+			var err error
+			out, err = os.OpenFile(outputFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				logger.Errorf("could not open output file: %v", err)
+				os.Exit(1)
+			}
+			defer out.Close()
+		}
+
+		var settings scanSettings
+		if watchResolve || watchScore {
+			settings = loadScanSettings(cmd)
+		}
+
+		options := ctstream.DefaultWatchOptions()
+		options.URL = certstreamURL
+		options.Domains = watchDomains
+		options.Verbose = watchVerbose
+
+		// Stop cleanly on Ctrl-C
+		stop := make(chan struct{})
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		go func() {
+			<-interrupt
+			close(stop)
+		}()
+
+		// New names are resolved and scored in batches to keep DNS and HTTP work bounded
+		var mu sync.Mutex
+		var pending []string
+		var wg sync.WaitGroup
+		if watchResolve || watchScore {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ticker := time.NewTicker(watchBatchInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-stop:
+						// Names seen since the last tick are processed before exiting
+						mu.Lock()
+						batch := pending
+						pending = nil
+						mu.Unlock()
+						processWatchBatch(batch, settings)
+						return
+					case <-ticker.C:
+						mu.Lock()
+						batch := pending
+						pending = nil
+						mu.Unlock()
+						processWatchBatch(batch, settings)
+					}
+				}
+			}()
+		}
+
+		logger.Infof("👀 Watching certificate transparency stream for: %v", watchDomains)
+		ctstream.Watch(options, stop, func(subdomain string) {
+			fmt.Printf("[new] %s\n", subdomain)
+			if out != nil {
+				out.WriteString(subdomain + "\n")
+			}
+			if watchResolve || watchScore {
+				mu.Lock()
+				pending = append(pending, subdomain)
+				mu.Unlock()
+			}
+		})
+
+		wg.Wait()
+		logger.Infof("Stopped watching")
+	},
+}
+
+// processWatchBatch resolves a batch of newly observed subdomains as a scan of
+// the watched domain they belong to would, and scores the alive ones
+func processWatchBatch(batch []string, settings scanSettings) {
+	if len(batch) == 0 {
+		return
+	}
+
+	byDomain := make(map[string][]string)
+	for _, subdomain := range batch {
+		domain := watchedDomainOf(subdomain)
+		byDomain[domain] = append(byDomain[domain], subdomain)
+	}
+	var records []resolver.DNSRecord
+	for _, domain := range append(watchDomains, "") {
+		names := byDomain[domain]
+		if len(names) == 0 {
+			continue
+		}
+		found := resolver.ResolveSubdomains(context.Background(), names, resolveOptionsFor(domain, settings))
+		if reverify {
+			found = reverifyRecords(context.Background(), domain, found, settings)
+		}
+		records = append(records, found...)
+	}
+	alive := resolver.Names(records)
+	if !watchScore || len(alive) == 0 {
+		for _, subdomain := range alive {
+			fmt.Printf("[alive] %s\n", subdomain)
+		}
+		return
+	}
+
+	options := scorer.DefaultOptions()
+	options.Concurrency = scoreConcurrency
+	options.Timeout = time.Duration(scoreTimeout) * time.Second
+	options.Records = resolver.RecordMap(records)
+	results := scorer.AnalyzeSubdomains(context.Background(), alive, options)
+	fmt.Print(scorer.FormatResults(results))
+}
+
+// watchedDomainOf returns the most specific watched domain the subdomain is
+// under, or "" when there is none
+func watchedDomainOf(subdomain string) string {
+	subdomain = strings.ToLower(subdomain)
+	match := ""
+	for _, domain := range watchDomains {
+		lower := strings.ToLower(domain)
+		if (subdomain == lower || strings.HasSuffix(subdomain, "."+lower)) && len(domain) > len(match) {
+			match = domain
+		}
+	}
+	return match
+}
+
+func init() {
+	watchCmd.Flags().StringSliceVarP(&watchDomains, "domain", "d", nil, "Domains to watch (comma-separated)")
+	watchCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Append newly observed subdomains to this file")
+	watchCmd.Flags().StringVar(&certstreamURL, "certstream-url", ctstream.DefaultURL, "Certstream-compatible websocket URL")
+	watchCmd.Flags().BoolVar(&watchResolve, "resolve", false, "Resolve newly observed subdomains")
+	watchCmd.Flags().BoolVar(&watchScore, "score", false, "Resolve and score newly observed subdomains")
+	watchCmd.Flags().DurationVar(&watchBatchInterval, "batch-interval", 30*time.Second, "How often to resolve/score the newly observed subdomains")
+	watchCmd.Flags().IntVar(&scoreConcurrency, "score-concurrency", 10, "Number of concurrent requests during scoring")
+	watchCmd.Flags().IntVar(&scoreTimeout, "score-timeout", 5, "Timeout in seconds for HTTP requests during scoring")
+	watchCmd.Flags().BoolVar(&watchVerbose, "verbose", false, "Show connection details")
+	addResolveFlags(watchCmd.Flags())
+
+	rootCmd.AddCommand(watchCmd)
+}
@@ -0,0 +1,110 @@
+package annotate
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// Annotation maps a subdomain pattern to its owning team
+type Annotation struct {
+	// Pattern is an exact hostname or a glob such as *.payments.example.com
+	Pattern string `json:"pattern"`
+	Owner   string `json:"owner,omitempty"`
+	Team    string `json:"team,omitempty"`
+	Notes   string `json:"notes,omitempty"`
+}
+
+// Annotations is an ordered list of annotations; the first matching pattern wins
+type Annotations []Annotation
+
+// Load reads annotations from a JSON file (a list of objects) or a CSV file
+// with the columns pattern,owner,team,notes
+func Load(path string) (Annotations, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return parseCSV(string(data))
+	}
+
+	var annotations Annotations
+	if err := json.Unmarshal(data, &annotations); err != nil {
+		return nil, fmt.Errorf("error parsing annotations file: %v", err)
+	}
+	return annotations, nil
+}
+
+// parseCSV parses annotations from CSV, skipping an optional header row
+func parseCSV(data string) (Annotations, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing annotations CSV: %v", err)
+	}
+
+	var annotations Annotations
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "pattern") {
+			continue
+		}
+		for len(record) < 4 {
+			record = append(record, "")
+		}
+		annotations = append(annotations, Annotation{
+			Pattern: strings.TrimSpace(record[0]),
+			Owner:   strings.TrimSpace(record[1]),
+			Team:    strings.TrimSpace(record[2]),
+			Notes:   strings.TrimSpace(record[3]),
+		})
+	}
+	return annotations, nil
+}
+
+// Match returns the first annotation whose pattern matches the host
+func (a Annotations) Match(host string) (Annotation, bool) {
+	host = strings.ToLower(host)
+	for _, annotation := range a {
+		pattern := strings.ToLower(annotation.Pattern)
+		if pattern == host {
+			return annotation, true
+		}
+		if matched, err := filepath.Match(pattern, host); err == nil && matched {
+			return annotation, true
+		}
+	}
+	return Annotation{}, false
+}
+
+// ApplyToScores attaches matching annotations to scoring results
+func (a Annotations) ApplyToScores(results []scorer.SubdomainInfo) {
+	for i := range results {
+		if annotation, ok := a.Match(results[i].Subdomain); ok {
+			results[i].Owner = annotation.Owner
+			results[i].Team = annotation.Team
+			results[i].Notes = annotation.Notes
+		}
+	}
+}
+
+// ApplyToProbes attaches matching annotations to probe results
+func (a Annotations) ApplyToProbes(results []probe.ProbeResult) {
+	for i := range results {
+		if annotation, ok := a.Match(results[i].Domain); ok {
+			results[i].Owner = annotation.Owner
+			results[i].Team = annotation.Team
+			results[i].Notes = annotation.Notes
+		}
+	}
+}
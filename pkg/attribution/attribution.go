@@ -0,0 +1,161 @@
+// Package attribution records which sources found each subdomain: passive
+// sources by name, and the active stages such as bruteforce, permutation and
+// tls-san. Reports list the sources of every host, and the per-source
+// statistics show which sources are worth their time.
+package attribution
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Source labels of the active stages
+const (
+	Bruteforce      = "bruteforce"
+	SmartBruteforce = "smart-bruteforce"
+	Permutation     = "permutation"
+	Feedback        = "feedback"
+	List            = "list"
+	ZoneTransfer    = "axfr"
+	ZoneWalk        = "nsec"
+	Apex            = "apex"
+	ReverseDNS      = "reverse-dns"
+	TLSSAN          = "tls-san"
+)
+
+// Sources collects the sources of each name. A nil *Sources records nothing.
+type Sources struct {
+	mu    sync.Mutex
+	names map[string][]string
+	order []string
+}
+
+// sourcesKey carries a *Sources through a scan's context
+type sourcesKey struct{}
+
+// With returns a context whose stages record the names they find in sources
+func With(ctx context.Context, sources *Sources) context.Context {
+	return context.WithValue(ctx, sourcesKey{}, sources)
+}
+
+// From returns the collector carried by ctx, or nil when there is none
+func From(ctx context.Context) *Sources {
+	sources, _ := ctx.Value(sourcesKey{}).(*Sources)
+	return sources
+}
+
+// Record records that source found the names in the collector carried by
+// ctx, if any
+func Record(ctx context.Context, source string, names ...string) {
+	From(ctx).Add(source, names...)
+}
+
+// Add records that source found the names, which are normalized the way
+// candidates are deduplicated
+func (s *Sources) Add(source string, names ...string) {
+	if s == nil || len(names) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.names == nil {
+		s.names = make(map[string][]string)
+	}
+	known := false
+	for _, seen := range s.order {
+		if seen == source {
+			known = true
+			break
+		}
+	}
+	if !known {
+		s.order = append(s.order, source)
+	}
+	for _, name := range names {
+		name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "*.")
+		name = strings.TrimSuffix(name, ".")
+		if name == "" || contains(s.names[name], source) {
+			continue
+		}
+		s.names[name] = append(s.names[name], source)
+	}
+}
+
+// Of returns the sources that found name, sorted
+func (s *Sources) Of(name string) []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sources := append([]string(nil), s.names[strings.ToLower(name)]...)
+	sort.Strings(sources)
+	return sources
+}
+
+// Stat summarizes what one source contributed to a scan
+type Stat struct {
+	Source string
+	// Found counts the names the source reported
+	Found int
+	// Unique counts the names no other source reported
+	Unique int
+	// Alive counts the names of Found that resolved
+	Alive int
+}
+
+// String describes the statistics on one line
+func (s Stat) String() string {
+	rate := 0.0
+	if s.Found > 0 {
+		rate = float64(s.Alive) / float64(s.Found) * 100
+	}
+	return fmt.Sprintf("%-18s %7d found %7d unique %7d alive (%.1f%%)", s.Source, s.Found, s.Unique, s.Alive, rate)
+}
+
+// Stats returns the statistics of every source, in the order the sources
+// first reported names, counting the alive names among them
+func (s *Sources) Stats(alive []string) []Stat {
+	if s == nil {
+		return nil
+	}
+	resolved := make(map[string]bool, len(alive))
+	for _, name := range alive {
+		resolved[strings.ToLower(name)] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := make(map[string]int, len(s.order))
+	stats := make([]Stat, len(s.order))
+	for i, source := range s.order {
+		index[source] = i
+		stats[i].Source = source
+	}
+	for name, sources := range s.names {
+		for _, source := range sources {
+			stat := &stats[index[source]]
+			stat.Found++
+			if len(sources) == 1 {
+				stat.Unique++
+			}
+			if resolved[name] {
+				stat.Alive++
+			}
+		}
+	}
+	return stats
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package bench
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/omerimzali/subscan/pkg/httpclient"
+)
+
+// MaxErrorRate is the highest error rate a concurrency level may show to be
+// considered sustainable when suggesting settings
+const MaxErrorRate = 0.01
+
+// Options contains configuration for a benchmark run
+type Options struct {
+	// Levels are the concurrency levels measured, in increasing order
+	Levels []int
+	// Queries is the number of DNS queries sent at each level
+	Queries int
+	// Requests is the number of HTTP requests sent at each level
+	Requests int
+	// Resolvers are benchmarked instead of a local DNS server when set
+	Resolvers []string
+	// Domain names the random labels sent to real resolvers; use one you control
+	Domain string
+	// URL is benchmarked instead of a local HTTP server when set
+	URL     string
+	Timeout time.Duration
+}
+
+// DefaultOptions returns the default benchmark options, which only use
+// local servers so nothing outside the machine is load tested
+func DefaultOptions() Options {
+	return Options{
+		Levels:   []int{10, 50, 100, 250, 500},
+		Queries:  2000,
+		Requests: 500,
+		Domain:   "example.com",
+		Timeout:  5 * time.Second,
+	}
+}
+
+// Measurement is the throughput observed at one concurrency level
+type Measurement struct {
+	Concurrency int
+	Total       int
+	Errors      int
+	Duration    time.Duration
+}
+
+// Rate returns the completed operations per second
+func (m Measurement) Rate() float64 {
+	if m.Duration <= 0 {
+		return 0
+	}
+	return float64(m.Total-m.Errors) / m.Duration.Seconds()
+}
+
+// ErrorRate returns the share of operations that failed
+func (m Measurement) ErrorRate() float64 {
+	if m.Total == 0 {
+		return 0
+	}
+	return float64(m.Errors) / float64(m.Total)
+}
+
+// Best returns the fastest measurement whose error rate stays within MaxErrorRate
+func Best(measurements []Measurement) (Measurement, bool) {
+	var best Measurement
+	found := false
+	for _, m := range measurements {
+		if m.ErrorRate() <= MaxErrorRate && (!found || m.Rate() > best.Rate()) {
+			best = m
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Resolve measures DNS queries per second at each concurrency level, against
+// the configured resolvers or a local DNS server answering every name
+func Resolve(options Options) ([]Measurement, error) {
+	servers := options.Resolvers
+	if len(servers) == 0 {
+		server, err := startDNSServer()
+		if err != nil {
+			return nil, fmt.Errorf("error starting local DNS server: %v", err)
+		}
+		defer server.Shutdown()
+		servers = []string{server.PacketConn.LocalAddr().String()}
+	}
+
+	var next uint32
+	query := func() error {
+		msg := new(dns.Msg)
+		msg.SetQuestion(dns.Fqdn(uniqueLabel()+"."+options.Domain), dns.TypeA)
+		client := &dns.Client{Timeout: options.Timeout}
+		server := servers[atomic.AddUint32(&next, 1)%uint32(len(servers))]
+		reply, _, err := client.Exchange(msg, server)
+		if err != nil {
+			return err
+		}
+		if reply.Rcode != dns.RcodeSuccess && reply.Rcode != dns.RcodeNameError {
+			return fmt.Errorf("%s answered %s", server, dns.RcodeToString[reply.Rcode])
+		}
+		return nil
+	}
+
+	return measure(options.Levels, options.Queries, query), nil
+}
+
+// HTTP measures HTTP requests per second at each concurrency level, against
+// the configured URL or a local HTTP server
+func HTTP(options Options) ([]Measurement, error) {
+	target := options.URL
+	if target == "" {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html><body>subscan bench</body></html>"))
+		}))
+		defer server.Close()
+		target = server.URL
+	}
+
+	// Probing opens a fresh connection per host, so keep-alives stay off here too
+	client := httpclient.New(httpclient.Options{
+		Timeout:           options.Timeout,
+		DisableKeepAlives: true,
+	})
+	request := func() error {
+		resp, err := client.Get(target)
+		if err != nil {
+			return err
+		}
+		httpclient.ReadBody(resp, 10*1024)
+		return resp.Body.Close()
+	}
+
+	return measure(options.Levels, options.Requests, request), nil
+}
+
+// measure runs total operations at each concurrency level and times them
+func measure(levels []int, total int, operation func() error) []Measurement {
+	var measurements []Measurement
+	for _, level := range levels {
+		jobs := make(chan struct{}, total)
+		for i := 0; i < total; i++ {
+			jobs <- struct{}{}
+		}
+		close(jobs)
+
+		var errors int32
+		var wg sync.WaitGroup
+		start := time.Now()
+		for i := 0; i < level; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range jobs {
+					if operation() != nil {
+						atomic.AddInt32(&errors, 1)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		measurements = append(measurements, Measurement{
+			Concurrency: level,
+			Total:       total,
+			Errors:      int(errors),
+			Duration:    time.Since(start),
+		})
+	}
+	return measurements
+}
+
+// startDNSServer starts a UDP DNS server on a loopback port that answers
+// every A query with 127.0.0.1
+func startDNSServer() (*dns.Server, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		reply := new(dns.Msg)
+		reply.SetReply(r)
+		for _, question := range r.Question {
+			reply.Answer = append(reply.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: question.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.IPv4(127, 0, 0, 1),
+			})
+		}
+		w.WriteMsg(reply)
+	})
+
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: conn, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go server.ActivateAndServe()
+	<-started
+	return server, nil
+}
+
+// labelNonce and labelCounter make every benchmark query name unique
+var (
+	labelNonce   = strconv.FormatInt(time.Now().UnixNano(), 36)
+	labelCounter uint64
+)
+
+// uniqueLabel returns a label that has never been queried, so resolver caches don't skew results
+func uniqueLabel() string {
+	return fmt.Sprintf("bench-%s-%d", labelNonce, atomic.AddUint64(&labelCounter, 1))
+}
+
+// FormatMeasurements renders measurements as a table
+func FormatMeasurements(title string, unit string, measurements []Measurement) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("=== %s ===\n", title))
+	output.WriteString(fmt.Sprintf("%-12s %-12s %-10s %s\n", "Concurrency", unit, "Errors", "Duration"))
+	for _, m := range measurements {
+		output.WriteString(fmt.Sprintf("%-12d %-12.0f %-10s %s\n",
+			m.Concurrency, m.Rate(), fmt.Sprintf("%.1f%%", m.ErrorRate()*100), m.Duration.Round(time.Millisecond)))
+	}
+	return output.String()
+}
@@ -0,0 +1,254 @@
+// Package buckets derives likely cloud storage bucket names from a domain and
+// checks whether they exist on the providers' endpoints. Buckets have no DNS
+// records of their own under the target, so resolution never finds them.
+package buckets
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/omerimzali/subscan/pkg/progress"
+)
+
+// Bucket access levels
+const (
+	// AccessPublic buckets list their objects to anyone
+	AccessPublic = "public"
+	// AccessPrivate buckets exist but refuse anonymous listing
+	AccessPrivate = "private"
+)
+
+// maxObjects is how many object keys of a public bucket are kept as a sample
+const maxObjects = 10
+
+// maxBody caps how much of a listing is read
+const maxBody = 256 * 1024
+
+// DefaultWords are joined to the company name to form bucket names
+var DefaultWords = []string{
+	"backup", "backups", "assets", "static", "media", "uploads", "files", "data",
+	"logs", "dev", "prod", "production", "staging", "stage", "test", "qa",
+	"public", "private", "cdn", "images", "img", "www", "web", "archive", "db",
+	"config", "internal", "docs", "reports", "exports",
+}
+
+var (
+	keyPattern      = regexp.MustCompile(`<Key>([^<]*)</Key>`)
+	endpointPattern = regexp.MustCompile(`<Endpoint>([^<]*)</Endpoint>`)
+	validBucket     = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+)
+
+// Provider is a storage service checked for buckets
+type Provider struct {
+	Name string
+	// Endpoint is the URL of a bucket with %s for its name, path-style so
+	// dotted names don't break certificate validation
+	Endpoint string
+}
+
+// DefaultProviders returns Amazon S3 and Google Cloud Storage
+func DefaultProviders() []Provider {
+	return []Provider{
+		{Name: "s3", Endpoint: "https://s3.amazonaws.com/%s/"},
+		{Name: "gcs", Endpoint: "https://storage.googleapis.com/%s/"},
+	}
+}
+
+// Options contains configuration for bucket checks
+type Options struct {
+	Providers   []Provider
+	Concurrency int
+	Timeout     time.Duration
+	UserAgent   string
+	// Proxy routes the requests through this proxy; see httpclient.Options
+	Proxy *url.URL
+}
+
+// DefaultOptions returns the default bucket check options
+func DefaultOptions() Options {
+	return Options{
+		Providers:   DefaultProviders(),
+		Concurrency: 10,
+		Timeout:     10 * time.Second,
+	}
+}
+
+// LoadWords reads a bucket word file, one word per line
+func LoadWords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open bucket words: %v", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// Names returns the bucket names likely used by the owner of domain: the
+// company name alone, with the public suffix, and joined to each word in
+// front or behind, e.g. example-backups and prod-example for example.com
+func Names(domain string, words []string) []string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if len(words) == 0 {
+		words = DefaultWords
+	}
+	company := strings.Split(domain, ".")[0]
+
+	names := []string{company, domain, strings.ReplaceAll(domain, ".", "-")}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+		names = append(names,
+			company+"-"+word, word+"-"+company,
+			company+word, word+company,
+			company+"."+word, word+"."+domain)
+	}
+
+	seen := make(map[string]bool)
+	var unique []string
+	for _, name := range names {
+		if validBucket.MatchString(name) && !strings.Contains(name, "..") && !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// Check looks each name up on every provider and returns the buckets that
+// exist, public ones first
+func Check(ctx context.Context, names []string, options Options) []model.Bucket {
+	client := httpclient.New(httpclient.Options{
+		Timeout:   options.Timeout,
+		UserAgent: options.UserAgent,
+		Context:   ctx,
+		Proxy:     options.Proxy,
+	})
+
+	type job struct {
+		name     string
+		provider Provider
+	}
+	jobs := make(chan job)
+	var mu sync.Mutex
+	var found []model.Bucket
+	var wg sync.WaitGroup
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	tracker := progress.Start("Buckets", len(names)*len(options.Providers))
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				bucket, ok := checkBucket(client, j.name, j.provider)
+				tracker.Increment()
+				if ok {
+					mu.Lock()
+					found = append(found, bucket)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, name := range names {
+		for _, provider := range options.Providers {
+			select {
+			case jobs <- job{name, provider}:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	tracker.Finish()
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Access != found[j].Access {
+			return found[i].Access == AccessPublic
+		}
+		if found[i].Name != found[j].Name {
+			return found[i].Name < found[j].Name
+		}
+		return found[i].Provider < found[j].Provider
+	})
+	return found
+}
+
+// checkBucket requests a bucket's listing. A listing means the bucket is
+// public, a refusal that it exists but is private, and a 404 that there is no
+// such bucket. S3 redirects requests for buckets of other regions to their
+// regional endpoint, which is followed once.
+func checkBucket(client *http.Client, name string, provider Provider) (model.Bucket, bool) {
+	bucket := model.Bucket{Name: name, Provider: provider.Name, URL: fmt.Sprintf(provider.Endpoint, name)}
+	status, body, err := get(client, bucket.URL)
+	if err != nil {
+		return bucket, false
+	}
+	if status == http.StatusMovedPermanently || status == http.StatusTemporaryRedirect {
+		if match := endpointPattern.FindStringSubmatch(body); match != nil {
+			regional := fmt.Sprintf("https://%s/%s/", match[1], name)
+			if strings.HasPrefix(match[1], name+".") {
+				regional = fmt.Sprintf("https://%s/", match[1])
+			}
+			if s, b, err := get(client, regional); err == nil {
+				bucket.URL, status, body = regional, s, b
+			}
+		}
+	}
+
+	switch {
+	case status == http.StatusOK && strings.Contains(body, "<ListBucketResult"):
+		bucket.Access = AccessPublic
+		for _, match := range keyPattern.FindAllStringSubmatch(body, maxObjects) {
+			bucket.Objects = append(bucket.Objects, match[1])
+		}
+	case status == http.StatusForbidden || status == http.StatusUnauthorized:
+		bucket.Access = AccessPrivate
+	case status == http.StatusMovedPermanently || status == http.StatusTemporaryRedirect:
+		// The bucket exists in a region that couldn't be asked
+		bucket.Access = AccessPrivate
+	default:
+		return bucket, false
+	}
+	return bucket, true
+}
+
+// get returns the status and the start of the body of a GET request
+func get(client *http.Client, target string) (int, string, error) {
+	resp, err := client.Get(target)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	return resp.StatusCode, string(body), nil
+}
@@ -0,0 +1,252 @@
+// Package checkpoint records the progress of a scan in a state file, so an
+// interrupted scan can resume where it stopped instead of starting over.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// DefaultInterval is the minimum time between two saves while a scan makes progress
+const DefaultInterval = 30 * time.Second
+
+// stateVersion is bumped when the state file changes incompatibly
+const stateVersion = 1
+
+// state is the content of a state file
+type state struct {
+	Version int    `json:"version"`
+	Target  string `json:"target"`
+	// Candidates is the enumerated candidate list, reused so a resumed scan
+	// works on the same names without querying sources again
+	Candidates []string         `json:"candidates"`
+	KnownPorts map[string][]int `json:"known_ports,omitempty"`
+	// Attempted are the candidates resolved so far, alive or not
+	Attempted []string               `json:"attempted"`
+	Alive     []resolver.DNSRecord   `json:"alive"`
+	Scored    []scorer.SubdomainInfo `json:"scored,omitempty"`
+	Probed    []probe.ProbeResult    `json:"probed,omitempty"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// Checkpoint tracks the progress of one domain's scan and saves it
+// periodically. It is safe for concurrent use by the scan workers.
+type Checkpoint struct {
+	mu        sync.Mutex
+	path      string
+	interval  time.Duration
+	lastSave  time.Time
+	state     state
+	attempted map[string]bool
+	scored    map[string]bool
+	probed    map[string]bool
+}
+
+// Load opens the state file at path, or starts a new checkpoint there when the
+// file doesn't exist yet. A state file of another target is refused.
+func Load(path string, target string) (*Checkpoint, error) {
+	c := &Checkpoint{
+		path:      path,
+		interval:  DefaultInterval,
+		lastSave:  time.Now(),
+		state:     state{Version: stateVersion, Target: target},
+		attempted: make(map[string]bool),
+		scored:    make(map[string]bool),
+		probed:    make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.state); err != nil {
+		return nil, fmt.Errorf("error parsing state file %s: %v", path, err)
+	}
+	if c.state.Version != stateVersion {
+		return nil, fmt.Errorf("state file %s has unsupported version %d", path, c.state.Version)
+	}
+	if c.state.Target != target {
+		return nil, fmt.Errorf("state file %s belongs to a scan of %q, not %q", path, c.state.Target, target)
+	}
+
+	for _, name := range c.state.Attempted {
+		c.attempted[name] = true
+	}
+	for _, info := range c.state.Scored {
+		c.scored[info.Subdomain] = true
+	}
+	for _, result := range c.state.Probed {
+		c.probed[result.Domain] = true
+	}
+	return c, nil
+}
+
+// Resumed reports whether the checkpoint was restored from an earlier run
+func (c *Checkpoint) Resumed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state.Candidates != nil
+}
+
+// Candidates returns the enumerated candidates and ports of an earlier run
+func (c *Checkpoint) Candidates() ([]string, map[string][]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state.Candidates, c.state.KnownPorts
+}
+
+// SetCandidates records the enumerated candidates and saves the checkpoint
+func (c *Checkpoint) SetCandidates(candidates []string, knownPorts map[string][]int) error {
+	c.mu.Lock()
+	c.state.Candidates = append([]string{}, candidates...)
+	c.state.KnownPorts = knownPorts
+	c.mu.Unlock()
+	return c.Save()
+}
+
+// Unresolved returns the candidates not resolved yet, in their original order
+func (c *Checkpoint) Unresolved(candidates []string) []string {
+	return c.pending(candidates, c.attempted)
+}
+
+// Alive returns the records of the candidates found alive so far
+func (c *Checkpoint) Alive() []resolver.DNSRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]resolver.DNSRecord{}, c.state.Alive...)
+}
+
+// Attempted records that a candidate was resolved, with its record when alive
+func (c *Checkpoint) Attempted(name string, record *resolver.DNSRecord) {
+	c.mu.Lock()
+	if !c.attempted[name] {
+		c.attempted[name] = true
+		c.state.Attempted = append(c.state.Attempted, name)
+		if record != nil {
+			c.state.Alive = append(c.state.Alive, *record)
+		}
+	}
+	c.mu.Unlock()
+	c.maybeSave()
+}
+
+// Unscored returns the hosts not scored yet
+func (c *Checkpoint) Unscored(hosts []string) []string {
+	return c.pending(hosts, c.scored)
+}
+
+// Scored returns the scoring results of earlier runs
+func (c *Checkpoint) Scored() []scorer.SubdomainInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]scorer.SubdomainInfo{}, c.state.Scored...)
+}
+
+// AddScored records a scoring result
+func (c *Checkpoint) AddScored(info scorer.SubdomainInfo) {
+	c.mu.Lock()
+	if !c.scored[info.Subdomain] {
+		c.scored[info.Subdomain] = true
+		c.state.Scored = append(c.state.Scored, info)
+	}
+	c.mu.Unlock()
+	c.maybeSave()
+}
+
+// Unprobed returns the hosts not probed yet
+func (c *Checkpoint) Unprobed(hosts []string) []string {
+	return c.pending(hosts, c.probed)
+}
+
+// Probed returns the probe results of earlier runs
+func (c *Checkpoint) Probed() []probe.ProbeResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]probe.ProbeResult{}, c.state.Probed...)
+}
+
+// AddProbed records a probe result
+func (c *Checkpoint) AddProbed(result probe.ProbeResult) {
+	c.mu.Lock()
+	if !c.probed[result.Domain] {
+		c.probed[result.Domain] = true
+		c.state.Probed = append(c.state.Probed, result)
+	}
+	c.mu.Unlock()
+	c.maybeSave()
+}
+
+// pending returns the names not in done, keeping their order
+func (c *Checkpoint) pending(names []string, done map[string]bool) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var rest []string
+	for _, name := range names {
+		if !done[name] {
+			rest = append(rest, name)
+		}
+	}
+	return rest
+}
+
+// maybeSave saves the checkpoint when the save interval has passed
+func (c *Checkpoint) maybeSave() {
+	c.mu.Lock()
+	due := time.Since(c.lastSave) >= c.interval
+	c.mu.Unlock()
+	if due {
+		if err := c.Save(); err != nil {
+			logger.Warnf("could not save checkpoint: %v", err)
+		}
+	}
+}
+
+// Save writes the checkpoint to its state file. The file is replaced
+// atomically, so an interruption while saving keeps the previous state.
+func (c *Checkpoint) Save() error {
+	c.mu.Lock()
+	c.state.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(c.state)
+	c.lastSave = time.Now()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// Remove deletes the state file once the scan has completed
+func (c *Checkpoint) Remove() error {
+	err := os.Remove(c.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+// Package config loads default settings for Subscan's flags from a YAML file.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the config file read from the home directory when --config is not given
+const DefaultFile = ".subscan.yaml"
+
+// Config holds the settings of a config file. Every setting except api-keys
+// is named after the flag it sets a default for, e.g. score-concurrency.
+type Config struct {
+	// APIKeys maps passive source names to their API keys
+	APIKeys map[string]string
+	// Values maps flag names to their configured values
+	Values map[string]interface{}
+}
+
+// DefaultPath returns the path of the config file in the home directory
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, DefaultFile)
+}
+
+// Load reads a config file. JSON files are accepted as well, being valid YAML.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+
+	config := &Config{APIKeys: make(map[string]string), Values: make(map[string]interface{})}
+	for key, value := range raw {
+		if key != "api-keys" {
+			config.Values[key] = value
+			continue
+		}
+		keys, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("error parsing %s: api-keys must map source names to keys", path)
+		}
+		for source, apiKey := range keys {
+			config.APIKeys[source] = fmt.Sprint(apiKey)
+		}
+	}
+	return config, nil
+}
+
+// Keys returns the names of the configured flags in sorted order
+func (c *Config) Keys() []string {
+	keys := make([]string, 0, len(c.Values))
+	for key := range c.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Apply sets the configured values of the flags that were not given on the
+// command line, so flags always override the config file. Settings for flags
+// the flag set doesn't have are skipped, as one file serves every command.
+func (c *Config) Apply(flags *pflag.FlagSet) error {
+	for _, key := range c.Keys() {
+		flag := flags.Lookup(key)
+		if flag == nil || flag.Changed {
+			continue
+		}
+		// Array flags take their items one by one, as they may hold commas
+		if list, ok := c.Values[key].([]interface{}); ok && flag.Value.Type() == "stringArray" {
+			for _, item := range list {
+				if err := flags.Set(key, fmt.Sprint(item)); err != nil {
+					return fmt.Errorf("invalid value for %s: %v", key, err)
+				}
+			}
+			continue
+		}
+		if err := flags.Set(key, flagValue(c.Values[key])); err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// flagValue renders a YAML value as flag text; lists become comma-separated
+func flagValue(value interface{}) string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	items := make([]string, len(list))
+	for i, item := range list {
+		items[i] = fmt.Sprint(item)
+	}
+	return strings.Join(items, ",")
+}
+
+// ResolveToggles turns a setting written as a map of names to booleans, such as
+// sources: {shodan: false}, into the list of enabled names out of all, which
+// start out enabled. List values are left as they are.
+func (c *Config) ResolveToggles(key string, all []string) {
+	toggles, ok := c.Values[key].(map[string]interface{})
+	if !ok {
+		return
+	}
+	var enabled []interface{}
+	for _, name := range all {
+		if on, ok := toggles[name].(bool); !ok || on {
+			enabled = append(enabled, name)
+		}
+	}
+	c.Values[key] = enabled
+}
@@ -0,0 +1,58 @@
+// Package coverage collects the caveats of a scan: passive sources that
+// failed, names no resolver answered, stages cut short. Reports list them so
+// their readers know how complete the results are.
+package coverage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Caveats collects what a scan skipped or left partial. A nil *Caveats
+// records nothing.
+type Caveats struct {
+	mu    sync.Mutex
+	items []string
+}
+
+// caveatsKey carries a *Caveats through a scan's context
+type caveatsKey struct{}
+
+// With returns a context whose stages record their caveats in caveats
+func With(ctx context.Context, caveats *Caveats) context.Context {
+	return context.WithValue(ctx, caveatsKey{}, caveats)
+}
+
+// Note records a caveat in the collector carried by ctx, if any
+func Note(ctx context.Context, format string, args ...interface{}) {
+	if caveats, ok := ctx.Value(caveatsKey{}).(*Caveats); ok {
+		caveats.Add(format, args...)
+	}
+}
+
+// Add records a caveat, once however many times it is added
+func (c *Caveats) Add(format string, args ...interface{}) {
+	if c == nil {
+		return
+	}
+	caveat := fmt.Sprintf(format, args...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, item := range c.items {
+		if item == caveat {
+			return
+		}
+	}
+	c.items = append(c.items, caveat)
+}
+
+// List returns the caveats in the order they were recorded
+func (c *Caveats) List() []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.items...)
+}
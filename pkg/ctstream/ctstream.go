@@ -0,0 +1,133 @@
+package ctstream
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+// DefaultURL is the public certstream websocket endpoint
+const DefaultURL = "wss://certstream.calidog.io/"
+
+// WatchOptions contains configuration for watching a certificate transparency stream
+type WatchOptions struct {
+	// URL of a certstream-compatible websocket
+	URL string
+	// Domains whose subdomains should be reported
+	Domains []string
+	// ReconnectDelay is how long to wait before reconnecting after a dropped stream
+	ReconnectDelay time.Duration
+	Verbose        bool
+}
+
+// DefaultWatchOptions returns a default set of watch options
+func DefaultWatchOptions() WatchOptions {
+	return WatchOptions{
+		URL:            DefaultURL,
+		ReconnectDelay: 5 * time.Second,
+	}
+}
+
+// certstreamMessage represents a certstream certificate update
+type certstreamMessage struct {
+	MessageType string `json:"message_type"`
+	Data        struct {
+		LeafCert struct {
+			AllDomains []string `json:"all_domains"`
+		} `json:"leaf_cert"`
+	} `json:"data"`
+}
+
+// Watch connects to the stream and calls found once for every newly observed
+// subdomain of the watched domains. It reconnects when the stream drops and
+// only returns when stop is closed.
+func Watch(options WatchOptions, stop <-chan struct{}, found func(subdomain string)) {
+	seen := make(map[string]bool)
+	var mu sync.Mutex
+
+	for {
+		err := watchOnce(options, stop, func(name string) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !seen[name] {
+				seen[name] = true
+				found(name)
+			}
+		})
+
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		logger.Infof("Certificate stream disconnected (%v), reconnecting in %s", err, options.ReconnectDelay)
+		select {
+		case <-stop:
+			return
+		case <-time.After(options.ReconnectDelay):
+		}
+	}
+}
+
+// watchOnce reads from a single stream connection until it fails or stop is closed
+func watchOnce(options WatchOptions, stop <-chan struct{}, found func(string)) error {
+	conn, _, err := websocket.DefaultDialer.Dial(options.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if options.Verbose {
+		logger.Infof("Connected to certificate stream %s", options.URL)
+	}
+
+	// Unblock the read loop when asked to stop
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var message certstreamMessage
+		if err := json.Unmarshal(data, &message); err != nil || message.MessageType != "certificate_update" {
+			continue
+		}
+
+		for _, name := range message.Data.LeafCert.AllDomains {
+			if subdomain, ok := matchDomain(name, options.Domains); ok {
+				found(subdomain)
+			}
+		}
+	}
+}
+
+// matchDomain normalizes a certificate name and reports whether it belongs to one
+// of the watched domains. Wildcard names are reported without the wildcard label.
+func matchDomain(name string, domains []string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimPrefix(name, "*.")
+	name = strings.TrimSuffix(name, ".")
+
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,282 @@
+// Package db persists scan runs in a SQLite database, so results can be
+// queried, deduplicated and compared across runs.
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+
+	_ "modernc.org/sqlite"
+)
+
+// schemaVersion is stored in the database's user_version and bumped with
+// every schema change
+const schemaVersion = 1
+
+// schema creates the tables of an empty database. Timestamps are RFC 3339 UTC
+// strings; lists of addresses and tags are comma-separated.
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	target      TEXT NOT NULL,
+	started_at  TEXT NOT NULL,
+	finished_at TEXT
+);
+CREATE TABLE IF NOT EXISTS subdomains (
+	name       TEXT PRIMARY KEY,
+	target     TEXT NOT NULL,
+	first_seen TEXT NOT NULL,
+	last_seen  TEXT NOT NULL,
+	first_run  INTEGER NOT NULL REFERENCES runs(id),
+	last_run   INTEGER NOT NULL REFERENCES runs(id)
+);
+CREATE TABLE IF NOT EXISTS run_subdomains (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	name   TEXT NOT NULL,
+	alive  INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (run_id, name)
+);
+CREATE TABLE IF NOT EXISTS dns_records (
+	run_id   INTEGER NOT NULL REFERENCES runs(id),
+	name     TEXT NOT NULL,
+	a        TEXT,
+	aaaa     TEXT,
+	cname    TEXT,
+	resolver TEXT,
+	PRIMARY KEY (run_id, name)
+);
+CREATE TABLE IF NOT EXISTS scores (
+	run_id         INTEGER NOT NULL REFERENCES runs(id),
+	name           TEXT NOT NULL,
+	status         INTEGER,
+	content_length INTEGER,
+	score          REAL,
+	tags           TEXT,
+	data           TEXT NOT NULL,
+	PRIMARY KEY (run_id, name)
+);
+CREATE TABLE IF NOT EXISTS probe_results (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	name   TEXT NOT NULL,
+	status INTEGER,
+	error  TEXT,
+	data   TEXT NOT NULL,
+	PRIMARY KEY (run_id, name)
+);
+CREATE TABLE IF NOT EXISTS findings (
+	run_id     INTEGER NOT NULL REFERENCES runs(id),
+	finding_id TEXT NOT NULL,
+	host       TEXT NOT NULL,
+	check_name TEXT NOT NULL,
+	evidence   TEXT,
+	PRIMARY KEY (run_id, finding_id)
+);
+CREATE INDEX IF NOT EXISTS runs_target ON runs(target, started_at);
+CREATE INDEX IF NOT EXISTS findings_id ON findings(finding_id);
+`
+
+// DB is a results database. It is safe for concurrent use; writes of
+// parallel domain scans are serialized.
+type DB struct {
+	sql *sql.DB
+}
+
+// Open opens the SQLite database at path, creating it and its tables when needed
+func Open(path string) (*DB, error) {
+	conn, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("error opening database %s: %v", path, err)
+	}
+	// SQLite has a single writer; one connection avoids lock contention
+	conn.SetMaxOpenConns(1)
+
+	var version int
+	if err := conn.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error opening database %s: %v", path, err)
+	}
+	if version > schemaVersion {
+		conn.Close()
+		return nil, fmt.Errorf("database %s was written by a newer version of subscan (schema %d)", path, version)
+	}
+	if _, err := conn.Exec(schema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error creating tables in %s: %v", path, err)
+	}
+	if _, err := conn.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &DB{sql: conn}, nil
+}
+
+// Close closes the database
+func (d *DB) Close() error {
+	return d.sql.Close()
+}
+
+// Run is one scan of a target recorded in the database
+type Run struct {
+	db     *DB
+	ID     int64
+	Target string
+}
+
+// StartRun records the start of a scan of target
+func (d *DB) StartRun(target string) (*Run, error) {
+	result, err := d.sql.Exec("INSERT INTO runs (target, started_at) VALUES (?, ?)", target, timestamp())
+	if err != nil {
+		return nil, fmt.Errorf("error recording run: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &Run{db: d, ID: id, Target: target}, nil
+}
+
+// Finish records that the run completed. Interrupted runs are not finished,
+// so their finished_at stays NULL.
+func (r *Run) Finish() error {
+	_, err := r.db.sql.Exec("UPDATE runs SET finished_at = ? WHERE id = ?", timestamp(), r.ID)
+	return err
+}
+
+// AddCandidates records the subdomains enumerated by the run. Subdomains are
+// deduplicated across runs, keeping when each was first and last seen.
+func (r *Run) AddCandidates(names []string) error {
+	now := timestamp()
+	return r.db.transaction(func(tx *sql.Tx) error {
+		seen, err := tx.Prepare(`INSERT INTO subdomains (name, target, first_seen, last_seen, first_run, last_run)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET last_seen = excluded.last_seen, last_run = excluded.last_run`)
+		if err != nil {
+			return err
+		}
+		defer seen.Close()
+		candidate, err := tx.Prepare("INSERT OR IGNORE INTO run_subdomains (run_id, name) VALUES (?, ?)")
+		if err != nil {
+			return err
+		}
+		defer candidate.Close()
+
+		for _, name := range names {
+			if _, err := seen.Exec(name, r.Target, now, now, r.ID, r.ID); err != nil {
+				return err
+			}
+			if _, err := candidate.Exec(r.ID, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddRecords records the DNS records of the subdomains found alive
+func (r *Run) AddRecords(records []resolver.DNSRecord) error {
+	return r.db.transaction(func(tx *sql.Tx) error {
+		alive, err := tx.Prepare(`INSERT INTO run_subdomains (run_id, name, alive) VALUES (?, ?, 1)
+			ON CONFLICT(run_id, name) DO UPDATE SET alive = 1`)
+		if err != nil {
+			return err
+		}
+		defer alive.Close()
+		insert, err := tx.Prepare("INSERT OR REPLACE INTO dns_records (run_id, name, a, aaaa, cname, resolver) VALUES (?, ?, ?, ?, ?, ?)")
+		if err != nil {
+			return err
+		}
+		defer insert.Close()
+
+		for _, record := range records {
+			if _, err := alive.Exec(r.ID, record.Name); err != nil {
+				return err
+			}
+			_, err := insert.Exec(r.ID, record.Name, strings.Join(record.A, ","), strings.Join(record.AAAA, ","), record.CNAME, record.Resolver)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddScores records the scoring results of the run
+func (r *Run) AddScores(results []scorer.SubdomainInfo) error {
+	return r.db.transaction(func(tx *sql.Tx) error {
+		insert, err := tx.Prepare("INSERT OR REPLACE INTO scores (run_id, name, status, content_length, score, tags, data) VALUES (?, ?, ?, ?, ?, ?, ?)")
+		if err != nil {
+			return err
+		}
+		defer insert.Close()
+
+		for _, info := range results {
+			data, err := json.Marshal(info)
+			if err != nil {
+				return err
+			}
+			_, err = insert.Exec(r.ID, info.Subdomain, info.HTTPStatus, info.ContentLength, info.Score, strings.Join(info.Tags, ","), string(data))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddProbeResults records the probe results of the run and their findings
+func (r *Run) AddProbeResults(results []probe.ProbeResult) error {
+	return r.db.transaction(func(tx *sql.Tx) error {
+		insert, err := tx.Prepare("INSERT OR REPLACE INTO probe_results (run_id, name, status, error, data) VALUES (?, ?, ?, ?, ?)")
+		if err != nil {
+			return err
+		}
+		defer insert.Close()
+		finding, err := tx.Prepare("INSERT OR IGNORE INTO findings (run_id, finding_id, host, check_name, evidence) VALUES (?, ?, ?, ?, ?)")
+		if err != nil {
+			return err
+		}
+		defer finding.Close()
+
+		for _, result := range results {
+			data, err := json.Marshal(result)
+			if err != nil {
+				return err
+			}
+			if _, err := insert.Exec(r.ID, result.Domain, result.HTTPStatus, result.Error, string(data)); err != nil {
+				return err
+			}
+			for _, f := range result.Findings {
+				if _, err := finding.Exec(r.ID, f.ID, f.Host, f.Check, f.Evidence); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// transaction runs fn in a transaction, committing when it succeeds
+func (d *DB) transaction(fn func(tx *sql.Tx) error) error {
+	tx, err := d.sql.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// timestamp returns the current time as stored in the database
+func timestamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,210 @@
+// Package diff compares the results of two scans, reporting the subdomains
+// that appeared or disappeared and the attributes that changed.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// Diff is the difference between a previous and a current scan
+type Diff struct {
+	Added   []model.Host `json:"added"`
+	Removed []model.Host `json:"removed"`
+	Changed []Change     `json:"changed"`
+}
+
+// Change lists what changed on a subdomain present in both scans
+type Change struct {
+	Domain string        `json:"domain"`
+	Fields []FieldChange `json:"fields,omitempty"`
+	// NewVulnerabilities were not reported by the previous scan
+	NewVulnerabilities []string `json:"new_vulnerabilities,omitempty"`
+	// FixedVulnerabilities are no longer reported by the current scan
+	FixedVulnerabilities []string `json:"fixed_vulnerabilities,omitempty"`
+}
+
+// FieldChange is an attribute whose value changed between the scans
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Empty reports whether the scans didn't differ
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare returns the difference between the hosts of a previous and a current scan.
+// Hosts are matched by domain; every list is sorted by domain.
+func Compare(previous []model.Host, current []model.Host) Diff {
+	oldHosts := byDomain(previous)
+	newHosts := byDomain(current)
+
+	d := Diff{Added: []model.Host{}, Removed: []model.Host{}, Changed: []Change{}}
+	for domain, host := range newHosts {
+		before, ok := oldHosts[domain]
+		if !ok {
+			d.Added = append(d.Added, host)
+			continue
+		}
+		if change, changed := compareHost(before, host); changed {
+			d.Changed = append(d.Changed, change)
+		}
+	}
+	for domain, host := range oldHosts {
+		if _, ok := newHosts[domain]; !ok {
+			d.Removed = append(d.Removed, host)
+		}
+	}
+
+	sort.Slice(d.Added, func(i, j int) bool { return d.Added[i].Domain < d.Added[j].Domain })
+	sort.Slice(d.Removed, func(i, j int) bool { return d.Removed[i].Domain < d.Removed[j].Domain })
+	sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Domain < d.Changed[j].Domain })
+	return d
+}
+
+// byDomain indexes hosts by their lowercased domain
+func byDomain(hosts []model.Host) map[string]model.Host {
+	index := make(map[string]model.Host, len(hosts))
+	for _, host := range hosts {
+		index[strings.ToLower(host.Domain)] = host
+	}
+	return index
+}
+
+// compareHost compares the attributes of a host in both scans
+func compareHost(before model.Host, after model.Host) (Change, bool) {
+	change := Change{Domain: after.Domain}
+	field := func(name string, oldValue string, newValue string) {
+		if oldValue != newValue {
+			change.Fields = append(change.Fields, FieldChange{Field: name, Old: oldValue, New: newValue})
+		}
+	}
+
+	field("status", status(before.Status), status(after.Status))
+	field("cname", before.CNAME, after.CNAME)
+	field("ips", joinSorted(before.IPs), joinSorted(after.IPs))
+	// Scores only count when both scans scored the host
+	if before.Score != 0 && after.Score != 0 {
+		field("score", fmt.Sprintf("%.1f", before.Score), fmt.Sprintf("%.1f", after.Score))
+	}
+	field("final_url", before.FinalURL, after.FinalURL)
+	field("cloud_provider", before.CloudProvider, after.CloudProvider)
+	field("takeover", flag(before.IsTakeover), flag(after.IsTakeover))
+
+	change.NewVulnerabilities = missing(after.Vulnerabilities, before.Vulnerabilities)
+	change.FixedVulnerabilities = missing(before.Vulnerabilities, after.Vulnerabilities)
+
+	changed := len(change.Fields) > 0 || len(change.NewVulnerabilities) > 0 || len(change.FixedVulnerabilities) > 0
+	return change, changed
+}
+
+// status formats an HTTP status, 0 meaning no response
+func status(code int) string {
+	if code == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d", code)
+}
+
+// flag formats a boolean attribute
+func flag(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// joinSorted joins values in sorted order, so reordered lists compare equal
+func joinSorted(values []string) string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// missing returns the values of a that are not in b
+func missing(a []string, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, value := range b {
+		in[value] = true
+	}
+	var rest []string
+	for _, value := range a {
+		if !in[value] {
+			rest = append(rest, value)
+		}
+	}
+	return rest
+}
+
+// ANSI colors of the terminal output
+const (
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+// FormatDiff formats a diff for the terminal: added subdomains in green,
+// removed ones in red and changes in yellow, when color is set
+func FormatDiff(d Diff, color bool) string {
+	paint := func(code string, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + colorReset
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Scan diff: %d added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed)))
+	if d.Empty() {
+		output.WriteString("No differences found.\n")
+		return output.String()
+	}
+
+	if len(d.Added) > 0 {
+		output.WriteString("\nAdded:\n")
+		for _, host := range d.Added {
+			output.WriteString(paint(colorGreen, "+ "+host.Domain) + describeHost(host) + "\n")
+		}
+	}
+	if len(d.Removed) > 0 {
+		output.WriteString("\nRemoved:\n")
+		for _, host := range d.Removed {
+			output.WriteString(paint(colorRed, "- "+host.Domain) + "\n")
+		}
+	}
+	if len(d.Changed) > 0 {
+		output.WriteString("\nChanged:\n")
+		for _, change := range d.Changed {
+			output.WriteString(paint(colorYellow, "~ "+change.Domain) + "\n")
+			for _, f := range change.Fields {
+				output.WriteString(fmt.Sprintf("    %s: %s -> %s\n", f.Field, f.Old, f.New))
+			}
+			for _, vuln := range change.NewVulnerabilities {
+				output.WriteString("    " + paint(colorRed, "new: "+vuln) + "\n")
+			}
+			for _, vuln := range change.FixedVulnerabilities {
+				output.WriteString("    " + paint(colorGreen, "fixed: "+vuln) + "\n")
+			}
+		}
+	}
+	return output.String()
+}
+
+// describeHost summarizes a new host's status and vulnerabilities
+func describeHost(host model.Host) string {
+	description := fmt.Sprintf(" [%s]", status(host.Status))
+	if host.Score != 0 {
+		description += fmt.Sprintf(" (Score: %.1f)", host.Score)
+	}
+	if len(host.Vulnerabilities) > 0 {
+		description += " " + strings.Join(host.Vulnerabilities, ", ")
+	}
+	return description
+}
@@ -5,16 +5,108 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+// Wordlist placement modes
+const (
+	// WordlistPrefix uses each word as a new leading label: word.example.com
+	WordlistPrefix = "prefix"
+	// WordlistSuffix appends each word to existing labels: api-word.example.com, example-word.example.com
+	WordlistSuffix = "suffix"
+	// WordlistInfix joins each word in front of existing labels: word-api.example.com
+	WordlistInfix = "infix"
 )
 
+// DefaultCombinationLimit caps the candidates of a suffix or infix wordlist,
+// which combines every word with every known subdomain
+const DefaultCombinationLimit = 500000
+
+// Wordlist is a brute-force wordlist along with how its words are placed
+type Wordlist struct {
+	Path string
+	Mode string
+	// Limit caps the candidates of suffix and infix modes; 0 means unlimited
+	Limit int
+}
+
+// ParseWordlist parses a wordlist flag value of the form path[:mode]
+func ParseWordlist(spec string) (Wordlist, error) {
+	wordlist := Wordlist{Path: spec, Mode: WordlistPrefix, Limit: DefaultCombinationLimit}
+	if i := strings.LastIndex(spec, ":"); i > 0 {
+		switch mode := spec[i+1:]; mode {
+		case WordlistPrefix, WordlistSuffix, WordlistInfix:
+			wordlist.Path, wordlist.Mode = spec[:i], mode
+		}
+	}
+	if _, err := os.Stat(wordlist.Path); err != nil {
+		return Wordlist{}, fmt.Errorf("wordlist %q not found (modes: %s, %s, %s)", wordlist.Path, WordlistPrefix, WordlistSuffix, WordlistInfix)
+	}
+	return wordlist, nil
+}
+
 // BruteForce attempts to generate subdomains by appending each word in the wordlist to the domain
 func BruteForce(domain string, wordlistPath string) []string {
+	return BruteForceWordlist(domain, Wordlist{Path: wordlistPath, Mode: WordlistPrefix}, nil)
+}
+
+// BruteForceWordlist generates candidates from a wordlist according to its mode.
+// Suffix and infix modes combine words with the first label of the apex and of
+// each known subdomain, the apex first, up to the wordlist's Limit.
+func BruteForceWordlist(domain string, wordlist Wordlist, known []string) []string {
 	var subdomains []string
 
+	words := readWords(wordlist.Path)
+	if wordlist.Mode == WordlistPrefix || wordlist.Mode == "" {
+		for _, word := range words {
+			subdomains = append(subdomains, fmt.Sprintf("%s.%s", word, domain))
+		}
+		return subdomains
+	}
+
+	// Split the apex and each known subdomain into first label and remainder
+	type base struct{ label, rest string }
+	bases := []base{{label: strings.SplitN(domain, ".", 2)[0], rest: domain}}
+	seen := make(map[string]bool)
+	for _, subdomain := range known {
+		subdomain = strings.ToLower(strings.TrimSpace(subdomain))
+		if !strings.HasSuffix(subdomain, "."+domain) {
+			continue
+		}
+		parts := strings.SplitN(subdomain, ".", 2)
+		if seen[subdomain] || parts[0] == "*" {
+			continue
+		}
+		seen[subdomain] = true
+		bases = append(bases, base{label: parts[0], rest: parts[1]})
+	}
+
+	for _, b := range bases {
+		for _, word := range words {
+			if wordlist.Limit > 0 && len(subdomains) >= wordlist.Limit {
+				logger.Warnf("Wordlist %s stopped at its limit of %d %s candidates; words weren't combined with every known subdomain", wordlist.Path, wordlist.Limit, wordlist.Mode)
+				return subdomains
+			}
+			if wordlist.Mode == WordlistSuffix {
+				subdomains = append(subdomains, fmt.Sprintf("%s-%s.%s", b.label, word, b.rest))
+			} else {
+				subdomains = append(subdomains, fmt.Sprintf("%s-%s.%s", word, b.label, b.rest))
+			}
+		}
+	}
+
+	return subdomains
+}
+
+// readWords reads the words of a wordlist, skipping blank lines and comments
+func readWords(wordlistPath string) []string {
+	var words []string
+
 	file, err := os.Open(wordlistPath)
 	if err != nil {
-		fmt.Printf("Error opening wordlist file: %v\n", err)
-		return subdomains
+		logger.Errorf("could not open wordlist file: %v", err)
+		return words
 	}
 	defer file.Close()
 
@@ -24,14 +116,12 @@ func BruteForce(domain string, wordlistPath string) []string {
 		if word == "" || strings.HasPrefix(word, "#") {
 			continue // Skip empty lines and comments
 		}
-
-		subdomain := fmt.Sprintf("%s.%s", word, domain)
-		subdomains = append(subdomains, subdomain)
+		words = append(words, word)
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading wordlist file: %v\n", err)
+		logger.Errorf("could not read wordlist file: %v", err)
 	}
 
-	return subdomains
-} 
\ No newline at end of file
+	return words
+}
@@ -0,0 +1,63 @@
+package enumeration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&alienVaultSource{})
+}
+
+// AlienVaultResult represents a result from the AlienVault OTX API
+type AlienVaultResult struct {
+	PassiveDNS []struct {
+		Hostname string `json:"hostname"`
+	} `json:"passive_dns"`
+}
+
+// alienVaultSource retrieves subdomains from AlienVault OTX passive DNS
+type alienVaultSource struct{}
+
+// Name returns the source identifier
+func (s *alienVaultSource) Name() string {
+	return "otx"
+}
+
+// Fetch retrieves subdomains from AlienVault OTX
+func (s *alienVaultSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	var results []string
+
+	client := newClient(30 * time.Second)
+
+	url := fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/domain/%s/passive_dns", domain)
+
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		return http.NewRequest("GET", url, nil)
+	})
+	if err != nil {
+		return results, fmt.Errorf("error accessing AlienVault OTX: %v", err)
+	}
+
+	var alienVaultResult AlienVaultResult
+	err = json.Unmarshal(body, &alienVaultResult)
+	if err != nil {
+		return results, fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	seenSubdomains := make(map[string]bool)
+
+	for _, pdns := range alienVaultResult.PassiveDNS {
+		hostname := strings.TrimSpace(pdns.Hostname)
+		if hostname != "" && strings.HasSuffix(hostname, domain) && !seenSubdomains[hostname] {
+			seenSubdomains[hostname] = true
+			results = append(results, hostname)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,82 @@
+package enumeration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&ChaosSource{APIKey: os.Getenv("CHAOS_KEY")})
+}
+
+const chaosAPI = "https://dns.projectdiscovery.io/dns"
+
+// ChaosSource retrieves subdomains from ProjectDiscovery's Chaos dataset
+type ChaosSource struct {
+	APIKey string
+}
+
+// chaosSubdomains represents a response from the subdomains endpoint
+type chaosSubdomains struct {
+	Domain     string   `json:"domain"`
+	Subdomains []string `json:"subdomains"`
+}
+
+// Name returns the source identifier
+func (s *ChaosSource) Name() string {
+	return "chaos"
+}
+
+// SetAPIKey sets the API key used to authenticate against Chaos
+func (s *ChaosSource) SetAPIKey(key string) {
+	s.APIKey = key
+}
+
+// Fetch retrieves subdomains from Chaos
+func (s *ChaosSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if s.APIKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	// The dataset of a large domain runs to megabytes
+	client := newClient(60 * time.Second)
+
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s/subdomains", chaosAPI, domain), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", s.APIKey)
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error accessing Chaos: %v", err)
+	}
+
+	var response chaosSubdomains
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	seenSubdomains := make(map[string]bool)
+	var results []string
+	// Subdomains are labels relative to the domain
+	for _, label := range response.Subdomains {
+		label = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(label)), "*.")
+		if label == "" || label == "*" {
+			continue
+		}
+		subdomain := fmt.Sprintf("%s.%s", label, domain)
+		if !seenSubdomains[subdomain] {
+			seenSubdomains[subdomain] = true
+			results = append(results, subdomain)
+		}
+	}
+
+	return results, nil
+}
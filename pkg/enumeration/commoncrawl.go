@@ -0,0 +1,107 @@
+package enumeration
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&commonCrawlSource{rateLimiter: rateLimiter{perMinute: 20}})
+}
+
+const (
+	commonCrawlIndexes = "https://index.commoncrawl.org/collinfo.json"
+	// commonCrawlCrawls is how many of the newest crawls are searched; each
+	// is a separate index and a separate request
+	commonCrawlCrawls = 3
+)
+
+// commonCrawlIndex is a crawl listed in collinfo.json
+type commonCrawlIndex struct {
+	ID     string `json:"id"`
+	CDXAPI string `json:"cdx-api"`
+}
+
+// commonCrawlSource retrieves the URLs Common Crawl crawled under the domain
+type commonCrawlSource struct {
+	urlStore
+	rateLimiter
+}
+
+// Name returns the source identifier
+func (s *commonCrawlSource) Name() string {
+	return "commoncrawl"
+}
+
+// Fetch retrieves subdomains from the hostnames of the URLs in the newest
+// Common Crawl indexes, keeping the URLs themselves to be saved
+func (s *commonCrawlSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	client := newClient(120 * time.Second)
+
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		return http.NewRequest("GET", commonCrawlIndexes, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error accessing Common Crawl: %v", err)
+	}
+	var indexes []commonCrawlIndex
+	if err := json.Unmarshal(body, &indexes); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	if len(indexes) > commonCrawlCrawls {
+		indexes = indexes[:commonCrawlCrawls]
+	}
+
+	query := url.Values{}
+	query.Set("url", "*."+domain)
+	query.Set("output", "json")
+	query.Set("fl", "url")
+
+	seen := make(map[string]bool)
+	var urls []string
+	for _, index := range indexes {
+		if err := s.wait(ctx); err != nil {
+			break
+		}
+		indexURL := index.CDXAPI + "?" + query.Encode()
+		body, err := doRequest(ctx, client, func() (*http.Request, error) {
+			return http.NewRequest("GET", indexURL, nil)
+		})
+		if err != nil {
+			// An index without captures of the domain answers 404
+			if bytes.Contains(body, []byte("No Captures found")) {
+				continue
+			}
+			s.set(domain, urls)
+			return urlSubdomains(urls, domain), fmt.Errorf("error accessing Common Crawl index %s: %v", index.ID, err)
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var capture struct {
+				URL string `json:"url"`
+			}
+			if json.Unmarshal(scanner.Bytes(), &capture) != nil {
+				continue
+			}
+			if u := strings.TrimSpace(capture.URL); u != "" && !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+	s.set(domain, urls)
+	return urlSubdomains(urls, domain), nil
+}
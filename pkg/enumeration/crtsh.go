@@ -0,0 +1,71 @@
+package enumeration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&crtShSource{})
+}
+
+// CrtShResult represents a result from crt.sh
+type CrtShResult struct {
+	NameValue string `json:"name_value"`
+}
+
+// crtShSource retrieves subdomains from certificate transparency logs via crt.sh
+type crtShSource struct {
+	// postgres queries crt.sh's database instead of the HTTP JSON endpoint
+	postgres bool
+}
+
+// Name returns the source identifier
+func (s *crtShSource) Name() string {
+	return "crtsh"
+}
+
+// Fetch retrieves subdomains from crt.sh
+func (s *crtShSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if s.postgres {
+		return s.fetchPostgres(ctx, domain)
+	}
+
+	var results []string
+
+	client := newClient(30 * time.Second)
+
+	url := fmt.Sprintf("https://crt.sh/?q=%%25.%s&output=json", domain)
+
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		return http.NewRequest("GET", url, nil)
+	})
+	if err != nil {
+		return results, fmt.Errorf("error accessing crt.sh: %v", err)
+	}
+
+	var crtShResults []CrtShResult
+	err = json.Unmarshal(body, &crtShResults)
+	if err != nil {
+		return results, fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	seenSubdomains := make(map[string]bool)
+
+	for _, result := range crtShResults {
+		// Some entries contain multiple subdomains separated by newlines
+		for _, subdomain := range strings.Split(result.NameValue, "\n") {
+			subdomain = strings.TrimSpace(subdomain)
+			if subdomain != "" && !seenSubdomains[subdomain] {
+				seenSubdomains[subdomain] = true
+				results = append(results, subdomain)
+			}
+		}
+	}
+
+	return results, nil
+}
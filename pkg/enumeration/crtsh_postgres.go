@@ -0,0 +1,75 @@
+package enumeration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq" // PostgreSQL driver for crt.sh's public database
+)
+
+// CrtShPostgresDSN connects to crt.sh's public, read-only certificate database
+const CrtShPostgresDSN = "host=crt.sh port=5432 user=guest dbname=certwatch sslmode=disable connect_timeout=30"
+
+// crtShPostgresTimeout bounds the query, which can take a while for large scopes
+const crtShPostgresTimeout = 5 * time.Minute
+
+// validDomain guards the domain that gets inlined into the crt.sh query
+var validDomain = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?$`)
+
+// UseCrtShPostgres switches the crtsh source between the HTTP JSON endpoint and
+// crt.sh's PostgreSQL interface, which is far more reliable for large domains
+func UseCrtShPostgres(enabled bool) {
+	if source, ok := GetSource("crtsh"); ok {
+		if crtSh, ok := source.(*crtShSource); ok {
+			crtSh.postgres = enabled
+		}
+	}
+}
+
+// fetchPostgres retrieves subdomains by querying crt.sh's database directly
+func (s *crtShSource) fetchPostgres(ctx context.Context, domain string) ([]string, error) {
+	var results []string
+
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if !validDomain.MatchString(domain) {
+		return results, fmt.Errorf("invalid domain %q", domain)
+	}
+
+	db, err := sql.Open("postgres", CrtShPostgresDSN)
+	if err != nil {
+		return results, fmt.Errorf("error connecting to crt.sh database: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, crtShPostgresTimeout)
+	defer cancel()
+
+	// crt.sh sits behind a connection pooler that doesn't support prepared
+	// statements, so the validated domain is inlined and sent as a simple query
+	query := fmt.Sprintf(`SELECT DISTINCT lower(cai.NAME_VALUE)
+		FROM certificate_and_identities cai
+		WHERE plainto_tsquery('certwatch', '%s') @@ identities(cai.CERTIFICATE)
+		AND lower(cai.NAME_VALUE) LIKE '%%.%s'`, domain, domain)
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return results, fmt.Errorf("error querying crt.sh database: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var subdomain string
+		if err := rows.Scan(&subdomain); err != nil {
+			return results, fmt.Errorf("error reading crt.sh rows: %v", err)
+		}
+		if subdomain = strings.TrimSpace(subdomain); subdomain != "" {
+			results = append(results, subdomain)
+		}
+	}
+
+	return results, rows.Err()
+}
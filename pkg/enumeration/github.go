@@ -0,0 +1,107 @@
+package enumeration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+func init() {
+	Register(&GitHubSource{APIKey: os.Getenv("GITHUB_TOKEN")})
+}
+
+const (
+	githubAPI = "https://api.github.com"
+	// githubMaxPages bounds pagination; code search stops at 1000 results
+	githubMaxPages = 10
+	// githubPageDelay spaces the pages out under code search's limit of 10
+	// requests a minute
+	githubPageDelay = 6 * time.Second
+)
+
+// GitHubSource retrieves subdomains mentioned in public code on GitHub.
+// Config files, CI pipelines and docs often name internal hosts.
+type GitHubSource struct {
+	APIKey string
+}
+
+// githubSearch represents a page of the code search API with text matches
+type githubSearch struct {
+	TotalCount int `json:"total_count"`
+	Items      []struct {
+		TextMatches []struct {
+			Fragment string `json:"fragment"`
+		} `json:"text_matches"`
+	} `json:"items"`
+}
+
+// Name returns the source identifier
+func (s *GitHubSource) Name() string {
+	return "github"
+}
+
+// SetAPIKey sets the personal access token used to authenticate against GitHub
+func (s *GitHubSource) SetAPIKey(key string) {
+	s.APIKey = key
+}
+
+// Fetch searches GitHub code for the domain and extracts the subdomains
+// named in the matching fragments
+func (s *GitHubSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if s.APIKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	client := newClient(30 * time.Second)
+	pattern := subdomainPattern(domain)
+
+	seenSubdomains := make(map[string]bool)
+	var results []string
+	for page := 1; page <= githubMaxPages; page++ {
+		if page > 1 {
+			if err := sleep(ctx, githubPageDelay); err != nil {
+				return results, err
+			}
+		}
+
+		query := url.Values{}
+		query.Set("q", fmt.Sprintf("%q", domain))
+		query.Set("per_page", "100")
+		query.Set("page", fmt.Sprint(page))
+		pageURL := githubAPI + "/search/code?" + query.Encode()
+
+		body, err := doRequest(ctx, client, func() (*http.Request, error) {
+			req, err := http.NewRequest("GET", pageURL, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Authorization", "token "+s.APIKey)
+			req.Header.Set("Accept", "application/vnd.github.v3.text-match+json")
+			return req, nil
+		})
+		if err != nil {
+			return results, fmt.Errorf("error accessing GitHub: %v", err)
+		}
+
+		var response githubSearch
+		if err := json.Unmarshal(body, &response); err != nil {
+			return results, fmt.Errorf("error parsing JSON: %v", err)
+		}
+
+		for _, item := range response.Items {
+			for _, match := range item.TextMatches {
+				results = append(results, scrapeSubdomains(pattern, match.Fragment, seenSubdomains)...)
+			}
+		}
+
+		if len(response.Items) < 100 || page*100 >= response.TotalCount {
+			break
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,61 @@
+package enumeration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&hackerTargetSource{rateLimiter: rateLimiter{perMinute: 5}})
+}
+
+// hackerTargetSource retrieves subdomains from the HackerTarget host search,
+// whose free tier allows a few dozen requests a day
+type hackerTargetSource struct {
+	rateLimiter
+}
+
+// Name returns the source identifier
+func (s *hackerTargetSource) Name() string {
+	return "hackertarget"
+}
+
+// Fetch retrieves subdomains from HackerTarget's hostsearch, which answers
+// with host,address lines
+func (s *hackerTargetSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	client := newClient(30 * time.Second)
+
+	searchURL := "https://api.hackertarget.com/hostsearch/?q=" + url.QueryEscape(domain)
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		return http.NewRequest("GET", searchURL, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error accessing HackerTarget: %v", err)
+	}
+
+	// Errors such as an exceeded quota come back as 200 OK with a message
+	text := strings.TrimSpace(string(body))
+	if text != "" && !strings.Contains(text, ",") {
+		return nil, fmt.Errorf("HackerTarget: %s", text)
+	}
+
+	seenSubdomains := make(map[string]bool)
+	var results []string
+	for _, line := range strings.Split(text, "\n") {
+		host := strings.ToLower(strings.TrimSpace(strings.SplitN(line, ",", 2)[0]))
+		if host != "" && !seenSubdomains[host] {
+			seenSubdomains[host] = true
+			results = append(results, host)
+		}
+	}
+
+	return results, nil
+}
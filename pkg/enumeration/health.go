@@ -0,0 +1,93 @@
+package enumeration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// SourceStatus summarizes the outcome of a source health check
+type SourceStatus string
+
+const (
+	// StatusOK means the sample query succeeded
+	StatusOK SourceStatus = "ok"
+	// StatusNoKey means the source needs an API key and none is configured
+	StatusNoKey SourceStatus = "no key"
+	// StatusAuthFailed means the API rejected the configured key
+	StatusAuthFailed SourceStatus = "auth failed"
+	// StatusRateLimited means the API kept answering 429 Too Many Requests
+	StatusRateLimited SourceStatus = "rate limited"
+	// StatusTimeout means the sample query did not finish in time
+	StatusTimeout SourceStatus = "timeout"
+	// StatusUnreachable means the API could not be connected to
+	StatusUnreachable SourceStatus = "unreachable"
+	// StatusFailed means the query failed for another reason, such as a
+	// server error or a response that could not be parsed
+	StatusFailed SourceStatus = "failed"
+)
+
+// SourceHealth is the result of checking a passive source
+type SourceHealth struct {
+	Source  string        `json:"source"`
+	Status  SourceStatus  `json:"status"`
+	Keyed   bool          `json:"keyed"`
+	Results int           `json:"results"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Healthy reports whether the source answered the sample query
+func (h SourceHealth) Healthy() bool {
+	return h.Status == StatusOK
+}
+
+// CheckSource runs a sample query for domain against the source and reports
+// whether it is reachable, accepts its API key and returns results, giving up
+// after timeout
+func CheckSource(ctx context.Context, source Source, domain string, timeout time.Duration) SourceHealth {
+	_, keyed := source.(KeyedSource)
+	health := SourceHealth{Source: source.Name(), Keyed: keyed}
+
+	start := time.Now()
+	subdomains, err := fetchSource(ctx, source, domain, timeout)
+	health.Elapsed = time.Since(start)
+	health.Results = len(subdomains)
+	health.Status = classifySourceError(err)
+	if err != nil {
+		health.Error = err.Error()
+	}
+	return health
+}
+
+// classifySourceError maps a Fetch error to a status. Sources report HTTP
+// failures as "HTTP <code>" and wrap transport errors as text, so the
+// classification goes by the message.
+func classifySourceError(err error) SourceStatus {
+	if err == nil {
+		return StatusOK
+	}
+	if errors.Is(err, ErrMissingAPIKey) {
+		return StatusNoKey
+	}
+	if errors.Is(err, ErrSourceTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return StatusTimeout
+	}
+
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "HTTP 401"), strings.Contains(message, "HTTP 403"):
+		return StatusAuthFailed
+	case strings.Contains(message, "HTTP 429"):
+		return StatusRateLimited
+	case strings.Contains(message, "Client.Timeout"), strings.Contains(message, "i/o timeout"):
+		return StatusTimeout
+	}
+	for _, unreachable := range []string{"no such host", "connection refused", "network is unreachable", "connection reset", "proxyconnect", "tls:", "x509:"} {
+		if strings.Contains(message, unreachable) {
+			return StatusUnreachable
+		}
+	}
+	return StatusFailed
+}
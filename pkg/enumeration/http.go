@@ -0,0 +1,219 @@
+package enumeration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+const (
+	// maxRateLimitRetries is how many times a rate-limited request is retried
+	maxRateLimitRetries = 3
+	// defaultRateLimitWait is used when a 429 response carries no Retry-After header
+	defaultRateLimitWait = 10 * time.Second
+	// maxRateLimitWait caps the Retry-After delay honored for a single retry
+	maxRateLimitWait = 60 * time.Second
+)
+
+// RetryOptions configures how requests failing with a transient error are
+// retried: connection failures, timeouts, 408 and 5xx responses
+type RetryOptions struct {
+	// Retries is how many times a failed request is retried; 0 disables retries
+	Retries int
+	// BaseDelay is the wait before the first retry, doubled for each further one
+	BaseDelay time.Duration
+	// MaxDelay caps the wait before a single retry
+	MaxDelay time.Duration
+}
+
+// DefaultRetryOptions returns the retry policy used when none is set
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		Retries:   3,
+		BaseDelay: 2 * time.Second,
+		MaxDelay:  30 * time.Second,
+	}
+}
+
+// secretParams are the query parameters sources pass API keys in
+var secretParams = []string{"key", "apikey", "api_key", "token", "access_token"}
+
+// proxy routes the requests of every source, set with SetProxy
+var proxy *url.URL
+
+// retry is the retry policy of every source, set with SetRetry
+var retry = DefaultRetryOptions()
+
+// SetRetry sets how the requests of every source are retried after a
+// transient error
+func SetRetry(options RetryOptions) {
+	retry = options
+}
+
+// SetProxy routes the requests of every source through the proxy, or through
+// the environment's proxy when nil (see httpclient.ProxyFunc)
+func SetProxy(p *url.URL) {
+	proxy = p
+}
+
+// newClient returns a client for a source's API, sending its requests through
+// the configured proxy
+func newClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = httpclient.ProxyFunc(proxy)
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// doRequest performs an HTTP request and returns the response body of a
+// 200 OK reply. When the API answers 429 Too Many Requests it waits as long as
+// Retry-After asks and tries again; transient failures are retried with
+// exponential backoff as the retry policy allows.
+func doRequest(ctx context.Context, client *http.Client, newRequest func() (*http.Request, error)) ([]byte, error) {
+	rateLimited, failed := 0, 0
+	for {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+
+		body, resp, err := send(client, req)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests && rateLimited < maxRateLimitRetries {
+			rateLimited++
+			wait := retryAfter(resp)
+			logger.Infof("Rate limited by %s, retrying in %s", req.URL.Host, wait)
+			if err := sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err == nil && resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("HTTP %d", resp.StatusCode)
+			if !transientStatus(resp.StatusCode) {
+				return body, err
+			}
+		}
+		if err == nil {
+			return body, nil
+		}
+
+		if failed >= retry.Retries {
+			return body, err
+		}
+		wait := backoff(failed, retry)
+		failed++
+		logger.Debugf("Request to %s failed (%v), retry %d/%d in %s", req.URL.Host, err, failed, retry.Retries, wait.Round(time.Millisecond))
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs a request and reads its whole response body
+func send(client *http.Client, req *http.Request) ([]byte, *http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, redactURL(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp, fmt.Errorf("error reading response: %v", err)
+	}
+	return body, resp, nil
+}
+
+// redactURL masks the API keys in the URL a transport error names, as its
+// message ends up in logs and in the caveats of shared reports
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted := *urlErr
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		redacted.URL = "(redacted)"
+		return &redacted
+	}
+	query := u.Query()
+	for _, param := range secretParams {
+		if query.Has(param) {
+			query.Set(param, "REDACTED")
+		}
+	}
+	u.RawQuery = query.Encode()
+	redacted.URL = u.String()
+	return &redacted
+}
+
+// transientStatus reports whether a response status is worth retrying
+func transientStatus(status int) bool {
+	return status == http.StatusRequestTimeout || status >= 500
+}
+
+// backoff returns the wait before retry number attempt (from 0): the base
+// delay doubled per attempt, capped, with up to half of it randomized so
+// clients failing together do not retry together
+func backoff(attempt int, options RetryOptions) time.Duration {
+	delay := options.BaseDelay
+	for i := 0; i < attempt && delay < options.MaxDelay; i++ {
+		delay *= 2
+	}
+	if options.MaxDelay > 0 && delay > options.MaxDelay {
+		delay = options.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// sleep waits for d, returning early with ctx's error when it is canceled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryAfter returns how long to wait before retrying a rate-limited request
+func retryAfter(resp *http.Response) time.Duration {
+	wait := defaultRateLimitWait
+
+	if header := resp.Header.Get("Retry-After"); header != "" {
+		if seconds, err := strconv.Atoi(header); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(header); err == nil {
+			wait = time.Until(date)
+		}
+	}
+
+	if wait < time.Second {
+		wait = time.Second
+	}
+	if wait > maxRateLimitWait {
+		wait = maxRateLimitWait
+	}
+	return wait
+}
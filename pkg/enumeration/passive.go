@@ -1,229 +1,231 @@
 package enumeration
 
 import (
-	"crypto/tls"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/omerimzali/subscan/pkg/attribution"
+	"github.com/omerimzali/subscan/pkg/coverage"
+	"github.com/omerimzali/subscan/pkg/logger"
 )
 
-// FetchPassive retrieves subdomains from various passive sources
-func FetchPassive(domain string) []string {
+// DefaultSourceTimeout bounds how long a single passive source may take
+const DefaultSourceTimeout = 5 * time.Minute
+
+// ErrSourceTimeout is returned for a source that did not finish within the
+// source timeout
+var ErrSourceTimeout = errors.New("timed out")
+
+// sourceTimeout bounds every source's Fetch, set with SetSourceTimeout
+var sourceTimeout = DefaultSourceTimeout
+
+// SetSourceTimeout bounds how long each passive source may take before it is
+// abandoned and the others are used alone; 0 lifts the limit
+func SetSourceTimeout(timeout time.Duration) {
+	sourceTimeout = timeout
+}
+
+// FetchPassive retrieves subdomains from the given passive sources.
+// A nil source list queries every registered source. Canceling ctx stops the
+// requests in flight and returns what was retrieved so far. A source running
+// past the source timeout is abandoned so it cannot stall the others.
+func FetchPassive(ctx context.Context, domain string, sources []Source) []string {
 	var allSubdomains []string
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	// Launch goroutines for each source
-	wg.Add(3)
-
-	// crt.sh
-	go func() {
-		defer wg.Done()
-		subdomains := fetchFromCrtSh(domain)
-		mu.Lock()
-		allSubdomains = append(allSubdomains, subdomains...)
-		mu.Unlock()
-		fmt.Printf("Retrieved %d subdomains from crt.sh\n", len(subdomains))
-	}()
-
-	// AlienVault OTX
-	go func() {
-		defer wg.Done()
-		subdomains := fetchFromAlienVault(domain)
-		mu.Lock()
-		allSubdomains = append(allSubdomains, subdomains...)
-		mu.Unlock()
-		fmt.Printf("Retrieved %d subdomains from AlienVault OTX\n", len(subdomains))
-	}()
+	if sources == nil {
+		sources = Sources()
+	}
 
-	// ThreatCrowd
-	go func() {
-		defer wg.Done()
-		subdomains := fetchFromThreatCrowd(domain)
-		mu.Lock()
-		allSubdomains = append(allSubdomains, subdomains...)
-		mu.Unlock()
-		fmt.Printf("Retrieved %d subdomains from ThreatCrowd\n", len(subdomains))
-	}()
+	// Launch a goroutine for each source
+	for _, source := range sources {
+		wg.Add(1)
+		go func(source Source) {
+			defer wg.Done()
+			subdomains, err := fetchSource(ctx, source, domain, sourceTimeout)
+			if errors.Is(err, ErrMissingAPIKey) {
+				logger.Infof("Skipping %s: %v", source.Name(), err)
+				coverage.Note(ctx, "passive source %s skipped: %v", source.Name(), err)
+				return
+			}
+			if errors.Is(err, ErrSourceTimeout) {
+				logger.Warnf("%s %v, continuing without the rest of its results", source.Name(), err)
+				coverage.Note(ctx, "passive source %s %v for %s", source.Name(), err, domain)
+			} else if err != nil && ctx.Err() == nil {
+				logger.Warnf("%s failed: %v", source.Name(), err)
+				coverage.Note(ctx, "passive source %s failed for %s: %v", source.Name(), domain, err)
+			}
+			attribution.Record(ctx, source.Name(), subdomains...)
+			mu.Lock()
+			allSubdomains = append(allSubdomains, subdomains...)
+			mu.Unlock()
+			logger.Infof("Retrieved %d subdomains from %s", len(subdomains), source.Name())
+		}(source)
+	}
 
 	// Wait for all fetching to complete
 	wg.Wait()
+	if ctx.Err() != nil {
+		coverage.Note(ctx, "passive enumeration of %s interrupted", domain)
+	}
 
 	return allSubdomains
 }
 
-// CrtShResult represents a result from crt.sh
-type CrtShResult struct {
-	NameValue string `json:"name_value"`
-}
-
-// fetchFromCrtSh retrieves subdomains from crt.sh
-func fetchFromCrtSh(domain string) []string {
-	var results []string
-	
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// fetchSource runs a source's Fetch under the timeout, returning
+// ErrSourceTimeout with whatever it retrieved when the timeout passes. A source
+// that ignores its context is left running in the background rather than
+// waited for.
+func fetchSource(ctx context.Context, source Source, domain string, timeout time.Duration) ([]string, error) {
+	if timeout <= 0 {
+		return source.Fetch(ctx, domain)
 	}
-	
-	url := fmt.Sprintf("https://crt.sh/?q=%%25.%s&output=json", domain)
-	
-	resp, err := client.Get(url)
-	if err != nil {
-		fmt.Printf("Error accessing crt.sh: %v\n", err)
-		return results
+
+	sourceCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		subdomains []string
+		err        error
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Error from crt.sh: HTTP %d\n", resp.StatusCode)
-		return results
+	done := make(chan result, 1)
+	go func() {
+		subdomains, err := source.Fetch(sourceCtx, domain)
+		done <- result{subdomains, err}
+	}()
+
+	r := result{err: context.DeadlineExceeded}
+	select {
+	case r = <-done:
+	case <-sourceCtx.Done():
+		// Give a source honoring the cancellation a moment to hand back
+		// its partial results
+		select {
+		case r = <-done:
+		case <-time.After(time.Second):
+		}
 	}
-	
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("Error reading response from crt.sh: %v\n", err)
-		return results
+	if r.err != nil && ctx.Err() == nil && errors.Is(sourceCtx.Err(), context.DeadlineExceeded) {
+		return r.subdomains, fmt.Errorf("%w after %s", ErrSourceTimeout, timeout)
 	}
-	
-	var crtShResults []CrtShResult
-	err = json.Unmarshal(body, &crtShResults)
-	if err != nil {
-		fmt.Printf("Error parsing JSON from crt.sh: %v\n", err)
-		return results
+	return r.subdomains, r.err
+}
+
+// RecursionOptions bounds recursive passive enumeration
+type RecursionOptions struct {
+	// Depth is how many levels below the domain are enumerated again
+	Depth int
+	// MaxTargets caps the names enumerated again per level; 0 lifts the cap
+	MaxTargets int
+	// Concurrency is how many names are enumerated at once
+	Concurrency int
+}
+
+// DefaultRecursionOptions returns the recursion bounds used when none are set
+func DefaultRecursionOptions() RecursionOptions {
+	return RecursionOptions{
+		Depth:       2,
+		MaxTargets:  25,
+		Concurrency: 3,
 	}
-	
-	seenSubdomains := make(map[string]bool)
-	
-	for _, result := range crtShResults {
-		// Some entries contain multiple subdomains separated by newlines
-		for _, subdomain := range strings.Split(result.NameValue, "\n") {
-			subdomain = strings.TrimSpace(subdomain)
-			if subdomain != "" && !seenSubdomains[subdomain] {
-				seenSubdomains[subdomain] = true
-				results = append(results, subdomain)
+}
+
+// FetchPassiveRecursive runs passive enumeration on the domain and then again on
+// the subdomains it discovers, up to options.Depth levels below the domain, so
+// nested names like a.b.dev.example.com surface. Each name is enumerated at
+// most once and results outside the domain are dropped. Per level, names that
+// already have names found below them go first and at most options.MaxTargets
+// are enumerated, options.Concurrency at a time.
+func FetchPassiveRecursive(ctx context.Context, domain string, sources []Source, options RecursionOptions) []string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	found := make(map[string]bool)
+	var allSubdomains []string
+
+	level := []string{domain}
+	for current := 0; current <= options.Depth && len(level) > 0 && ctx.Err() == nil; current++ {
+		if current > 0 {
+			level = recursionTargets(ctx, level, allSubdomains, current, options.MaxTargets)
+		}
+
+		results := make([][]string, len(level))
+		concurrency := options.Concurrency
+		if concurrency <= 0 {
+			concurrency = 1
+		}
+		semaphore := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i, target := range level {
+			wg.Add(1)
+			go func(i int, target string) {
+				defer wg.Done()
+				select {
+				case semaphore <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				defer func() { <-semaphore }()
+				if current > 0 {
+					logger.Infof("Recursing into %s (depth %d)", target, current)
+				}
+				results[i] = FetchPassive(ctx, target, sources)
+			}(i, target)
+		}
+		wg.Wait()
+
+		// Merge in target order so the output does not depend on timing
+		var next []string
+		for _, subdomains := range results {
+			for _, subdomain := range subdomains {
+				subdomain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(subdomain)), "*.")
+				if subdomain == "" || found[subdomain] || !strings.HasSuffix(subdomain, "."+domain) {
+					continue
+				}
+				found[subdomain] = true
+				allSubdomains = append(allSubdomains, subdomain)
+				next = append(next, subdomain)
 			}
 		}
+		level = next
 	}
-	
-	return results
-}
 
-// AlienVaultResult represents a result from the AlienVault OTX API
-type AlienVaultResult struct {
-	PassiveDNS []struct {
-		Hostname string `json:"hostname"`
-	} `json:"passive_dns"`
+	return allSubdomains
 }
 
-// fetchFromAlienVault retrieves subdomains from AlienVault OTX
-func fetchFromAlienVault(domain string) []string {
-	var results []string
-	
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// recursionTargets picks the names of a level to enumerate again: those with
+// names already found below them first, since they are the likeliest to hold
+// more, then the rest in discovery order, up to maxTargets
+func recursionTargets(ctx context.Context, candidates, known []string, depth, maxTargets int) []string {
+	if maxTargets <= 0 || len(candidates) <= maxTargets {
+		return candidates
 	}
-	
-	url := fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/domain/%s/passive_dns", domain)
-	
-	resp, err := client.Get(url)
-	if err != nil {
-		fmt.Printf("Error accessing AlienVault OTX: %v\n", err)
-		return results
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Error from AlienVault OTX: HTTP %d\n", resp.StatusCode)
-		return results
-	}
-	
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("Error reading response from AlienVault OTX: %v\n", err)
-		return results
-	}
-	
-	var alienVaultResult AlienVaultResult
-	err = json.Unmarshal(body, &alienVaultResult)
-	if err != nil {
-		fmt.Printf("Error parsing JSON from AlienVault OTX: %v\n", err)
-		return results
-	}
-	
-	seenSubdomains := make(map[string]bool)
-	
-	for _, pdns := range alienVaultResult.PassiveDNS {
-		hostname := strings.TrimSpace(pdns.Hostname)
-		if hostname != "" && strings.HasSuffix(hostname, domain) && !seenSubdomains[hostname] {
-			seenSubdomains[hostname] = true
-			results = append(results, hostname)
+
+	var parents, leaves []string
+	for _, candidate := range candidates {
+		if hasChildren(candidate, known) {
+			parents = append(parents, candidate)
+		} else {
+			leaves = append(leaves, candidate)
 		}
 	}
-	
-	return results
-}
+	targets := append(parents, leaves...)[:maxTargets]
 
-// ThreatCrowdResult represents a result from the ThreatCrowd API
-type ThreatCrowdResult struct {
-	Subdomains []string `json:"subdomains"`
+	logger.Warnf("Recursing into %d of %d subdomains at depth %d", maxTargets, len(candidates), depth)
+	coverage.Note(ctx, "recursive enumeration skipped %d of %d subdomains at depth %d", len(candidates)-maxTargets, len(candidates), depth)
+	return targets
 }
 
-// fetchFromThreatCrowd retrieves subdomains from ThreatCrowd
-func fetchFromThreatCrowd(domain string) []string {
-	var results []string
-	
-	// Create a custom transport with TLS configuration that skips verification
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	
-	client := &http.Client{
-		Timeout:   30 * time.Second,
-		Transport: tr,
-	}
-	
-	escapedDomain := url.QueryEscape(domain)
-	url := fmt.Sprintf("https://www.threatcrowd.org/searchApi/v2/domain/report/?domain=%s", escapedDomain)
-	
-	resp, err := client.Get(url)
-	if err != nil {
-		fmt.Printf("Error accessing ThreatCrowd: %v\n", err)
-		return results
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Error from ThreatCrowd: HTTP %d\n", resp.StatusCode)
-		return results
-	}
-	
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("Error reading response from ThreatCrowd: %v\n", err)
-		return results
-	}
-	
-	var threatCrowdResult ThreatCrowdResult
-	err = json.Unmarshal(body, &threatCrowdResult)
-	if err != nil {
-		fmt.Printf("Error parsing JSON from ThreatCrowd: %v\n", err)
-		return results
-	}
-	
-	seenSubdomains := make(map[string]bool)
-	
-	for _, subdomain := range threatCrowdResult.Subdomains {
-		subdomain = strings.TrimSpace(subdomain)
-		if subdomain != "" && !seenSubdomains[subdomain] {
-			seenSubdomains[subdomain] = true
-			results = append(results, subdomain)
+// hasChildren reports whether any known name lies below name
+func hasChildren(name string, known []string) bool {
+	suffix := "." + name
+	for _, other := range known {
+		if strings.HasSuffix(other, suffix) {
+			return true
 		}
 	}
-	
-	return results
-} 
\ No newline at end of file
+	return false
+}
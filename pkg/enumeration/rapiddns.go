@@ -0,0 +1,46 @@
+package enumeration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func init() {
+	Register(&rapidDNSSource{rateLimiter: rateLimiter{perMinute: 10}})
+}
+
+// rapidDNSSource scrapes subdomains from the rapiddns.io subdomain search
+type rapidDNSSource struct {
+	rateLimiter
+}
+
+// Name returns the source identifier
+func (s *rapidDNSSource) Name() string {
+	return "rapiddns"
+}
+
+// Fetch retrieves subdomains from the rapiddns.io results page
+func (s *rapidDNSSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	client := newClient(60 * time.Second)
+
+	pageURL := fmt.Sprintf("https://rapiddns.io/subdomain/%s?full=1", domain)
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		req, err := http.NewRequest("GET", pageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Subscan/1.0)")
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error accessing RapidDNS: %v", err)
+	}
+
+	return scrapeSubdomains(subdomainPattern(domain), string(body), make(map[string]bool)), nil
+}
@@ -0,0 +1,71 @@
+package enumeration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitedSource is a Source that spaces out its requests, so free
+// endpoints that ban heavy users aren't hammered by recursive or
+// multi-domain runs
+type RateLimitedSource interface {
+	Source
+	// SetRateLimit sets the requests allowed per minute; 0 lifts the limit
+	SetRateLimit(perMinute int)
+}
+
+// rateLimiter spaces the requests of a source evenly, allowing perMinute
+// requests a minute. Embedding it makes a source a RateLimitedSource.
+type rateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	next      time.Time
+}
+
+// SetRateLimit sets the requests allowed per minute; 0 lifts the limit
+func (l *rateLimiter) SetRateLimit(perMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.perMinute = perMinute
+}
+
+// wait blocks until the next request is allowed, returning early with ctx's
+// error when it is canceled
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	if l.perMinute <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(time.Minute / time.Duration(l.perMinute))
+	l.mu.Unlock()
+
+	if delay := time.Until(at); delay > 0 {
+		return sleep(ctx, delay)
+	}
+	return nil
+}
+
+// SetRateLimit sets the requests per minute of a registered rate-limited source
+func SetRateLimit(name string, perMinute int) error {
+	source, ok := GetSource(name)
+	if !ok {
+		return fmt.Errorf("unknown passive source '%s'", name)
+	}
+	limited, ok := source.(RateLimitedSource)
+	if !ok {
+		return fmt.Errorf("passive source '%s' is not rate limited", name)
+	}
+	if perMinute < 0 {
+		return fmt.Errorf("invalid rate limit %d for '%s'", perMinute, name)
+	}
+	limited.SetRateLimit(perMinute)
+	return nil
+}
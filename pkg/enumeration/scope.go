@@ -0,0 +1,167 @@
+package enumeration
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// InScope reports whether name is the domain itself or one of its subdomains
+func InScope(name string, domain string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
+
+// Depth returns how many labels name has below domain (a.b.example.com is 2 below example.com)
+func Depth(name string, domain string) int {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if name == domain {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(name, "."+domain), ".") + 1
+}
+
+// ScopeCandidates drops names outside the domain and, when maxDepth is positive,
+// names more than maxDepth labels below it. It returns the kept names and how
+// many were dropped.
+func ScopeCandidates(names []string, domain string, maxDepth int) ([]string, int) {
+	var kept []string
+	for _, name := range names {
+		if !InScope(name, domain) {
+			continue
+		}
+		if maxDepth > 0 && Depth(name, domain) > maxDepth {
+			continue
+		}
+		kept = append(kept, name)
+	}
+	return kept, len(names) - len(kept)
+}
+
+// ScopeFilter drops names matching exclude patterns, and names matching none
+// of the include patterns when there are any, so hosts ruled out of scope are
+// never resolved or probed. A nil ScopeFilter keeps every name.
+type ScopeFilter struct {
+	include  []*regexp.Regexp
+	included map[string]bool
+	exclude  []*regexp.Regexp
+	excluded map[string]bool
+}
+
+// NewScopeFilter compiles include and exclude patterns. A pattern between
+// slashes, such as /^api[0-9]+\./, is a regular expression; anything else is a
+// glob whose * matches any characters, dots included, and ? a single one, so
+// *.marketing.example.com matches every name under marketing.example.com.
+// Patterns without wildcards match that exact name. Matching ignores case.
+func NewScopeFilter(include []string, exclude []string) (*ScopeFilter, error) {
+	filter := &ScopeFilter{included: make(map[string]bool), excluded: make(map[string]bool)}
+	for _, pattern := range include {
+		re, name, err := compileScopePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case name != "":
+			filter.included[name] = true
+		case re != nil:
+			filter.include = append(filter.include, re)
+		}
+	}
+	for _, pattern := range exclude {
+		re, name, err := compileScopePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case name != "":
+			// Exact names, such as the lines of an exclusion list, are
+			// looked up rather than matched one by one
+			filter.excluded[name] = true
+		case re != nil:
+			filter.exclude = append(filter.exclude, re)
+		}
+	}
+	if len(filter.include) == 0 && len(filter.included) == 0 && len(filter.exclude) == 0 && len(filter.excluded) == 0 {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// compileScopePattern compiles a pattern to a regular expression, or returns
+// the name itself for a pattern without wildcards. Blank patterns yield neither.
+func compileScopePattern(pattern string) (*regexp.Regexp, string, error) {
+	pattern = strings.TrimSpace(pattern)
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid scope pattern %s: %v", pattern, err)
+		}
+		return re, "", nil
+	}
+
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	if pattern == "" {
+		return nil, "", nil
+	}
+	if !strings.ContainsAny(pattern, "*?") {
+		return nil, pattern, nil
+	}
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String()), "", nil
+}
+
+// Allows reports whether name is in scope
+func (f *ScopeFilter) Allows(name string) bool {
+	if f == nil {
+		return true
+	}
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if f.excluded[name] {
+		return false
+	}
+	for _, re := range f.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(f.include) == 0 && len(f.included) == 0 {
+		return true
+	}
+	if f.included[name] {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the names in scope and how many were dropped
+func (f *ScopeFilter) Filter(names []string) ([]string, int) {
+	if f == nil {
+		return names, 0
+	}
+	var kept []string
+	for _, name := range names {
+		if f.Allows(name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept, len(names) - len(kept)
+}
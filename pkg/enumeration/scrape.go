@@ -0,0 +1,29 @@
+package enumeration
+
+import (
+	"regexp"
+	"strings"
+)
+
+// scrapeEscapes are the encodings that glue a hostname to what precedes it
+// in scraped text, such as URL-encoded slashes and escaped newlines
+var scrapeEscapes = strings.NewReplacer("%2F", " ", "%2f", " ", "%3A", " ", "%3a", " ", `\n`, " ", `\t`, " ", `\r`, " ")
+
+// subdomainPattern matches the names under domain in free text
+func subdomainPattern(domain string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+` + regexp.QuoteMeta(domain))
+}
+
+// scrapeSubdomains returns the unique names under domain found in text,
+// lowercased, in the order they appear
+func scrapeSubdomains(pattern *regexp.Regexp, text string, seen map[string]bool) []string {
+	var subdomains []string
+	for _, subdomain := range pattern.FindAllString(scrapeEscapes.Replace(text), -1) {
+		subdomain = strings.ToLower(subdomain)
+		if !seen[subdomain] {
+			seen[subdomain] = true
+			subdomains = append(subdomains, subdomain)
+		}
+	}
+	return subdomains
+}
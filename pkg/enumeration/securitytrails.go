@@ -0,0 +1,158 @@
+package enumeration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&SecurityTrailsSource{APIKey: os.Getenv("SECURITYTRAILS_API_KEY")})
+}
+
+const (
+	securityTrailsAPI = "https://api.securitytrails.com/v1"
+	// securityTrailsMaxPages bounds scroll pagination to protect the API quota
+	securityTrailsMaxPages = 100
+)
+
+// SecurityTrailsSource retrieves subdomains from the SecurityTrails API
+type SecurityTrailsSource struct {
+	APIKey string
+}
+
+// securityTrailsSubdomains represents a response from the subdomains endpoint
+type securityTrailsSubdomains struct {
+	Subdomains []string `json:"subdomains"`
+	Meta       struct {
+		LimitReached bool `json:"limit_reached"`
+	} `json:"meta"`
+}
+
+// securityTrailsScroll represents a page of the domain list scroll API
+type securityTrailsScroll struct {
+	Records []struct {
+		Hostname string `json:"hostname"`
+	} `json:"records"`
+	Meta struct {
+		ScrollID   string `json:"scroll_id"`
+		TotalPages int    `json:"total_pages"`
+	} `json:"meta"`
+}
+
+// Name returns the source identifier
+func (s *SecurityTrailsSource) Name() string {
+	return "securitytrails"
+}
+
+// SetAPIKey sets the API key used to authenticate against SecurityTrails
+func (s *SecurityTrailsSource) SetAPIKey(key string) {
+	s.APIKey = key
+}
+
+// Fetch retrieves subdomains from SecurityTrails, switching to the paginated
+// scroll API when the subdomains endpoint reports its result limit was reached
+func (s *SecurityTrailsSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if s.APIKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	client := newClient(30 * time.Second)
+
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		url := fmt.Sprintf("%s/domain/%s/subdomains?children_only=false&include_inactive=true", securityTrailsAPI, domain)
+		return s.newRequest("GET", url, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error accessing SecurityTrails: %v", err)
+	}
+
+	var response securityTrailsSubdomains
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	seenSubdomains := make(map[string]bool)
+	var results []string
+	add := func(subdomain string) {
+		subdomain = strings.ToLower(strings.TrimSpace(subdomain))
+		if subdomain != "" && !seenSubdomains[subdomain] {
+			seenSubdomains[subdomain] = true
+			results = append(results, subdomain)
+		}
+	}
+
+	// The subdomains endpoint returns labels relative to the domain
+	for _, label := range response.Subdomains {
+		add(fmt.Sprintf("%s.%s", label, domain))
+	}
+
+	if response.Meta.LimitReached {
+		hostnames, err := s.scroll(ctx, client, domain)
+		for _, hostname := range hostnames {
+			add(hostname)
+		}
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// scroll pages through the domain list API for every hostname under the apex domain
+func (s *SecurityTrailsSource) scroll(ctx context.Context, client *http.Client, domain string) ([]string, error) {
+	var hostnames []string
+
+	query, _ := json.Marshal(map[string]string{
+		"query": fmt.Sprintf(`apex_domain = "%s"`, domain),
+	})
+
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		return s.newRequest("POST", securityTrailsAPI+"/domains/list?include_ipv4=false&scroll=true", query)
+	})
+
+	for page := 1; ; page++ {
+		if err != nil {
+			return hostnames, fmt.Errorf("error paging SecurityTrails results: %v", err)
+		}
+
+		var response securityTrailsScroll
+		if err := json.Unmarshal(body, &response); err != nil {
+			return hostnames, fmt.Errorf("error parsing JSON: %v", err)
+		}
+
+		for _, record := range response.Records {
+			hostnames = append(hostnames, record.Hostname)
+		}
+
+		if len(response.Records) == 0 || response.Meta.ScrollID == "" ||
+			page >= response.Meta.TotalPages || page >= securityTrailsMaxPages {
+			return hostnames, nil
+		}
+
+		scrollID := response.Meta.ScrollID
+		body, err = doRequest(ctx, client, func() (*http.Request, error) {
+			return s.newRequest("GET", fmt.Sprintf("%s/scroll/%s", securityTrailsAPI, scrollID), nil)
+		})
+	}
+}
+
+// newRequest creates an authenticated SecurityTrails API request
+func (s *SecurityTrailsSource) newRequest(method string, url string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("APIKEY", s.APIKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
@@ -0,0 +1,160 @@
+package enumeration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	Register(&ShodanSource{APIKey: os.Getenv("SHODAN_API_KEY")})
+}
+
+const (
+	shodanAPI = "https://api.shodan.io"
+	// shodanMaxPages bounds pagination, each page costs one query credit
+	shodanMaxPages = 10
+)
+
+// ShodanSource retrieves subdomains and their open ports from the Shodan DNS database
+type ShodanSource struct {
+	APIKey string
+
+	mu sync.Mutex
+	// ports holds the open ports per host, by the domain they were fetched for
+	ports map[string]map[string][]int
+}
+
+// shodanDomain represents a page of the Shodan DNS domain endpoint
+type shodanDomain struct {
+	Subdomains []string `json:"subdomains"`
+	Data       []struct {
+		Subdomain string `json:"subdomain"`
+		Ports     []int  `json:"ports"`
+	} `json:"data"`
+	More bool `json:"more"`
+}
+
+// Name returns the source identifier
+func (s *ShodanSource) Name() string {
+	return "shodan"
+}
+
+// SetAPIKey sets the API key used to authenticate against Shodan
+func (s *ShodanSource) SetAPIKey(key string) {
+	s.APIKey = key
+}
+
+// Ports returns the open ports Shodan reported per subdomain when fetching
+// domain and its subdomains
+func (s *ShodanSource) Ports(domain string) map[string][]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domain = strings.ToLower(domain)
+	ports := make(map[string][]int)
+	for fetched, hosts := range s.ports {
+		if fetched != domain && !strings.HasSuffix(fetched, "."+domain) {
+			continue
+		}
+		for host, list := range hosts {
+			ports[host] = mergePorts(ports[host], list)
+		}
+	}
+	return ports
+}
+
+// Fetch retrieves subdomains from Shodan, recording the open ports seen on each
+func (s *ShodanSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if s.APIKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	client := newClient(30 * time.Second)
+
+	var results []string
+	seenSubdomains := make(map[string]bool)
+	ports := make(map[string][]int)
+
+	for page := 1; page <= shodanMaxPages; page++ {
+		pageURL := fmt.Sprintf("%s/dns/domain/%s?key=%s&page=%d", shodanAPI, domain, s.APIKey, page)
+
+		body, err := doRequest(ctx, client, func() (*http.Request, error) {
+			return http.NewRequest("GET", pageURL, nil)
+		})
+		if err != nil {
+			s.setPorts(domain, ports)
+			return results, fmt.Errorf("error accessing Shodan: %v", err)
+		}
+
+		var response shodanDomain
+		if err := json.Unmarshal(body, &response); err != nil {
+			s.setPorts(domain, ports)
+			return results, fmt.Errorf("error parsing JSON: %v", err)
+		}
+
+		for _, label := range response.Subdomains {
+			subdomain := strings.ToLower(fmt.Sprintf("%s.%s", label, domain))
+			if label != "" && !seenSubdomains[subdomain] {
+				seenSubdomains[subdomain] = true
+				results = append(results, subdomain)
+			}
+		}
+
+		for _, record := range response.Data {
+			host := domain
+			if record.Subdomain != "" {
+				host = strings.ToLower(fmt.Sprintf("%s.%s", record.Subdomain, domain))
+			}
+			ports[host] = mergePorts(ports[host], record.Ports)
+		}
+
+		if !response.More {
+			break
+		}
+	}
+
+	s.setPorts(domain, ports)
+	return results, nil
+}
+
+// setPorts merges the ports fetched for domain into the ones stored for it,
+// so a fetch finishing late cannot drop what another one found
+func (s *ShodanSource) setPorts(domain string, ports map[string][]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ports == nil {
+		s.ports = make(map[string]map[string][]int)
+	}
+	domain = strings.ToLower(domain)
+	stored := s.ports[domain]
+	if stored == nil {
+		stored = make(map[string][]int, len(ports))
+		s.ports[domain] = stored
+	}
+	for host, list := range ports {
+		stored[host] = mergePorts(stored[host], list)
+	}
+}
+
+// mergePorts appends ports not already present in the list
+func mergePorts(existing []int, ports []int) []int {
+	for _, port := range ports {
+		found := false
+		for _, p := range existing {
+			if p == port {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing = append(existing, port)
+		}
+	}
+	return existing
+}
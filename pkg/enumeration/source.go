@@ -0,0 +1,166 @@
+package enumeration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Source is a passive subdomain data source
+type Source interface {
+	// Name returns the short identifier used to select the source, e.g. "crtsh"
+	Name() string
+	// Fetch retrieves subdomains of the given domain from the source, giving
+	// up when ctx is canceled
+	Fetch(ctx context.Context, domain string) ([]string, error)
+}
+
+// KeyedSource is a Source that requires an API key
+type KeyedSource interface {
+	Source
+	SetAPIKey(key string)
+}
+
+// PortSource is a Source that also reports open ports per discovered host,
+// keeping them by the domain they were fetched for
+type PortSource interface {
+	Source
+	Ports(domain string) map[string][]int
+}
+
+// URLSource is a Source whose data are URLs, which it keeps for content
+// discovery after extracting their hostnames
+type URLSource interface {
+	Source
+	// URLs returns the URLs found under domain by the Fetches so far
+	URLs(domain string) []string
+}
+
+// ErrMissingAPIKey is returned by keyed sources queried without an API key
+var ErrMissingAPIKey = errors.New("no API key configured")
+
+var (
+	registryMu    sync.RWMutex
+	registry      = make(map[string]Source)
+	registryOrder []string
+)
+
+// Register adds a source to the registry, replacing any source with the same name
+func Register(source Source) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	name := strings.ToLower(source.Name())
+	if _, exists := registry[name]; !exists {
+		registryOrder = append(registryOrder, name)
+	}
+	registry[name] = source
+}
+
+// GetSource returns the registered source with the given name
+func GetSource(name string) (Source, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	source, ok := registry[strings.ToLower(name)]
+	return source, ok
+}
+
+// Sources returns all registered sources in registration order
+func Sources() []Source {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	sources := make([]Source, 0, len(registryOrder))
+	for _, name := range registryOrder {
+		sources = append(sources, registry[name])
+	}
+	return sources
+}
+
+// SourceNames returns the names of all registered sources in registration order
+func SourceNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return append([]string(nil), registryOrder...)
+}
+
+// SetAPIKey configures the API key of a registered keyed source
+func SetAPIKey(name string, key string) error {
+	source, ok := GetSource(name)
+	if !ok {
+		return fmt.Errorf("unknown passive source '%s'", name)
+	}
+	keyed, ok := source.(KeyedSource)
+	if !ok {
+		return fmt.Errorf("passive source '%s' does not use an API key", name)
+	}
+	keyed.SetAPIKey(key)
+	return nil
+}
+
+// CollectPorts merges the open ports every port-aware source reported under
+// the domain, including the ones fetched for its subdomains
+func CollectPorts(sources []Source, domain string) map[string][]int {
+	ports := make(map[string][]int)
+	for _, source := range sources {
+		portSource, ok := source.(PortSource)
+		if !ok {
+			continue
+		}
+		for host, list := range portSource.Ports(domain) {
+			ports[host] = mergePorts(ports[host], list)
+		}
+	}
+	return ports
+}
+
+// CollectURLs merges the URLs every URL source found under the domain,
+// including the ones fetched for its subdomains, sorted and deduplicated
+func CollectURLs(sources []Source, domain string) []string {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, source := range sources {
+		urlSource, ok := source.(URLSource)
+		if !ok {
+			continue
+		}
+		for _, u := range urlSource.URLs(domain) {
+			if !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// SelectSources resolves a list of source names to registered sources.
+// An empty list selects every registered source.
+func SelectSources(names []string) ([]Source, error) {
+	if len(names) == 0 {
+		return Sources(), nil
+	}
+
+	var sources []Source
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		source, ok := GetSource(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown passive source '%s' (available: %s)", name, strings.Join(SourceNames(), ", "))
+		}
+		sources = append(sources, source)
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no passive source named (available: %s)", strings.Join(SourceNames(), ", "))
+	}
+	return sources, nil
+}
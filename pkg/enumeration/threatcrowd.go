@@ -0,0 +1,75 @@
+package enumeration
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+)
+
+func init() {
+	Register(&threatCrowdSource{})
+}
+
+// ThreatCrowdResult represents a result from the ThreatCrowd API
+type ThreatCrowdResult struct {
+	Subdomains []string `json:"subdomains"`
+}
+
+// threatCrowdSource retrieves subdomains from the ThreatCrowd domain report API
+type threatCrowdSource struct{}
+
+// Name returns the source identifier
+func (s *threatCrowdSource) Name() string {
+	return "threatcrowd"
+}
+
+// Fetch retrieves subdomains from ThreatCrowd
+func (s *threatCrowdSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	var results []string
+
+	// Create a custom transport with TLS configuration that skips verification
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		Proxy:           httpclient.ProxyFunc(proxy),
+	}
+
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: tr,
+	}
+
+	escapedDomain := url.QueryEscape(domain)
+	url := fmt.Sprintf("https://www.threatcrowd.org/searchApi/v2/domain/report/?domain=%s", escapedDomain)
+
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		return http.NewRequest("GET", url, nil)
+	})
+	if err != nil {
+		return results, fmt.Errorf("error accessing ThreatCrowd: %v", err)
+	}
+
+	var threatCrowdResult ThreatCrowdResult
+	err = json.Unmarshal(body, &threatCrowdResult)
+	if err != nil {
+		return results, fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	seenSubdomains := make(map[string]bool)
+
+	for _, subdomain := range threatCrowdResult.Subdomains {
+		subdomain = strings.TrimSpace(subdomain)
+		if subdomain != "" && !seenSubdomains[subdomain] {
+			seenSubdomains[subdomain] = true
+			results = append(results, subdomain)
+		}
+	}
+
+	return results, nil
+}
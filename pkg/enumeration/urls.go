@@ -0,0 +1,65 @@
+package enumeration
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// urlStore keeps the URLs a source fetched, by the domain they were fetched for
+type urlStore struct {
+	mu   sync.Mutex
+	urls map[string][]string
+}
+
+// set stores the URLs fetched for domain, replacing earlier ones
+func (s *urlStore) set(domain string, urls []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.urls == nil {
+		s.urls = make(map[string][]string)
+	}
+	s.urls[strings.ToLower(domain)] = urls
+}
+
+// URLs returns the URLs fetched for domain and its subdomains
+func (s *urlStore) URLs(domain string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	domain = strings.ToLower(domain)
+	var urls []string
+	for fetched, list := range s.urls {
+		if fetched == domain || strings.HasSuffix(fetched, "."+domain) {
+			urls = append(urls, list...)
+		}
+	}
+	return urls
+}
+
+// urlHostname returns the lowercase hostname of an archived URL, which may
+// lack a scheme, or "" when it has none
+func urlHostname(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+}
+
+// urlSubdomains returns the unique hostnames under domain of the URLs
+func urlSubdomains(urls []string, domain string) []string {
+	seen := make(map[string]bool)
+	var subdomains []string
+	for _, u := range urls {
+		host := urlHostname(u)
+		if strings.HasSuffix(host, "."+domain) && !seen[host] {
+			seen[host] = true
+			subdomains = append(subdomains, host)
+		}
+	}
+	return subdomains
+}
@@ -0,0 +1,110 @@
+package enumeration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+func init() {
+	Register(&VirusTotalSource{APIKey: os.Getenv("VIRUSTOTAL_API_KEY")})
+}
+
+const (
+	virusTotalAPI = "https://www.virustotal.com/api/v3"
+	// virusTotalPageDelay keeps paging within the free-tier quota of 4 requests per minute
+	virusTotalPageDelay = 15 * time.Second
+	// virusTotalMaxPages bounds cursor pagination so large domains can't exhaust the daily quota
+	virusTotalMaxPages = 25
+)
+
+// VirusTotalSource retrieves subdomains from the VirusTotal v3 domain relationships API
+type VirusTotalSource struct {
+	APIKey string
+}
+
+// virusTotalSubdomains represents a page of the subdomains relationship
+type virusTotalSubdomains struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+	Meta struct {
+		Cursor string `json:"cursor"`
+	} `json:"meta"`
+}
+
+// Name returns the source identifier
+func (s *VirusTotalSource) Name() string {
+	return "virustotal"
+}
+
+// SetAPIKey sets the API key used to authenticate against VirusTotal
+func (s *VirusTotalSource) SetAPIKey(key string) {
+	s.APIKey = key
+}
+
+// Fetch retrieves subdomains from VirusTotal, following the pagination cursor
+func (s *VirusTotalSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if s.APIKey == "" {
+		return nil, ErrMissingAPIKey
+	}
+
+	client := newClient(30 * time.Second)
+
+	var results []string
+	seenSubdomains := make(map[string]bool)
+	cursor := ""
+
+	for page := 0; page < virusTotalMaxPages; page++ {
+		if page > 0 {
+			if err := sleep(ctx, virusTotalPageDelay); err != nil {
+				return results, err
+			}
+		}
+
+		pageURL := fmt.Sprintf("%s/domains/%s/relationships/subdomains?limit=40", virusTotalAPI, domain)
+		if cursor != "" {
+			pageURL += "&cursor=" + url.QueryEscape(cursor)
+		}
+
+		body, err := doRequest(ctx, client, func() (*http.Request, error) {
+			req, err := http.NewRequest("GET", pageURL, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("x-apikey", s.APIKey)
+			return req, nil
+		})
+		if err != nil {
+			return results, fmt.Errorf("error accessing VirusTotal: %v", err)
+		}
+
+		var response virusTotalSubdomains
+		if err := json.Unmarshal(body, &response); err != nil {
+			return results, fmt.Errorf("error parsing JSON: %v", err)
+		}
+
+		for _, item := range response.Data {
+			subdomain := strings.ToLower(strings.TrimSpace(item.ID))
+			if subdomain != "" && !seenSubdomains[subdomain] {
+				seenSubdomains[subdomain] = true
+				results = append(results, subdomain)
+			}
+		}
+
+		if response.Meta.Cursor == "" || len(response.Data) == 0 {
+			return results, nil
+		}
+		cursor = response.Meta.Cursor
+	}
+
+	logger.Infof("VirusTotal: stopped after %d pages to preserve API quota", virusTotalMaxPages)
+	return results, nil
+}
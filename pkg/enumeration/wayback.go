@@ -0,0 +1,63 @@
+package enumeration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&waybackSource{rateLimiter: rateLimiter{perMinute: 15}})
+}
+
+// waybackMaxURLs caps the URLs requested from the CDX API, which can hold
+// millions for a large site
+const waybackMaxURLs = 200000
+
+// waybackSource retrieves the URLs the Wayback Machine archived under the domain
+type waybackSource struct {
+	urlStore
+	rateLimiter
+}
+
+// Name returns the source identifier
+func (s *waybackSource) Name() string {
+	return "wayback"
+}
+
+// Fetch retrieves subdomains from the hostnames of the Wayback Machine's
+// archived URLs, keeping the URLs themselves to be saved
+func (s *waybackSource) Fetch(ctx context.Context, domain string) ([]string, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	client := newClient(120 * time.Second)
+
+	query := url.Values{}
+	query.Set("url", "*."+domain+"/*")
+	query.Set("output", "txt")
+	query.Set("fl", "original")
+	query.Set("collapse", "urlkey")
+	query.Set("limit", fmt.Sprint(waybackMaxURLs))
+	cdxURL := "https://web.archive.org/cdx/search/cdx?" + query.Encode()
+
+	body, err := doRequest(ctx, client, func() (*http.Request, error) {
+		return http.NewRequest("GET", cdxURL, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error accessing the Wayback Machine: %v", err)
+	}
+
+	var urls []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			urls = append(urls, line)
+		}
+	}
+	s.set(domain, urls)
+	return urlSubdomains(urls, domain), nil
+}
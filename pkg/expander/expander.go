@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/omerimzali/subscan/pkg/logger"
 )
 
 // Common prefixes and suffixes for permutation
@@ -33,6 +35,11 @@ type ExpandOptions struct {
 	CommonspeakPath   string
 	UseDNSTwist       bool
 	VerboseOutput     bool
+	// Twist bounds the DNS twist variations
+	Twist TwistOptions
+	// Seed returns the expanded list in a reproducible order (see Order); 0
+	// leaves it in generation order
+	Seed int64
 }
 
 // ExpandWordlist takes a list of passive subdomains and expands it with smart permutations
@@ -46,7 +53,7 @@ func ExpandWordlist(options ExpandOptions) []string {
 	prefixes := extractPrefixes(options.PassiveSubdomains)
 	
 	if options.VerboseOutput {
-		fmt.Println("🧩 Extracted prefixes:", strings.Join(prefixes, ", "))
+		logger.Infof("🧩 Extracted prefixes: %s", strings.Join(prefixes, ", "))
 	}
 
 	// Add base subdomains
@@ -69,7 +76,7 @@ func ExpandWordlist(options ExpandOptions) []string {
 		mu.Unlock()
 		
 		if options.VerboseOutput {
-			fmt.Printf("🔄 Generated %d permutations from prefixes\n", len(perms))
+			logger.Infof("🔄 Generated %d permutations from prefixes", len(perms))
 		}
 	}()
 
@@ -89,7 +96,7 @@ func ExpandWordlist(options ExpandOptions) []string {
 			mu.Unlock()
 			
 			if options.VerboseOutput {
-				fmt.Printf("📚 Imported %d entries from Commonspeak2\n", len(commons))
+				logger.Infof("📚 Imported %d entries from Commonspeak2", len(commons))
 			}
 		}()
 	}
@@ -99,7 +106,7 @@ func ExpandWordlist(options ExpandOptions) []string {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			twists := generateDNSTwist(options.PassiveSubdomains)
+			twists := Twist(options.PassiveSubdomains, options.Twist)
 			mu.Lock()
 			for _, t := range twists {
 				if !uniqueMap[t] {
@@ -110,7 +117,10 @@ func ExpandWordlist(options ExpandOptions) []string {
 			mu.Unlock()
 			
 			if options.VerboseOutput {
-				fmt.Printf("🔤 Generated %d variations using DNSTwist patterns\n", len(twists))
+				logger.Infof("🔤 Generated %d variations using DNSTwist patterns", len(twists))
+			}
+			if options.Twist.Limit > 0 && len(twists) >= options.Twist.Limit {
+				logger.Warnf("DNSTwist stopped at its limit of %d names; the lowest-priority subdomains weren't twisted", options.Twist.Limit)
 			}
 		}()
 	}
@@ -122,6 +132,9 @@ func ExpandWordlist(options ExpandOptions) []string {
 		expandedList = append(expandedList, subdomain)
 	}
 
+	if options.Seed != 0 {
+		return Order(expandedList, options.Seed)
+	}
 	return expandedList
 }
 
@@ -227,7 +240,7 @@ func importCommonspeak(commonspeakPath string) []string {
 	// Try to open the file
 	file, err := os.Open(commonspeakPath)
 	if err != nil {
-		fmt.Printf("Warning: Could not open Commonspeak2 wordlist: %v\n", err)
+		logger.Warnf("could not open Commonspeak2 wordlist: %v", err)
 		return wordlist
 	}
 	defer file.Close()
@@ -243,80 +256,3 @@ func importCommonspeak(commonspeakPath string) []string {
 
 	return wordlist
 }
-
-// generateDNSTwist creates variations using common typosquatting patterns
-func generateDNSTwist(subdomains []string) []string {
-	var variations []string
-	
-	// Character replacements (for typosquatting)
-	replacements := map[rune][]rune{
-		'a': {'4', '@'},
-		'e': {'3'},
-		'i': {'1', '!'},
-		'o': {'0'},
-		's': {'5', '$'},
-		'l': {'1'},
-	}
-	
-	for _, subdomain := range subdomains {
-		parts := strings.Split(subdomain, ".")
-		
-		// Skip if fewer than 2 parts
-		if len(parts) < 2 {
-			continue
-		}
-		
-		// For each part, generate typo variations
-		for i, part := range parts {
-			if len(part) < 3 {
-				continue // Skip very short parts
-			}
-			
-			// Character substitution
-			for j, char := range part {
-				if replacements[char] != nil {
-					for _, replacement := range replacements[char] {
-						newPart := part[:j] + string(replacement) + part[j+1:]
-						newParts := make([]string, len(parts))
-						copy(newParts, parts)
-						newParts[i] = newPart
-						variations = append(variations, strings.Join(newParts, "."))
-					}
-				}
-			}
-			
-			// Character addition (for each position)
-			for j := 0; j <= len(part); j++ {
-				for _, char := range []rune{'0', '1', '-', '_'} {
-					newPart := part[:j] + string(char) + part[j:]
-					newParts := make([]string, len(parts))
-					copy(newParts, parts)
-					newParts[i] = newPart
-					variations = append(variations, strings.Join(newParts, "."))
-				}
-			}
-			
-			// Character omission (if part is long enough)
-			if len(part) > 3 {
-				for j := 0; j < len(part); j++ {
-					newPart := part[:j] + part[j+1:]
-					newParts := make([]string, len(parts))
-					copy(newParts, parts)
-					newParts[i] = newPart
-					variations = append(variations, strings.Join(newParts, "."))
-				}
-			}
-			
-			// Character swapping (adjacent chars)
-			for j := 0; j < len(part)-1; j++ {
-				newPart := part[:j] + string(part[j+1]) + string(part[j]) + part[j+2:]
-				newParts := make([]string, len(parts))
-				copy(newParts, parts)
-				newParts[i] = newPart
-				variations = append(variations, strings.Join(newParts, "."))
-			}
-		}
-	}
-	
-	return variations
-} 
\ No newline at end of file
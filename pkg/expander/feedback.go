@@ -0,0 +1,22 @@
+package expander
+
+// Feedback generates a bounded round of permutations of seed subdomains using
+// only the words of the labels of the subdomains confirmed to resolve, so the
+// naming schemes that proved to exist (web-eu-2 next to api-eu-1) are
+// explored further without multiplying every candidate by the built-in words.
+// Names in tried are skipped.
+func Feedback(seeds []string, confirmed []string, tried map[string]bool, options PermutationOptions) []string {
+	options.Words = labelTokens(confirmed, options.Domain)
+	if len(options.Words) == 0 {
+		return nil
+	}
+	options.Level = 1
+
+	var candidates []string
+	for _, name := range Permute(seeds, options) {
+		if !tried[name] {
+			candidates = append(candidates, name)
+		}
+	}
+	return candidates
+}
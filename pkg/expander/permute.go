@@ -0,0 +1,256 @@
+package expander
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+// Permutation word sets used when no custom word file is given
+var (
+	environmentWords = []string{
+		"dev", "development", "test", "testing", "qa", "uat", "stage", "staging", "stg",
+		"preprod", "prod", "production", "sandbox", "demo", "int", "internal",
+	}
+
+	regionWords = []string{
+		"us", "eu", "ap", "east", "west", "north", "south", "emea", "apac",
+		"us-east-1", "us-east-2", "us-west-1", "us-west-2", "eu-west-1", "eu-west-2",
+		"eu-central-1", "ap-south-1", "ap-southeast-1", "ap-southeast-2", "ap-northeast-1",
+		"ca-central-1", "sa-east-1",
+	}
+
+	serviceWords = []string{
+		"api", "app", "admin", "portal", "cdn", "static", "assets", "auth", "sso",
+		"mail", "vpn", "git", "ci", "jenkins", "grafana", "old", "new", "legacy",
+		"backup", "v1", "v2",
+	}
+)
+
+// numberPattern finds the numbers inside a label, e.g. the 01 of web01
+var numberPattern = regexp.MustCompile(`[0-9]+`)
+
+// PermutationOptions contains configuration for the permutation engine
+type PermutationOptions struct {
+	// Domain is the apex the names are permuted below
+	Domain string
+	// Level is the number of permutation rounds; each round permutes the names
+	// the previous one generated
+	Level int
+	// Words are inserted as labels, joined to labels and swapped for label
+	// parts; the built-in environment, region and service words when empty
+	Words []string
+	// NumberRange is how far numbers in labels are counted up and down, so
+	// web01 yields web00 through web21 at 20
+	NumberRange int
+	// Limit caps the number of generated names; 0 means unlimited
+	Limit int
+	// Seed makes the generation order, and so the names kept under Limit,
+	// reproducible (see Order); 0 keeps the order of the given names
+	Seed int64
+}
+
+// DefaultPermutationOptions returns the default permutation options
+func DefaultPermutationOptions() PermutationOptions {
+	return PermutationOptions{
+		Level:       1,
+		NumberRange: 20,
+		Limit:       500000,
+	}
+}
+
+// DefaultPermutationWords returns the built-in environment, region and
+// service words
+func DefaultPermutationWords() []string {
+	words := append([]string{}, environmentWords...)
+	words = append(words, regionWords...)
+	return append(words, serviceWords...)
+}
+
+// LoadPermutationWords reads a permutation word file, one word per line
+func LoadPermutationWords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open permutation words: %v", err)
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// Permute generates altdns-style permutations of known subdomains: words
+// inserted as a new label at every position, joined to every label and
+// swapped for the dash-separated parts of labels, and numbers in labels
+// counted up and down. The words also include the parts of the known labels,
+// so naming schemes in use are reused across hosts. The known names
+// themselves are not returned.
+func Permute(subdomains []string, options PermutationOptions) []string {
+	domain := strings.ToLower(strings.TrimSuffix(options.Domain, "."))
+	words := options.Words
+	if len(words) == 0 {
+		words = DefaultPermutationWords()
+	}
+	words = dedupeWords(append(append([]string{}, words...), labelTokens(subdomains, domain)...))
+	if options.Seed != 0 {
+		subdomains = Order(subdomains, options.Seed)
+		words = Order(words, options.Seed)
+	}
+
+	seen := make(map[string]bool)
+	var current []string
+	for _, subdomain := range subdomains {
+		subdomain = strings.ToLower(strings.TrimSuffix(subdomain, "."))
+		if !seen[subdomain] && strings.HasSuffix(subdomain, "."+domain) {
+			seen[subdomain] = true
+			current = append(current, subdomain)
+		}
+	}
+
+	var generated []string
+	limited := false
+	for round := 0; round < options.Level && len(current) > 0 && !limited; round++ {
+		var next []string
+		for _, name := range current {
+			labels := strings.Split(strings.TrimSuffix(name, "."+domain), ".")
+			for _, candidate := range permuteLabels(labels, words, options.NumberRange) {
+				full := strings.Join(candidate, ".") + "." + domain
+				if seen[full] || !validName(candidate, full) {
+					continue
+				}
+				seen[full] = true
+				next = append(next, full)
+				if options.Limit > 0 && len(generated)+len(next) >= options.Limit {
+					limited = true
+					break
+				}
+			}
+			if limited {
+				break
+			}
+		}
+		generated = append(generated, next...)
+		current = next
+	}
+	if limited {
+		logger.Warnf("permutations capped at %d names; lower the permutation level or use fewer words", options.Limit)
+	}
+	return generated
+}
+
+// permuteLabels returns the label lists one permutation away from labels
+func permuteLabels(labels []string, words []string, numberRange int) [][]string {
+	var candidates [][]string
+	with := func(i int, label string) []string {
+		candidate := append([]string{}, labels...)
+		candidate[i] = label
+		return candidate
+	}
+
+	// A word as a new label at every position
+	for i := 0; i <= len(labels); i++ {
+		for _, word := range words {
+			candidate := append(append(append([]string{}, labels[:i]...), word), labels[i:]...)
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	for i, label := range labels {
+		// A word joined to the label on either side
+		for _, word := range words {
+			candidates = append(candidates,
+				with(i, word+"-"+label), with(i, label+"-"+word),
+				with(i, word+label), with(i, label+word))
+		}
+
+		// A word swapped for each dash-separated part: api-dev -> api-staging
+		parts := strings.Split(label, "-")
+		if len(parts) > 1 {
+			for p := range parts {
+				for _, word := range words {
+					if word == parts[p] {
+						continue
+					}
+					swapped := append([]string{}, parts...)
+					swapped[p] = word
+					candidates = append(candidates, with(i, strings.Join(swapped, "-")))
+				}
+			}
+		}
+
+		// Numbers counted up and down, keeping their zero padding
+		for _, loc := range numberPattern.FindAllStringIndex(label, -1) {
+			digits := label[loc[0]:loc[1]]
+			n, err := strconv.Atoi(digits)
+			if err != nil {
+				continue
+			}
+			for value := n - numberRange; value <= n+numberRange; value++ {
+				if value < 0 || value == n {
+					continue
+				}
+				number := fmt.Sprintf("%0*d", len(digits), value)
+				candidates = append(candidates, with(i, label[:loc[0]]+number+label[loc[1]:]))
+			}
+		}
+	}
+	return candidates
+}
+
+// validName reports whether a generated name fits DNS length limits
+func validName(labels []string, name string) bool {
+	if len(name) > 253 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+	}
+	return true
+}
+
+// labelTokens returns the dash-separated parts of the labels of the known
+// subdomains, without the numbers
+func labelTokens(subdomains []string, domain string) []string {
+	var tokens []string
+	for _, subdomain := range subdomains {
+		subdomain = strings.ToLower(strings.TrimSuffix(subdomain, "."))
+		if !strings.HasSuffix(subdomain, "."+domain) {
+			continue
+		}
+		for _, label := range strings.Split(strings.TrimSuffix(subdomain, "."+domain), ".") {
+			for _, token := range strings.Split(label, "-") {
+				if token = strings.Trim(numberPattern.ReplaceAllString(token, ""), "_"); len(token) > 1 {
+					tokens = append(tokens, token)
+				}
+			}
+		}
+	}
+	return tokens
+}
+
+// dedupeWords removes empty and repeated words, keeping their order
+func dedupeWords(words []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, word := range words {
+		if word != "" && !seen[word] {
+			seen[word] = true
+			unique = append(unique, word)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,20 @@
+package expander
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Order returns names in a reproducible order for seed: sorted, then shuffled
+// by a generator seeded with seed. The same names and seed always give the
+// same order, whatever order the names arrived in, so capped or sampled
+// generation picks the same candidates on every run.
+func Order(names []string, seed int64) []string {
+	ordered := append([]string{}, names...)
+	sort.Strings(ordered)
+	random := rand.New(rand.NewSource(seed))
+	random.Shuffle(len(ordered), func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+	return ordered
+}
@@ -0,0 +1,202 @@
+package expander
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// twistReplacements are look-alike substitutions, limited to characters valid
+// in host names
+var twistReplacements = map[byte][]byte{
+	'a': {'4'},
+	'e': {'3'},
+	'i': {'1'},
+	'o': {'0'},
+	's': {'5'},
+	'l': {'1'},
+}
+
+// twistPriorityWords mark names whose typo variants are most worth resolving
+var twistPriorityWords = []string{
+	"admin", "api", "app", "auth", "dev", "git", "internal", "jenkins", "login",
+	"mail", "portal", "prod", "sso", "stage", "staging", "test", "vpn", "www",
+}
+
+// TwistOptions bounds the typo variations generated from known subdomains
+type TwistOptions struct {
+	// Domain is the apex the names are twisted below; its own labels are kept.
+	// When empty, the last two labels of each name are kept.
+	Domain string
+	// PerSubdomain caps the variations of one subdomain, sampling them when a
+	// name has more; 0 means unlimited
+	PerSubdomain int
+	// Limit caps the number of generated names; 0 means unlimited
+	Limit int
+	// MaxLabelLength skips labels longer than this, whose variations are
+	// rarely registered; 0 means no maximum
+	MaxLabelLength int
+	// Seed varies which variations are sampled; runs with the same seed
+	// sample the same ones
+	Seed int64
+}
+
+// DefaultTwistOptions returns the default DNS twist options
+func DefaultTwistOptions() TwistOptions {
+	return TwistOptions{
+		PerSubdomain:   50,
+		Limit:          20000,
+		MaxLabelLength: 24,
+	}
+}
+
+// Twist generates typosquatting variations of subdomains: look-alike
+// characters, added, omitted and swapped characters. Names with high-value
+// words and short labels are twisted first, so the caps cut the least
+// interesting names on domains with thousands of passive results.
+func Twist(subdomains []string, options TwistOptions) []string {
+	domain := strings.ToLower(strings.TrimSuffix(options.Domain, "."))
+
+	if options.Seed != 0 {
+		subdomains = Order(subdomains, options.Seed)
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, subdomain := range subdomains {
+		subdomain = strings.ToLower(strings.TrimSuffix(subdomain, "."))
+		if !seen[subdomain] {
+			seen[subdomain] = true
+			names = append(names, subdomain)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		pi, pj := twistPriority(names[i], domain), twistPriority(names[j], domain)
+		if pi != pj {
+			return pi > pj
+		}
+		return len(names[i]) < len(names[j])
+	})
+
+	var generated []string
+	for _, name := range names {
+		variations := twistName(name, domain, options.MaxLabelLength)
+		var fresh []string
+		added := make(map[string]bool)
+		for _, variation := range variations {
+			if !seen[variation] && !added[variation] {
+				added[variation] = true
+				fresh = append(fresh, variation)
+			}
+		}
+		if options.PerSubdomain > 0 && len(fresh) > options.PerSubdomain {
+			fresh = sampleNames(fresh, options.PerSubdomain, name, options.Seed)
+		}
+		for _, variation := range fresh {
+			if options.Limit > 0 && len(generated) >= options.Limit {
+				return generated
+			}
+			seen[variation] = true
+			generated = append(generated, variation)
+		}
+	}
+	return generated
+}
+
+// twistPriority counts the high-value words in the labels of a name below
+// the apex
+func twistPriority(name string, domain string) int {
+	labels, _ := splitApex(name, domain)
+	priority := 0
+	for _, label := range labels {
+		for _, word := range twistPriorityWords {
+			if strings.Contains(label, word) {
+				priority++
+			}
+		}
+	}
+	return priority
+}
+
+// splitApex splits a name into its labels below the apex and the apex itself
+func splitApex(name string, domain string) ([]string, string) {
+	if domain != "" {
+		if !strings.HasSuffix(name, "."+domain) {
+			return nil, domain
+		}
+		return strings.Split(strings.TrimSuffix(name, "."+domain), "."), domain
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) < 3 {
+		return nil, name
+	}
+	return parts[:len(parts)-2], strings.Join(parts[len(parts)-2:], ".")
+}
+
+// twistName returns the typo variations of the labels of a name below the apex
+func twistName(name string, domain string, maxLabelLength int) []string {
+	labels, apex := splitApex(name, domain)
+	var variations []string
+	for i, label := range labels {
+		if len(label) < 3 || (maxLabelLength > 0 && len(label) > maxLabelLength) {
+			continue
+		}
+		for _, twisted := range twistLabel(label) {
+			candidate := append([]string{}, labels...)
+			candidate[i] = twisted
+			full := strings.Join(candidate, ".") + "." + apex
+			if validName(candidate, full) {
+				variations = append(variations, full)
+			}
+		}
+	}
+	return variations
+}
+
+// twistLabel returns the typo variations of one label
+func twistLabel(label string) []string {
+	var twisted []string
+
+	// Look-alike substitution
+	for j := 0; j < len(label); j++ {
+		for _, replacement := range twistReplacements[label[j]] {
+			twisted = append(twisted, label[:j]+string(replacement)+label[j+1:])
+		}
+	}
+
+	// Character addition at each position
+	for j := 0; j <= len(label); j++ {
+		for _, char := range []string{"0", "1", "-", "_"} {
+			twisted = append(twisted, label[:j]+char+label[j:])
+		}
+	}
+
+	// Character omission, for labels long enough to stay recognizable
+	if len(label) > 3 {
+		for j := 0; j < len(label); j++ {
+			twisted = append(twisted, label[:j]+label[j+1:])
+		}
+	}
+
+	// Adjacent character swap
+	for j := 0; j < len(label)-1; j++ {
+		if label[j] != label[j+1] {
+			twisted = append(twisted, label[:j]+string(label[j+1])+string(label[j])+label[j+2:])
+		}
+	}
+	return twisted
+}
+
+// sampleNames picks n of names at random, seeded by key and seed so repeated
+// runs resolve the same sample
+func sampleNames(names []string, n int, key string, seed int64) []string {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	random := rand.New(rand.NewSource(int64(hash.Sum64()) ^ seed))
+
+	sample := append([]string{}, names...)
+	random.Shuffle(len(sample), func(i, j int) {
+		sample[i], sample[j] = sample[j], sample[i]
+	})
+	return sample[:n]
+}
@@ -0,0 +1,170 @@
+// Package filter restricts which hosts a scan's outputs show, by HTTP
+// status, score, cloud hosting and tags, the same way for every output format.
+package filter
+
+import (
+	"path"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+	"github.com/omerimzali/subscan/pkg/sink"
+)
+
+// Filter keeps the hosts meeting every criterion it sets. A nil Filter keeps
+// every host.
+type Filter struct {
+	// Statuses keeps hosts that answered with one of these HTTP status codes
+	Statuses []int
+	// MinScore keeps scored hosts scoring at least this much; 0 keeps all
+	MinScore float64
+	// OnlyCloud keeps hosts served by a cloud provider, as told by their CNAME
+	OnlyCloud bool
+	// Tags keeps hosts carrying one of these tags. Matching ignores case and
+	// wildcards stand for families of tags, such as PORT-*.
+	Tags []string
+}
+
+// NeedsHTTP reports whether the filter looks at what only scoring or probing
+// learns: status codes, scores and tags
+func (f *Filter) NeedsHTTP() bool {
+	return f != nil && (len(f.Statuses) > 0 || f.MinScore != 0 || len(f.Tags) > 0)
+}
+
+// Subdomain reports whether a scored host is kept
+func (f *Filter) Subdomain(info scorer.SubdomainInfo) bool {
+	if f == nil {
+		return true
+	}
+	if f.MinScore != 0 && info.Score < f.MinScore {
+		return false
+	}
+	if f.OnlyCloud && info.CloudProvider == "" && !isCloud(info.CNAMEs...) {
+		return false
+	}
+	return f.matchStatus(info.HTTPStatus) && f.matchTags(info.Tags)
+}
+
+// Probe reports whether a probed host is kept. Probe results carry no score,
+// so MinScore does not apply to them.
+func (f *Filter) Probe(result probe.ProbeResult) bool {
+	if f == nil {
+		return true
+	}
+	if f.OnlyCloud && !isCloud(result.CNAME) {
+		return false
+	}
+	return f.matchStatus(result.HTTPStatus) && f.matchTags(result.Tags)
+}
+
+// Record reports whether a resolved host is kept. DNS records only tell
+// whether a host is cloud hosted, so the other criteria do not apply to them.
+func (f *Filter) Record(record resolver.DNSRecord) bool {
+	if f == nil {
+		return true
+	}
+	return !f.OnlyCloud || isCloud(record.CNAME)
+}
+
+// Result reports whether a streamed result is kept
+func (f *Filter) Result(result sink.Result) bool {
+	switch {
+	case result.Subdomain != nil:
+		return f.Subdomain(*result.Subdomain)
+	case result.Probe != nil:
+		return f.Probe(*result.Probe)
+	case result.Record != nil:
+		return f.Record(*result.Record)
+	}
+	return true
+}
+
+// Subdomains returns the scored hosts kept, in order
+func (f *Filter) Subdomains(results []scorer.SubdomainInfo) []scorer.SubdomainInfo {
+	if f == nil {
+		return results
+	}
+	kept := make([]scorer.SubdomainInfo, 0, len(results))
+	for _, info := range results {
+		if f.Subdomain(info) {
+			kept = append(kept, info)
+		}
+	}
+	return kept
+}
+
+// Probes returns the probed hosts kept, in order
+func (f *Filter) Probes(results []probe.ProbeResult) []probe.ProbeResult {
+	if f == nil {
+		return results
+	}
+	kept := make([]probe.ProbeResult, 0, len(results))
+	for _, result := range results {
+		if f.Probe(result) {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// Records returns the resolved hosts kept, in order
+func (f *Filter) Records(records []resolver.DNSRecord) []resolver.DNSRecord {
+	if f == nil {
+		return records
+	}
+	kept := make([]resolver.DNSRecord, 0, len(records))
+	for _, record := range records {
+		if f.Record(record) {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
+
+// Sink wraps s so only the results kept reach it
+func (f *Filter) Sink(s sink.OutputSink) sink.OutputSink {
+	if f == nil || s == nil {
+		return s
+	}
+	return sink.Filter{Sink: s, Keep: f.Result}
+}
+
+// matchStatus reports whether status is one of the statuses kept
+func (f *Filter) matchStatus(status int) bool {
+	if len(f.Statuses) == 0 {
+		return true
+	}
+	for _, kept := range f.Statuses {
+		if status == kept {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTags reports whether one of tags is among the tags kept
+func (f *Filter) matchTags(tags []string) bool {
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, pattern := range f.Tags {
+		pattern = strings.ToUpper(pattern)
+		for _, tag := range tags {
+			if matched, _ := path.Match(pattern, strings.ToUpper(tag)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isCloud reports whether one of the CNAMEs points at a cloud provider
+func isCloud(cnames ...string) bool {
+	for _, cname := range cnames {
+		if cname != "" && scorer.CloudProvider(cname) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"net/http"
+	"strings"
+)
+
+// maxFavicon caps how much of a favicon is read
+const maxFavicon = 1024 * 1024
+
+// FetchFavicon requests baseURL's /favicon.ico and returns its hash, or false
+// when the host serves no favicon. Pages served in place of a missing icon
+// are not hashed.
+func FetchFavicon(client *http.Client, baseURL string) (int32, bool) {
+	resp, err := client.Get(strings.TrimSuffix(baseURL, "/") + "/favicon.ico")
+	if err != nil {
+		return 0, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || strings.HasPrefix(resp.Header.Get("Content-Type"), "text/") {
+		return 0, false
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFavicon))
+	if err != nil || len(data) == 0 || bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return 0, false
+	}
+	return FaviconHash(data), true
+}
+
+// FaviconHash returns the hash Shodan indexes favicons by (http.favicon.hash):
+// the MurmurHash3 of the icon's base64 encoding, wrapped at 76 characters
+// with a trailing newline as Python's base64.encodebytes does
+func FaviconHash(data []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var wrapped strings.Builder
+	for len(encoded) > 76 {
+		wrapped.WriteString(encoded[:76])
+		wrapped.WriteByte('\n')
+		encoded = encoded[76:]
+	}
+	wrapped.WriteString(encoded)
+	wrapped.WriteByte('\n')
+	return int32(murmur3([]byte(wrapped.String())))
+}
+
+// murmur3 returns the 32-bit x86 MurmurHash3 of data with a zero seed
+func murmur3(data []byte) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+	var h uint32
+	blocks := len(data) / 4
+	for i := 0; i < blocks; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	tail := data[blocks*4:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,206 @@
+// Package fingerprint identifies the web technologies behind a response, such
+// as server software, frameworks and CMSs, from its headers, cookies and the
+// start of its body, in the manner of Wappalyzer.
+package fingerprint
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// signature identifies a technology. Any matching pattern is enough; the
+// first capture group of a matching pattern, when present, is its version.
+type signature struct {
+	name string
+	// headers maps header names to patterns of their values
+	headers map[string]*regexp.Regexp
+	// cookies are names, or name prefixes ending in *, of cookies it sets
+	cookies []string
+	// body holds patterns of the page's HTML
+	body []*regexp.Regexp
+	// implies names technologies it always runs on
+	implies []string
+}
+
+// version captures a dotted version number
+const version = `([0-9]+(?:\.[0-9]+)*)`
+
+var signatures = []signature{
+	// Web servers and proxies
+	{name: "nginx", headers: headers("Server", `(?i)^nginx(?:/`+version+`)?`)},
+	{name: "OpenResty", headers: headers("Server", `(?i)^openresty(?:/`+version+`)?`), implies: []string{"nginx"}},
+	{name: "Apache", headers: headers("Server", `(?i)^apache(?:/`+version+`)?(?:\s|$)`)},
+	{name: "IIS", headers: headers("Server", `(?i)^microsoft-iis(?:/`+version+`)?`)},
+	{name: "LiteSpeed", headers: headers("Server", `(?i)^litespeed`)},
+	{name: "Caddy", headers: headers("Server", `(?i)^caddy`)},
+	{name: "Envoy", headers: headers("Server", `(?i)^envoy`, "X-Envoy-Upstream-Service-Time", `.`)},
+	{name: "Jetty", headers: headers("Server", `(?i)jetty(?:\(`+version+`)?`)},
+	{name: "Apache Tomcat", body: patterns(`Apache Tomcat/`+version, `<title>Apache Tomcat`)},
+	{name: "gunicorn", headers: headers("Server", `(?i)^gunicorn(?:/`+version+`)?`)},
+	{name: "Kestrel", headers: headers("Server", `(?i)^kestrel`)},
+	{name: "Varnish", headers: headers("Via", `(?i)varnish`, "X-Varnish", `.`)},
+	{name: "Cloudflare", headers: headers("Server", `(?i)^cloudflare`, "CF-Ray", `.`)},
+	{name: "Amazon CloudFront", headers: headers("X-Amz-Cf-Id", `.`, "Via", `(?i)cloudfront`)},
+	{name: "Amazon S3", headers: headers("Server", `^AmazonS3`)},
+	{name: "Akamai", headers: headers("Server", `(?i)^akamaighost`)},
+	{name: "Fastly", headers: headers("X-Served-By", `^cache-`, "Fastly-Debug-Digest", `.`)},
+
+	// Languages and frameworks
+	{name: "PHP", headers: headers("X-Powered-By", `(?i)php(?:/`+version+`)?`), cookies: []string{"PHPSESSID"}},
+	{name: "ASP.NET", headers: headers("X-AspNet-Version", `^`+version, "X-Powered-By", `(?i)asp\.net`), cookies: []string{"ASP.NET_SessionId", ".AspNetCore.*"}},
+	{name: "Java", cookies: []string{"JSESSIONID"}},
+	{name: "Express", headers: headers("X-Powered-By", `(?i)^express`), implies: []string{"Node.js"}},
+	{name: "Next.js", headers: headers("X-Powered-By", `(?i)next\.js(?:\s`+version+`)?`), body: patterns(`__NEXT_DATA__`, `/_next/static/`), implies: []string{"React"}},
+	{name: "Nuxt.js", body: patterns(`window\.__NUXT__`, `/_nuxt/`), implies: []string{"Vue.js"}},
+	{name: "React", body: patterns(`data-reactroot`, `react(?:\.production)?\.min\.js`)},
+	{name: "Vue.js", body: patterns(`vue@`+version, `data-v-[0-9a-f]{8}`, `vue(?:\.runtime)?(?:\.global)?(?:\.prod)?(?:\.min)?\.js`)},
+	{name: "Angular", body: patterns(`ng-version="` + version + `"`)},
+	{name: "AngularJS", body: patterns(`ng-app=`, `angular(?:\.min)?\.js`)},
+	{name: "Django", cookies: []string{"csrftoken", "django_language"}, body: patterns(`csrfmiddlewaretoken`)},
+	{name: "Laravel", cookies: []string{"laravel_session"}, implies: []string{"PHP"}},
+	{name: "Ruby on Rails", headers: headers("X-Powered-By", `(?i)phusion passenger`), body: patterns(`<meta name="csrf-param" content="authenticity_token"`)},
+	{name: "Flask", headers: headers("Server", `(?i)^werkzeug(?:/`+version+`)?`)},
+	{name: "jQuery", body: patterns(`jquery[.-]`+version+`(?:\.min)?\.js`, `jquery(?:\.min)?\.js`)},
+	{name: "Bootstrap", body: patterns(`bootstrap[.-]?` + version + `?(?:\.min)?\.(?:css|js)`)},
+
+	// CMSs and shops
+	{name: "WordPress", body: patterns(`<meta name="generator" content="WordPress `+version, `/wp-content/`, `/wp-includes/`), implies: []string{"PHP"}},
+	{name: "Drupal", headers: headers("X-Generator", `(?i)drupal(?:\s`+version+`)?`, "X-Drupal-Cache", `.`), body: patterns(`<meta name="Generator" content="Drupal `+version, `drupal-settings-json`, `Drupal\.settings`), implies: []string{"PHP"}},
+	{name: "Joomla", body: patterns(`<meta name="generator" content="Joomla`, `/media/jui/`), implies: []string{"PHP"}},
+	{name: "Ghost", body: patterns(`<meta name="generator" content="Ghost ` + version)},
+	{name: "Magento", cookies: []string{"X-Magento-Vary"}, body: patterns(`Mage\.Cookies`, `/static/version[0-9]+/frontend/`), implies: []string{"PHP"}},
+	{name: "Shopify", headers: headers("X-ShopId", `.`), body: patterns(`cdn\.shopify\.com`)},
+	{name: "SharePoint", headers: headers("MicrosoftSharePointTeamServices", `^`+version)},
+
+	// Applications
+	{name: "Jira", cookies: []string{"atlassian.xsrf.token"}, body: patterns(`data-name="jira" data-version="`+version, `<meta name="ajs-jira-base-url"`, `jira\.webresources`)},
+	{name: "Confluence", headers: headers("X-Confluence-Request-Time", `.`), body: patterns(`<span id='footer-build-information'>`+version, `confluence-base-url`)},
+	{name: "Bitbucket", body: patterns(`bitbucket\.page`, `<meta name="application-name" content="Bitbucket"`)},
+	{name: "GitLab", cookies: []string{"_gitlab_session"}, body: patterns(`gon\.gitlab_url`, `<meta content="GitLab" property="og:site_name"`)},
+	{name: "Jenkins", headers: headers("X-Jenkins", `^`+version, "X-Hudson", `.`)},
+	{name: "Grafana", body: patterns(`"buildInfo":\{[^}]*"version":"`+version, `window\.grafanaBootData`, `<title>Grafana</title>`)},
+	{name: "Kibana", headers: headers("kbn-version", `^`+version, "kbn-name", `.`)},
+	{name: "Prometheus", body: patterns(`<title>Prometheus Time Series Collection and Processing Server</title>`)},
+	{name: "SonarQube", body: patterns(`<title>SonarQube</title>`)},
+	{name: "phpMyAdmin", cookies: []string{"phpMyAdmin", "pma_lang"}, body: patterns(`<title>phpMyAdmin`), implies: []string{"PHP"}},
+	{name: "Outlook Web App", headers: headers("X-OWA-Version", `^`+version), body: patterns(`/owa/auth/`)},
+	{name: "Citrix Gateway", cookies: []string{"NSC_*"}, body: patterns(`/vpn/resources/`, `<title>Citrix Gateway`)},
+	{name: "Keycloak", body: patterns(`/auth/resources/[^/]+/login/keycloak`, `kc-form-login`)},
+	{name: "RabbitMQ", body: patterns(`<title>RabbitMQ Management</title>`)},
+	{name: "Elasticsearch", body: patterns(`"number"\s*:\s*"`+version+`"[^}]*"lucene_version"`, `"lucene_version"`)},
+}
+
+// generatorPattern reads the generator meta tag of pages built by software no
+// signature knows
+var generatorPattern = regexp.MustCompile(`(?i)<meta\s+name=["']generator["']\s+content=["']([^"']{1,64})["']`)
+
+// headers builds the header patterns of a signature from name, pattern pairs
+func headers(pairs ...string) map[string]*regexp.Regexp {
+	compiled := make(map[string]*regexp.Regexp, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		compiled[pairs[i]] = regexp.MustCompile(pairs[i+1])
+	}
+	return compiled
+}
+
+// patterns compiles the body patterns of a signature
+func patterns(expressions ...string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(expressions))
+	for i, expression := range expressions {
+		compiled[i] = regexp.MustCompile(expression)
+	}
+	return compiled
+}
+
+// Detect returns the technologies a response reveals, with their versions
+// when exposed, e.g. "nginx 1.18.0" or "WordPress". A Server header or
+// generator meta tag no signature knows is reported as is.
+func Detect(header http.Header, body []byte) []string {
+	cookies := cookieNames(header)
+	content := string(body)
+
+	var found []string
+	seen := make(map[string]bool)
+	add := func(name string, version string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		if version != "" {
+			name += " " + version
+		}
+		found = append(found, name)
+	}
+
+	serverKnown := false
+	for _, sig := range signatures {
+		matched, version := sig.match(header, cookies, content)
+		if !matched {
+			continue
+		}
+		if pattern, ok := sig.headers["Server"]; ok && pattern.MatchString(header.Get("Server")) {
+			serverKnown = true
+		}
+		add(sig.name, version)
+		for _, implied := range sig.implies {
+			add(implied, "")
+		}
+	}
+
+	if server := strings.TrimSpace(header.Get("Server")); server != "" && !serverKnown {
+		add(server, "")
+	}
+	if match := generatorPattern.FindStringSubmatch(content); match != nil {
+		generator := strings.TrimSpace(match[1])
+		if name := strings.Fields(generator); len(name) > 0 && !seen[name[0]] {
+			add(generator, "")
+		}
+	}
+	return found
+}
+
+// match reports whether the response matches the signature, with the version
+// captured by the first matching pattern that has one
+func (s signature) match(header http.Header, cookies []string, content string) (bool, string) {
+	matched := false
+	version := ""
+	try := func(pattern *regexp.Regexp, value string) {
+		groups := pattern.FindStringSubmatch(value)
+		if groups == nil {
+			return
+		}
+		matched = true
+		if version == "" && len(groups) > 1 {
+			version = groups[1]
+		}
+	}
+
+	for name, pattern := range s.headers {
+		for _, value := range header.Values(name) {
+			try(pattern, value)
+		}
+	}
+	for _, pattern := range s.body {
+		try(pattern, content)
+	}
+	for _, cookie := range s.cookies {
+		for _, name := range cookies {
+			if prefix := strings.TrimSuffix(cookie, "*"); name == cookie || (prefix != cookie && strings.HasPrefix(name, prefix)) {
+				matched = true
+			}
+		}
+	}
+	return matched, version
+}
+
+// cookieNames returns the names of the cookies a response sets
+func cookieNames(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Set-Cookie") {
+		if name, _, ok := strings.Cut(value, "="); ok {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	return names
+}
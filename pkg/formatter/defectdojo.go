@@ -0,0 +1,112 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/probe"
+)
+
+// FormatDefectDojo is DefectDojo's Generic Findings Import JSON, for importing
+// probe findings with their remediation into DefectDojo
+const FormatDefectDojo = "defectdojo"
+
+// defectDojoReport is the layout of a Generic Findings Import file
+type defectDojoReport struct {
+	Findings []defectDojoFinding `json:"findings"`
+}
+
+// defectDojoFinding is a finding of a Generic Findings Import file
+type defectDojoFinding struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Severity    string               `json:"severity"`
+	Mitigation  string               `json:"mitigation,omitempty"`
+	References  string               `json:"references,omitempty"`
+	Date        string               `json:"date"`
+	UniqueID    string               `json:"unique_id_from_tool"`
+	VulnID      string               `json:"vuln_id_from_tool,omitempty"`
+	Component   string               `json:"component_name"`
+	Dynamic     bool                 `json:"dynamic_finding"`
+	Static      bool                 `json:"static_finding"`
+	Endpoints   []defectDojoEndpoint `json:"endpoints,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+}
+
+// defectDojoEndpoint is a host a finding affects
+type defectDojoEndpoint struct {
+	Host string `json:"host"`
+}
+
+// defectDojoSeverities are DefectDojo's names of the probe severities
+var defectDojoSeverities = map[probe.Severity]string{
+	probe.SeverityNone:     "Info",
+	probe.SeverityLow:      "Low",
+	probe.SeverityMedium:   "Medium",
+	probe.SeverityHigh:     "High",
+	probe.SeverityCritical: "Critical",
+}
+
+// formatProbeResultsDefectDojo exports the probe findings as a DefectDojo
+// Generic Findings Import file. The fingerprint IDs become DefectDojo's unique
+// IDs, so reimporting a later scan closes fixed findings and keeps open ones.
+func formatProbeResultsDefectDojo(results []probe.ProbeResult) (string, error) {
+	report := defectDojoReport{Findings: []defectDojoFinding{}}
+	for _, result := range sortedBySeverity(results) {
+		date := time.Now().Format("2006-01-02")
+		if probed, err := time.Parse(time.RFC3339, result.ProbedAt); err == nil {
+			date = probed.Format("2006-01-02")
+		}
+		// Findings are rebuilt so reports written before remediation guidance
+		// existed get it too
+		for _, finding := range probe.BuildFindings(result) {
+			severity := probe.VulnerabilitySeverity(finding.Check)
+			report.Findings = append(report.Findings, defectDojoFinding{
+				Title:       fmt.Sprintf("%s on %s", finding.Check, finding.Host),
+				Description: defectDojoDescription(result, finding),
+				Severity:    defectDojoSeverities[severity],
+				Mitigation:  finding.Remediation,
+				References:  strings.Join(finding.References, "\n"),
+				Date:        date,
+				UniqueID:    finding.ID,
+				VulnID:      probe.VulnerabilityCheck(finding.Check),
+				Component:   finding.Host,
+				Dynamic:     true,
+				Endpoints:   []defectDojoEndpoint{{Host: finding.Host}},
+				Tags:        result.Tags,
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling DefectDojo findings: %v", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// defectDojoDescription describes a finding with the evidence of its check
+func defectDojoDescription(result probe.ProbeResult, finding probe.Finding) string {
+	var description strings.Builder
+	description.WriteString(fmt.Sprintf("Subscan found **%s** on `%s`.\n", finding.Check, finding.Host))
+	if result.CNAME != "" {
+		description.WriteString(fmt.Sprintf("\n**CNAME:** `%s`\n", result.CNAME))
+	}
+	switch probe.VulnerabilityCheck(finding.Check) {
+	case probe.CheckOpenRedirect:
+		description.WriteString(fmt.Sprintf("\n**Redirect URL:** `%s`\n", result.RedirectURL))
+	case probe.CheckSensitiveFiles:
+		for _, file := range result.ExposedFiles {
+			description.WriteString(fmt.Sprintf("\n**Exposed file:** `%s`\n", file))
+		}
+	}
+	if result.Owner != "" {
+		description.WriteString(fmt.Sprintf("\n**Owner:** %s\n", result.Owner))
+	}
+	if result.Team != "" {
+		description.WriteString(fmt.Sprintf("\n**Team:** %s\n", result.Team))
+	}
+	return description.String()
+}
@@ -0,0 +1,124 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/diff"
+	"github.com/omerimzali/subscan/pkg/input"
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+)
+
+// ReadHosts reads the hosts of any scan output so two scans can be compared:
+// probe and score reports, resolved DNS records or a plain host list
+func ReadHosts(data []byte) ([]model.Host, error) {
+	var hosts []model.Host
+	switch {
+	case IsProbeReport(data):
+		results, err := probe.ParseProbeResults(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			hosts = append(hosts, probe.ReportHost(result))
+		}
+	case IsScoredReport(data):
+		results, err := ParseScoredResults(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range results {
+			hosts = append(hosts, scoreHost(info))
+		}
+	case model.IsReport(data):
+		report, err := model.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		hosts = report.Hosts
+	case resolver.IsRecordList(data):
+		records, err := resolver.ParseRecords(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			hosts = append(hosts, model.Host{Domain: record.Name, CNAME: record.CNAME, IPs: record.IPs()})
+		}
+	default:
+		names, err := input.ParseHosts(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			hosts = append(hosts, model.Host{Domain: name})
+		}
+	}
+	return hosts, nil
+}
+
+// FormatDiff converts a scan diff to the specified format
+func FormatDiff(d diff.Diff, format string) (string, error) {
+	switch format {
+	case FormatPlain:
+		return diff.FormatDiff(d, false), nil
+	case FormatJSON:
+		jsonBytes, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error marshaling diff to JSON: %v", err)
+		}
+		return string(jsonBytes), nil
+	case FormatMarkdown:
+		return formatDiffMarkdown(d), nil
+	default:
+		return "", fmt.Errorf("unsupported format for diff: %s", format)
+	}
+}
+
+// formatDiffMarkdown formats a scan diff as Markdown
+func formatDiffMarkdown(d diff.Diff) string {
+	var md strings.Builder
+
+	md.WriteString("# Scan Diff\n\n")
+	md.WriteString(fmt.Sprintf("**Added:** %d | **Removed:** %d | **Changed:** %d\n\n", len(d.Added), len(d.Removed), len(d.Changed)))
+
+	if len(d.Added) > 0 {
+		md.WriteString("## Added\n\n")
+		md.WriteString("| Domain | Status | Score | Vulnerabilities |\n")
+		md.WriteString("|--------|--------|-------|----------------|\n")
+		for _, host := range d.Added {
+			md.WriteString(fmt.Sprintf("| %s | %d | %.1f | %s |\n", host.Domain, host.Status, host.Score, strings.Join(host.Vulnerabilities, ", ")))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(d.Removed) > 0 {
+		md.WriteString("## Removed\n\n")
+		for _, host := range d.Removed {
+			md.WriteString(fmt.Sprintf("- %s\n", host.Domain))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(d.Changed) > 0 {
+		md.WriteString("## Changed\n\n")
+		md.WriteString("| Domain | Change | Old | New |\n")
+		md.WriteString("|--------|--------|-----|-----|\n")
+		for _, change := range d.Changed {
+			for _, f := range change.Fields {
+				md.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", change.Domain, f.Field, f.Old, f.New))
+			}
+			for _, vuln := range change.NewVulnerabilities {
+				md.WriteString(fmt.Sprintf("| %s | new vulnerability | | %s |\n", change.Domain, vuln))
+			}
+			for _, vuln := range change.FixedVulnerabilities {
+				md.WriteString(fmt.Sprintf("| %s | fixed vulnerability | %s | |\n", change.Domain, vuln))
+			}
+		}
+		md.WriteString("\n")
+	}
+
+	return md.String()
+}
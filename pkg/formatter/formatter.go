@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/omerimzali/subscan/pkg/model"
 	"github.com/omerimzali/subscan/pkg/probe"
 	"github.com/omerimzali/subscan/pkg/scorer"
 )
@@ -21,12 +23,13 @@ const (
 	FormatCSV      = "csv"
 	FormatHTML     = "html"
 	FormatMarkdown = "markdown"
+	FormatJSONL    = "jsonl"
 )
 
 // IsValidFormat checks if the provided format is supported
 func IsValidFormat(format string) bool {
 	switch format {
-	case FormatPlain, FormatJSON, FormatCSV, FormatHTML, FormatMarkdown:
+	case FormatPlain, FormatJSON, FormatCSV, FormatHTML, FormatMarkdown, FormatJSONL, FormatNmap, FormatMasscan, FormatURLs, FormatDefectDojo:
 		return true
 	default:
 		return false
@@ -39,10 +42,30 @@ type SubdomainData struct {
 	Status        int      `json:"status"`
 	ContentLength int64    `json:"content_length"`
 	CNAME         string   `json:"cname,omitempty"`
+	IPs           []string `json:"ips,omitempty"`
 	CloudProvider string   `json:"cloud_provider,omitempty"`
 	Score         float64  `json:"score"`
 	Tags          []string `json:"tags,omitempty"`
 	IsTLS         bool     `json:"is_tls"`
+	FinalURL      string   `json:"final_url,omitempty"`
+	RedirectChain []string `json:"redirect_chain,omitempty"`
+	URLs          []string `json:"urls,omitempty"`
+	Language      string   `json:"language,omitempty"`
+	SaaSProvider  string   `json:"saas_provider,omitempty"`
+	Title         string   `json:"title,omitempty"`
+	Server        string   `json:"server,omitempty"`
+	PoweredBy     string   `json:"powered_by,omitempty"`
+	Technologies  []string `json:"technologies,omitempty"`
+	FaviconHash   int32    `json:"favicon_hash,omitempty"`
+	OpenPorts     []int    `json:"open_ports,omitempty"`
+	Provenance    string   `json:"provenance,omitempty"`
+	Sources       []string `json:"sources,omitempty"`
+	Screenshots   []string `json:"screenshots,omitempty"`
+	Owner         string   `json:"owner,omitempty"`
+	Team          string   `json:"team,omitempty"`
+	Notes         string   `json:"notes,omitempty"`
+
+	TLS *model.TLSInfo `json:"tls,omitempty"`
 }
 
 // HTMLTemplateData holds data for the HTML template rendering
@@ -53,28 +76,93 @@ type HTMLTemplateData struct {
 	Subdomains  []SubdomainData
 	DomainName  string
 	GeneratedBy string
+	SaaS        []SaaSEntry
+	Nameservers []model.Nameserver
+	Baseline    []model.Profile
+	Buckets     []model.Bucket
+	Apex        *model.ApexRecords
+	Caveats     []string
+	Legend      []model.Tag
+	Screenshots []ScreenshotEntry
+}
+
+// ScreenshotEntry is a captured web service shown in the HTML gallery, with
+// its thumbnail embedded
+type ScreenshotEntry struct {
+	Domain    string
+	URL       string
+	Path      string
+	Thumbnail template.URL
+}
+
+// SaaSEntry lists the subdomains hosted by one third-party SaaS provider
+type SaaSEntry struct {
+	Provider   string
+	Subdomains []string
+}
+
+// joinPorts formats a list of ports separated by sep
+func joinPorts(ports []int, sep string) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = fmt.Sprintf("%d", port)
+	}
+	return strings.Join(parts, sep)
+}
+
+// faviconHash formats a favicon hash for CSV, empty when there is none
+func faviconHash(hash int32) string {
+	if hash == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", hash)
+}
+
+// saasEntries returns the SaaS inventory of the results sorted by provider
+func saasEntries(results []scorer.SubdomainInfo) []SaaSEntry {
+	inventory := scorer.SaaSInventory(results)
+	var entries []SaaSEntry
+	for provider, subdomains := range inventory {
+		entries = append(entries, SaaSEntry{Provider: provider, Subdomains: subdomains})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Provider < entries[j].Provider })
+	return entries
 }
 
 // Format converts the analyis results to the specified format
 func Format(results []scorer.SubdomainInfo, format string, targetDomain string) (string, error) {
+	return FormatScan(results, format, targetDomain, ScanInfo{})
+}
+
+// FormatScan converts the analysis results to the specified format, adding the
+// target-level data of the scan to the formats with a section for it
+func FormatScan(results []scorer.SubdomainInfo, format string, targetDomain string, info ScanInfo) (string, error) {
 	switch format {
 	case FormatPlain:
-		return formatPlain(results), nil
+		return formatPlain(results, info), nil
 	case FormatJSON:
-		return formatJSON(results)
+		return formatJSON(results, targetDomain, info)
+	case FormatJSONL:
+		return formatJSONL(results)
 	case FormatCSV:
 		return formatCSV(results)
 	case FormatHTML:
-		return formatHTML(results, targetDomain)
+		return formatHTML(results, targetDomain, info)
 	case FormatMarkdown:
-		return formatMarkdown(results, targetDomain), nil
+		return formatMarkdown(results, targetDomain, info), nil
+	case FormatNmap, FormatMasscan:
+		return formatScoredTargets(results, format)
+	case FormatURLs:
+		return formatURLs(results), nil
+	case FormatDefectDojo:
+		return "", fmt.Errorf("the %s format exports probe findings; probe the hosts first", format)
 	default:
 		return "", fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
 // formatPlain formats the results as plain text
-func formatPlain(results []scorer.SubdomainInfo) string {
+func formatPlain(results []scorer.SubdomainInfo, info ScanInfo) string {
 	var output strings.Builder
 	
 	for _, info := range results {
@@ -103,45 +191,146 @@ func formatPlain(results []scorer.SubdomainInfo) string {
 		
 		// Format additional info
 		additional := ""
+		if info.Title != "" {
+			additional += fmt.Sprintf(" [Title: %q]", info.Title)
+		}
+		if info.Server != "" {
+			additional += fmt.Sprintf(" [Server: %s]", info.Server)
+		}
+		if info.PoweredBy != "" {
+			additional += fmt.Sprintf(" [Powered-By: %s]", info.PoweredBy)
+		}
 		if info.CloudProvider != "" {
 			additional += fmt.Sprintf(" [Cloud: %s]", info.CloudProvider)
 		}
 		if len(info.CNAMEs) > 0 {
 			additional += fmt.Sprintf(" [CNAME: %s]", info.CNAMEs[0])
 		}
+		if len(info.IPs) > 0 {
+			additional += fmt.Sprintf(" [IP: %s]", strings.Join(info.IPs, ","))
+		}
+		if info.FinalURL != "" {
+			additional += fmt.Sprintf(" [Final: %s]", info.FinalURL)
+		}
+		if len(info.OpenPorts) > 0 {
+			additional += fmt.Sprintf(" [Ports: %s]", joinPorts(info.OpenPorts, ","))
+		}
+		if len(info.Technologies) > 0 {
+			additional += fmt.Sprintf(" [Tech: %s]", strings.Join(info.Technologies, ", "))
+		}
+		if info.FaviconHash != 0 {
+			additional += fmt.Sprintf(" [Favicon: %d]", info.FaviconHash)
+		}
+		if info.Provenance != "" {
+			additional += fmt.Sprintf(" [Via: %s]", info.Provenance)
+		}
+		if len(info.Sources) > 0 {
+			additional += fmt.Sprintf(" [Sources: %s]", strings.Join(info.Sources, ", "))
+		}
+		if len(info.Screenshots) > 0 {
+			additional += fmt.Sprintf(" [Screenshot: %s]", strings.Join(info.Screenshots, ", "))
+		}
+		if info.TLS != nil {
+			additional += fmt.Sprintf(" [Cert: %s, expires %s]", info.TLS.Version, info.TLS.NotAfter.Format("2006-01-02"))
+		}
 		
 		line := fmt.Sprintf("%s%s [%s]%s%s\n", tags, info.Subdomain, status, size, additional)
 		output.WriteString(line)
 	}
 	
+	output.WriteString(scorer.FormatSaaSInventory(results))
+	output.WriteString(info.plain())
+	
 	return output.String()
 }
 
-// formatJSON formats the results as JSON
-func formatJSON(results []scorer.SubdomainInfo) (string, error) {
-	var jsonData []SubdomainData
+// toSubdomainData converts an analysis result into its serialized form
+func toSubdomainData(info scorer.SubdomainInfo) SubdomainData {
+	cname := ""
+	if len(info.CNAMEs) > 0 {
+		cname = info.CNAMEs[0]
+	}
 	
+	return SubdomainData{
+		Domain:        info.Subdomain,
+		Status:        info.HTTPStatus,
+		ContentLength: info.ContentLength,
+		CNAME:         cname,
+		IPs:           info.IPs,
+		CloudProvider: info.CloudProvider,
+		Score:         info.Score,
+		Tags:          info.Tags,
+		IsTLS:         info.IsTLS,
+		FinalURL:      info.FinalURL,
+		RedirectChain: info.RedirectChain,
+		URLs:          info.URLs,
+		Language:      info.Language,
+		SaaSProvider:  info.SaaSProvider,
+		Title:         info.Title,
+		Server:        info.Server,
+		PoweredBy:     info.PoweredBy,
+		Technologies:  info.Technologies,
+		FaviconHash:   info.FaviconHash,
+		OpenPorts:     info.OpenPorts,
+		Provenance:    info.Provenance,
+		Sources:       info.Sources,
+		Screenshots:   info.Screenshots,
+		TLS:           info.TLS,
+		Owner:         info.Owner,
+		Team:          info.Team,
+		Notes:         info.Notes,
+	}
+}
+
+// scoreHost converts a scored subdomain into a report host
+func scoreHost(info scorer.SubdomainInfo) model.Host {
+	data := toSubdomainData(info)
+	return model.Host{
+		Domain:        data.Domain,
+		Status:        data.Status,
+		ContentLength: data.ContentLength,
+		CNAME:         data.CNAME,
+		IPs:           data.IPs,
+		Tags:          data.Tags,
+		FinalURL:      data.FinalURL,
+		RedirectChain: data.RedirectChain,
+		URLs:          data.URLs,
+		Score:         data.Score,
+		CloudProvider: data.CloudProvider,
+		IsTLS:         data.IsTLS,
+		Language:      data.Language,
+		SaaSProvider:  data.SaaSProvider,
+		Title:         data.Title,
+		Server:        data.Server,
+		PoweredBy:     data.PoweredBy,
+		Technologies:  data.Technologies,
+		FaviconHash:   data.FaviconHash,
+		OpenPorts:     data.OpenPorts,
+		Provenance:    data.Provenance,
+		Sources:       data.Sources,
+		Screenshots:   data.Screenshots,
+		TLS:           data.TLS,
+		Owner:         data.Owner,
+		Team:          data.Team,
+		Notes:         data.Notes,
+	}
+}
+
+// formatJSON formats the results as a versioned JSON report
+func formatJSON(results []scorer.SubdomainInfo, targetDomain string, info ScanInfo) (string, error) {
+	report := model.NewReport(model.KindScore, targetDomain)
+	report.Nameservers = info.Nameservers
+	report.Baseline = info.Baseline
+	report.Buckets = info.Buckets
+	report.Apex = info.Apex
+	report.Caveats = info.Caveats
 	for _, info := range results {
-		cname := ""
-		if len(info.CNAMEs) > 0 {
-			cname = info.CNAMEs[0]
-		}
-		
-		data := SubdomainData{
-			Domain:        info.Subdomain,
-			Status:        info.HTTPStatus,
-			ContentLength: info.ContentLength,
-			CNAME:         cname,
-			CloudProvider: info.CloudProvider,
-			Score:         info.Score,
-			Tags:          info.Tags,
-			IsTLS:         info.IsTLS,
-		}
-		
-		jsonData = append(jsonData, data)
+		report.Hosts = append(report.Hosts, scoreHost(info))
 	}
+	report.Legend = hostLegend(report.Hosts)
+	report.Scan.HostCount = len(report.Hosts)
 	
-	jsonBytes, err := json.MarshalIndent(jsonData, "", "  ")
+	jsonBytes, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("error marshaling to JSON: %v", err)
 	}
@@ -155,7 +344,7 @@ func formatCSV(results []scorer.SubdomainInfo) (string, error) {
 	writer := csv.NewWriter(&buf)
 	
 	// Write header
-	header := []string{"Domain", "Status", "ContentLength", "CNAME", "CloudProvider", "Score", "Tags", "IsTLS"}
+	header := []string{"Domain", "Status", "ContentLength", "CNAME", "IPs", "CloudProvider", "Score", "Tags", "IsTLS", "FinalURL", "Language", "SaaSProvider", "Title", "Server", "PoweredBy", "Technologies", "FaviconHash", "OpenPorts", "Provenance", "Sources", "Screenshots", "CertFingerprint", "CertExpiry", "TLSVersion", "Owner", "Team", "Notes"}
 	if err := writer.Write(header); err != nil {
 		return "", fmt.Errorf("error writing CSV header: %v", err)
 	}
@@ -172,16 +361,41 @@ func formatCSV(results []scorer.SubdomainInfo) (string, error) {
 		if info.IsTLS {
 			isTLS = "true"
 		}
+		var certFingerprint, certExpiry, tlsVersion string
+		if info.TLS != nil {
+			certFingerprint = info.TLS.Fingerprint
+			certExpiry = info.TLS.NotAfter.Format(time.RFC3339)
+			tlsVersion = info.TLS.Version
+		}
 		
 		row := []string{
 			info.Subdomain,
 			fmt.Sprintf("%d", info.HTTPStatus),
 			fmt.Sprintf("%d", info.ContentLength),
 			cname,
+			strings.Join(info.IPs, ","),
 			info.CloudProvider,
 			fmt.Sprintf("%.2f", info.Score),
 			tags,
 			isTLS,
+			info.FinalURL,
+			info.Language,
+			info.SaaSProvider,
+			info.Title,
+			info.Server,
+			info.PoweredBy,
+			strings.Join(info.Technologies, ","),
+			faviconHash(info.FaviconHash),
+			joinPorts(info.OpenPorts, ","),
+			info.Provenance,
+			strings.Join(info.Sources, ","),
+			strings.Join(info.Screenshots, ","),
+			certFingerprint,
+			certExpiry,
+			tlsVersion,
+			info.Owner,
+			info.Team,
+			info.Notes,
 		}
 		
 		if err := writer.Write(row); err != nil {
@@ -198,27 +412,11 @@ func formatCSV(results []scorer.SubdomainInfo) (string, error) {
 }
 
 // formatHTML formats the results as HTML
-func formatHTML(results []scorer.SubdomainInfo, targetDomain string) (string, error) {
+func formatHTML(results []scorer.SubdomainInfo, targetDomain string, info ScanInfo) (string, error) {
 	var subdomains []SubdomainData
 	
 	for _, info := range results {
-		cname := ""
-		if len(info.CNAMEs) > 0 {
-			cname = info.CNAMEs[0]
-		}
-		
-		data := SubdomainData{
-			Domain:        info.Subdomain,
-			Status:        info.HTTPStatus,
-			ContentLength: info.ContentLength,
-			CNAME:         cname,
-			CloudProvider: info.CloudProvider,
-			Score:         info.Score,
-			Tags:          info.Tags,
-			IsTLS:         info.IsTLS,
-		}
-		
-		subdomains = append(subdomains, data)
+		subdomains = append(subdomains, toSubdomainData(info))
 	}
 	
 	data := HTMLTemplateData{
@@ -228,6 +426,14 @@ func formatHTML(results []scorer.SubdomainInfo, targetDomain string) (string, er
 		Subdomains:  subdomains,
 		DomainName:  targetDomain,
 		GeneratedBy: "Subscan",
+		SaaS:        saasEntries(results),
+		Nameservers: info.Nameservers,
+		Baseline:    info.Baseline,
+		Buckets:     info.Buckets,
+		Apex:        info.Apex,
+		Caveats:     info.Caveats,
+		Legend:      scoreLegend(results),
+		Screenshots: screenshotEntries(results),
 	}
 	
 	var buf bytes.Buffer
@@ -267,6 +473,37 @@ func writeHTMLReport(w io.Writer, data HTMLTemplateData) error {
             border-radius: 5px;
             margin-bottom: 20px;
         }
+        .caveats {
+            background-color: #fff8e1;
+            border-left: 4px solid #ffc107;
+            padding: 10px 15px;
+            margin-bottom: 20px;
+        }
+        .caveats h2 {
+            margin-top: 0;
+            font-size: 1.1em;
+        }
+        .gallery {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 15px;
+            margin-bottom: 20px;
+        }
+        .gallery figure {
+            margin: 0;
+            width: 320px;
+            border: 1px solid #ddd;
+            background-color: white;
+        }
+        .gallery img {
+            width: 100%;
+            display: block;
+        }
+        .gallery figcaption {
+            padding: 5px 8px;
+            font-size: 0.9em;
+            word-break: break-all;
+        }
         table {
             width: 100%;
             border-collapse: collapse;
@@ -308,6 +545,7 @@ func writeHTMLReport(w io.Writer, data HTMLTemplateData) error {
         .tag-REDIRECT { background-color: #2196f3; color: white; }
         .tag-LARGE { background-color: #009688; color: white; }
         .tag-cloud { background-color: #3f51b5; color: white; }
+        .tag-vuln { background-color: #f44336; color: white; }
         footer {
             margin-top: 40px;
             text-align: center;
@@ -325,6 +563,15 @@ func writeHTMLReport(w io.Writer, data HTMLTemplateData) error {
         <p><strong>Subdomains Found:</strong> {{ .Count }}</p>
     </div>
     
+    {{ if .Caveats }}
+    <div class="caveats">
+        <h2>Coverage Caveats</h2>
+        <ul>
+            {{ range .Caveats }}<li>{{ . }}</li>{{ end }}
+        </ul>
+    </div>
+    {{ end }}
+    
     <table>
         <thead>
             <tr>
@@ -332,17 +579,22 @@ func writeHTMLReport(w io.Writer, data HTMLTemplateData) error {
                 <th>Status</th>
                 <th>Size</th>
                 <th>CNAME</th>
+                <th>Title</th>
+                <th>Technologies</th>
                 <th>Score</th>
                 <th>Tags</th>
+                <th>Owner</th>
             </tr>
         </thead>
         <tbody>
             {{ range .Subdomains }}
             <tr>
-                <td>{{ if .IsTLS }}<span title="HTTPS Available">🔒</span>{{ end }} {{ .Domain }}</td>
+                <td>{{ if .IsTLS }}<span title="HTTPS Available{{ with .TLS }}, certificate expires {{ .NotAfter.Format "2006-01-02" }}{{ end }}">🔒</span>{{ end }} {{ .Domain }}{{ if .Sources }}<br><small>Sources: {{ range $i, $s := .Sources }}{{ if $i }}, {{ end }}{{ $s }}{{ end }}</small>{{ end }}</td>
                 <td>{{ .Status }}</td>
                 <td>{{ if gt .ContentLength 0 }}{{ .ContentLength }} bytes{{ end }}</td>
-                <td>{{ if .CloudProvider }}<span class="tag tag-cloud">{{ .CloudProvider }}</span>{{ end }} {{ .CNAME }}</td>
+                <td>{{ if .CloudProvider }}<span class="tag tag-cloud">{{ .CloudProvider }}</span>{{ end }} {{ .CNAME }}{{ if .OpenPorts }}<br><small>Ports: {{ range .OpenPorts }}{{ . }} {{ end }}</small>{{ end }}{{ if .FinalURL }}<br><small title="{{ range .RedirectChain }}{{ . }} &#8594; {{ end }}">&#8594; {{ .FinalURL }}</small>{{ end }}</td>
+                <td>{{ .Title }}{{ if .Server }}<br><small>Server: {{ .Server }}</small>{{ end }}{{ if .PoweredBy }}<br><small>X-Powered-By: {{ .PoweredBy }}</small>{{ end }}</td>
+                <td>{{ range .Technologies }}{{ . }}<br>{{ end }}{{ if .FaviconHash }}<small>Favicon: {{ .FaviconHash }}</small>{{ end }}</td>
                 <td>{{ printf "%.1f" .Score }}</td>
                 <td>
                     {{ range .Tags }}
@@ -357,11 +609,166 @@ func writeHTMLReport(w io.Writer, data HTMLTemplateData) error {
                     ">{{ . }}</span>
                     {{ end }}
                 </td>
+                <td>{{ .Owner }}{{ if .Team }} ({{ .Team }}){{ end }}{{ if .Notes }}<br><small>{{ .Notes }}</small>{{ end }}</td>
             </tr>
             {{ end }}
         </tbody>
     </table>
     
+    {{ if .SaaS }}
+    <h2>SaaS Inventory</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Provider</th>
+                <th>Subdomains</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .SaaS }}
+            <tr>
+                <td>{{ .Provider }}</td>
+                <td>{{ range .Subdomains }}{{ . }}<br>{{ end }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+    
+    {{ if .Baseline }}
+    <h2>Baseline</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Host</th>
+                <th>Status</th>
+                <th>Title</th>
+                <th>Technologies</th>
+                <th>Certificate</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Baseline }}
+            <tr>
+                <td>{{ if .URL }}<a href="{{ .URL }}" target="_blank">{{ .Host }}</a>{{ else }}{{ .Host }}{{ end }}</td>
+                <td>{{ if .Error }}{{ .Error }}{{ else }}{{ .Status }}{{ end }}</td>
+                <td>{{ .Title }}</td>
+                <td>{{ range .Technologies }}{{ . }}<br>{{ end }}</td>
+                <td>{{ if .CertIssuer }}{{ .CertSubject }} by {{ .CertIssuer }}, expires {{ .CertExpires }}{{ end }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+    
+    {{ with .Apex }}
+    <h2>Apex Records of {{ .Domain }}</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Type</th>
+                <th>Value</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .NS }}<tr><td>NS</td><td>{{ . }}</td></tr>
+            {{ end }}{{ range .MX }}<tr><td>MX</td><td>{{ .Preference }} {{ .Host }}</td></tr>
+            {{ end }}{{ if .SOA }}<tr><td>SOA</td><td>{{ .SOA }}</td></tr>
+            {{ end }}{{ range .TXT }}<tr><td>TXT</td><td>{{ . }}</td></tr>
+            {{ end }}{{ if .DMARC }}<tr><td>DMARC</td><td>{{ .DMARC }}</td></tr>
+            {{ end }}{{ range .SRV }}<tr><td>SRV</td><td>{{ . }}</td></tr>
+            {{ end }}{{ with .Verifications }}<tr><td>Verified with</td><td>{{ range . }}{{ . }}<br>{{ end }}</td></tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+    
+    {{ if .Nameservers }}
+    <h2>Nameservers</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Nameserver</th>
+                <th>Addresses</th>
+                <th>Version</th>
+                <th>Hostname</th>
+                <th>Zone Transfer</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Nameservers }}
+            <tr>
+                <td>{{ .Name }}</td>
+                <td>{{ range .Addresses }}{{ . }}<br>{{ end }}</td>
+                <td>{{ .Version }}</td>
+                <td>{{ .Hostname }}</td>
+                <td>{{ if .ZoneTransfer }}<span class="tag tag-vuln">ALLOWED</span>{{ end }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+    
+    {{ if .Buckets }}
+    <h2>Buckets</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Bucket</th>
+                <th>Provider</th>
+                <th>Access</th>
+                <th>Objects</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Buckets }}
+            <tr>
+                <td><a href="{{ .URL }}" target="_blank">{{ .Name }}</a></td>
+                <td>{{ .Provider }}</td>
+                <td>{{ .Access }}</td>
+                <td>{{ range .Objects }}{{ . }}<br>{{ end }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+    
+    {{ if .Screenshots }}
+    <h2>Screenshots</h2>
+    <div class="gallery">
+        {{ range .Screenshots }}
+        <figure>
+            <a href="{{ .Path }}" target="_blank"><img src="{{ .Thumbnail }}" alt="{{ .URL }}" loading="lazy"></a>
+            <figcaption>{{ .Domain }}<br><small>{{ .URL }}</small></figcaption>
+        </figure>
+        {{ end }}
+    </div>
+    {{ end }}
+    
+    {{ if .Legend }}
+    <h2>Tag Legend</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Tag</th>
+                <th>Severity</th>
+                <th>Category</th>
+                <th>Description</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Legend }}
+            <tr>
+                <td><span class="tag">{{ .Name }}</span></td>
+                <td>{{ .Severity }}</td>
+                <td>{{ .Category }}</td>
+                <td>{{ .Description }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+    
     <footer>
         <p>Generated by {{ .GeneratedBy }} on {{ .Date }}</p>
     </footer>
@@ -377,7 +784,7 @@ func writeHTMLReport(w io.Writer, data HTMLTemplateData) error {
 }
 
 // formatMarkdown formats the results as Markdown
-func formatMarkdown(results []scorer.SubdomainInfo, targetDomain string) string {
+func formatMarkdown(results []scorer.SubdomainInfo, targetDomain string, info ScanInfo) string {
 	var output strings.Builder
 	
 	// Write header
@@ -387,8 +794,8 @@ func formatMarkdown(results []scorer.SubdomainInfo, targetDomain string) string
 	output.WriteString(fmt.Sprintf("**Subdomains Found:** %d  \n\n", len(results)))
 	
 	// Table header
-	output.WriteString("| Domain | Status | Size | CNAME | Score | Tags |\n")
-	output.WriteString("|--------|--------|------|-------|-------|------|\n")
+	output.WriteString("| Domain | Status | Size | CNAME | Title | Technologies | Score | Tags | Sources |\n")
+	output.WriteString("|--------|--------|------|-------|-------|--------------|-------|------|---------|\n")
 	
 	// Table rows
 	for _, info := range results {
@@ -427,11 +834,36 @@ func formatMarkdown(results []scorer.SubdomainInfo, targetDomain string) string
 			cname = fmt.Sprintf("%s (`%s`)", cname, info.CloudProvider)
 		}
 		
-		line := fmt.Sprintf("| %s%s | %d | %s | %s | %.1f | %s |\n",
-			tlsIndicator, info.Subdomain, info.HTTPStatus, size, cname, info.Score, tags)
+		// Show where redirects ended up
+		if info.FinalURL != "" {
+			cname = fmt.Sprintf("%s → %s", cname, info.FinalURL)
+		}
+		
+		// Favicon hashes follow the technologies
+		technologies := strings.Join(info.Technologies, ", ")
+		if info.FaviconHash != 0 {
+			technologies = strings.TrimPrefix(fmt.Sprintf("%s, favicon `%d`", technologies, info.FaviconHash), ", ")
+		}
+		
+		// Pipes in titles would split the cell
+		title := strings.ReplaceAll(info.Title, "|", "\\|")
+		
+		line := fmt.Sprintf("| %s%s | %d | %s | %s | %s | %s | %.1f | %s | %s |\n",
+			tlsIndicator, info.Subdomain, info.HTTPStatus, size, cname, title, technologies, info.Score, tags, strings.Join(info.Sources, ", "))
 		output.WriteString(line)
 	}
 	
+	// SaaS inventory
+	if entries := saasEntries(results); len(entries) > 0 {
+		output.WriteString("\n## SaaS Inventory\n\n")
+		output.WriteString("| Provider | Subdomains |\n")
+		output.WriteString("|----------|------------|\n")
+		for _, entry := range entries {
+			output.WriteString(fmt.Sprintf("| %s | %s |\n", entry.Provider, strings.Join(entry.Subdomains, ", ")))
+		}
+	}
+	output.WriteString(info.markdown())
+	
 	// Footer
 	output.WriteString("\n\n*Generated by Subscan*\n")
 	
@@ -440,17 +872,31 @@ func formatMarkdown(results []scorer.SubdomainInfo, targetDomain string) string
 
 // FormatProbeResults formats probe results in the specified format
 func FormatProbeResults(results []probe.ProbeResult, format string) (string, error) {
+	return FormatProbeScan(results, format, ScanInfo{})
+}
+
+// FormatProbeScan formats probe results in the specified format, adding the
+// target-level data of the scan to the formats with a section for it
+func FormatProbeScan(results []probe.ProbeResult, format string, info ScanInfo) (string, error) {
 	switch format {
 	case FormatJSON:
-		return formatProbeResultsJSON(results)
+		return formatProbeResultsJSON(results, info)
+	case FormatJSONL:
+		return formatProbeResultsJSONL(results)
 	case FormatCSV:
 		return formatProbeResultsCSV(results)
 	case FormatHTML:
-		return formatProbeResultsHTML(results)
+		return formatProbeResultsHTML(results, info)
 	case FormatMarkdown:
-		return formatProbeResultsMarkdown(results), nil
+		return formatProbeResultsMarkdown(results, info), nil
 	case FormatPlain:
-		return probe.FormatProbeResults(results, true), nil
+		return probe.FormatProbeResults(results, true) + info.plain(), nil
+	case FormatNmap, FormatMasscan:
+		return formatProbeTargets(results, format)
+	case FormatURLs:
+		return formatProbeURLs(results), nil
+	case FormatDefectDojo:
+		return formatProbeResultsDefectDojo(results)
 	default:
 		// Format is not supported
 		return "", fmt.Errorf("unsupported format for probe results: %s", format)
@@ -458,8 +904,15 @@ func FormatProbeResults(results []probe.ProbeResult, format string) (string, err
 }
 
 // formatProbeResultsJSON formats probe results as JSON
-func formatProbeResultsJSON(results []probe.ProbeResult) (string, error) {
-	jsonBytes, err := json.MarshalIndent(results, "", "  ")
+func formatProbeResultsJSON(results []probe.ProbeResult, info ScanInfo) (string, error) {
+	report := probe.NewReport(results, "")
+	report.Nameservers = info.Nameservers
+	report.Baseline = info.Baseline
+	report.Buckets = info.Buckets
+	report.Apex = info.Apex
+	report.Caveats = info.Caveats
+	report.Legend = hostLegend(report.Hosts)
+	jsonBytes, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("error marshaling probe results to JSON: %v", err)
 	}
@@ -473,7 +926,7 @@ func formatProbeResultsCSV(results []probe.ProbeResult) (string, error) {
 	writer := csv.NewWriter(&buf)
 	
 	// Write header
-	header := []string{"Domain", "CNAME", "HTTPStatus", "ContentLength", "IsTakeover", "S3Public", "S3Private", "ExposedFiles", "OpenRedirect", "RedirectURL", "Vulnerabilities", "Tags"}
+	header := []string{"Domain", "CNAME", "IPs", "HTTPStatus", "ContentLength", "IsTakeover", "S3Public", "S3Private", "ExposedFiles", "OpenRedirect", "RedirectURL", "FinalURL", "Vulnerabilities", "Tags", "Owner", "Team", "Notes", "Sources", "Error", "Skipped"}
 	if err := writer.Write(header); err != nil {
 		return "", fmt.Errorf("error writing CSV header: %v", err)
 	}
@@ -507,6 +960,7 @@ func formatProbeResultsCSV(results []probe.ProbeResult) (string, error) {
 		row := []string{
 			result.Domain,
 			result.CNAME,
+			strings.Join(result.IPs, "|"),
 			fmt.Sprintf("%d", result.HTTPStatus),
 			fmt.Sprintf("%d", result.ContentLength),
 			isTakeover,
@@ -515,8 +969,15 @@ func formatProbeResultsCSV(results []probe.ProbeResult) (string, error) {
 			exposedFiles,
 			openRedirect,
 			result.RedirectURL,
+			result.FinalURL,
 			vulnerabilities,
 			tags,
+			result.Owner,
+			result.Team,
+			result.Notes,
+			strings.Join(result.Sources, "|"),
+			result.Error,
+			result.Skipped,
 		}
 		
 		if err := writer.Write(row); err != nil {
@@ -537,7 +998,17 @@ type ProbeTemplateData struct {
 	Title       string
 	Date        string
 	Count       int
+	// Results are sorted by severity, most severe first
 	Results     []probe.ProbeResult
+	Groups      []probe.FindingGroup
+	Errored     []probe.ProbeResult
+	Skipped     []probe.ProbeResult
+	Nameservers []model.Nameserver
+	Baseline    []model.Profile
+	Buckets     []model.Bucket
+	Apex        *model.ApexRecords
+	Caveats     []string
+	Legend      []model.Tag
 	GeneratedBy string
 	Stats       struct {
 		Total        int
@@ -545,35 +1016,47 @@ type ProbeTemplateData struct {
 		S3Issues     int
 		ExposedFiles int
 		OpenRedirect int
+		Errored      int
+		Skipped      int
 	}
 }
 
 // formatProbeResultsHTML formats probe results as HTML
-func formatProbeResultsHTML(results []probe.ProbeResult) (string, error) {
+func formatProbeResultsHTML(results []probe.ProbeResult, info ScanInfo) (string, error) {
+	sorted := sortedBySeverity(results)
 	data := ProbeTemplateData{
 		Title:       "Subscan Probe Results",
 		Date:        time.Now().Format("2006-01-02 15:04:05"),
 		Count:       len(results),
-		Results:     results,
+		Results:     sorted,
+		Groups:      probe.GroupFindings(results),
+		Nameservers: info.Nameservers,
+		Baseline:    info.Baseline,
+		Buckets:     info.Buckets,
+		Apex:        info.Apex,
+		Caveats:     info.Caveats,
+		Legend:      probeLegend(results),
 		GeneratedBy: "Subscan",
 	}
 	
-	// Calculate statistics
+	// Calculate statistics; finding counts match the sizes of the sections
+	// the stat boxes link to
+	groupSizes := findingGroupSizes(data.Groups)
 	data.Stats.Total = len(results)
-	for _, result := range results {
-		if result.IsTakeover {
-			data.Stats.Takeovers++
-		}
-		if result.S3Public {
-			data.Stats.S3Issues++
-		}
-		if len(result.ExposedFiles) > 0 {
-			data.Stats.ExposedFiles++
+	data.Stats.Takeovers = groupSizes[probe.CheckTakeover]
+	data.Stats.S3Issues = groupSizes[probe.CheckS3]
+	data.Stats.ExposedFiles = groupSizes[probe.CheckSensitiveFiles]
+	data.Stats.OpenRedirect = groupSizes[probe.CheckOpenRedirect]
+	for _, result := range sorted {
+		if result.Error != "" {
+			data.Errored = append(data.Errored, result)
 		}
-		if result.OpenRedirect {
-			data.Stats.OpenRedirect++
+		if result.Skipped != "" {
+			data.Skipped = append(data.Skipped, result)
 		}
 	}
+	data.Stats.Errored = len(data.Errored)
+	data.Stats.Skipped = len(data.Skipped)
 	
 	var buf bytes.Buffer
 	if err := writeProbeHTMLReport(&buf, data); err != nil {
@@ -583,6 +1066,36 @@ func formatProbeResultsHTML(results []probe.ProbeResult) (string, error) {
 	return buf.String(), nil
 }
 
+// sortedBySeverity returns a copy of the results sorted by severity
+func sortedBySeverity(results []probe.ProbeResult) []probe.ProbeResult {
+	sorted := make([]probe.ProbeResult, len(results))
+	copy(sorted, results)
+	probe.SortBySeverity(sorted)
+	return sorted
+}
+
+// findingGroupSizes returns the number of hosts of each finding group by check
+func findingGroupSizes(groups []probe.FindingGroup) map[string]int {
+	sizes := make(map[string]int)
+	for _, group := range groups {
+		sizes[group.Check] = len(group.Entries)
+	}
+	return sizes
+}
+
+// groupAnchor returns the anchor of a finding group's report section
+func groupAnchor(check string) string {
+	if check == "" {
+		return "other-findings"
+	}
+	return check
+}
+
+// hostAnchor returns the anchor of a host's row in the report
+func hostAnchor(domain string) string {
+	return "host-" + domain
+}
+
 // writeProbeHTMLReport writes an HTML report for probe results
 func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
 	htmlTemplate := `<!DOCTYPE html>
@@ -615,6 +1128,25 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
             gap: 10px;
             margin-bottom: 20px;
         }
+        .caveats {
+            background-color: #fff8e1;
+            border-left: 4px solid #ffc107;
+            padding: 10px 15px;
+            margin-bottom: 20px;
+        }
+        .caveats h2 {
+            margin-top: 0;
+            font-size: 1.1em;
+        }
+        .remediation {
+            background-color: #e8f5e9;
+            border-left: 4px solid #4caf50;
+            padding: 10px 15px;
+            margin-bottom: 20px;
+        }
+        .remediation p {
+            margin: 0 0 5px 0;
+        }
         .stat-box {
             background-color: #f8f8f8;
             border: 1px solid #ddd;
@@ -628,6 +1160,13 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
             background-color: #fff3cd;
             border-color: #ffecb5;
         }
+        a.stat-box {
+            color: inherit;
+            text-decoration: none;
+        }
+        a.stat-box:hover {
+            border-color: #999;
+        }
         .stat-box h3 {
             margin: 0;
             font-size: 14px;
@@ -675,6 +1214,28 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
         .tag.warning {
             background-color: #ffd7d7;
         }
+        .severity {
+            display: inline-block;
+            padding: 2px 8px;
+            border-radius: 3px;
+            font-size: 12px;
+            font-weight: bold;
+            text-transform: uppercase;
+            color: #fff;
+            background-color: #6c757d;
+        }
+        .severity.critical {
+            background-color: #b02a37;
+        }
+        .severity.high {
+            background-color: #dc3545;
+        }
+        .severity.medium {
+            background-color: #fd7e14;
+        }
+        .severity.low {
+            background-color: #0d6efd;
+        }
         .vuln-list {
             margin: 0;
             padding-left: 20px;
@@ -694,32 +1255,93 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
     </header>
 
     <div class="stats">
-        <div class="stat-box">
+        <a class="stat-box" href="#all-hosts">
             <h3>Total Domains</h3>
             <p>{{ .Stats.Total }}</p>
-        </div>
-        <div class="stat-box {{ if gt .Stats.Takeovers 0 }}warning{{ end }}">
+        </a>
+        <a class="stat-box {{ if gt .Stats.Takeovers 0 }}warning{{ end }}" href="{{ if gt .Stats.Takeovers 0 }}#takeover{{ else }}#all-hosts{{ end }}">
             <h3>Takeover Candidates</h3>
             <p>{{ .Stats.Takeovers }}</p>
-        </div>
-        <div class="stat-box {{ if gt .Stats.S3Issues 0 }}warning{{ end }}">
+        </a>
+        <a class="stat-box {{ if gt .Stats.S3Issues 0 }}warning{{ end }}" href="{{ if gt .Stats.S3Issues 0 }}#s3{{ else }}#all-hosts{{ end }}">
             <h3>S3 Bucket Issues</h3>
             <p>{{ .Stats.S3Issues }}</p>
-        </div>
-        <div class="stat-box {{ if gt .Stats.ExposedFiles 0 }}warning{{ end }}">
+        </a>
+        <a class="stat-box {{ if gt .Stats.ExposedFiles 0 }}warning{{ end }}" href="{{ if gt .Stats.ExposedFiles 0 }}#sensitive-files{{ else }}#all-hosts{{ end }}">
             <h3>Exposed Files</h3>
             <p>{{ .Stats.ExposedFiles }}</p>
-        </div>
-        <div class="stat-box {{ if gt .Stats.OpenRedirect 0 }}warning{{ end }}">
+        </a>
+        <a class="stat-box {{ if gt .Stats.OpenRedirect 0 }}warning{{ end }}" href="{{ if gt .Stats.OpenRedirect 0 }}#open-redirect{{ else }}#all-hosts{{ end }}">
             <h3>Open Redirects</h3>
             <p>{{ .Stats.OpenRedirect }}</p>
-        </div>
+        </a>
+        <a class="stat-box {{ if gt .Stats.Errored 0 }}warning{{ end }}" href="{{ if gt .Stats.Errored 0 }}#errored{{ else }}#all-hosts{{ end }}">
+            <h3>Errored Hosts</h3>
+            <p>{{ .Stats.Errored }}</p>
+        </a>
+        <a class="stat-box" href="{{ if gt .Stats.Skipped 0 }}#skipped{{ else }}#all-hosts{{ end }}">
+            <h3>Skipped Hosts</h3>
+            <p>{{ .Stats.Skipped }}</p>
+        </a>
+    </div>
+
+    {{ if .Caveats }}
+    <div class="caveats">
+        <h2>Coverage Caveats</h2>
+        <ul>
+            {{ range .Caveats }}<li>{{ . }}</li>{{ end }}
+        </ul>
     </div>
+    {{ end }}
 
-    <h2>Vulnerability Details</h2>
+    {{ if .Groups }}
+    <h2>Findings by Type</h2>
+    {{ range $group := .Groups }}
+    <h3 id="{{ anchor .Check }}">{{ .Title }} ({{ len .Entries }})</h3>
     <table>
         <thead>
             <tr>
+                <th>Severity</th>
+                <th>Domain</th>
+                <th>Findings</th>
+                <th>Evidence</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Entries }}
+                <tr>
+                    <td><span class="severity {{ .Severity }}">{{ .Severity }}</span></td>
+                    <td><a href="#{{ hostAnchor .Result.Domain }}">{{ .Result.Domain }}</a></td>
+                    <td>
+                        <ul class="vuln-list">
+                            {{ range .Vulnerabilities }}
+                                <li>{{ . }}</li>
+                            {{ end }}
+                        </ul>
+                    </td>
+                    <td>
+                        {{ if eq $group.Check "open-redirect" }}{{ .Result.RedirectURL }}{{ else if eq $group.Check "sensitive-files" }}{{ range .Result.ExposedFiles }}{{ . }}<br>{{ end }}{{ else }}{{ .Result.CNAME }}{{ end }}
+                    </td>
+                </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ if .Remediations }}
+    <div class="remediation">
+        <strong>Remediation</strong>
+        {{ range .Remediations }}
+        <p>{{ .Summary }}{{ range .References }} <a href="{{ . }}" target="_blank" rel="noopener">Reference</a>{{ end }}</p>
+        {{ end }}
+    </div>
+    {{ end }}
+    {{ end }}
+    {{ end }}
+
+    <h2 id="all-hosts">Vulnerability Details</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Severity</th>
                 <th>Domain</th>
                 <th>Issues</th>
                 <th>Details</th>
@@ -728,8 +1350,9 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
         </thead>
         <tbody>
             {{ range .Results }}
-                <tr{{ if or .IsTakeover .S3Public (len .ExposedFiles) .OpenRedirect (len .Vulnerabilities) }} class="has-issues"{{ end }}>
-                    <td>{{ .Domain }}</td>
+                <tr id="{{ hostAnchor .Domain }}"{{ if or .IsTakeover .S3Public (len .ExposedFiles) .OpenRedirect (len .Vulnerabilities) }} class="has-issues"{{ end }}>
+                    <td>{{ with severity . }}{{ if gt . 0 }}<span class="severity {{ . }}">{{ . }}</span>{{ end }}{{ end }}</td>
+                    <td>{{ .Domain }}{{ if .Sources }}<br><small>Sources: {{ range $i, $s := .Sources }}{{ if $i }}, {{ end }}{{ $s }}{{ end }}</small>{{ end }}</td>
                     <td>
                         <ul class="vuln-list">
                             {{ range .Vulnerabilities }}
@@ -742,6 +1365,14 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
                             <strong>CNAME:</strong> {{ .CNAME }}<br>
                         {{ end }}
                         
+                        {{ if .Error }}
+                            <strong>Error:</strong> {{ .Error }}<br>
+                        {{ end }}
+                        
+                        {{ if .Skipped }}
+                            <strong>Skipped:</strong> {{ .Skipped }}<br>
+                        {{ end }}
+                        
                         {{ if gt .HTTPStatus 0 }}
                             <strong>Status:</strong> {{ .HTTPStatus }}<br>
                         {{ end }}
@@ -754,6 +1385,18 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
                             <strong>Redirect URL:</strong> {{ .RedirectURL }}<br>
                         {{ end }}
                         
+                        {{ if or .Owner .Team }}
+                            <strong>Owner:</strong> {{ .Owner }}{{ if .Team }} ({{ .Team }}){{ end }}<br>
+                        {{ end }}
+                        
+                        {{ if .Notes }}
+                            <strong>Notes:</strong> {{ .Notes }}<br>
+                        {{ end }}
+                        
+                        {{ if .FinalURL }}
+                            <strong>Final URL:</strong> {{ .FinalURL }} ({{ len .RedirectChain }} URLs in chain)<br>
+                        {{ end }}
+                        
                         {{ if len .ExposedFiles }}
                             <strong>Exposed Files:</strong>
                             <ul class="vuln-list">
@@ -765,7 +1408,7 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
                     </td>
                     <td>
                         {{ range .Tags }}
-                            <span class="tag {{ if or (eq . "TAKEOVER-CANDIDATE") (eq . "PUBLIC-S3") (eq . "OPEN-REDIRECT") }}warning{{ end }}">{{ . }}</span>
+                            <span class="tag {{ if or (eq . "TAKEOVER-CANDIDATE") (eq . "PUBLIC-S3") (eq . "OPEN-REDIRECT") (eq . "ZONE-TRANSFER") }}warning{{ end }}">{{ . }}</span>
                         {{ end }}
                     </td>
                 </tr>
@@ -773,13 +1416,158 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
         </tbody>
     </table>
 
+    {{ if .Errored }}
+    <h2 id="errored">Errored Hosts</h2>
+    <ul>
+        {{ range .Errored }}
+            <li><a href="#{{ hostAnchor .Domain }}">{{ .Domain }}</a>: {{ .Error }}</li>
+        {{ end }}
+    </ul>
+    {{ end }}
+
+    {{ if .Skipped }}
+    <h2 id="skipped">Skipped Hosts</h2>
+    <ul>
+        {{ range .Skipped }}
+            <li><a href="#{{ hostAnchor .Domain }}">{{ .Domain }}</a>: {{ .Skipped }}</li>
+        {{ end }}
+    </ul>
+    {{ end }}
+
+    {{ if .Baseline }}
+    <h2>Baseline</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Host</th>
+                <th>Status</th>
+                <th>Title</th>
+                <th>Technologies</th>
+                <th>Certificate</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Baseline }}
+            <tr>
+                <td>{{ if .URL }}<a href="{{ .URL }}" target="_blank">{{ .Host }}</a>{{ else }}{{ .Host }}{{ end }}</td>
+                <td>{{ if .Error }}{{ .Error }}{{ else }}{{ .Status }}{{ end }}</td>
+                <td>{{ .Title }}</td>
+                <td>{{ range .Technologies }}{{ . }}<br>{{ end }}</td>
+                <td>{{ if .CertIssuer }}{{ .CertSubject }} by {{ .CertIssuer }}, expires {{ .CertExpires }}{{ end }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+
+    {{ with .Apex }}
+    <h2>Apex Records of {{ .Domain }}</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Type</th>
+                <th>Value</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .NS }}<tr><td>NS</td><td>{{ . }}</td></tr>
+            {{ end }}{{ range .MX }}<tr><td>MX</td><td>{{ .Preference }} {{ .Host }}</td></tr>
+            {{ end }}{{ if .SOA }}<tr><td>SOA</td><td>{{ .SOA }}</td></tr>
+            {{ end }}{{ range .TXT }}<tr><td>TXT</td><td>{{ . }}</td></tr>
+            {{ end }}{{ if .DMARC }}<tr><td>DMARC</td><td>{{ .DMARC }}</td></tr>
+            {{ end }}{{ range .SRV }}<tr><td>SRV</td><td>{{ . }}</td></tr>
+            {{ end }}{{ with .Verifications }}<tr><td>Verified with</td><td>{{ range . }}{{ . }}<br>{{ end }}</td></tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+    
+    {{ if .Nameservers }}
+    <h2>Nameservers</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Nameserver</th>
+                <th>Addresses</th>
+                <th>Version</th>
+                <th>Hostname</th>
+                <th>Zone Transfer</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Nameservers }}
+            <tr>
+                <td>{{ .Name }}</td>
+                <td>{{ range .Addresses }}{{ . }}<br>{{ end }}</td>
+                <td>{{ .Version }}</td>
+                <td>{{ .Hostname }}</td>
+                <td>{{ if .ZoneTransfer }}<span class="tag warning">ALLOWED</span>{{ end }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+    
+    {{ if .Buckets }}
+    <h2>Buckets</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Bucket</th>
+                <th>Provider</th>
+                <th>Access</th>
+                <th>Objects</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Buckets }}
+            <tr>
+                <td><a href="{{ .URL }}" target="_blank">{{ .Name }}</a></td>
+                <td>{{ .Provider }}</td>
+                <td>{{ .Access }}</td>
+                <td>{{ range .Objects }}{{ . }}<br>{{ end }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+
+    {{ if .Legend }}
+    <h2>Tag Legend</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Tag</th>
+                <th>Severity</th>
+                <th>Category</th>
+                <th>Description</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Legend }}
+            <tr>
+                <td><span class="tag">{{ .Name }}</span></td>
+                <td>{{ .Severity }}</td>
+                <td>{{ .Category }}</td>
+                <td>{{ .Description }}</td>
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+
     <footer>
         <p>Generated by Subscan on {{ .Date }}</p>
     </footer>
 </body>
 </html>`
 
-	tmpl, err := template.New("probeReport").Parse(htmlTemplate)
+	funcs := template.FuncMap{
+		"severity":   probe.ResultSeverity,
+		"anchor":     groupAnchor,
+		"hostAnchor": hostAnchor,
+	}
+	tmpl, err := template.New("probeReport").Funcs(funcs).Parse(htmlTemplate)
 	if err != nil {
 		return err
 	}
@@ -788,50 +1576,72 @@ func writeProbeHTMLReport(w io.Writer, data ProbeTemplateData) error {
 }
 
 // formatProbeResultsMarkdown formats probe results as Markdown
-func formatProbeResultsMarkdown(results []probe.ProbeResult) string {
+func formatProbeResultsMarkdown(results []probe.ProbeResult, info ScanInfo) string {
 	var md strings.Builder
 	
 	// Add title and timestamp
 	md.WriteString("# Subscan Probe Results\n\n")
 	md.WriteString(fmt.Sprintf("Generated on: %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
 	
-	// Count statistics
-	var takeovers, s3Issues, exposedFiles, openRedirects int
-	
-	for _, result := range results {
-		if result.IsTakeover {
-			takeovers++
+	// Count statistics; finding counts match the sections they link to
+	sorted := sortedBySeverity(results)
+	groups := probe.GroupFindings(results)
+	groupSizes := findingGroupSizes(groups)
+	var errored, skipped []probe.ProbeResult
+	for _, result := range sorted {
+		if result.Error != "" {
+			errored = append(errored, result)
 		}
-		if result.S3Public {
-			s3Issues++
-		}
-		if len(result.ExposedFiles) > 0 {
-			exposedFiles++
-		}
-		if result.OpenRedirect {
-			openRedirects++
+		if result.Skipped != "" {
+			skipped = append(skipped, result)
 		}
 	}
 	
-	// Add summary
+	// Add summary, linking each count to its section
 	md.WriteString("## Summary\n\n")
 	md.WriteString("| Category | Count |\n")
 	md.WriteString("|----------|-------|\n")
-	md.WriteString(fmt.Sprintf("| Total domains | %d |\n", len(results)))
-	md.WriteString(fmt.Sprintf("| Takeover candidates | %d |\n", takeovers))
-	md.WriteString(fmt.Sprintf("| S3 bucket issues | %d |\n", s3Issues))
-	md.WriteString(fmt.Sprintf("| Exposed sensitive files | %d |\n", exposedFiles))
-	md.WriteString(fmt.Sprintf("| Open redirects | %d |\n", openRedirects))
+	md.WriteString(fmt.Sprintf("| [Total domains](#all-hosts) | %d |\n", len(results)))
+	md.WriteString(markdownSummaryRow("Takeover candidates", groupAnchor(probe.CheckTakeover), groupSizes[probe.CheckTakeover]))
+	md.WriteString(markdownSummaryRow("S3 bucket issues", groupAnchor(probe.CheckS3), groupSizes[probe.CheckS3]))
+	md.WriteString(markdownSummaryRow("Exposed sensitive files", groupAnchor(probe.CheckSensitiveFiles), groupSizes[probe.CheckSensitiveFiles]))
+	md.WriteString(markdownSummaryRow("Open redirects", groupAnchor(probe.CheckOpenRedirect), groupSizes[probe.CheckOpenRedirect]))
+	md.WriteString(markdownSummaryRow("Errored hosts", "errored", len(errored)))
+	md.WriteString(markdownSummaryRow("Skipped hosts", "skipped", len(skipped)))
 	
-	md.WriteString("\n## Vulnerability Details\n\n")
+	// Findings grouped by type, most severe hosts first
+	if len(groups) > 0 {
+		md.WriteString("\n## Findings by Type\n")
+	}
+	for _, group := range groups {
+		md.WriteString(fmt.Sprintf("\n<a id=\"%s\"></a>\n### %s (%d)\n\n", groupAnchor(group.Check), group.Title, len(group.Entries)))
+		md.WriteString("| Severity | Domain | Findings |\n")
+		md.WriteString("|----------|--------|----------|\n")
+		for _, entry := range group.Entries {
+			md.WriteString(fmt.Sprintf("| %s | [%s](#%s) | %s |\n", strings.ToUpper(entry.Severity.String()), entry.Result.Domain, hostAnchor(entry.Result.Domain), strings.Join(entry.Vulnerabilities, ", ")))
+		}
+		if len(group.Remediations) > 0 {
+			md.WriteString("\n**Remediation:**\n\n")
+			for _, remediation := range group.Remediations {
+				md.WriteString("- " + remediation.Summary)
+				for i, reference := range remediation.References {
+					md.WriteString(fmt.Sprintf(" [[%d]](%s)", i+1, reference))
+				}
+				md.WriteString("\n")
+			}
+		}
+	}
 	
-	// List vulnerable domains
-	for _, result := range results {
+	md.WriteString("\n<a id=\"all-hosts\"></a>\n## Vulnerability Details\n\n")
+	
+	// List vulnerable domains, most severe first
+	for _, result := range sorted {
 		if len(result.Vulnerabilities) == 0 {
 			continue // Skip non-vulnerable domains
 		}
 		
-		md.WriteString(fmt.Sprintf("### %s\n\n", result.Domain))
+		md.WriteString(fmt.Sprintf("<a id=\"%s\"></a>\n### %s\n\n", hostAnchor(result.Domain), result.Domain))
+		md.WriteString(fmt.Sprintf("**Severity:** %s\n\n", strings.ToUpper(probe.ResultSeverity(result).String())))
 		
 		if result.CNAME != "" {
 			md.WriteString(fmt.Sprintf("**CNAME:** %s\n\n", result.CNAME))
@@ -857,12 +1667,122 @@ func formatProbeResultsMarkdown(results []probe.ProbeResult) string {
 			md.WriteString(fmt.Sprintf("**Open Redirect URL:** %s\n\n", result.RedirectURL))
 		}
 		
+		if result.FinalURL != "" {
+			md.WriteString(fmt.Sprintf("**Redirect Chain:** %s\n\n", strings.Join(result.RedirectChain, " → ")))
+		}
+		
 		if len(result.Tags) > 0 {
 			md.WriteString(fmt.Sprintf("**Tags:** %s\n\n", strings.Join(result.Tags, ", ")))
 		}
 		
+		if len(result.Sources) > 0 {
+			md.WriteString(fmt.Sprintf("**Sources:** %s\n\n", strings.Join(result.Sources, ", ")))
+		}
+		
 		md.WriteString("---\n\n")
 	}
 	
+	if len(errored) > 0 {
+		md.WriteString("<a id=\"errored\"></a>\n## Errored Hosts\n\n")
+		for _, result := range errored {
+			md.WriteString(fmt.Sprintf("- %s: %s\n", result.Domain, result.Error))
+		}
+		md.WriteString("\n")
+	}
+	
+	if len(skipped) > 0 {
+		md.WriteString("<a id=\"skipped\"></a>\n## Skipped Hosts\n\n")
+		for _, result := range skipped {
+			md.WriteString(fmt.Sprintf("- %s: %s\n", result.Domain, result.Skipped))
+		}
+		md.WriteString("\n")
+	}
+	md.WriteString(info.markdown())
+	
 	return md.String()
-} 
\ No newline at end of file
+}
+
+// markdownSummaryRow formats a summary table row, linking the category to its
+// section when there is one
+func markdownSummaryRow(category string, anchor string, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("| %s | %d |\n", category, count)
+	}
+	return fmt.Sprintf("| [%s](#%s) | %d |\n", category, anchor, count)
+} 
+// FormatRecheckResults formats remediation status results in the specified format
+func FormatRecheckResults(results []probe.RecheckResult, format string) (string, error) {
+	switch format {
+	case FormatJSON:
+		jsonBytes, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error marshaling recheck results to JSON: %v", err)
+		}
+		return string(jsonBytes), nil
+	case FormatJSONL:
+		return formatRecheckResultsJSONL(results)
+	case FormatCSV:
+		return formatRecheckResultsCSV(results)
+	case FormatMarkdown:
+		return formatRecheckResultsMarkdown(results), nil
+	case FormatPlain:
+		return probe.FormatRecheckResults(results), nil
+	default:
+		return "", fmt.Errorf("unsupported format for recheck results: %s", format)
+	}
+}
+
+// formatRecheckResultsCSV formats recheck results as CSV
+func formatRecheckResultsCSV(results []probe.RecheckResult) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"Domain", "Status", "StillVulnerable", "Fixed", "PreviousCNAME", "CurrentCNAME", "CurrentStatus", "CheckedAt"}
+	if err := writer.Write(header); err != nil {
+		return "", fmt.Errorf("error writing CSV header: %v", err)
+	}
+
+	for _, result := range results {
+		row := []string{
+			result.Domain,
+			result.Status,
+			strings.Join(result.StillVulnerable, "|"),
+			strings.Join(result.Fixed, "|"),
+			result.PreviousCNAME,
+			result.CurrentCNAME,
+			fmt.Sprintf("%d", result.CurrentHTTPStatus),
+			result.CheckedAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(row); err != nil {
+			return "", fmt.Errorf("error writing CSV row: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("error flushing CSV writer: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// formatRecheckResultsMarkdown formats recheck results as a Markdown remediation report
+func formatRecheckResultsMarkdown(results []probe.RecheckResult) string {
+	var md strings.Builder
+
+	md.WriteString("# Subscan Remediation Status\n\n")
+	md.WriteString(fmt.Sprintf("Generated on: %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
+
+	md.WriteString("| Domain | Status | Still Vulnerable | Fixed |\n")
+	md.WriteString("|--------|--------|------------------|-------|\n")
+	for _, result := range results {
+		md.WriteString(fmt.Sprintf("| %s | `%s` | %s | %s |\n",
+			result.Domain, result.Status,
+			strings.Join(result.StillVulnerable, "<br>"),
+			strings.Join(result.Fixed, "<br>")))
+	}
+
+	md.WriteString("\n\n*Generated by Subscan*\n")
+
+	return md.String()
+}
@@ -0,0 +1,51 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// formatJSONL formats the results as JSON Lines, one subdomain per line
+func formatJSONL(results []scorer.SubdomainInfo) (string, error) {
+	lines := make([]interface{}, 0, len(results))
+	for _, info := range results {
+		lines = append(lines, toSubdomainData(info))
+	}
+	return joinJSONLines(lines)
+}
+
+// formatProbeResultsJSONL formats probe results as JSON Lines, one host per line
+func formatProbeResultsJSONL(results []probe.ProbeResult) (string, error) {
+	lines := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		lines = append(lines, result)
+	}
+	return joinJSONLines(lines)
+}
+
+// formatRecheckResultsJSONL formats recheck results as JSON Lines, one host per line
+func formatRecheckResultsJSONL(results []probe.RecheckResult) (string, error) {
+	lines := make([]interface{}, 0, len(results))
+	for _, result := range results {
+		lines = append(lines, result)
+	}
+	return joinJSONLines(lines)
+}
+
+// joinJSONLines marshals each value onto its own line
+func joinJSONLines(values []interface{}) (string, error) {
+	var builder strings.Builder
+	for _, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("error marshaling to JSON Lines: %v", err)
+		}
+		builder.Write(data)
+		builder.WriteString("\n")
+	}
+	return builder.String(), nil
+}
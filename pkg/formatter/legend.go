@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/scorer"
+	"github.com/omerimzali/subscan/pkg/tags"
+)
+
+// tagLegend describes the distinct tags in the lists the registry knows
+func tagLegend(lists ...[]string) []model.Tag {
+	var names []string
+	for _, list := range lists {
+		names = append(names, list...)
+	}
+	var legend []model.Tag
+	for _, tag := range tags.Legend(names) {
+		legend = append(legend, model.Tag{
+			Name:        tag.Name,
+			Category:    tag.Category,
+			Severity:    string(tag.Severity),
+			Description: tag.Description,
+		})
+	}
+	return legend
+}
+
+// hostLegend describes the tags the hosts carry
+func hostLegend(hosts []model.Host) []model.Tag {
+	lists := make([][]string, len(hosts))
+	for i, host := range hosts {
+		lists[i] = host.Tags
+	}
+	return tagLegend(lists...)
+}
+
+// scoreLegend describes the tags of scored subdomains
+func scoreLegend(results []scorer.SubdomainInfo) []model.Tag {
+	lists := make([][]string, len(results))
+	for i, info := range results {
+		lists[i] = info.Tags
+	}
+	return tagLegend(lists...)
+}
+
+// probeLegend describes the tags of probe results
+func probeLegend(results []probe.ProbeResult) []model.Tag {
+	lists := make([][]string, len(results))
+	for i, result := range results {
+		lists[i] = result.Tags
+	}
+	return tagLegend(lists...)
+}
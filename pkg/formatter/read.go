@@ -0,0 +1,227 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/input"
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// IsScoredReport reports whether data holds scored subdomains as written by
+// the json, jsonl or csv formats
+func IsScoredReport(data []byte) bool {
+	if model.IsReport(data) {
+		return reportKind(data) == model.KindScore
+	}
+	fields := input.Fields(data)
+	return input.HasField(fields, "domain") && input.HasField(fields, "score")
+}
+
+// IsProbeReport reports whether data holds probe results
+func IsProbeReport(data []byte) bool {
+	if model.IsReport(data) {
+		return reportKind(data) == model.KindProbe
+	}
+	fields := input.Fields(data)
+	return input.HasField(fields, "domain") && (input.HasField(fields, "is_takeover") || input.HasField(fields, "istakeover") || input.HasField(fields, "probed_at"))
+}
+
+// reportKind returns the kind of a versioned report
+func reportKind(data []byte) string {
+	report, err := model.Parse(data)
+	if err != nil {
+		return ""
+	}
+	return report.Scan.Kind
+}
+
+// ParseScoredResults reads scored subdomains back from a json, jsonl or csv
+// report, so a later stage can re-format or extend them
+func ParseScoredResults(data []byte) ([]scorer.SubdomainInfo, error) {
+	if input.Detect(data) == input.FormatCSV {
+		return parseScoredCSV(data)
+	}
+	if model.IsReport(data) {
+		report, err := model.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]scorer.SubdomainInfo, 0, len(report.Hosts))
+		for _, host := range report.Hosts {
+			results = append(results, fromReportHost(host))
+		}
+		return results, nil
+	}
+
+	items, err := input.Objects(data)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]scorer.SubdomainInfo, 0, len(items))
+	for i, item := range items {
+		var entry SubdomainData
+		if err := json.Unmarshal(item, &entry); err != nil {
+			return nil, fmt.Errorf("result %d: %v", i+1, err)
+		}
+		results = append(results, fromSubdomainData(entry))
+	}
+	return results, nil
+}
+
+// parseScoredCSV reads scored subdomains from the csv report
+func parseScoredCSV(data []byte) ([]scorer.SubdomainInfo, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CSV: %v", err)
+	}
+	if len(records) == 0 || input.HostColumn(records[0]) < 0 {
+		return nil, fmt.Errorf("CSV has no domain column")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	domainColumn := input.HostColumn(records[0])
+
+	var results []scorer.SubdomainInfo
+	for _, record := range records[1:] {
+		get := func(name string) string {
+			if i, ok := columns[strings.ToLower(name)]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		list := func(name string) []string {
+			if value := get(name); value != "" {
+				return strings.Split(value, ",")
+			}
+			return nil
+		}
+
+		entry := SubdomainData{
+			Domain:        input.NormalizeHost(record[domainColumn]),
+			CNAME:         get("CNAME"),
+			IPs:           list("IPs"),
+			CloudProvider: get("CloudProvider"),
+			Tags:          list("Tags"),
+			IsTLS:         get("IsTLS") == "true",
+			FinalURL:      get("FinalURL"),
+			Language:      get("Language"),
+			SaaSProvider:  get("SaaSProvider"),
+			Title:         get("Title"),
+			Server:        get("Server"),
+			PoweredBy:     get("PoweredBy"),
+			Technologies:  list("Technologies"),
+			Provenance:    get("Provenance"),
+			Sources:       list("Sources"),
+			Screenshots:   list("Screenshots"),
+			TLS:           csvTLSInfo(get("CertFingerprint"), get("CertExpiry"), get("TLSVersion")),
+			Owner:         get("Owner"),
+			Team:          get("Team"),
+			Notes:         get("Notes"),
+		}
+		entry.Status, _ = strconv.Atoi(get("Status"))
+		entry.ContentLength, _ = strconv.ParseInt(get("ContentLength"), 10, 64)
+		entry.Score, _ = strconv.ParseFloat(get("Score"), 64)
+		if hash, err := strconv.ParseInt(get("FaviconHash"), 10, 32); err == nil {
+			entry.FaviconHash = int32(hash)
+		}
+		for _, port := range list("OpenPorts") {
+			if n, err := strconv.Atoi(port); err == nil {
+				entry.OpenPorts = append(entry.OpenPorts, n)
+			}
+		}
+		results = append(results, fromSubdomainData(entry))
+	}
+	return results, nil
+}
+
+// fromReportHost converts a report host back into a scored subdomain
+func fromReportHost(host model.Host) scorer.SubdomainInfo {
+	return fromSubdomainData(SubdomainData{
+		Domain:        host.Domain,
+		Status:        host.Status,
+		ContentLength: host.ContentLength,
+		CNAME:         host.CNAME,
+		IPs:           host.IPs,
+		CloudProvider: host.CloudProvider,
+		Score:         host.Score,
+		Tags:          host.Tags,
+		IsTLS:         host.IsTLS,
+		FinalURL:      host.FinalURL,
+		RedirectChain: host.RedirectChain,
+		URLs:          host.URLs,
+		Language:      host.Language,
+		SaaSProvider:  host.SaaSProvider,
+		Title:         host.Title,
+		Server:        host.Server,
+		PoweredBy:     host.PoweredBy,
+		Technologies:  host.Technologies,
+		FaviconHash:   host.FaviconHash,
+		OpenPorts:     host.OpenPorts,
+		Provenance:    host.Provenance,
+		Sources:       host.Sources,
+		Screenshots:   host.Screenshots,
+		TLS:           host.TLS,
+		Owner:         host.Owner,
+		Team:          host.Team,
+		Notes:         host.Notes,
+	})
+}
+
+// fromSubdomainData converts a report entry back into a scored subdomain
+func fromSubdomainData(entry SubdomainData) scorer.SubdomainInfo {
+	info := scorer.SubdomainInfo{
+		Subdomain:     entry.Domain,
+		HTTPStatus:    entry.Status,
+		ContentLength: entry.ContentLength,
+		IPs:           entry.IPs,
+		CloudProvider: entry.CloudProvider,
+		Score:         entry.Score,
+		Tags:          entry.Tags,
+		IsTLS:         entry.IsTLS,
+		FinalURL:      entry.FinalURL,
+		RedirectChain: entry.RedirectChain,
+		URLs:          entry.URLs,
+		Language:      entry.Language,
+		SaaSProvider:  entry.SaaSProvider,
+		Title:         entry.Title,
+		Server:        entry.Server,
+		PoweredBy:     entry.PoweredBy,
+		Technologies:  entry.Technologies,
+		FaviconHash:   entry.FaviconHash,
+		OpenPorts:     entry.OpenPorts,
+		Provenance:    entry.Provenance,
+		Sources:       entry.Sources,
+		Screenshots:   entry.Screenshots,
+		TLS:           entry.TLS,
+		Owner:         entry.Owner,
+		Team:          entry.Team,
+		Notes:         entry.Notes,
+	}
+	if entry.CNAME != "" {
+		info.CNAMEs = []string{entry.CNAME}
+	}
+	return info
+}
+
+// csvTLSInfo rebuilds the certificate details a CSV report keeps, nil when it
+// has none
+func csvTLSInfo(fingerprint string, expiry string, version string) *model.TLSInfo {
+	if fingerprint == "" {
+		return nil
+	}
+	notAfter, _ := time.Parse(time.RFC3339, expiry)
+	return &model.TLSInfo{Fingerprint: fingerprint, NotAfter: notAfter, Version: version}
+}
@@ -0,0 +1,145 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// ScanInfo is target-level data reported alongside the hosts of a scan
+type ScanInfo struct {
+	// Nameservers are the target's authoritative nameservers, when
+	// fingerprinted or handing out the zone
+	Nameservers []model.Nameserver
+	// Baseline profiles the apex and www hosts as the reference for the others
+	Baseline []model.Profile
+	// Buckets are the cloud storage buckets found under names derived from
+	// the target
+	Buckets []model.Bucket
+	// Apex holds the DNS records of the target domain itself, when looked up
+	Apex *model.ApexRecords
+	// Caveats list what the scan skipped or left partial, so readers know
+	// how complete the results are
+	Caveats []string
+}
+
+// plain formats the scan data as plain text sections
+func (info ScanInfo) plain() string {
+	var output strings.Builder
+	if len(info.Baseline) > 0 {
+		output.WriteString("\nBaseline:\n")
+		for _, profile := range info.Baseline {
+			output.WriteString("  " + profile.String() + "\n")
+		}
+	}
+	if info.Apex != nil {
+		output.WriteString("\nApex records of " + info.Apex.Domain + ":\n")
+		for _, row := range apexRows(*info.Apex) {
+			output.WriteString(fmt.Sprintf("  %-13s %s\n", row[0], row[1]))
+		}
+	}
+	if len(info.Nameservers) > 0 {
+		output.WriteString("\nNameservers:\n")
+		for _, ns := range info.Nameservers {
+			output.WriteString("  " + ns.String() + "\n")
+		}
+	}
+	if len(info.Buckets) > 0 {
+		output.WriteString("\nBuckets:\n")
+		for _, bucket := range info.Buckets {
+			output.WriteString("  " + bucket.String() + "\n")
+		}
+	}
+	if len(info.Caveats) > 0 {
+		output.WriteString("\nCoverage caveats:\n")
+		for _, caveat := range info.Caveats {
+			output.WriteString("  - " + caveat + "\n")
+		}
+	}
+	return output.String()
+}
+
+// markdown formats the scan data as Markdown sections
+func (info ScanInfo) markdown() string {
+	var output strings.Builder
+	if len(info.Baseline) > 0 {
+		output.WriteString("\n## Baseline\n\n")
+		output.WriteString("| Host | Status | Title | Technologies | Certificate |\n")
+		output.WriteString("|------|--------|-------|--------------|-------------|\n")
+		for _, p := range info.Baseline {
+			status := fmt.Sprintf("%d", p.Status)
+			if p.Error != "" {
+				status = p.Error
+			}
+			cert := ""
+			if p.CertIssuer != "" {
+				cert = fmt.Sprintf("%s by %s, expires %s", p.CertSubject, p.CertIssuer, p.CertExpires)
+			}
+			output.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", p.Host, status, p.Title, strings.Join(p.Technologies, ", "), cert))
+		}
+	}
+	if info.Apex != nil {
+		output.WriteString("\n## Apex Records of " + info.Apex.Domain + "\n\n")
+		output.WriteString("| Type | Value |\n")
+		output.WriteString("|------|-------|\n")
+		for _, row := range apexRows(*info.Apex) {
+			output.WriteString(fmt.Sprintf("| %s | %s |\n", row[0], strings.ReplaceAll(row[1], "|", "\\|")))
+		}
+	}
+	if len(info.Nameservers) > 0 {
+		output.WriteString("\n## Nameservers\n\n")
+		output.WriteString("| Nameserver | Addresses | Version | Hostname | Zone Transfer |\n")
+		output.WriteString("|------------|-----------|---------|----------|---------------|\n")
+		for _, ns := range info.Nameservers {
+			transfer := ""
+			if ns.ZoneTransfer {
+				transfer = "**allowed**"
+			}
+			output.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", ns.Name, strings.Join(ns.Addresses, ", "), ns.Version, ns.Hostname, transfer))
+		}
+	}
+	if len(info.Buckets) > 0 {
+		output.WriteString("\n## Buckets\n\n")
+		output.WriteString("| Bucket | Provider | Access | Objects |\n")
+		output.WriteString("|--------|----------|--------|---------|\n")
+		for _, b := range info.Buckets {
+			output.WriteString(fmt.Sprintf("| [%s](%s) | %s | %s | %s |\n", b.Name, b.URL, b.Provider, b.Access, strings.Join(b.Objects, ", ")))
+		}
+	}
+	if len(info.Caveats) > 0 {
+		output.WriteString("\n## Coverage Caveats\n\n")
+		for _, caveat := range info.Caveats {
+			output.WriteString("- " + caveat + "\n")
+		}
+	}
+	return output.String()
+}
+
+// apexRows lists the apex records as type and value pairs, in the order the
+// text formats show them
+func apexRows(records model.ApexRecords) [][2]string {
+	var rows [][2]string
+	for _, ns := range records.NS {
+		rows = append(rows, [2]string{"NS", ns})
+	}
+	for _, mx := range records.MX {
+		rows = append(rows, [2]string{"MX", fmt.Sprintf("%d %s", mx.Preference, mx.Host)})
+	}
+	if records.SOA != nil {
+		rows = append(rows, [2]string{"SOA", records.SOA.String()})
+	}
+	for _, txt := range records.TXT {
+		rows = append(rows, [2]string{"TXT", txt})
+	}
+	if records.DMARC != "" {
+		rows = append(rows, [2]string{"DMARC", records.DMARC})
+	}
+	for _, srv := range records.SRV {
+		rows = append(rows, [2]string{"SRV", srv.String()})
+	}
+	if services := records.Verifications(); len(services) > 0 {
+		rows = append(rows, [2]string{"Verified with", strings.Join(services, ", ")})
+	}
+	return rows
+}
@@ -0,0 +1,37 @@
+package formatter
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/omerimzali/subscan/pkg/scorer"
+	"github.com/omerimzali/subscan/pkg/screenshot"
+)
+
+// screenshotEntries lists the captured web services with their thumbnails
+// embedded, falling back to the full screenshot when it has no thumbnail.
+// Screenshots deleted since the scan are left out.
+func screenshotEntries(results []scorer.SubdomainInfo) []ScreenshotEntry {
+	var entries []ScreenshotEntry
+	for _, info := range results {
+		for _, path := range info.Screenshots {
+			image := screenshot.ThumbnailPath(path)
+			if _, err := os.Stat(image); err != nil {
+				image = path
+			}
+			uri, err := screenshot.DataURI(image)
+			if err != nil {
+				continue
+			}
+			entry := ScreenshotEntry{Domain: info.Subdomain, Path: path, Thumbnail: template.URL(uri)}
+			for _, url := range info.URLs {
+				if screenshot.FileName(url) == filepath.Base(path) {
+					entry.URL = url
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,100 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// StreamWriter writes results one line at a time as soon as they are produced.
+// Plain format writes human-readable lines and the urls format one URL per
+// line; every other format writes JSON Lines.
+// It is safe for concurrent use by the worker callbacks.
+type StreamWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	plain bool
+	urls  bool
+}
+
+// NewStreamWriter returns a stream writer for the given output format
+func NewStreamWriter(w io.Writer, format string) *StreamWriter {
+	return &StreamWriter{w: w, plain: format == "" || format == FormatPlain, urls: format == FormatURLs}
+}
+
+// WriteRecord streams a resolved subdomain
+func (s *StreamWriter) WriteRecord(record resolver.DNSRecord) error {
+	if s.plain {
+		return s.writeLine(record.Name)
+	}
+	return s.writeJSON(record)
+}
+
+// WriteSubdomain streams a scored subdomain
+func (s *StreamWriter) WriteSubdomain(info scorer.SubdomainInfo) error {
+	if s.urls {
+		return s.writeURLs(scoredURLs(info))
+	}
+	if s.plain {
+		line := fmt.Sprintf("%s [%d] (Score: %.1f)", info.Subdomain, info.HTTPStatus, info.Score)
+		if len(info.Tags) > 0 {
+			line = "[" + strings.Join(info.Tags, "][") + "] " + line
+		}
+		return s.writeLine(line)
+	}
+	return s.writeJSON(toSubdomainData(info))
+}
+
+// WriteProbeResult streams a probe result
+func (s *StreamWriter) WriteProbeResult(result probe.ProbeResult) error {
+	if s.urls {
+		return s.writeURLs(result.URLs)
+	}
+	if s.plain {
+		line := fmt.Sprintf("%s [%d]", result.Domain, result.HTTPStatus)
+		if len(result.Vulnerabilities) > 0 {
+			line += " " + strings.Join(result.Vulnerabilities, ", ")
+		}
+		return s.writeLine(line)
+	}
+	return s.writeJSON(result)
+}
+
+// writeURLs writes each URL on its own line
+func (s *StreamWriter) writeURLs(urls []string) error {
+	for _, url := range urls {
+		if err := s.writeLine(url); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON writes v as a single JSON line
+func (s *StreamWriter) writeJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.writeLine(string(data))
+}
+
+// writeLine writes a line and flushes buffered writers so consumers see it immediately
+func (s *StreamWriter) writeLine(line string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := io.WriteString(s.w, line+"\n"); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// otherCloud names the assets whose CNAME points at no known cloud provider
+const otherCloud = "Other"
+
+// DomainSummary rolls up the scan of one domain for the executive summary
+type DomainSummary struct {
+	Domain string
+	// Assets is the number of alive subdomains
+	Assets int
+	// Findings by severity; zero unless the domain was probed
+	Critical int
+	High     int
+	Medium   int
+	Low      int
+	// Clouds counts the assets by the cloud provider their CNAME points at
+	Clouds map[string]int
+}
+
+// Findings returns the domain's number of findings
+func (s DomainSummary) Findings() int {
+	return s.Critical + s.High + s.Medium + s.Low
+}
+
+// SummarizeDomain rolls up the resolved records and probe results of a domain
+func SummarizeDomain(domain string, records []resolver.DNSRecord, results []probe.ProbeResult) DomainSummary {
+	summary := DomainSummary{Domain: domain, Assets: len(records), Clouds: make(map[string]int)}
+	for _, record := range records {
+		provider := otherCloud
+		if record.CNAME != "" {
+			if cloud := scorer.CloudProvider(record.CNAME); cloud != "" {
+				provider = cloud
+			}
+		}
+		summary.Clouds[provider]++
+	}
+	for _, result := range results {
+		for _, vuln := range result.Vulnerabilities {
+			switch probe.VulnerabilitySeverity(vuln) {
+			case probe.SeverityCritical:
+				summary.Critical++
+			case probe.SeverityHigh:
+				summary.High++
+			case probe.SeverityMedium:
+				summary.Medium++
+			default:
+				summary.Low++
+			}
+		}
+	}
+	return summary
+}
+
+// summaryRow is a domain of the summary with its cloud counts in column order
+type summaryRow struct {
+	DomainSummary
+	CloudCounts []int
+}
+
+// summaryData holds the data of the executive summary templates
+type summaryData struct {
+	Title       string
+	Date        string
+	Rows        []summaryRow
+	Total       DomainSummary
+	Clouds      []string
+	GeneratedBy string
+}
+
+// FormatSummary formats an executive summary comparing the scanned domains by
+// assets, findings per severity and cloud distribution, most severe first
+func FormatSummary(summaries []DomainSummary, format string) (string, error) {
+	data := newSummaryData(summaries)
+	switch format {
+	case FormatHTML:
+		var buf bytes.Buffer
+		tmpl, err := template.New("summary").Parse(summaryTemplate)
+		if err != nil {
+			return "", err
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("error generating HTML summary: %v", err)
+		}
+		return buf.String(), nil
+	case FormatMarkdown:
+		return summaryMarkdown(data), nil
+	default:
+		return "", fmt.Errorf("unsupported format for the summary: %s (available: html, markdown)", format)
+	}
+}
+
+// newSummaryData sorts the domains by severity and lays out the cloud columns,
+// known providers by asset count and Other last
+func newSummaryData(summaries []DomainSummary) summaryData {
+	data := summaryData{
+		Title:       "Subscan Executive Summary",
+		Date:        time.Now().Format("2006-01-02 15:04:05"),
+		Total:       DomainSummary{Domain: "Total", Clouds: make(map[string]int)},
+		GeneratedBy: "Subscan",
+	}
+	for _, s := range summaries {
+		data.Total.Assets += s.Assets
+		data.Total.Critical += s.Critical
+		data.Total.High += s.High
+		data.Total.Medium += s.Medium
+		data.Total.Low += s.Low
+		for cloud, count := range s.Clouds {
+			data.Total.Clouds[cloud] += count
+		}
+	}
+
+	for cloud := range data.Total.Clouds {
+		if cloud != otherCloud {
+			data.Clouds = append(data.Clouds, cloud)
+		}
+	}
+	sort.Slice(data.Clouds, func(i, j int) bool {
+		ci, cj := data.Total.Clouds[data.Clouds[i]], data.Total.Clouds[data.Clouds[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return data.Clouds[i] < data.Clouds[j]
+	})
+	if data.Total.Clouds[otherCloud] > 0 {
+		data.Clouds = append(data.Clouds, otherCloud)
+	}
+
+	sorted := append([]DomainSummary{}, summaries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		for _, pair := range [][2]int{{a.Critical, b.Critical}, {a.High, b.High}, {a.Medium, b.Medium}, {a.Low, b.Low}, {a.Assets, b.Assets}} {
+			if pair[0] != pair[1] {
+				return pair[0] > pair[1]
+			}
+		}
+		return a.Domain < b.Domain
+	})
+	for _, s := range sorted {
+		row := summaryRow{DomainSummary: s}
+		for _, cloud := range data.Clouds {
+			row.CloudCounts = append(row.CloudCounts, s.Clouds[cloud])
+		}
+		data.Rows = append(data.Rows, row)
+	}
+	return data
+}
+
+// summaryMarkdown formats the executive summary as Markdown
+func summaryMarkdown(data summaryData) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("# %s\n\n", data.Title))
+	output.WriteString(fmt.Sprintf("**Date:** %s  \n", data.Date))
+	output.WriteString(fmt.Sprintf("**Domains:** %d  \n", len(data.Rows)))
+	output.WriteString(fmt.Sprintf("**Assets:** %d  \n", data.Total.Assets))
+	output.WriteString(fmt.Sprintf("**Findings:** %d (%d critical, %d high, %d medium, %d low)  \n\n",
+		data.Total.Findings(), data.Total.Critical, data.Total.High, data.Total.Medium, data.Total.Low))
+
+	output.WriteString("## Domains\n\n")
+	output.WriteString("| Domain | Assets | Critical | High | Medium | Low | Findings |\n")
+	output.WriteString("|--------|--------|----------|------|--------|-----|----------|\n")
+	for _, row := range append(data.Rows, summaryRow{DomainSummary: data.Total}) {
+		output.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d | %d | %d |\n",
+			row.Domain, row.Assets, row.Critical, row.High, row.Medium, row.Low, row.Findings()))
+	}
+
+	if len(data.Clouds) > 0 {
+		output.WriteString("\n## Cloud Distribution\n\n")
+		output.WriteString("| Domain | " + strings.Join(data.Clouds, " | ") + " |\n")
+		output.WriteString("|--------|" + strings.Repeat("------|", len(data.Clouds)) + "\n")
+		for _, row := range data.Rows {
+			counts := make([]string, len(row.CloudCounts))
+			for i, count := range row.CloudCounts {
+				counts[i] = fmt.Sprintf("%d", count)
+			}
+			output.WriteString(fmt.Sprintf("| %s | %s |\n", row.Domain, strings.Join(counts, " | ")))
+		}
+	}
+
+	output.WriteString(fmt.Sprintf("\n*Generated by %s on %s*\n", data.GeneratedBy, data.Date))
+	return output.String()
+}
+
+// summaryTemplate renders the executive summary as HTML
+const summaryTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{ .Title }}</title>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            line-height: 1.6;
+            padding: 20px;
+            color: #333;
+            max-width: 1200px;
+            margin: 0 auto;
+        }
+        h1 {
+            color: #2c3e50;
+            border-bottom: 2px solid #eaecef;
+            padding-bottom: 10px;
+        }
+        .stats {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 15px;
+            margin-bottom: 20px;
+        }
+        .stat-box {
+            flex: 1;
+            min-width: 120px;
+            padding: 15px;
+            border-radius: 5px;
+            background-color: #f8f9fa;
+            text-align: center;
+        }
+        .stat-box .value {
+            font-size: 28px;
+            font-weight: bold;
+        }
+        .critical { color: #b71c1c; }
+        .high { color: #e65100; }
+        .medium { color: #f9a825; }
+        .low { color: #1565c0; }
+        table {
+            width: 100%;
+            border-collapse: collapse;
+            margin-bottom: 20px;
+        }
+        th, td {
+            border: 1px solid #ddd;
+            padding: 8px 12px;
+            text-align: left;
+        }
+        th {
+            background-color: #f2f2f2;
+            font-weight: bold;
+        }
+        tr:nth-child(even) {
+            background-color: #f9f9f9;
+        }
+        .has-critical td {
+            background-color: #ffebee;
+        }
+        .total td {
+            font-weight: bold;
+        }
+        footer {
+            margin-top: 40px;
+            text-align: center;
+            font-size: 0.8em;
+            color: #777;
+        }
+    </style>
+</head>
+<body>
+    <h1>{{ .Title }}</h1>
+    <p><strong>Date:</strong> {{ .Date }}</p>
+
+    <div class="stats">
+        <div class="stat-box"><div class="value">{{ len .Rows }}</div>Domains</div>
+        <div class="stat-box"><div class="value">{{ .Total.Assets }}</div>Assets</div>
+        <div class="stat-box"><div class="value critical">{{ .Total.Critical }}</div>Critical</div>
+        <div class="stat-box"><div class="value high">{{ .Total.High }}</div>High</div>
+        <div class="stat-box"><div class="value medium">{{ .Total.Medium }}</div>Medium</div>
+        <div class="stat-box"><div class="value low">{{ .Total.Low }}</div>Low</div>
+    </div>
+
+    <h2>Domains</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Domain</th>
+                <th>Assets</th>
+                <th>Critical</th>
+                <th>High</th>
+                <th>Medium</th>
+                <th>Low</th>
+                <th>Findings</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Rows }}
+            <tr{{ if gt .Critical 0 }} class="has-critical"{{ end }}>
+                <td>{{ .Domain }}</td>
+                <td>{{ .Assets }}</td>
+                <td class="critical">{{ .Critical }}</td>
+                <td class="high">{{ .High }}</td>
+                <td class="medium">{{ .Medium }}</td>
+                <td class="low">{{ .Low }}</td>
+                <td>{{ .Findings }}</td>
+            </tr>
+            {{ end }}
+            <tr class="total">
+                <td>Total</td>
+                <td>{{ .Total.Assets }}</td>
+                <td class="critical">{{ .Total.Critical }}</td>
+                <td class="high">{{ .Total.High }}</td>
+                <td class="medium">{{ .Total.Medium }}</td>
+                <td class="low">{{ .Total.Low }}</td>
+                <td>{{ .Total.Findings }}</td>
+            </tr>
+        </tbody>
+    </table>
+
+    {{ if .Clouds }}
+    <h2>Cloud Distribution</h2>
+    <table>
+        <thead>
+            <tr>
+                <th>Domain</th>
+                {{ range .Clouds }}<th>{{ . }}</th>{{ end }}
+            </tr>
+        </thead>
+        <tbody>
+            {{ range .Rows }}
+            <tr>
+                <td>{{ .Domain }}</td>
+                {{ range .CloudCounts }}<td>{{ . }}</td>{{ end }}
+            </tr>
+            {{ end }}
+        </tbody>
+    </table>
+    {{ end }}
+
+    <footer>
+        <p>Generated by {{ .GeneratedBy }} on {{ .Date }}</p>
+    </footer>
+</body>
+</html>`
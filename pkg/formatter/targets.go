@@ -0,0 +1,99 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// Port scanner target formats
+const (
+	FormatNmap    = "nmap"
+	FormatMasscan = "masscan"
+)
+
+// IsTargetFormat reports whether the format is a port scanner target list,
+// which only needs resolved addresses rather than scoring
+func IsTargetFormat(format string) bool {
+	return format == FormatNmap || format == FormatMasscan
+}
+
+// target is a host and the addresses it resolved to
+type target struct {
+	host string
+	ips  []string
+}
+
+// FormatTargets exports resolved subdomains as a port scanner target list
+func FormatTargets(records []resolver.DNSRecord, format string) (string, error) {
+	targets := make([]target, 0, len(records))
+	for _, record := range records {
+		targets = append(targets, target{host: record.Name, ips: record.IPs()})
+	}
+	return formatTargets(targets, format)
+}
+
+// formatScoredTargets exports scored subdomains as a port scanner target list
+func formatScoredTargets(results []scorer.SubdomainInfo, format string) (string, error) {
+	targets := make([]target, 0, len(results))
+	for _, info := range results {
+		targets = append(targets, target{host: info.Subdomain, ips: info.IPs})
+	}
+	return formatTargets(targets, format)
+}
+
+// formatProbeTargets exports probed subdomains as a port scanner target list
+func formatProbeTargets(results []probe.ProbeResult, format string) (string, error) {
+	targets := make([]target, 0, len(results))
+	for _, result := range results {
+		targets = append(targets, target{host: result.Domain, ips: result.IPs})
+	}
+	return formatTargets(targets, format)
+}
+
+// formatTargets writes each address once per line. The nmap list (nmap -iL)
+// annotates addresses with the hosts resolving to them and lists hosts without
+// known addresses by name so nmap resolves them itself. masscan cannot resolve
+// names, so its include file (masscan --includefile) holds bare addresses only.
+func formatTargets(targets []target, format string) (string, error) {
+	if !IsTargetFormat(format) {
+		return "", fmt.Errorf("unsupported target format: %s", format)
+	}
+
+	var addresses []string
+	hostsByIP := make(map[string][]string)
+	var unresolved []string
+	for _, t := range targets {
+		if len(t.ips) == 0 {
+			unresolved = append(unresolved, t.host)
+			continue
+		}
+		for _, ip := range t.ips {
+			if _, seen := hostsByIP[ip]; !seen {
+				addresses = append(addresses, ip)
+			}
+			hostsByIP[ip] = append(hostsByIP[ip], t.host)
+		}
+	}
+
+	var output strings.Builder
+	if format == FormatMasscan {
+		for _, ip := range addresses {
+			output.WriteString(ip + "\n")
+		}
+		return output.String(), nil
+	}
+
+	output.WriteString("# nmap target list generated by Subscan (use with nmap -iL)\n")
+	for _, ip := range addresses {
+		output.WriteString(fmt.Sprintf("%s # %s\n", ip, strings.Join(hostsByIP[ip], ", ")))
+	}
+	for _, host := range unresolved {
+		output.WriteString(host + "\n")
+	}
+
+	return output.String(), nil
+}
@@ -0,0 +1,59 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// FormatURLs lists the base URL of every live web service, one per line, for
+// crawlers and fuzzers
+const FormatURLs = "urls"
+
+// scoredURLs returns the web service URLs of a scored subdomain. Results read
+// back from reports written before URLs were recorded fall back to the scheme
+// of the main request.
+func scoredURLs(info scorer.SubdomainInfo) []string {
+	if len(info.URLs) > 0 || info.HTTPStatus == 0 {
+		return info.URLs
+	}
+	scheme := "http"
+	if info.IsTLS {
+		scheme = "https"
+	}
+	return []string{httpclient.BaseURL(scheme, info.Subdomain, 0)}
+}
+
+// formatURLs lists the web services of scored subdomains
+func formatURLs(results []scorer.SubdomainInfo) string {
+	var urls []string
+	for _, info := range results {
+		urls = append(urls, scoredURLs(info)...)
+	}
+	return joinURLs(urls)
+}
+
+// formatProbeURLs lists the web services of probed subdomains
+func formatProbeURLs(results []probe.ProbeResult) string {
+	var urls []string
+	for _, result := range results {
+		urls = append(urls, result.URLs...)
+	}
+	return joinURLs(urls)
+}
+
+// joinURLs writes each URL once per line, keeping their order
+func joinURLs(urls []string) string {
+	seen := make(map[string]bool)
+	var output strings.Builder
+	for _, url := range urls {
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+		output.WriteString(url + "\n")
+	}
+	return output.String()
+}
@@ -0,0 +1,24 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+)
+
+// MaxMeasuredBody bounds how many body bytes are read to measure a response's size
+const MaxMeasuredBody = 1024 * 1024
+
+// ReadBody returns up to keep bytes of the response body along with the body's
+// actual size. The size is measured by reading (at most MaxMeasuredBody bytes)
+// since Content-Length is -1 for chunked and transparently decompressed responses.
+func ReadBody(resp *http.Response, keep int64) ([]byte, int64) {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, keep))
+	rest, _ := io.Copy(io.Discard, io.LimitReader(resp.Body, MaxMeasuredBody-int64(len(body))))
+
+	size := int64(len(body)) + rest
+	if size >= MaxMeasuredBody && resp.ContentLength > size {
+		// Larger than we're willing to read; trust the header
+		return body, resp.ContentLength
+	}
+	return body, size
+}
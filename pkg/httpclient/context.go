@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// contextTransport aborts requests when a scan-wide context is canceled, on
+// top of each request's own context and the client timeout
+type contextTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+// RoundTrip performs the request, canceling it along with the transport's
+// context until the response body is closed
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	go func() {
+		select {
+		case <-t.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the request's context when the body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases the request's context
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
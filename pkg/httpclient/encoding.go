@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// DefaultAcceptEncoding is advertised when no explicit Accept-Encoding is configured
+const DefaultAcceptEncoding = "gzip, deflate, br"
+
+// decodingTransport advertises the configured content encodings and decompresses
+// responses before they reach signature matching. The stdlib only handles gzip,
+// and only when it set Accept-Encoding itself.
+type decodingTransport struct {
+	base           http.RoundTripper
+	acceptEncoding string
+}
+
+// RoundTrip performs the request and transparently decodes the response body
+func (t *decodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", t.acceptEncoding)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	decodeBody(resp)
+	return resp, nil
+}
+
+// decodedBody reads decompressed data while closing the underlying body
+type decodedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// decodeBody replaces a gzip, deflate or brotli encoded body with its decoded
+// form. Bodies that don't look like the advertised encoding are left untouched.
+func decodeBody(resp *http.Response) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return
+	}
+
+	buffered := bufio.NewReader(resp.Body)
+	magic, _ := buffered.Peek(2)
+
+	var decoded io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+			resp.Body = decodedBody{Reader: buffered, Closer: resp.Body}
+			return
+		}
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			resp.Body = decodedBody{Reader: buffered, Closer: resp.Body}
+			return
+		}
+		decoded = reader
+	case "deflate":
+		// Servers disagree on whether deflate means zlib-wrapped or raw data
+		if len(magic) == 2 && magic[0]&0x0f == 8 && (uint16(magic[0])<<8|uint16(magic[1]))%31 == 0 {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				resp.Body = decodedBody{Reader: buffered, Closer: resp.Body}
+				return
+			}
+			decoded = reader
+		} else {
+			decoded = flate.NewReader(buffered)
+		}
+	case "br":
+		decoded = brotli.NewReader(buffered)
+	default:
+		resp.Body = decodedBody{Reader: buffered, Closer: resp.Body}
+		return
+	}
+
+	resp.Body = decodedBody{Reader: decoded, Closer: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
@@ -0,0 +1,136 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRedirects is the redirect limit used when following is enabled without an explicit limit
+const DefaultMaxRedirects = 10
+
+// Options contains configuration for the HTTP clients used by the scorer and probe stages
+type Options struct {
+	Timeout           time.Duration
+	FollowRedirects   bool
+	MaxRedirects      int
+	DisableKeepAlives bool
+	// UserAgent is set on every request that doesn't carry its own
+	UserAgent string
+	// RequestDelay is the minimum time between two requests to the same host
+	RequestDelay time.Duration
+	// AcceptEncoding is advertised on requests; DefaultAcceptEncoding when empty
+	AcceptEncoding string
+	// Context cancels every request of the client when it is done
+	Context context.Context
+	// Proxy routes every request through this proxy; the environment's proxy
+	// when nil (see ProxyFunc)
+	Proxy *url.URL
+	// Warmer supplies the connections and addresses it warmed ahead of the
+	// requests
+	Warmer *Warmer
+}
+
+// New creates an HTTP client that skips certificate validation and only follows
+// redirects when asked to, stopping after MaxRedirects hops
+func New(options Options) *http.Client {
+	maxRedirects := options.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = DefaultMaxRedirects
+	}
+
+	base := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true, // Skip certificate validation for analysis
+		},
+		DisableKeepAlives: options.DisableKeepAlives,
+		Proxy:             ProxyFunc(options.Proxy),
+	}
+	if options.Warmer != nil {
+		base.DialContext = options.Warmer.dialContext
+		base.DialTLSContext = options.Warmer.dialTLSContext
+	}
+	var transport http.RoundTripper = base
+
+	acceptEncoding := options.AcceptEncoding
+	if acceptEncoding == "" {
+		acceptEncoding = DefaultAcceptEncoding
+	}
+	transport = &decodingTransport{base: transport, acceptEncoding: acceptEncoding}
+
+	if options.UserAgent != "" || options.RequestDelay > 0 {
+		transport = &politeTransport{
+			base:      transport,
+			userAgent: options.UserAgent,
+			delay:     options.RequestDelay,
+		}
+	}
+
+	if options.Context != nil {
+		transport = &contextTransport{base: transport, ctx: options.Context}
+	}
+
+	return &http.Client{
+		Timeout:   options.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !options.FollowRedirects {
+				return http.ErrUseLastResponse // Don't follow redirects
+			}
+			if len(via) > maxRedirects {
+				// Keep the last redirect response instead of failing the request
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+}
+
+// RedirectChain returns every URL visited to produce resp, starting with the
+// original request and ending with the final destination
+func RedirectChain(resp *http.Response) []string {
+	if resp == nil || resp.Request == nil {
+		return nil
+	}
+
+	var chain []string
+	for req := resp.Request; req != nil; {
+		chain = append([]string{req.URL.String()}, chain...)
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+
+	return chain
+}
+
+// FinalHost returns the host name of the last request made to produce resp
+func FinalHost(resp *http.Response) string {
+	if resp == nil || resp.Request == nil {
+		return ""
+	}
+	return resp.Request.URL.Hostname()
+}
+
+// InScope reports whether host is the scope domain itself or one of its subdomains
+func InScope(host string, scope string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	scope = strings.TrimSuffix(strings.ToLower(scope), ".")
+	if scope == "" {
+		return true
+	}
+	return host == scope || strings.HasSuffix(host, "."+scope)
+}
+
+// DescribeChain formats a redirect chain for terminal output
+func DescribeChain(chain []string) string {
+	if len(chain) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s (%d hops)", strings.Join(chain, " -> "), len(chain)-1)
+}
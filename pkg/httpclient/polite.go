@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PoliteUserAgent identifies subscan to the owners of scanned hosts
+const PoliteUserAgent = "Subscan/1.0 (+https://github.com/omerimzali/subscan; polite mode)"
+
+// hostSchedule tracks when each host may receive its next request. It is shared
+// by every client so the delay holds across the scorer and probe stages.
+var hostSchedule = struct {
+	sync.Mutex
+	next map[string]time.Time
+}{next: make(map[string]time.Time)}
+
+// politeTransport sets a default User-Agent and spaces out requests per host
+type politeTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	delay     time.Duration
+}
+
+// RoundTrip waits for the host's turn and performs the request
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	if t.delay > 0 {
+		wait := reserve(req.URL.Host, t.delay)
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// reserve books the next request slot for a host and returns how long to wait for it
+func reserve(host string, delay time.Duration) time.Duration {
+	hostSchedule.Lock()
+	defer hostSchedule.Unlock()
+
+	now := time.Now()
+	slot := hostSchedule.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	hostSchedule.next[host] = slot.Add(delay)
+
+	return slot.Sub(now)
+}
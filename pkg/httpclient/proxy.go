@@ -0,0 +1,136 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// Direct is the proxy that disables proxying, including through the
+// HTTP_PROXY and HTTPS_PROXY environment variables
+var Direct = &url.URL{Scheme: "direct"}
+
+// ParseProxy parses a proxy given as http://, https:// or socks5:// URL, with
+// optional user:password credentials, or "direct" for no proxy
+func ParseProxy(raw string) (*url.URL, error) {
+	if strings.EqualFold(raw, Direct.Scheme) {
+		return Direct, nil
+	}
+	proxy, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %v", raw, err)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: use an http://, https:// or socks5:// URL", raw)
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", raw)
+	}
+	return proxy, nil
+}
+
+// ProxyFunc returns the proxy selection of a transport sending requests
+// through proxy, or through the proxies of the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables when proxy is nil
+func ProxyFunc(proxy *url.URL) func(*http.Request) (*url.URL, error) {
+	switch proxy {
+	case nil:
+		return http.ProxyFromEnvironment
+	case Direct:
+		return nil
+	}
+	return http.ProxyURL(proxy)
+}
+
+// DialProxy connects to addr through proxy, with a CONNECT tunnel through
+// http:// and https:// proxies and a SOCKS5 connection through socks5://
+// ones. It serves clients that pick the address themselves, which a
+// transport's Proxy can't do since the proxy resolves the request's host.
+func DialProxy(ctx context.Context, dialer *net.Dialer, proxy *url.URL, addr string) (net.Conn, error) {
+	switch proxy.Scheme {
+	case "socks5":
+		var auth *xproxy.Auth
+		if proxy.User != nil {
+			password, _ := proxy.User.Password()
+			auth = &xproxy.Auth{User: proxy.User.Username(), Password: password}
+		}
+		socks, err := xproxy.SOCKS5("tcp", proxyAddr(proxy), auth, dialer)
+		if err != nil {
+			return nil, err
+		}
+		return socks.(xproxy.ContextDialer).DialContext(ctx, "tcp", addr)
+	case "http", "https":
+		return dialConnect(ctx, dialer, proxy, addr)
+	}
+	return nil, fmt.Errorf("unsupported proxy %q", proxy.Redacted())
+}
+
+// dialConnect opens a CONNECT tunnel to addr through an HTTP proxy
+func dialConnect(ctx context.Context, dialer *net.Dialer, proxy *url.URL, addr string) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr(proxy))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else if dialer.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(dialer.Timeout))
+	}
+	if proxy.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxy.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", proxy.Host, addr, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// proxyAddr returns the host:port of proxy, with the scheme's default port
+// when the URL has none
+func proxyAddr(proxy *url.URL) string {
+	if proxy.Port() != "" {
+		return proxy.Host
+	}
+	port := map[string]string{"http": "80", "https": "443", "socks5": "1080"}[proxy.Scheme]
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
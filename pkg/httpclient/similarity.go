@@ -0,0 +1,30 @@
+package httpclient
+
+import "strings"
+
+// Similarity returns the Jaccard similarity of the words of a and b, which
+// tolerates the nonces and timestamps that differ between two page loads
+func Similarity(a string, b string) float64 {
+	wordsA := wordSet(a)
+	wordsB := wordSet(b)
+	if len(wordsA) == 0 && len(wordsB) == 0 {
+		return 1
+	}
+
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// wordSet returns the distinct whitespace-separated words of s
+func wordSet(s string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(s) {
+		words[word] = true
+	}
+	return words
+}
@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// BaseURL returns the root URL of a web service, leaving out the scheme's
+// default port, e.g. https://host or http://host:8080
+func BaseURL(scheme string, host string, port int) string {
+	if port == 0 || (scheme == "https" && port == 443) || (scheme == "http" && port == 80) {
+		return scheme + "://" + host
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// URLPort returns the port of a base URL, including the scheme's default
+// port, or 0 when it has none
+func URLPort(rawURL string) int {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	if port, err := strconv.Atoi(parsed.Port()); err == nil {
+		return port
+	}
+	switch parsed.Scheme {
+	case "https":
+		return 443
+	case "http":
+		return 80
+	}
+	return 0
+}
+
+// DetectWebService tries HTTPS and then plain HTTP on a host's port and
+// returns the base URL of the first that answers, or "" when neither does
+func DetectWebService(client *http.Client, host string, port int) string {
+	for _, scheme := range []string{"https", "http"} {
+		url := BaseURL(scheme, host, port)
+		resp, err := client.Get(url)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		return url
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// warmMaxAge is how long a warmed connection is kept for its first request;
+// servers close idle connections, and a closed one would fail the request
+const warmMaxAge = 10 * time.Second
+
+// Warmer resolves hosts and completes the TLS handshake with them ahead of
+// the requests to them, so DNS and handshake latency overlaps other work.
+// Clients created with it use the warmed connections for their first HTTPS
+// request to a host and the resolved addresses for every request.
+type Warmer struct {
+	timeout time.Duration
+	proxy   func(*http.Request) (*url.URL, error)
+	dialer  *net.Dialer
+
+	mu     sync.Mutex
+	addrs  map[string][]string
+	conns  map[string]warmConn
+	closed bool
+}
+
+// warmConn is a connection past its TLS handshake, waiting for its request
+type warmConn struct {
+	conn    net.Conn
+	created time.Time
+}
+
+// NewWarmer returns a warmer dialing with timeout. Hosts reached through
+// proxy, or the environment's proxy when nil (see ProxyFunc), are not warmed.
+func NewWarmer(timeout time.Duration, proxy *url.URL) *Warmer {
+	return &Warmer{
+		timeout: timeout,
+		proxy:   ProxyFunc(proxy),
+		dialer:  &net.Dialer{Timeout: timeout},
+		addrs:   make(map[string][]string),
+		conns:   make(map[string]warmConn),
+	}
+}
+
+// Warm resolves host, unless its addresses are given, and opens a TLS
+// connection to its port 443. Failures are left for the requests to report.
+func (w *Warmer) Warm(ctx context.Context, host string, addrs []string) {
+	if w.proxied(host) {
+		return
+	}
+	if len(addrs) == 0 {
+		resolved, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return
+		}
+		addrs = resolved
+	}
+	w.mu.Lock()
+	w.addrs[host] = addrs
+	w.mu.Unlock()
+
+	conn, err := w.dialTLS(ctx, net.JoinHostPort(host, "443"))
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if old, ok := w.conns[host]; ok {
+		old.conn.Close()
+	}
+	if w.closed {
+		conn.Close()
+		return
+	}
+	w.conns[host] = warmConn{conn: conn, created: time.Now()}
+}
+
+// Close closes the warmed connections no request used, and the ones warmed
+// from then on
+func (w *Warmer) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	for host, warm := range w.conns {
+		warm.conn.Close()
+		delete(w.conns, host)
+	}
+}
+
+// proxied reports whether requests to host go through a proxy
+func (w *Warmer) proxied(host string) bool {
+	if w.proxy == nil {
+		return false
+	}
+	proxy, err := w.proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: host}})
+	return err != nil || proxy != nil
+}
+
+// dialContext dials addr through the host's warmed addresses when known
+func (w *Warmer) dialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	addrs := w.addrs[host]
+	w.mu.Unlock()
+	if len(addrs) == 0 {
+		return w.dialer.DialContext(ctx, network, addr)
+	}
+	for _, ip := range addrs {
+		var conn net.Conn
+		if conn, err = w.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// dialTLSContext hands out the host's warmed connection on port 443, or dials
+// and completes a new handshake
+func (w *Warmer) dialTLSContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	if host, port, err := net.SplitHostPort(addr); err == nil && port == "443" {
+		w.mu.Lock()
+		warm, ok := w.conns[host]
+		delete(w.conns, host)
+		w.mu.Unlock()
+		if ok && time.Since(warm.created) < warmMaxAge {
+			return warm.conn, nil
+		}
+		if ok {
+			warm.conn.Close()
+		}
+	}
+	return w.dialTLS(ctx, addr)
+}
+
+// dialTLS dials addr and completes the TLS handshake without certificate
+// validation, as the clients' own transports do
+func (w *Warmer) dialTLS(ctx context.Context, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := w.dialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := tls.Client(raw, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true, // Skip certificate validation for analysis
+		NextProtos:         []string{"http/1.1"},
+	})
+	if w.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.timeout)
+		defer cancel()
+	}
+	if err := conn.HandshakeContext(ctx); err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("tls handshake with %s: %v", addr, err)
+	}
+	return conn, nil
+}
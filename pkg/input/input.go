@@ -0,0 +1,318 @@
+package input
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Input formats recognized by Detect
+const (
+	FormatPlain = "plain"
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// hostFields are the keys and column names that carry a host name, in order of
+// preference. They cover subscan's own outputs as well as common tools such as
+// subfinder, amass and httpx.
+var hostFields = []string{"domain", "subdomain", "host", "hostname", "name", "input", "url"}
+
+// Detect guesses the format of the given data: a JSON document, JSON Lines,
+// CSV with a header row, or a plain list with one host per line
+func Detect(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return FormatPlain
+	}
+
+	firstLine := trimmed
+	if i := bytes.IndexByte(trimmed, '\n'); i >= 0 {
+		firstLine = bytes.TrimSpace(trimmed[:i])
+	}
+
+	switch {
+	case trimmed[0] == '[':
+		return FormatJSON
+	case trimmed[0] == '{':
+		if json.Valid(trimmed) {
+			return FormatJSON
+		}
+		return FormatJSONL
+	case bytes.ContainsRune(firstLine, ','):
+		return FormatCSV
+	default:
+		return FormatPlain
+	}
+}
+
+// Read returns the contents of a file, or of standard input for a path of "-"
+func Read(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// ReadHosts reads host names from a file in any format recognized by Detect.
+// A path of "-" reads standard input.
+func ReadHosts(path string) ([]string, error) {
+	data, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseHosts(data)
+}
+
+// Objects splits a JSON array, a single JSON object or JSON Lines into the raw
+// objects they hold, so callers can decode them into their own types
+func Objects(data []byte) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	switch Detect(data) {
+	case FormatJSON:
+		if bytes.TrimSpace(data)[0] != '[' {
+			return []json.RawMessage{data}, nil
+		}
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("error parsing JSON input: %v", err)
+		}
+	case FormatJSONL:
+		for i, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if !json.Valid([]byte(line)) {
+				return nil, fmt.Errorf("error parsing JSON Lines input: line %d is not valid JSON", i+1)
+			}
+			items = append(items, json.RawMessage(line))
+		}
+	default:
+		return nil, fmt.Errorf("input is not JSON")
+	}
+	return items, nil
+}
+
+// Fields returns the lowercased keys of the first JSON object, or the column
+// names of a CSV header, so callers can tell which kind of report they were given
+func Fields(data []byte) []string {
+	var fields []string
+	switch Detect(data) {
+	case FormatJSON, FormatJSONL:
+		items, err := Objects(data)
+		if err != nil || len(items) == 0 {
+			return nil
+		}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(items[0], &object); err != nil {
+			return nil
+		}
+		// Describe the hosts of a versioned report rather than the document
+		var hosts []map[string]json.RawMessage
+		if err := json.Unmarshal(object["hosts"], &hosts); err == nil && len(hosts) > 0 {
+			object = hosts[0]
+		}
+		for key := range object {
+			fields = append(fields, strings.ToLower(key))
+		}
+	case FormatCSV:
+		header, err := csv.NewReader(bytes.NewReader(data)).Read()
+		if err != nil {
+			return nil
+		}
+		for _, name := range header {
+			fields = append(fields, strings.ToLower(strings.TrimSpace(name)))
+		}
+	}
+	return fields
+}
+
+// HasField reports whether fields, as returned by Fields, contain name
+func HasField(fields []string, name string) bool {
+	for _, field := range fields {
+		if field == strings.ToLower(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHosts extracts host names from data in any format recognized by Detect.
+// Names are normalized and de-duplicated, keeping their first-seen order.
+func ParseHosts(data []byte) ([]string, error) {
+	var raw []string
+	var err error
+	switch Detect(data) {
+	case FormatJSON:
+		raw, err = parseJSON(data)
+	case FormatJSONL:
+		raw, err = parseJSONL(data)
+	case FormatCSV:
+		raw, err = parseCSV(data)
+	default:
+		raw = parsePlain(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, host := range raw {
+		host = NormalizeHost(host)
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// NormalizeHost reduces a host name or URL to a lowercase host name without
+// scheme, port, path or wildcard prefix
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[:i], ":") {
+		host = host[:i]
+	}
+	host = strings.TrimPrefix(host, "*.")
+	return strings.TrimSuffix(host, ".")
+}
+
+// parsePlain reads one host per line, ignoring blank lines and # comments
+func parsePlain(data []byte) []string {
+	var hosts []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hosts = append(hosts, strings.Fields(line)[0])
+	}
+	return hosts
+}
+
+// parseJSON reads a JSON array of host names or objects, a single object, or
+// the hosts of a versioned report
+func parseJSON(data []byte) ([]string, error) {
+	var items []json.RawMessage
+	if bytes.TrimSpace(data)[0] == '[' {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("error parsing JSON input: %v", err)
+		}
+	} else {
+		// Versioned reports keep their hosts under "hosts"
+		var report struct {
+			Hosts []json.RawMessage `json:"hosts"`
+		}
+		if err := json.Unmarshal(data, &report); err == nil && report.Hosts != nil {
+			items = report.Hosts
+		} else {
+			items = []json.RawMessage{data}
+		}
+	}
+
+	var hosts []string
+	for _, item := range items {
+		if host := hostFromJSON(item); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+// parseJSONL reads one JSON object or string per line
+func parseJSONL(data []byte) ([]string, error) {
+	var hosts []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !json.Valid([]byte(line)) {
+			return nil, fmt.Errorf("error parsing JSON Lines input: line %d is not valid JSON", i+1)
+		}
+		if host := hostFromJSON([]byte(line)); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+// hostFromJSON returns the host carried by a JSON string or object
+func hostFromJSON(item []byte) string {
+	var name string
+	if err := json.Unmarshal(item, &name); err == nil {
+		return name
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(item, &object); err != nil {
+		return ""
+	}
+	for _, field := range hostFields {
+		for key, value := range object {
+			if strings.EqualFold(key, field) {
+				if name, ok := value.(string); ok && name != "" {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// parseCSV reads the host column of a CSV file. The column is found by name in
+// the header row; without a recognizable header the first column is used.
+func parseCSV(data []byte) ([]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CSV input: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	column := HostColumn(records[0])
+	if column >= 0 {
+		records = records[1:]
+	} else {
+		column = 0
+	}
+
+	var hosts []string
+	for _, record := range records {
+		if column < len(record) {
+			hosts = append(hosts, record[column])
+		}
+	}
+	return hosts, nil
+}
+
+// HostColumn returns the index of the host column in a CSV header row, or -1
+func HostColumn(header []string) int {
+	for _, field := range hostFields {
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), field) {
+				return i
+			}
+		}
+	}
+	return -1
+}
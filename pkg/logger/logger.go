@@ -0,0 +1,178 @@
+// Package logger writes leveled progress and diagnostic messages to standard
+// error, as text or JSON lines, so standard output only carries results and
+// can be piped into other tools. On terminals a status line, such as a
+// progress bar, can be kept below the messages.
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log message
+type Level int
+
+// Levels from most to least verbose. LevelSilent disables every message.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelSilent
+)
+
+// String returns the level's name
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "silent"
+	}
+}
+
+// ParseLevel returns the level with the given name
+func ParseLevel(name string) (Level, error) {
+	for level := LevelDebug; level <= LevelSilent; level++ {
+		if strings.EqualFold(strings.TrimSpace(name), level.String()) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (available: debug, info, warn, error, silent)", name)
+}
+
+// Logger writes the messages at or above its level
+type Logger struct {
+	mu       sync.Mutex
+	out      io.Writer
+	level    Level
+	json     bool
+	terminal bool
+	// status is the status line currently drawn below the messages
+	status string
+}
+
+// New returns a logger writing to out, as JSON lines when jsonOutput is set
+func New(out io.Writer, level Level, jsonOutput bool) *Logger {
+	l := &Logger{out: out, level: level, json: jsonOutput}
+	if f, ok := out.(*os.File); ok {
+		info, err := f.Stat()
+		l.terminal = err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	return l
+}
+
+// Interactive reports whether status lines are drawn, which takes a terminal,
+// text output and the info level
+func (l *Logger) Interactive() bool {
+	return l.terminal && !l.json && l.Enabled(LevelInfo)
+}
+
+// Status replaces the status line with line, or erases it when line is empty.
+// It does nothing unless the logger is interactive.
+func (l *Logger) Status(line string) {
+	if !l.Interactive() {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.status = line
+	io.WriteString(l.out, clearLine+line)
+}
+
+// clearLine moves to the start of the line and erases it
+const clearLine = "\r\033[K"
+
+// Enabled reports whether messages of the level are written
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level && l.level != LevelSilent
+}
+
+// Log writes a message of the given level. Text messages get a prefix naming
+// warnings and errors; JSON messages carry the time, level and message.
+func (l *Logger) Log(level Level, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	msg := strings.TrimSpace(fmt.Sprintf(format, args...))
+
+	var line []byte
+	if l.json {
+		line, _ = json.Marshal(struct {
+			Time  string `json:"time"`
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}{time.Now().UTC().Format(time.RFC3339), level.String(), msg})
+	} else {
+		switch level {
+		case LevelWarn:
+			msg = "Warning: " + msg
+		case LevelError:
+			msg = "Error: " + msg
+		}
+		line = []byte(msg)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.status != "" {
+		// Messages go above the status line, which is drawn again below them
+		io.WriteString(l.out, clearLine)
+		defer io.WriteString(l.out, l.status)
+	}
+	l.out.Write(append(line, '\n'))
+}
+
+// std is the logger of the package-level functions
+var std = New(os.Stderr, LevelInfo, false)
+
+// Configure sets the level and format of the package-level functions
+func Configure(level Level, jsonOutput bool) {
+	std = New(os.Stderr, level, jsonOutput)
+}
+
+// Enabled reports whether the package-level functions write messages of the level
+func Enabled(level Level) bool {
+	return std.Enabled(level)
+}
+
+// Interactive reports whether the package-level functions draw status lines
+func Interactive() bool {
+	return std.Interactive()
+}
+
+// Status replaces the status line of the package-level functions
+func Status(line string) {
+	std.Status(line)
+}
+
+// Debugf logs per-item details only shown at the debug level
+func Debugf(format string, args ...interface{}) {
+	std.Log(LevelDebug, format, args...)
+}
+
+// Infof logs progress
+func Infof(format string, args ...interface{}) {
+	std.Log(LevelInfo, format, args...)
+}
+
+// Warnf logs a problem the run continues after
+func Warnf(format string, args ...interface{}) {
+	std.Log(LevelWarn, format, args...)
+}
+
+// Errorf logs a problem that stops the current task
+func Errorf(format string, args ...interface{}) {
+	std.Log(LevelError, format, args...)
+}
@@ -0,0 +1,381 @@
+// Package model defines the versioned JSON document Subscan writes for scan
+// results, so automation can rely on a stable shape.
+//
+// Compatibility rules: fields are only ever added within a schema version, so
+// readers must ignore fields they don't know. Removing or changing the meaning
+// of a field bumps SchemaVersion. Reports written before the schema existed
+// were bare JSON arrays of hosts; Parse still reads those as version 0.
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the report schema written by this release
+const SchemaVersion = 1
+
+// Report kinds, telling which stage produced the hosts
+const (
+	KindScore = "score"
+	KindProbe = "probe"
+	// KindResolve reports hold resolved hosts only, with their CNAME and addresses
+	KindResolve = "resolve"
+)
+
+// Report is the top-level JSON document of a scan
+type Report struct {
+	SchemaVersion int       `json:"schema_version"`
+	Scan          Scan      `json:"scan"`
+	Hosts         []Host    `json:"hosts"`
+	Findings      []Finding `json:"findings,omitempty"`
+	// Nameservers are the target's authoritative nameservers, when fingerprinted
+	Nameservers []Nameserver `json:"nameservers,omitempty"`
+	// Baseline profiles the apex and www hosts, the reference other hosts'
+	// responses are compared with
+	Baseline []Profile `json:"baseline,omitempty"`
+	// Buckets are the cloud storage buckets found under names derived from
+	// the target
+	Buckets []Bucket `json:"buckets,omitempty"`
+	// Apex holds the NS, MX, TXT, SOA and SRV records of the target domain
+	Apex *ApexRecords `json:"apex,omitempty"`
+	// Caveats list what the scan skipped or left partial, such as failed
+	// passive sources and interrupted stages
+	Caveats []string `json:"caveats,omitempty"`
+	// Legend describes the tags the report's hosts carry
+	Legend []Tag `json:"legend,omitempty"`
+}
+
+// Scan describes the run that produced a report
+type Scan struct {
+	Tool string `json:"tool"`
+	// Kind is KindScore, KindProbe or KindResolve
+	Kind string `json:"kind"`
+	// Target is the scanned apex domain, empty for scans of a host list
+	Target      string    `json:"target,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+	HostCount   int       `json:"host_count"`
+}
+
+// Host is a scanned subdomain. Score reports fill the scoring fields, probe
+// reports the probe fields; field names match the json and jsonl formats.
+type Host struct {
+	Domain        string   `json:"domain"`
+	Status        int      `json:"status"`
+	ContentLength int64    `json:"content_length"`
+	CNAME         string   `json:"cname,omitempty"`
+	IPs           []string `json:"ips,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	FinalURL      string   `json:"final_url,omitempty"`
+	RedirectChain []string `json:"redirect_chain,omitempty"`
+	URLs          []string `json:"urls,omitempty"`
+	Sources       []string `json:"sources,omitempty"`
+
+	// Scoring
+	Score         float64  `json:"score,omitempty"`
+	CloudProvider string   `json:"cloud_provider,omitempty"`
+	IsTLS         bool     `json:"is_tls,omitempty"`
+	Language      string   `json:"language,omitempty"`
+	SaaSProvider  string   `json:"saas_provider,omitempty"`
+	Title         string   `json:"title,omitempty"`
+	Server        string   `json:"server,omitempty"`
+	PoweredBy     string   `json:"powered_by,omitempty"`
+	Technologies  []string `json:"technologies,omitempty"`
+	FaviconHash   int32    `json:"favicon_hash,omitempty"`
+	OpenPorts     []int    `json:"open_ports,omitempty"`
+	Provenance    string   `json:"provenance,omitempty"`
+	Screenshots   []string `json:"screenshots,omitempty"`
+	TLS           *TLSInfo `json:"tls,omitempty"`
+
+	// Probing
+	IsTakeover      bool     `json:"is_takeover,omitempty"`
+	S3Public        bool     `json:"s3_public,omitempty"`
+	S3Private       bool     `json:"s3_private,omitempty"`
+	ExposedFiles    []string `json:"exposed_files,omitempty"`
+	OpenRedirect    bool     `json:"open_redirect,omitempty"`
+	RedirectURL     string   `json:"redirect_url,omitempty"`
+	Vulnerabilities []string `json:"vulnerabilities,omitempty"`
+	ProbedAt        string   `json:"probed_at,omitempty"`
+	Error           string   `json:"error,omitempty"`
+	Skipped         string   `json:"skipped,omitempty"`
+
+	// Ownership annotations
+	Owner string `json:"owner,omitempty"`
+	Team  string `json:"team,omitempty"`
+	Notes string `json:"notes,omitempty"`
+}
+
+// TLSInfo is what a host's TLS handshake revealed about its certificate and
+// configuration
+type TLSInfo struct {
+	// Fingerprint is the SHA-256 of the leaf certificate, in hex
+	Fingerprint string    `json:"fingerprint"`
+	Subject     string    `json:"subject,omitempty"`
+	Issuer      string    `json:"issuer,omitempty"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	SelfSigned  bool      `json:"self_signed,omitempty"`
+	Expired     bool      `json:"expired,omitempty"`
+	// Wildcard is set when the certificate covers a *. name
+	Wildcard bool `json:"wildcard,omitempty"`
+	// Version and Cipher are what the host negotiated with Subscan
+	Version string `json:"version,omitempty"`
+	Cipher  string `json:"cipher,omitempty"`
+	// WeakProtocols are the deprecated protocol versions the host still
+	// accepts and WeakCipher an insecure cipher suite it agreed to, when checked
+	WeakProtocols []string `json:"weak_protocols,omitempty"`
+	WeakCipher    string   `json:"weak_cipher,omitempty"`
+}
+
+// Finding is an issue reported by a probe check, identified by a stable ID
+type Finding struct {
+	ID       string `json:"id"`
+	Host     string `json:"host"`
+	Check    string `json:"check"`
+	Evidence string `json:"evidence,omitempty"`
+	// Remediation is how to fix the finding, with References to read more
+	Remediation string   `json:"remediation,omitempty"`
+	References  []string `json:"references,omitempty"`
+}
+
+// Nameserver is an authoritative nameserver of the target. Version and
+// Hostname are what it answers to the CHAOS-class version.bind and
+// hostname.bind queries, empty when it refuses them as hardened servers do.
+type Nameserver struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses,omitempty"`
+	Version   string   `json:"version,omitempty"`
+	Hostname  string   `json:"hostname,omitempty"`
+	// ZoneTransfer is set when the nameserver hands the zone out over AXFR
+	ZoneTransfer bool `json:"zone_transfer,omitempty"`
+}
+
+// String describes the nameserver on one line
+func (ns Nameserver) String() string {
+	var details []string
+	if ns.Version != "" {
+		details = append(details, "version "+ns.Version)
+	}
+	if ns.Hostname != "" {
+		details = append(details, "hostname "+ns.Hostname)
+	}
+	if len(details) == 0 {
+		details = append(details, "no version disclosed")
+	}
+	if ns.ZoneTransfer {
+		details = append(details, "zone transfer allowed")
+	}
+	return fmt.Sprintf("%s (%s): %s", ns.Name, strings.Join(ns.Addresses, ", "), strings.Join(details, ", "))
+}
+
+// Profile is a snapshot of a host's web response. Error is set instead of the
+// response fields when the host didn't answer over HTTP(S).
+type Profile struct {
+	Host         string   `json:"host"`
+	URL          string   `json:"url,omitempty"`
+	Status       int      `json:"status,omitempty"`
+	Title        string   `json:"title,omitempty"`
+	Technologies []string `json:"technologies,omitempty"`
+	CertIssuer   string   `json:"cert_issuer,omitempty"`
+	CertSubject  string   `json:"cert_subject,omitempty"`
+	CertExpires  string   `json:"cert_expires,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// String describes the profile on one line
+func (p Profile) String() string {
+	if p.Error != "" {
+		return fmt.Sprintf("%s: %s", p.Host, p.Error)
+	}
+	details := []string{fmt.Sprintf("%d", p.Status)}
+	if p.Title != "" {
+		details = append(details, fmt.Sprintf("%q", p.Title))
+	}
+	if len(p.Technologies) > 0 {
+		details = append(details, strings.Join(p.Technologies, ", "))
+	}
+	if p.CertIssuer != "" {
+		details = append(details, fmt.Sprintf("cert by %s until %s", p.CertIssuer, p.CertExpires))
+	}
+	return fmt.Sprintf("%s (%s): %s", p.Host, p.URL, strings.Join(details, " | "))
+}
+
+// Bucket is a cloud storage bucket named after the target. Access is
+// "public" when anyone can list its objects, of which Objects holds a sample,
+// and "private" when it exists but refuses anonymous listing.
+type Bucket struct {
+	Name     string   `json:"name"`
+	Provider string   `json:"provider"`
+	URL      string   `json:"url"`
+	Access   string   `json:"access"`
+	Objects  []string `json:"objects,omitempty"`
+}
+
+// String describes the bucket on one line
+func (b Bucket) String() string {
+	line := fmt.Sprintf("%s (%s): %s %s", b.Name, b.Provider, b.Access, b.URL)
+	if len(b.Objects) > 0 {
+		line += fmt.Sprintf(" [%s]", strings.Join(b.Objects, ", "))
+	}
+	return line
+}
+
+// ApexRecords are the DNS records of the target domain itself: where its
+// mail goes, who serves its zone, and the SPF, DMARC and verification TXT
+// records revealing the services it uses
+type ApexRecords struct {
+	Domain string      `json:"domain"`
+	NS     []string    `json:"ns,omitempty"`
+	MX     []MXRecord  `json:"mx,omitempty"`
+	TXT    []string    `json:"txt,omitempty"`
+	SOA    *SOARecord  `json:"soa,omitempty"`
+	SRV    []SRVRecord `json:"srv,omitempty"`
+	// DMARC is the TXT policy record of _dmarc under the domain
+	DMARC string `json:"dmarc,omitempty"`
+}
+
+// MXRecord is a mail exchanger of the domain
+type MXRecord struct {
+	Host       string `json:"host"`
+	Preference uint16 `json:"preference"`
+}
+
+// SOARecord is the start of authority of the domain's zone
+type SOARecord struct {
+	PrimaryNS string `json:"primary_ns"`
+	Mailbox   string `json:"mailbox"`
+	Serial    uint32 `json:"serial"`
+	Refresh   uint32 `json:"refresh"`
+	Retry     uint32 `json:"retry"`
+	Expire    uint32 `json:"expire"`
+	MinTTL    uint32 `json:"min_ttl"`
+}
+
+// SRVRecord is a service record found under a well-known service name such
+// as _sip._tls
+type SRVRecord struct {
+	Service  string `json:"service"`
+	Target   string `json:"target"`
+	Port     uint16 `json:"port"`
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+}
+
+// String describes the SRV record on one line
+func (r SRVRecord) String() string {
+	return fmt.Sprintf("%s -> %s:%d (priority %d, weight %d)", r.Service, r.Target, r.Port, r.Priority, r.Weight)
+}
+
+// String describes the SOA record on one line
+func (r SOARecord) String() string {
+	return fmt.Sprintf("%s %s serial %d", r.PrimaryNS, r.Mailbox, r.Serial)
+}
+
+// SPF returns the domain's SPF record, or "" when it has none
+func (r ApexRecords) SPF() string {
+	for _, txt := range r.TXT {
+		if strings.HasPrefix(strings.ToLower(txt), "v=spf1") {
+			return txt
+		}
+	}
+	return ""
+}
+
+// Verifications returns the services the domain's TXT records prove
+// ownership to, named as in the records, e.g. google-site-verification
+func (r ApexRecords) Verifications() []string {
+	var services []string
+	seen := make(map[string]bool)
+	for _, txt := range r.TXT {
+		i := strings.Index(txt, "=")
+		if i <= 0 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(txt[:i]))
+		if !strings.Contains(name, "verification") && !strings.HasSuffix(name, "-verify") && name != "ms" {
+			continue
+		}
+		if name == "ms" {
+			name = "microsoft (MS)"
+		}
+		if !seen[name] {
+			seen[name] = true
+			services = append(services, name)
+		}
+	}
+	return services
+}
+
+// Empty reports whether no records were found
+func (r ApexRecords) Empty() bool {
+	return len(r.NS) == 0 && len(r.MX) == 0 && len(r.TXT) == 0 && r.SOA == nil && len(r.SRV) == 0 && r.DMARC == ""
+}
+
+// Tag describes a tag: its category, how urgent hosts carrying it are (info,
+// low, medium, high or critical) and what it means
+type Tag struct {
+	Name        string `json:"name"`
+	Category    string `json:"category"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// NewReport returns an empty report of the current schema version
+func NewReport(kind string, target string) Report {
+	return Report{
+		SchemaVersion: SchemaVersion,
+		Scan: Scan{
+			Tool:        "subscan",
+			Kind:        kind,
+			Target:      target,
+			GeneratedAt: time.Now().UTC(),
+		},
+		Hosts: []Host{},
+	}
+}
+
+// IsReport reports whether data is a versioned report document
+func IsReport(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return false
+	}
+	var header struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	return json.Unmarshal(trimmed, &header) == nil && header.SchemaVersion != nil
+}
+
+// Parse reads a report document, or a bare JSON array of hosts as written
+// before the schema was versioned, which is returned as version 0
+func Parse(data []byte) (Report, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var hosts []Host
+		if err := json.Unmarshal(trimmed, &hosts); err != nil {
+			return Report{}, fmt.Errorf("error parsing report: %v", err)
+		}
+		return Report{Hosts: hosts, Scan: Scan{HostCount: len(hosts)}}, nil
+	}
+
+	var report Report
+	if err := json.Unmarshal(trimmed, &report); err != nil {
+		return Report{}, fmt.Errorf("error parsing report: %v", err)
+	}
+	if !IsReport(trimmed) {
+		return Report{}, fmt.Errorf("error parsing report: no schema_version")
+	}
+	return report, nil
+}
+
+// FindingsByHost groups the report's findings by host
+func (r Report) FindingsByHost() map[string][]Finding {
+	byHost := make(map[string][]Finding)
+	for _, finding := range r.Findings {
+		byHost[finding.Host] = append(byHost[finding.Host], finding)
+	}
+	return byHost
+}
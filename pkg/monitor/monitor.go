@@ -0,0 +1,84 @@
+// Package monitor keeps the snapshots of repeated scans of a target, so each
+// scan can be compared with the previous one.
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/omerimzali/subscan/pkg/probe"
+)
+
+// DefaultDir is the directory snapshots are kept in when none is given
+const DefaultDir = ".subscan-monitor"
+
+// snapshotTime is the layout of snapshot file names, sortable as strings
+const snapshotTime = "20060102T150405Z"
+
+// Store keeps snapshots in a directory per target. Each snapshot is a report
+// file named after the time it was taken, so it can be read by the report and
+// diff commands.
+type Store struct {
+	dir string
+}
+
+// Open returns the snapshot store in dir, creating the directory when needed
+func Open(dir string) (*Store, error) {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating snapshot directory: %v", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// targetDir returns the directory of a target's snapshots
+func (s *Store) targetDir(target string) string {
+	return filepath.Join(s.dir, strings.ReplaceAll(target, string(filepath.Separator), "_"))
+}
+
+// Latest returns the most recent snapshot of a target, or nil when there is none
+func (s *Store) Latest(target string) (*model.Report, error) {
+	paths, err := filepath.Glob(filepath.Join(s.targetDir(target), "[0-9]*.json"))
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	data, err := os.ReadFile(paths[len(paths)-1])
+	if err != nil {
+		return nil, err
+	}
+	report, err := model.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s: %v", paths[len(paths)-1], err)
+	}
+	return &report, nil
+}
+
+// Save stores a snapshot of the report's target and returns its path
+func (s *Store) Save(report model.Report) (string, error) {
+	dir := s.targetDir(report.Scan.Target)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, report.Scan.GeneratedAt.UTC().Format(snapshotTime)+".json")
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// Findings returns the store remembering which findings of a target were
+// already alerted on
+func (s *Store) Findings(target string) (*probe.FindingStore, error) {
+	return probe.LoadFindingStore(filepath.Join(s.targetDir(target), "findings-state.json"))
+}
@@ -0,0 +1,227 @@
+// Package notify sends alerts about scan results to chat webhooks, generic
+// HTTP endpoints and email.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// Event is something worth alerting about in a scan of a target
+type Event struct {
+	Target        string          `json:"target"`
+	Time          time.Time       `json:"time"`
+	NewSubdomains []string        `json:"new_subdomains,omitempty"`
+	NewFindings   []model.Finding `json:"new_findings,omitempty"`
+}
+
+// Empty reports whether the event has nothing to alert about
+func (e Event) Empty() bool {
+	return len(e.NewSubdomains) == 0 && len(e.NewFindings) == 0
+}
+
+// Title is a one-line summary of the event
+func (e Event) Title() string {
+	var parts []string
+	if n := len(e.NewSubdomains); n > 0 {
+		parts = append(parts, plural(n, "new subdomain"))
+	}
+	if n := len(e.NewFindings); n > 0 {
+		parts = append(parts, plural(n, "new finding"))
+	}
+	return fmt.Sprintf("[subscan] %s: %s", e.Target, strings.Join(parts, ", "))
+}
+
+// Text is the event's title followed by its subdomains and findings
+func (e Event) Text() string {
+	var text strings.Builder
+	text.WriteString(e.Title() + "\n")
+	for _, subdomain := range e.NewSubdomains {
+		text.WriteString("+ " + subdomain + "\n")
+	}
+	for _, finding := range e.NewFindings {
+		line := fmt.Sprintf("! %s %s", finding.Host, finding.Check)
+		if finding.Evidence != "" {
+			line += ": " + finding.Evidence
+		}
+		text.WriteString(line + "\n")
+	}
+	return text.String()
+}
+
+// plural formats a count of things
+func plural(n int, thing string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", thing)
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
+
+// Notifier delivers events to one destination
+type Notifier interface {
+	Notify(event Event) error
+}
+
+// Options configures the destinations of notifications; empty ones are skipped
+type Options struct {
+	SlackWebhook   string
+	DiscordWebhook string
+	// Webhook receives each event as a JSON POST
+	Webhook string
+	Email   EmailOptions
+	Timeout time.Duration
+}
+
+// EmailOptions configures delivery by SMTP
+type EmailOptions struct {
+	// Server is the SMTP server as host:port
+	Server   string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// DefaultOptions returns options with no destinations
+func DefaultOptions() Options {
+	return Options{Timeout: 10 * time.Second}
+}
+
+// New returns a notifier delivering to every configured destination, or nil
+// when none is configured
+func New(options Options) Notifier {
+	client := &http.Client{Timeout: options.Timeout}
+
+	var all Multi
+	if options.SlackWebhook != "" {
+		all = append(all, &Slack{URL: options.SlackWebhook, client: client})
+	}
+	if options.DiscordWebhook != "" {
+		all = append(all, &Discord{URL: options.DiscordWebhook, client: client})
+	}
+	if options.Webhook != "" {
+		all = append(all, &Webhook{URL: options.Webhook, client: client})
+	}
+	if options.Email.Server != "" && len(options.Email.To) > 0 {
+		all = append(all, &Email{Options: options.Email})
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return all
+}
+
+// Multi delivers events to several notifiers
+type Multi []Notifier
+
+// Notify delivers the event to every notifier, returning their failures joined
+func (m Multi) Notify(event Event) error {
+	var failures []string
+	for _, notifier := range m {
+		if err := notifier.Notify(event); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Slack posts events to a Slack incoming webhook
+type Slack struct {
+	URL    string
+	client *http.Client
+}
+
+// Notify posts the event's text to Slack
+func (s *Slack) Notify(event Event) error {
+	return postJSON(s.client, s.URL, map[string]string{"text": event.Text()}, "Slack")
+}
+
+// discordLimit is the maximum length of a Discord message
+const discordLimit = 2000
+
+// Discord posts events to a Discord webhook
+type Discord struct {
+	URL    string
+	client *http.Client
+}
+
+// Notify posts the event's text to Discord, truncated to its message limit
+func (d *Discord) Notify(event Event) error {
+	content := event.Text()
+	if len(content) > discordLimit {
+		content = content[:discordLimit-4] + "\n..."
+	}
+	return postJSON(d.client, d.URL, map[string]string{"content": content}, "Discord")
+}
+
+// Webhook posts events as JSON to any HTTP endpoint
+type Webhook struct {
+	URL    string
+	client *http.Client
+}
+
+// Notify posts the event as JSON
+func (w *Webhook) Notify(event Event) error {
+	return postJSON(w.client, w.URL, event, "webhook")
+}
+
+// postJSON posts v as JSON to url, failing on non-2xx answers
+func postJSON(client *http.Client, url string, v interface{}, name string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s notification failed: %v", name, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s notification failed: %s", name, resp.Status)
+	}
+	return nil
+}
+
+// Email sends events by SMTP
+type Email struct {
+	Options EmailOptions
+}
+
+// Notify mails the event to the configured recipients
+func (e *Email) Notify(event Event) error {
+	host := e.Options.Server
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	var auth smtp.Auth
+	if e.Options.Username != "" {
+		auth = smtp.PlainAuth("", e.Options.Username, e.Options.Password, host)
+	}
+
+	from := e.Options.From
+	if from == "" {
+		from = e.Options.Username
+	}
+
+	var message strings.Builder
+	message.WriteString("From: " + from + "\r\n")
+	message.WriteString("To: " + strings.Join(e.Options.To, ", ") + "\r\n")
+	message.WriteString("Subject: " + event.Title() + "\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(event.Text(), "\n", "\r\n"))
+
+	if err := smtp.SendMail(e.Options.Server, auth, from, e.Options.To, []byte(message.String())); err != nil {
+		return fmt.Errorf("email notification failed: %v", err)
+	}
+	return nil
+}
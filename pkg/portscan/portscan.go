@@ -0,0 +1,214 @@
+// Package portscan checks resolved hosts for open TCP ports with plain
+// connect scans, so services away from the web ports are found without
+// passive port data or external tools.
+package portscan
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/progress"
+)
+
+// CommonPorts are the web, remote access, database and dev service ports
+// scanned by default
+var CommonPorts = []int{
+	21, 22, 23, 25, 53, 80, 110, 143, 443, 445, 993, 995, 1433, 2375, 3000,
+	3306, 3389, 5432, 5601, 5900, 6379, 8000, 8080, 8443, 8888, 9000, 9090,
+	9200, 11211, 27017,
+}
+
+// TopPorts are the 100 TCP ports most often found open, as ranked by nmap
+var TopPorts = []int{
+	7, 9, 13, 21, 22, 23, 25, 26, 37, 53, 79, 80, 81, 88, 106, 110, 111, 113,
+	119, 135, 139, 143, 144, 179, 199, 389, 427, 443, 444, 445, 465, 513, 514,
+	515, 543, 544, 548, 554, 587, 631, 646, 873, 990, 993, 995, 1025, 1026,
+	1027, 1028, 1029, 1110, 1433, 1720, 1723, 1755, 1900, 2000, 2001, 2049,
+	2121, 2717, 3000, 3128, 3306, 3389, 3986, 4899, 5000, 5009, 5051, 5060,
+	5101, 5190, 5357, 5432, 5631, 5666, 5800, 5900, 6000, 6001, 6646, 7070,
+	8000, 8008, 8009, 8080, 8081, 8443, 8888, 9100, 9999, 10000, 32768, 49152,
+	49153, 49154, 49155, 49156, 49157,
+}
+
+// Options configures a port scan
+type Options struct {
+	// Ports are the TCP ports checked on every address
+	Ports []int
+	// Concurrency is the number of connections attempted at once
+	Concurrency int
+	// Timeout bounds each connection attempt
+	Timeout time.Duration
+}
+
+// DefaultOptions returns the default port scan options
+func DefaultOptions() Options {
+	return Options{
+		Ports:       CommonPorts,
+		Concurrency: 100,
+		Timeout:     2 * time.Second,
+	}
+}
+
+// ParsePorts parses a port list: "common", "top-100", or comma-separated
+// ports and ranges like 80,443,8000-8100. The ports are returned sorted and
+// deduplicated.
+func ParsePorts(value string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+	add := func(port int) {
+		if !seen[port] {
+			seen[port] = true
+			ports = append(ports, port)
+		}
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		switch strings.ToLower(part) {
+		case "":
+			continue
+		case "common":
+			for _, port := range CommonPorts {
+				add(port)
+			}
+			continue
+		case "top-100", "top100":
+			for _, port := range TopPorts {
+				add(port)
+			}
+			continue
+		}
+
+		first, last := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			first, last = part[:i], part[i+1:]
+		}
+		from, err := parsePort(first)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parsePort(last)
+		if err != nil {
+			return nil, err
+		}
+		if from > to {
+			return nil, fmt.Errorf("invalid port range %q", part)
+		}
+		for port := from; port <= to; port++ {
+			add(port)
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports given")
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+// parsePort parses a single TCP port number
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", value)
+	}
+	return port, nil
+}
+
+// Scan connects to the ports of every address of the hosts, given as their
+// resolved IPs by name, and returns the open ports of each host, sorted.
+// Hosts sharing an address are scanned once. Canceling ctx returns the ports
+// found so far.
+func Scan(ctx context.Context, hosts map[string][]string, options Options) map[string][]int {
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var addresses []string
+	seen := make(map[string]bool)
+	for _, ips := range hosts {
+		for _, ip := range ips {
+			if !seen[ip] {
+				seen[ip] = true
+				addresses = append(addresses, ip)
+			}
+		}
+	}
+	sort.Strings(addresses)
+
+	type job struct {
+		ip   string
+		port int
+	}
+	open := make(map[string][]int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan job)
+	tracker := progress.Start("Port scanning", len(addresses)*len(options.Ports))
+	dialer := &net.Dialer{Timeout: options.Timeout}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(j.ip, strconv.Itoa(j.port)))
+				tracker.Increment()
+				if err != nil {
+					continue
+				}
+				conn.Close()
+				mu.Lock()
+				open[j.ip] = append(open[j.ip], j.port)
+				mu.Unlock()
+			}
+		}()
+	}
+
+scan:
+	for _, ip := range addresses {
+		for _, port := range options.Ports {
+			if ctx.Err() != nil {
+				break scan
+			}
+			jobs <- job{ip: ip, port: port}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	tracker.Finish()
+
+	ports := make(map[string][]int)
+	for host, ips := range hosts {
+		for _, ip := range ips {
+			ports[host] = Merge(ports[host], open[ip])
+		}
+	}
+	for host, list := range ports {
+		if len(list) == 0 {
+			delete(ports, host)
+		}
+	}
+	return ports
+}
+
+// Merge returns the ports of both lists, sorted and deduplicated
+func Merge(existing []int, ports []int) []int {
+	seen := make(map[int]bool, len(existing)+len(ports))
+	var merged []int
+	for _, list := range [][]int{existing, ports} {
+		for _, port := range list {
+			if !seen[port] {
+				seen[port] = true
+				merged = append(merged, port)
+			}
+		}
+	}
+	sort.Ints(merged)
+	return merged
+}
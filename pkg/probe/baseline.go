@@ -0,0 +1,65 @@
+package probe
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+)
+
+// baselineSimilarity is the similarity above which a sensitive file response
+// is taken for the host's generic page rather than the file
+const baselineSimilarity = 0.9
+
+// softNotFound is a host's answer to a path that cannot exist. Single-page
+// apps and catch-all routes answer every path with 200 and the same page,
+// which would otherwise match loose file signatures like "Disallow:".
+type softNotFound struct {
+	path string
+	body string
+}
+
+// fetchSoftNotFound requests a random path and returns the host's answer when
+// it is a 200, or nil when the host reports missing paths properly
+func fetchSoftNotFound(guard *blockGuard, client *http.Client, domain string, userAgent string) *softNotFound {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil
+	}
+	path := "/subscan-" + hex.EncodeToString(nonce)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s%s", domain, path), nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := guard.do(client, req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 5*1024))
+	if err != nil {
+		return nil
+	}
+	return &softNotFound{path: path, body: string(body)}
+}
+
+// matches reports whether the body returned for path is the host's generic
+// page. Both paths are removed first since many pages echo the request path.
+func (b *softNotFound) matches(body string, path string) bool {
+	if b == nil {
+		return false
+	}
+	generic := strings.ReplaceAll(b.body, b.path, "")
+	body = strings.ReplaceAll(body, path, "")
+	return httpclient.Similarity(generic, body) >= baselineSimilarity
+}
@@ -0,0 +1,143 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// TagBlocked marks hosts that started blocking the scanner during probing
+const TagBlocked = "BLOCKED"
+
+// errBlocked is returned for requests answered with a block page
+var errBlocked = errors.New("host is blocking requests")
+
+// blockSignatures are body markers of WAF challenge and captcha pages
+var blockSignatures = []string{
+	"Attention Required! | Cloudflare",
+	"cf-chl-",
+	"/cdn-cgi/challenge-platform/",
+	"g-recaptcha",
+	"h-captcha",
+	"_Incapsula_Resource",
+	"Request unsuccessful. Incapsula incident",
+	"Access Denied - Sucuri Website Firewall",
+	"The requested URL was rejected. Please consult with your administrator.",
+}
+
+// blockStatus reports whether a status code may carry a block page
+func blockStatus(status int) bool {
+	return status == http.StatusForbidden || status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// blockReason describes why a response looks like the host is blocking the
+// scanner, or returns "" when it does not
+func blockReason(resp *http.Response, body []byte) string {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return "rate limited (429)"
+	}
+	if resp.Header.Get("Cf-Mitigated") == "challenge" {
+		return fmt.Sprintf("Cloudflare challenge (%d)", resp.StatusCode)
+	}
+	if !blockStatus(resp.StatusCode) {
+		return ""
+	}
+	for _, sig := range blockSignatures {
+		if bytes.Contains(body, []byte(sig)) {
+			return fmt.Sprintf("challenge page (%d)", resp.StatusCode)
+		}
+	}
+	return ""
+}
+
+// retryAfter returns the delay requested by a Retry-After header in seconds, or 0
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// blockGuard tracks block signals from a single host. On the first block it
+// waits out the cooldown once and lets the caller retry; a host that keeps
+// blocking is left alone for the rest of the probe.
+type blockGuard struct {
+	cooldown  time.Duration
+	backedOff bool
+	reason    string
+	// ctx cuts the cooldown short when the probe is canceled
+	ctx context.Context
+}
+
+// inspect records a block when the response looks like one and reports whether it did
+func (g *blockGuard) inspect(resp *http.Response, body []byte) bool {
+	reason := blockReason(resp, body)
+	if reason == "" {
+		return false
+	}
+	g.reason = reason
+	return true
+}
+
+// backoff sleeps for the cooldown, shortened to the host's Retry-After when
+// that is sooner, and reports whether the caller should retry. It only backs
+// off once per host, and not at all once the probe is canceled.
+func (g *blockGuard) backoff(resp *http.Response) bool {
+	if g.backedOff || g.cooldown <= 0 {
+		return false
+	}
+	g.backedOff = true
+
+	wait := g.cooldown
+	if after := retryAfter(resp); after > 0 && after < wait {
+		wait = after
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-g.ctx.Done():
+		return false
+	}
+	g.reason = ""
+	return true
+}
+
+// blocked reports whether the host is still blocking
+func (g *blockGuard) blocked() bool {
+	return g.reason != ""
+}
+
+// do sends an active check request. Block pages are backed off from and
+// retried once, after which errBlocked is returned.
+func (g *blockGuard) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	for {
+		resp, err := client.Do(req)
+		if err != nil || !blockStatus(resp.StatusCode) {
+			return resp, err
+		}
+
+		peek, _ := io.ReadAll(io.LimitReader(resp.Body, 5*1024))
+		if !g.inspect(resp, peek) {
+			resp.Body = peekedBody{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if !g.backoff(resp) {
+			return nil, errBlocked
+		}
+	}
+}
+
+// peekedBody replays the bytes read while checking for a block page
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,68 @@
+package probe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Probe check names usable with ProbeOptions.Checks
+const (
+	CheckTakeover       = "takeover"
+	CheckS3             = "s3"
+	CheckSensitiveFiles = "sensitive-files"
+	CheckOpenRedirect   = "open-redirect"
+	CheckZoneTransfer   = "zone-transfer"
+)
+
+// AllChecks lists every probe check in the order they run
+var AllChecks = []string{CheckTakeover, CheckS3, CheckSensitiveFiles, CheckOpenRedirect, CheckZoneTransfer}
+
+// SelectChecks returns the checks to run: the given names (every check when
+// empty) minus the disabled ones, which may leave none. Unknown names are
+// rejected.
+func SelectChecks(names []string, disabled []string) ([]string, error) {
+	if len(names) == 0 {
+		names = AllChecks
+	}
+
+	skip := make(map[string]bool)
+	for _, name := range disabled {
+		skip[name] = true
+	}
+
+	checks := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isKnownCheck(name) {
+			return nil, fmt.Errorf("unknown probe check %q (available: %s)", name, strings.Join(AllChecks, ", "))
+		}
+		if !skip[name] {
+			checks = append(checks, name)
+		}
+	}
+	return checks, nil
+}
+
+// isKnownCheck reports whether name is a probe check
+func isKnownCheck(name string) bool {
+	for _, check := range AllChecks {
+		if check == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEnabled reports whether a check should run: any check when Checks is
+// nil, and otherwise only those in it
+func (options ProbeOptions) checkEnabled(name string) bool {
+	if options.Checks == nil {
+		return true
+	}
+	for _, check := range options.Checks {
+		if check == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package probe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// Finding is a single vulnerability on a host with a stable fingerprint ID
+type Finding = model.Finding
+
+// SeenFinding is a finding along with when it was first and last observed
+type SeenFinding struct {
+	Finding
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Fingerprint returns a stable ID for a finding derived from host, check and evidence
+func Fingerprint(host string, check string, evidence string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(host) + "\x00" + check + "\x00" + evidence))
+	return hex.EncodeToString(sum[:8])
+}
+
+// BuildFindings converts the vulnerabilities of a probe result into fingerprinted findings
+func BuildFindings(result ProbeResult) []Finding {
+	var findings []Finding
+	for _, vuln := range result.Vulnerabilities {
+		evidence := findingEvidence(result, vuln)
+		finding := Finding{
+			ID:       Fingerprint(result.Domain, vuln, evidence),
+			Host:     result.Domain,
+			Check:    vuln,
+			Evidence: evidence,
+		}
+		if remediation, ok := VulnerabilityRemediation(vuln); ok {
+			finding.Remediation = remediation.Summary
+			finding.References = remediation.References
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// findingEvidence picks the evidence that identifies a vulnerability, so a finding
+// keeps its ID while unchanged and gets a new one when what it points at changes
+func findingEvidence(result ProbeResult, vuln string) string {
+	switch {
+	case strings.HasPrefix(vuln, "Subdomain Takeover"), strings.Contains(vuln, "S3 Bucket"):
+		return result.CNAME
+	case vuln == "Open Redirect":
+		return result.RedirectURL
+	default:
+		return ""
+	}
+}
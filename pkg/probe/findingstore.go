@@ -0,0 +1,74 @@
+package probe
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// FindingStore remembers findings across runs so unchanged findings can be
+// suppressed while their last-seen timestamps are still tracked
+type FindingStore struct {
+	path     string
+	Findings map[string]*SeenFinding `json:"findings"`
+}
+
+// LoadFindingStore reads a finding store from path, starting empty if the file doesn't exist
+func LoadFindingStore(path string) (*FindingStore, error) {
+	store := &FindingStore{
+		path:     path,
+		Findings: make(map[string]*SeenFinding),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, err
+	}
+	if store.Findings == nil {
+		store.Findings = make(map[string]*SeenFinding)
+	}
+	return store, nil
+}
+
+// Observe records findings seen now and returns the ones never seen before
+func (s *FindingStore) Observe(findings []Finding, now time.Time) []Finding {
+	var fresh []Finding
+	for _, finding := range findings {
+		if existing, ok := s.Findings[finding.ID]; ok {
+			existing.LastSeen = now
+			continue
+		}
+		s.Findings[finding.ID] = &SeenFinding{Finding: finding, FirstSeen: now, LastSeen: now}
+		fresh = append(fresh, finding)
+	}
+	return fresh
+}
+
+// Stale returns stored findings not seen since the given time, oldest first
+func (s *FindingStore) Stale(since time.Time) []SeenFinding {
+	var stale []SeenFinding
+	for _, finding := range s.Findings {
+		if finding.LastSeen.Before(since) {
+			stale = append(stale, *finding)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].LastSeen.Before(stale[j].LastSeen) })
+	return stale
+}
+
+// Save writes the store back to its file
+func (s *FindingStore) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
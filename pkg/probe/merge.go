@@ -0,0 +1,80 @@
+package probe
+
+import (
+	"sort"
+	"time"
+)
+
+// MergeResults merges probe result sets from several runs into one. For hosts
+// present in more than one set the most recently probed result wins, falling
+// back to the later set when timestamps are missing or equal, while tags,
+// vulnerabilities, exposed files and findings are unioned across all sets.
+func MergeResults(sets ...[]ProbeResult) []ProbeResult {
+	merged := make(map[string]*ProbeResult)
+	var order []string
+
+	for _, set := range sets {
+		for _, result := range set {
+			existing, ok := merged[result.Domain]
+			if !ok {
+				copied := result
+				merged[result.Domain] = &copied
+				order = append(order, result.Domain)
+				continue
+			}
+
+			winner, other := result, *existing
+			if probedAt(existing.ProbedAt).After(probedAt(result.ProbedAt)) {
+				winner, other = *existing, result
+			}
+
+			winner.Tags = unionStrings(winner.Tags, other.Tags)
+			winner.Vulnerabilities = unionStrings(winner.Vulnerabilities, other.Vulnerabilities)
+			winner.ExposedFiles = unionStrings(winner.ExposedFiles, other.ExposedFiles)
+			winner.Findings = unionFindings(winner.Findings, other.Findings)
+			*existing = winner
+		}
+	}
+
+	sort.Strings(order)
+	results := make([]ProbeResult, 0, len(order))
+	for _, domain := range order {
+		results = append(results, *merged[domain])
+	}
+	return results
+}
+
+// probedAt parses a result timestamp, returning the zero time when missing or invalid
+func probedAt(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// unionStrings appends the values of b missing from a, keeping a's order
+func unionStrings(a []string, b []string) []string {
+	seen := make(map[string]bool)
+	var union []string
+	for _, value := range append(append([]string{}, a...), b...) {
+		if !seen[value] {
+			seen[value] = true
+			union = append(union, value)
+		}
+	}
+	return union
+}
+
+// unionFindings merges two finding lists by fingerprint ID
+func unionFindings(a []Finding, b []Finding) []Finding {
+	seen := make(map[string]bool)
+	var union []Finding
+	for _, finding := range append(append([]Finding{}, a...), b...) {
+		if !seen[finding.ID] {
+			seen[finding.ID] = true
+			union = append(union, finding)
+		}
+	}
+	return union
+}
@@ -1,23 +1,38 @@
 package probe
 
 import (
-	"crypto/tls"
+	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/omerimzali/subscan/pkg/coverage"
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/input"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/omerimzali/subscan/pkg/progress"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/robots"
+	"github.com/omerimzali/subscan/pkg/schedule"
 )
 
 // ProbeResult represents the result of probing a subdomain for misconfigurations
 type ProbeResult struct {
 	Domain           string   `json:"domain"`
 	CNAME            string   `json:"cname,omitempty"`
+	IPs              []string `json:"ips,omitempty"`
 	HTTPStatus       int      `json:"status"`
 	ContentLength    int64    `json:"content_length"`
 	IsTakeover       bool     `json:"is_takeover"`
@@ -26,8 +41,23 @@ type ProbeResult struct {
 	ExposedFiles     []string `json:"exposed_files,omitempty"`
 	RedirectURL      string   `json:"redirect_url,omitempty"`
 	OpenRedirect     bool     `json:"open_redirect"`
+	RedirectChain    []string `json:"redirect_chain,omitempty"`
+	FinalURL         string   `json:"final_url,omitempty"`
+	// URLs are the base URLs of the web services that answered
+	URLs             []string `json:"urls,omitempty"`
 	Vulnerabilities  []string `json:"vulnerabilities,omitempty"`
+	Findings         []Finding `json:"findings,omitempty"`
 	Tags             []string `json:"tags,omitempty"`
+	Owner            string   `json:"owner,omitempty"`
+	Team             string   `json:"team,omitempty"`
+	Notes            string   `json:"notes,omitempty"`
+	// Sources are the passive sources and stages that found the host
+	Sources          []string `json:"sources,omitempty"`
+	ProbedAt         string   `json:"probed_at,omitempty"`
+	// Error is set when the host could not be probed at all
+	Error            string   `json:"error,omitempty"`
+	// Skipped explains why the host's checks were not run
+	Skipped          string   `json:"skipped,omitempty"`
 }
 
 // ProbeOptions contains configuration for the probing process
@@ -36,15 +66,51 @@ type ProbeOptions struct {
 	Timeout     time.Duration
 	UserAgent   string
 	Verbose     bool
+	// FollowRedirects makes the initial request follow redirects and record the chain
+	FollowRedirects bool
+	MaxRedirects    int
+	// Scope is the target domain; redirects ending outside it are flagged
+	Scope string
+	// RespectRobots skips path checks disallowed by the host's robots.txt
+	RespectRobots bool
+	// RequestDelay is the minimum time between two requests to the same host
+	RequestDelay time.Duration
+	// AcceptEncoding is advertised on requests; responses are decoded before matching
+	AcceptEncoding string
+	// Checks restricts probing to these checks when non-nil; nil runs every check
+	Checks []string
+	// Records holds DNS data from resolution, reused instead of querying again
+	Records map[string]resolver.DNSRecord
+	// OnResult is called from the workers with each result as soon as it is probed
+	OnResult func(ProbeResult)
+	// DiscardResults only hands results to OnResult instead of also collecting
+	// them, for scans too large to hold in memory
+	DiscardResults bool
+	// Windows restricts work to scan windows, pausing between jobs outside them
+	Windows schedule.Windows
+	// BlockCooldown is waited once when a host starts blocking before retrying;
+	// 0 skips the host's remaining active checks right away
+	BlockCooldown time.Duration
+	// Proxy routes every request; the environment's proxy when nil
+	Proxy *url.URL
+	// HTTPPorts are tried for HTTP(S) besides 80 and 443, adding the web
+	// services that answer to URLs
+	HTTPPorts []int
+	// Warmup resolves the next hosts and completes their TLS handshakes while
+	// the current ones are probed
+	Warmup bool
+	// warmer holds what Warmup prepared for the hosts' clients
+	warmer *httpclient.Warmer
 }
 
 // DefaultProbeOptions returns a default set of probe options
 func DefaultProbeOptions() ProbeOptions {
 	return ProbeOptions{
-		Concurrency: 10,
-		Timeout:     10 * time.Second,
-		UserAgent:   "Subscan/1.0",
-		Verbose:     false,
+		Concurrency:  10,
+		Timeout:      10 * time.Second,
+		UserAgent:    "Subscan/1.0",
+		Verbose:      false,
+		MaxRedirects: httpclient.DefaultMaxRedirects,
 	}
 }
 
@@ -77,6 +143,35 @@ var takeoversignatures = map[string]struct {
 	"Netlify":            {[]string{"netlify.app", "netlify.com"}, []string{"Not found", "404"}},
 }
 
+// takeoverProviders returns the providers whose CNAME patterns match the CNAME
+func takeoverProviders(cname string) []string {
+	var providers []string
+	for provider, signature := range takeoversignatures {
+		for _, cnamePattern := range signature.cname {
+			if strings.Contains(cname, cnamePattern) {
+				providers = append(providers, provider)
+				break
+			}
+		}
+	}
+	return providers
+}
+
+// takeoverMatches returns the providers matching the CNAME whose unclaimed
+// resource signature appears in the response body
+func takeoverMatches(cname string, body string) []string {
+	var matches []string
+	for _, provider := range takeoverProviders(cname) {
+		for _, contentPattern := range takeoversignatures[provider].matches {
+			if strings.Contains(body, contentPattern) {
+				matches = append(matches, provider)
+				break
+			}
+		}
+	}
+	return matches
+}
+
 // Sensitive file paths to check for exposure
 var sensitiveFilePaths = []struct {
 	path        string
@@ -116,29 +211,74 @@ var openRedirectPatterns = []struct {
 	{"/", "r"},
 }
 
-// RunProbes runs all probes against a list of domains
-func RunProbes(domains []string, options ProbeOptions) []ProbeResult {
-	results := make([]ProbeResult, 0, len(domains))
-	resultsChan := make(chan ProbeResult, len(domains))
+// RunProbes runs all probes against a list of domains. Canceling ctx stops
+// the probes and returns the results of the hosts finished so far.
+func RunProbes(ctx context.Context, domains []string, options ProbeOptions) []ProbeResult {
+	var results []ProbeResult
+	var probed int32
+	capacity := len(domains)
+	if options.DiscardResults {
+		capacity = 0
+	}
+	resultsChan := make(chan ProbeResult, capacity)
 	var wg sync.WaitGroup
 	
 	// Create a rate limiter to control concurrency
 	semaphore := make(chan struct{}, options.Concurrency)
+	tracker := progress.Start("Probing", len(domains))
+	
+	// The next batch of hosts is warmed up while the current one is probed
+	var dispatched int32
+	var blocked int32
+	ahead := make(chan struct{}, options.Concurrency)
+	if options.Warmup {
+		warmCtx, stopWarming := context.WithCancel(ctx)
+		options.warmer = httpclient.NewWarmer(options.Timeout, options.Proxy)
+		defer options.warmer.Close()
+		defer stopWarming()
+		go warmAhead(warmCtx, domains, &dispatched, ahead, options)
+	}
 	
 	// Process all domains
 	for _, domain := range domains {
+		if ctx.Err() != nil {
+			break
+		}
 		wg.Add(1)
 		
+		// Acquire semaphore before starting the goroutine so only as many
+		// goroutines as probes in flight exist
+		semaphore <- struct{}{}
+		atomic.AddInt32(&dispatched, 1)
+		select {
+		case <-ahead:
+		default:
+		}
+		
 		go func(domain string) {
 			defer wg.Done()
-			
-			// Acquire semaphore
-			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
+			defer tracker.Increment()
 			
 			// Perform the probe
-			result := probeDomain(domain, options)
-			resultsChan <- result
+			if options.Windows.Wait(ctx) != nil {
+				return
+			}
+			result := probeDomain(ctx, domain, options)
+			if ctx.Err() != nil {
+				// Checks cut short would report the host as clean
+				return
+			}
+			atomic.AddInt32(&probed, 1)
+			if hasTag(result, TagBlocked) {
+				atomic.AddInt32(&blocked, 1)
+			}
+			if !options.DiscardResults {
+				resultsChan <- result
+			}
+			if options.OnResult != nil {
+				options.OnResult(result)
+			}
 			
 			if options.Verbose {
 				// Print any detected issues
@@ -157,9 +297,9 @@ func RunProbes(domains []string, options ProbeOptions) []ProbeResult {
 				}
 				
 				if len(issues) > 0 {
-					fmt.Printf("🔴 %s: %s\n", domain, strings.Join(issues, ", "))
+					logger.Infof("🔴 %s: %s", domain, strings.Join(issues, ", "))
 				} else if options.Verbose {
-					fmt.Printf("🟢 %s: No issues found\n", domain)
+					logger.Infof("🟢 %s: No issues found", domain)
 				}
 			}
 		}(domain)
@@ -175,102 +315,169 @@ func RunProbes(domains []string, options ProbeOptions) []ProbeResult {
 	for result := range resultsChan {
 		results = append(results, result)
 	}
+	tracker.Finish()
+	if blocked > 0 {
+		coverage.Note(ctx, "%d hosts started blocking the probes; their checks are partial", blocked)
+	}
+	if ctx.Err() != nil {
+		logger.Warnf("probing interrupted after %d of %d hosts", probed, len(domains))
+		coverage.Note(ctx, "probing interrupted after %d of %d hosts", probed, len(domains))
+	}
 	
 	return results
 }
 
+// warmAhead warms the hosts up to one batch ahead of the probes. Each slot
+// of ahead is a warmed host waiting for its probe; hosts the probes reached
+// first are skipped.
+func warmAhead(ctx context.Context, domains []string, dispatched *int32, ahead chan struct{}, options ProbeOptions) {
+	for i, domain := range domains {
+		select {
+		case ahead <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		if i < int(atomic.LoadInt32(dispatched)) {
+			select {
+			case <-ahead:
+			default:
+			}
+			continue
+		}
+		var addrs []string
+		if record, ok := options.Records[domain]; ok {
+			addrs = record.IPs()
+		}
+		go options.warmer.Warm(ctx, domain, addrs)
+	}
+}
+
 // probeDomain performs a comprehensive probe of a single domain
-func probeDomain(domain string, options ProbeOptions) ProbeResult {
+func probeDomain(ctx context.Context, domain string, options ProbeOptions) ProbeResult {
 	result := ProbeResult{
-		Domain: domain,
-		Tags:   []string{},
+		Domain:   domain,
+		Tags:     []string{},
+		ProbedAt: time.Now().UTC().Format(time.RFC3339),
 	}
 	
-	// HTTP Client with custom timeout and TLS configuration
-	client := &http.Client{
-		Timeout: options.Timeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, // Skip certificate validation for probing
-			},
-			DisableKeepAlives: true,
-		},
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			// Don't follow redirects automatically
-			return http.ErrUseLastResponse
-		},
-	}
+	// HTTP Client with custom timeout and TLS configuration. The individual
+	// checks never follow redirects since open redirect detection relies on 3xx.
+	client := httpclient.New(httpclient.Options{
+		Timeout:           options.Timeout,
+		DisableKeepAlives: true,
+		UserAgent:         options.UserAgent,
+		RequestDelay:      options.RequestDelay,
+		AcceptEncoding:    options.AcceptEncoding,
+		Context:           ctx,
+		Proxy:             options.Proxy,
+		Warmer:            options.warmer,
+	})
+	
+	// The initial request may follow redirects to record the final destination
+	pageClient := httpclient.New(httpclient.Options{
+		Timeout:           options.Timeout,
+		FollowRedirects:   options.FollowRedirects,
+		MaxRedirects:      options.MaxRedirects,
+		DisableKeepAlives: true,
+		UserAgent:         options.UserAgent,
+		RequestDelay:      options.RequestDelay,
+		AcceptEncoding:    options.AcceptEncoding,
+		Context:           ctx,
+		Proxy:             options.Proxy,
+		Warmer:            options.warmer,
+	})
 	
 	// 1. Perform initial HTTP request
 	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s", domain), nil)
 	if err != nil {
+		result.Error = err.Error()
 		return result
 	}
 	
 	req.Header.Set("User-Agent", options.UserAgent)
-	resp, err := client.Do(req)
+	resp, err := pageClient.Do(req)
 	
 	var body []byte
 	if err == nil {
 		defer resp.Body.Close()
 		result.HTTPStatus = resp.StatusCode
-		result.ContentLength = resp.ContentLength
+		result.URLs = []string{httpclient.BaseURL("https", domain, 0)}
 		
-		// Read response body (limited to 10KB to avoid memory issues)
-		bodyReader := io.LimitReader(resp.Body, 10*1024)
-		body, _ = io.ReadAll(bodyReader)
+		// Keep the first 10KB of the body and measure the rest
+		body, result.ContentLength = httpclient.ReadBody(resp, 10*1024)
 	} else {
 		// Try HTTP if HTTPS fails
 		req, err = http.NewRequest("GET", fmt.Sprintf("http://%s", domain), nil)
 		if err != nil {
+			result.Error = err.Error()
 			return result
 		}
 		
 		req.Header.Set("User-Agent", options.UserAgent)
-		resp, err = client.Do(req)
+		resp, err = pageClient.Do(req)
 		
 		if err == nil {
 			defer resp.Body.Close()
 			result.HTTPStatus = resp.StatusCode
-			result.ContentLength = resp.ContentLength
+			result.URLs = []string{httpclient.BaseURL("http", domain, 0)}
 			
-			bodyReader := io.LimitReader(resp.Body, 10*1024)
-			body, _ = io.ReadAll(bodyReader)
+			body, result.ContentLength = httpclient.ReadBody(resp, 10*1024)
+		} else {
+			result.Error = err.Error()
+		}
+	}
+	
+	// Challenge pages and rate limiting are backed off from, then recorded as
+	// BLOCKED so the remaining active checks don't report false negatives
+	guard := &blockGuard{cooldown: options.BlockCooldown, ctx: ctx}
+	if err == nil && guard.inspect(resp, body) && guard.backoff(resp) {
+		if retryResp, retryErr := pageClient.Do(req); retryErr == nil {
+			defer retryResp.Body.Close()
+			resp = retryResp
+			result.HTTPStatus = resp.StatusCode
+			body, result.ContentLength = httpclient.ReadBody(resp, 10*1024)
+			guard.inspect(resp, body)
+		}
+	}
+	
+	if err == nil && options.FollowRedirects {
+		recordRedirects(&result, resp, options)
+	}
+	
+	// Dev services often answer on ports other than 80 and 443
+	for _, port := range options.HTTPPorts {
+		if port == 80 || port == 443 || guard.blocked() || ctx.Err() != nil {
+			continue
+		}
+		if url := httpclient.DetectWebService(pageClient, domain, port); url != "" {
+			result.URLs = append(result.URLs, url)
 		}
 	}
 	
-	// 2. Get CNAME records
-	cnames, err := lookupCNAME(domain)
-	if err == nil && len(cnames) > 0 {
+	// 2. Get CNAME records, reusing the resolution stage's records when available
+	if record, ok := options.Records[domain]; ok {
+		result.CNAME = record.CNAME
+		result.IPs = record.IPs()
+		result.Sources = record.Sources
+	} else if cnames, err := lookupCNAME(domain); err == nil && len(cnames) > 0 {
 		result.CNAME = cnames[0]
 	}
 	
 	// 3. Check for subdomain takeover
-	if result.CNAME != "" {
-		for provider, signature := range takeoversignatures {
-			for _, cnamePattern := range signature.cname {
-				if strings.Contains(result.CNAME, cnamePattern) {
-					// Found a matching CNAME pattern, now check the response content
-					for _, contentPattern := range signature.matches {
-						if resp != nil && strings.Contains(string(body), contentPattern) {
-							result.IsTakeover = true
-							vulnDesc := fmt.Sprintf("Subdomain Takeover (%s)", provider)
-							result.Vulnerabilities = append(result.Vulnerabilities, vulnDesc)
-							result.Tags = append(result.Tags, "TAKEOVER-CANDIDATE")
-							result.Tags = append(result.Tags, provider)
-							break
-						}
-					}
-					break
-				}
-			}
+	if result.CNAME != "" && options.checkEnabled(CheckTakeover) && resp != nil {
+		for _, provider := range takeoverMatches(result.CNAME, string(body)) {
+			result.IsTakeover = true
+			vulnDesc := fmt.Sprintf("Subdomain Takeover (%s)", provider)
+			result.Vulnerabilities = append(result.Vulnerabilities, vulnDesc)
+			result.Tags = append(result.Tags, "TAKEOVER-CANDIDATE")
+			result.Tags = append(result.Tags, provider)
 		}
 	}
 	
 	// 4. Check for S3 bucket
-	if (result.CNAME != "" && (strings.Contains(result.CNAME, "s3.amazonaws.com") || 
+	if options.checkEnabled(CheckS3) && ((result.CNAME != "" && (strings.Contains(result.CNAME, "s3.amazonaws.com") || 
 		strings.Contains(result.CNAME, "amazonaws.com"))) || 
-		(resp != nil && strings.Contains(string(body), "<ListBucketResult")) {
+		(resp != nil && strings.Contains(string(body), "<ListBucketResult"))) {
 		
 		// Check for S3 bucket status
 		if strings.Contains(string(body), "<ListBucketResult") {
@@ -305,13 +512,38 @@ func probeDomain(domain string, options ProbeOptions) ProbeResult {
 		}
 	}
 	
-	// 5. Check for sensitive files
+	// Path checks honor robots.txt in polite mode
+	var rules *robots.Rules
+	if options.RespectRobots {
+		rules = robots.Fetch(client, fmt.Sprintf("https://%s", domain), options.UserAgent)
+	}
+	
+	// Path checks disallowed by robots.txt are counted so a host with every
+	// check disallowed is reported as skipped rather than clean
+	var pathChecks, disallowed int
+	
+	// 5. Check for sensitive files, comparing hits with the host's answer to a
+	// path that doesn't exist so catch-all pages aren't reported as files
+	var baseline *softNotFound
+	if options.checkEnabled(CheckSensitiveFiles) && !guard.blocked() {
+		baseline = fetchSoftNotFound(guard, client, domain, options.UserAgent)
+	}
 	for _, filePath := range sensitiveFilePaths {
+		if !options.checkEnabled(CheckSensitiveFiles) || guard.blocked() {
+			break
+		}
+		
 		// Skip if we already have a large number of vulnerabilities
 		if len(result.Vulnerabilities) >= 5 {
 			break
 		}
 		
+		pathChecks++
+		if !rules.Allowed(filePath.path) {
+			disallowed++
+			continue
+		}
+		
 		fileURL := fmt.Sprintf("https://%s%s", domain, filePath.path)
 		req, err := http.NewRequest("GET", fileURL, nil)
 		if err != nil {
@@ -319,7 +551,7 @@ func probeDomain(domain string, options ProbeOptions) ProbeResult {
 		}
 		
 		req.Header.Set("User-Agent", options.UserAgent)
-		fileResp, err := client.Do(req)
+		fileResp, err := guard.do(client, req)
 		if err != nil {
 			continue
 		}
@@ -327,7 +559,7 @@ func probeDomain(domain string, options ProbeOptions) ProbeResult {
 		if fileResp.StatusCode == 200 {
 			defer fileResp.Body.Close()
 			fileBody, err := io.ReadAll(io.LimitReader(fileResp.Body, 5*1024))
-			if err != nil {
+			if err != nil || baseline.matches(string(fileBody), filePath.path) {
 				continue
 			}
 			
@@ -347,8 +579,8 @@ func probeDomain(domain string, options ProbeOptions) ProbeResult {
 	
 	// 6. Check for open redirects
 	for _, redirectPattern := range openRedirectPatterns {
-		// Skip if we already found a redirect vulnerability
-		if result.OpenRedirect {
+		// Skip if we already found a redirect vulnerability or the check is disabled
+		if result.OpenRedirect || !options.checkEnabled(CheckOpenRedirect) || guard.blocked() {
 			break
 		}
 		
@@ -357,38 +589,74 @@ func probeDomain(domain string, options ProbeOptions) ProbeResult {
 			break
 		}
 		
-		// Test URL
-		testURL := fmt.Sprintf("https://%s%s?%s=https://evil.com", 
-			domain, redirectPattern.pathPattern, redirectPattern.param)
-		
-		req, err := http.NewRequest("GET", testURL, nil)
-		if err != nil {
-			continue
-		}
-		
-		req.Header.Set("User-Agent", options.UserAgent)
-		redirectResp, err := client.Do(req)
-		if err != nil {
+		pathChecks++
+		if !rules.Allowed(redirectPattern.pathPattern) {
+			disallowed++
 			continue
 		}
 		
-		defer redirectResp.Body.Close()
-		
-		// Check if it's a redirect to our evil domain
-		if redirectResp.StatusCode >= 300 && redirectResp.StatusCode < 400 {
-			location := redirectResp.Header.Get("Location")
-			if strings.Contains(location, "evil.com") {
+		// Try each payload encoding until one redirects off the host
+		for _, payload := range redirectPayloads {
+			if guard.blocked() {
+				break
+			}
+			
+			testURL := fmt.Sprintf("https://%s%s?%s=%s", 
+				domain, redirectPattern.pathPattern, redirectPattern.param, strings.ReplaceAll(payload, "{host}", domain))
+			
+			req, err := http.NewRequest("GET", testURL, nil)
+			if err != nil {
+				continue
+			}
+			
+			req.Header.Set("User-Agent", options.UserAgent)
+			redirectResp, err := guard.do(client, req)
+			if err != nil {
+				break // The endpoint is unreachable; other encodings won't fare better
+			}
+			redirectResp.Body.Close()
+			
+			// Only a Location that actually leaves for the canary host counts
+			if redirectResp.StatusCode >= 300 && redirectResp.StatusCode < 400 &&
+				redirectsToCanary(redirectResp.Header.Get("Location"), req.URL) {
 				result.OpenRedirect = true
 				result.RedirectURL = testURL
 				result.Vulnerabilities = append(result.Vulnerabilities, "Open Redirect")
 				result.Tags = append(result.Tags, "OPEN-REDIRECT")
+				break
 			}
 		}
 	}
 	
+	if pathChecks > 0 && disallowed == pathChecks {
+		result.Skipped = "all path checks disallowed by robots.txt"
+	}
+	if guard.blocked() {
+		result.Skipped = "blocked: " + guard.reason
+		result.Tags = append(result.Tags, TagBlocked)
+	}
+	
+	result.Findings = BuildFindings(result)
+	
 	return result
 }
 
+// recordRedirects stores the redirect chain of the initial request and flags
+// chains that end outside the scanned scope
+func recordRedirects(result *ProbeResult, resp *http.Response, options ProbeOptions) {
+	chain := httpclient.RedirectChain(resp)
+	if len(chain) < 2 {
+		return
+	}
+	
+	result.RedirectChain = chain
+	result.FinalURL = chain[len(chain)-1]
+	
+	if !httpclient.InScope(httpclient.FinalHost(resp), options.Scope) {
+		result.Tags = append(result.Tags, "OFF-SCOPE-REDIRECT")
+	}
+}
+
 // lookupCNAME performs DNS CNAME lookup for a domain
 func lookupCNAME(domain string) ([]string, error) {
 	var cnames []string
@@ -411,57 +679,210 @@ func lookupCNAME(domain string) ([]string, error) {
 	return cnames, nil
 }
 
-// ReadProbeResultsFromFile reads probe results from a file
+// errHostList is returned when probe results are read from a plain host list
+var errHostList = fmt.Errorf("plain host list, not a probe report")
+
+// ReadProbeResultsFromFile reads probe results from a JSON, JSON Lines or CSV
+// file, detecting the format from its content. A filename of "-" reads
+// standard input.
 func ReadProbeResultsFromFile(filename string) ([]ProbeResult, error) {
-	file, err := os.ReadFile(filename)
+	file, err := input.Read(filename)
 	if err != nil {
 		return nil, err
 	}
 	
+	results, err := ParseProbeResults(file)
+	if err == errHostList {
+		return nil, fmt.Errorf("%s is a plain host list, not a probe report", filename)
+	}
+	return results, err
+}
+
+// ParseProbeResults parses probe results from JSON, JSON Lines or CSV data
+func ParseProbeResults(file []byte) ([]ProbeResult, error) {
+	var err error
 	var results []ProbeResult
-	err = json.Unmarshal(file, &results)
-	if err != nil {
-		return nil, err
+	trimmed := strings.TrimSpace(string(file))
+	switch input.Detect(file) {
+	case input.FormatJSON:
+		if model.IsReport(file) {
+			report, err := model.Parse(file)
+			if err != nil {
+				return nil, err
+			}
+			return FromReport(report), nil
+		}
+		if strings.HasPrefix(trimmed, "{") {
+			var result ProbeResult
+			err = json.Unmarshal(file, &result)
+			return []ProbeResult{result}, err
+		}
+		err = json.Unmarshal(file, &results)
+		if err != nil {
+			return nil, err
+		}
+		return results, nil
+	case input.FormatCSV:
+		return parseProbeCSV(file)
+	case input.FormatPlain:
+		if trimmed == "" {
+			return nil, nil
+		}
+		return nil, errHostList
+	}
+	
+	// JSON Lines, as written by the jsonl format and streaming mode
+	for i, line := range strings.Split(trimmed, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var result ProbeResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		results = append(results, result)
 	}
 	
 	return results, nil
 }
 
-// FormatProbeResults formats probe results for terminal output
-func FormatProbeResults(results []ProbeResult, includeAll bool) string {
-	var builder strings.Builder
+// parseProbeCSV reads probe results back from the CSV probe report
+func parseProbeCSV(data []byte) ([]ProbeResult, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CSV: %v", err)
+	}
+	if len(records) == 0 || input.HostColumn(records[0]) < 0 {
+		return nil, fmt.Errorf("CSV has no domain column")
+	}
 	
-	// Count statistics
-	var takeovers, s3Issues, exposedFiles, openRedirects int
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	domainColumn := input.HostColumn(records[0])
 	
-	for _, result := range results {
-		if result.IsTakeover {
-			takeovers++
+	var results []ProbeResult
+	for _, record := range records[1:] {
+		get := func(name string) string {
+			if i, ok := columns[strings.ToLower(name)]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
 		}
-		if result.S3Public {
-			s3Issues++
+		list := func(name string) []string {
+			if value := get(name); value != "" {
+				return strings.Split(value, "|")
+			}
+			return nil
 		}
-		if len(result.ExposedFiles) > 0 {
-			exposedFiles++
+		
+		result := ProbeResult{
+			Domain:          input.NormalizeHost(record[domainColumn]),
+			CNAME:           get("CNAME"),
+			IPs:             list("IPs"),
+			IsTakeover:      get("IsTakeover") == "true",
+			S3Public:        get("S3Public") == "true",
+			S3Private:       get("S3Private") == "true",
+			ExposedFiles:    list("ExposedFiles"),
+			OpenRedirect:    get("OpenRedirect") == "true",
+			RedirectURL:     get("RedirectURL"),
+			FinalURL:        get("FinalURL"),
+			Vulnerabilities: list("Vulnerabilities"),
+			Tags:            list("Tags"),
+			Owner:           get("Owner"),
+			Team:            get("Team"),
+			Notes:           get("Notes"),
+			Sources:         list("Sources"),
+			Error:           get("Error"),
+			Skipped:         get("Skipped"),
 		}
-		if result.OpenRedirect {
-			openRedirects++
+		result.HTTPStatus, _ = strconv.Atoi(get("HTTPStatus"))
+		result.ContentLength, _ = strconv.ParseInt(get("ContentLength"), 10, 64)
+		result.Findings = BuildFindings(result)
+		results = append(results, result)
+	}
+	
+	return results, nil
+}
+
+// Summary counts probe outcomes, so totals can be reported without keeping every result
+type Summary struct {
+	Total         int
+	Takeovers     int
+	S3Issues      int
+	ExposedFiles  int
+	OpenRedirects int
+	ZoneTransfers int
+	Errored       int
+	Skipped       int
+}
+
+// Add counts a probe result
+func (s *Summary) Add(result ProbeResult) {
+	s.Total++
+	if result.Error != "" {
+		s.Errored++
+	}
+	if result.Skipped != "" {
+		s.Skipped++
+	}
+	if result.IsTakeover {
+		s.Takeovers++
+	}
+	if result.S3Public {
+		s.S3Issues++
+	}
+	if len(result.ExposedFiles) > 0 {
+		s.ExposedFiles++
+	}
+	if result.OpenRedirect {
+		s.OpenRedirects++
+	}
+	for _, tag := range result.Tags {
+		if tag == TagZoneTransfer {
+			s.ZoneTransfers++
 		}
 	}
+}
+
+// String formats the summary for terminal output
+func (s Summary) String() string {
+	var builder strings.Builder
+	builder.WriteString("=== Probe Summary ===\n")
+	builder.WriteString(fmt.Sprintf("Total domains probed: %d\n", s.Total))
+	builder.WriteString(fmt.Sprintf("Takeover candidates: %d\n", s.Takeovers))
+	builder.WriteString(fmt.Sprintf("S3 bucket issues: %d\n", s.S3Issues))
+	builder.WriteString(fmt.Sprintf("Exposed sensitive files: %d\n", s.ExposedFiles))
+	builder.WriteString(fmt.Sprintf("Open redirects: %d\n", s.OpenRedirects))
+	if s.ZoneTransfers > 0 {
+		builder.WriteString(fmt.Sprintf("Open zone transfers: %d\n", s.ZoneTransfers))
+	}
+	builder.WriteString(fmt.Sprintf("Errored hosts: %d\n", s.Errored))
+	builder.WriteString(fmt.Sprintf("Skipped hosts: %d\n", s.Skipped))
+	return builder.String()
+}
+
+// FormatProbeResults formats probe results for terminal output
+func FormatProbeResults(results []ProbeResult, includeAll bool) string {
+	var builder strings.Builder
 	
 	// Add summary
-	builder.WriteString(fmt.Sprintf("=== Probe Summary ===\n"))
-	builder.WriteString(fmt.Sprintf("Total domains probed: %d\n", len(results)))
-	builder.WriteString(fmt.Sprintf("Takeover candidates: %d\n", takeovers))
-	builder.WriteString(fmt.Sprintf("S3 bucket issues: %d\n", s3Issues))
-	builder.WriteString(fmt.Sprintf("Exposed sensitive files: %d\n", exposedFiles))
-	builder.WriteString(fmt.Sprintf("Open redirects: %d\n", openRedirects))
+	var summary Summary
+	for _, result := range results {
+		summary.Add(result)
+	}
+	builder.WriteString(summary.String())
 	builder.WriteString("\n=== Vulnerability Details ===\n")
 	
 	// Add detailed results for vulnerable domains
 	for _, result := range results {
-		if !includeAll && len(result.Vulnerabilities) == 0 {
-			continue // Skip non-vulnerable domains unless includeAll is true
+		if !includeAll && len(result.Vulnerabilities) == 0 && result.Error == "" && result.Skipped == "" {
+			continue // Skip clean domains unless includeAll is true
 		}
 		
 		// Format tags
@@ -476,6 +897,18 @@ func FormatProbeResults(results []ProbeResult, includeAll bool) string {
 			builder.WriteString(fmt.Sprintf("  CNAME: %s\n", result.CNAME))
 		}
 		
+		if len(result.Sources) > 0 {
+			builder.WriteString(fmt.Sprintf("  Sources: %s\n", strings.Join(result.Sources, ", ")))
+		}
+		
+		if result.Error != "" {
+			builder.WriteString(fmt.Sprintf("  Error: %s\n", result.Error))
+		}
+		
+		if result.Skipped != "" {
+			builder.WriteString(fmt.Sprintf("  Skipped: %s\n", result.Skipped))
+		}
+		
 		if len(result.Vulnerabilities) > 0 {
 			builder.WriteString("  Vulnerabilities:\n")
 			for _, vuln := range result.Vulnerabilities {
@@ -494,6 +927,10 @@ func FormatProbeResults(results []ProbeResult, includeAll bool) string {
 			builder.WriteString(fmt.Sprintf("  Open Redirect URL: %s\n", result.RedirectURL))
 		}
 		
+		if result.FinalURL != "" {
+			builder.WriteString(fmt.Sprintf("  Redirect Chain: %s\n", httpclient.DescribeChain(result.RedirectChain)))
+		}
+		
 		builder.WriteString("\n")
 	}
 	
@@ -0,0 +1,171 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+// Remediation statuses reported by RecheckFindings
+const (
+	StatusFixed           = "fixed"
+	StatusPartiallyFixed  = "partially-fixed"
+	StatusStillVulnerable = "still-vulnerable"
+	// StatusUnreachable means the host no longer answers; removed DNS records
+	// resolve dangling CNAMEs, but network failures look the same
+	StatusUnreachable = "unreachable"
+)
+
+// RecheckResult represents the remediation status of a previously reported host
+type RecheckResult struct {
+	Domain            string    `json:"domain"`
+	Status            string    `json:"status"`
+	Previous          []string  `json:"previous_vulnerabilities"`
+	StillVulnerable   []string  `json:"still_vulnerable,omitempty"`
+	Fixed             []string  `json:"fixed,omitempty"`
+	PreviousCNAME     string    `json:"previous_cname,omitempty"`
+	CurrentCNAME      string    `json:"current_cname,omitempty"`
+	CurrentHTTPStatus int       `json:"current_status"`
+	CheckedAt         time.Time `json:"checked_at"`
+}
+
+// HasFindings reports whether a probe result contains takeover or exposure findings worth re-checking
+func HasFindings(result ProbeResult) bool {
+	return result.IsTakeover || result.S3Public || result.OpenRedirect ||
+		len(result.ExposedFiles) > 0 || len(result.Vulnerabilities) > 0
+}
+
+// RecheckFindings re-probes only the hosts with previously reported findings and
+// marks each reported vulnerability as fixed or still present. Canceling ctx
+// stops the re-checks; hosts not fully re-probed are left out rather than
+// reported as fixed.
+func RecheckFindings(ctx context.Context, previous []ProbeResult, options ProbeOptions) []RecheckResult {
+	var results []RecheckResult
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	semaphore := make(chan struct{}, options.Concurrency)
+
+	for _, prev := range previous {
+		if !HasFindings(prev) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(prev ProbeResult) {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-semaphore }()
+
+			current := probeDomain(ctx, prev.Domain, options)
+			if ctx.Err() != nil {
+				return
+			}
+			result := compareFindings(prev, current)
+
+			if options.Verbose {
+				logger.Infof("%s: %s", result.Domain, result.Status)
+			}
+
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(prev)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// compareFindings builds a RecheckResult from a previous and a fresh probe of the same host
+func compareFindings(prev ProbeResult, current ProbeResult) RecheckResult {
+	result := RecheckResult{
+		Domain:            prev.Domain,
+		Previous:          prev.Vulnerabilities,
+		PreviousCNAME:     prev.CNAME,
+		CurrentCNAME:      current.CNAME,
+		CurrentHTTPStatus: current.HTTPStatus,
+		CheckedAt:         time.Now(),
+	}
+
+	currentVulns := make(map[string]bool)
+	for _, vuln := range current.Vulnerabilities {
+		currentVulns[vuln] = true
+	}
+
+	for _, vuln := range prev.Vulnerabilities {
+		if currentVulns[vuln] {
+			result.StillVulnerable = append(result.StillVulnerable, vuln)
+		} else {
+			result.Fixed = append(result.Fixed, vuln)
+		}
+	}
+
+	switch {
+	case current.HTTPStatus == 0 && current.CNAME == "":
+		result.Status = StatusUnreachable
+		result.Fixed = nil
+		result.StillVulnerable = nil
+	case len(result.StillVulnerable) == 0:
+		result.Status = StatusFixed
+	case len(result.Fixed) == 0:
+		result.Status = StatusStillVulnerable
+	default:
+		result.Status = StatusPartiallyFixed
+	}
+
+	return result
+}
+
+// FormatRecheckResults formats recheck results for terminal output
+func FormatRecheckResults(results []RecheckResult) string {
+	var builder strings.Builder
+
+	var fixed, partial, vulnerable, unreachable int
+	for _, result := range results {
+		switch result.Status {
+		case StatusFixed:
+			fixed++
+		case StatusPartiallyFixed:
+			partial++
+		case StatusStillVulnerable:
+			vulnerable++
+		case StatusUnreachable:
+			unreachable++
+		}
+	}
+
+	builder.WriteString("=== Remediation Status ===\n")
+	builder.WriteString(fmt.Sprintf("Hosts re-checked: %d\n", len(results)))
+	builder.WriteString(fmt.Sprintf("Fixed: %d\n", fixed))
+	builder.WriteString(fmt.Sprintf("Partially fixed: %d\n", partial))
+	builder.WriteString(fmt.Sprintf("Still vulnerable: %d\n", vulnerable))
+	builder.WriteString(fmt.Sprintf("Unreachable: %d\n", unreachable))
+	builder.WriteString("\n=== Details ===\n")
+
+	for _, result := range results {
+		builder.WriteString(fmt.Sprintf("[%s] %s\n", strings.ToUpper(result.Status), result.Domain))
+		for _, vuln := range result.StillVulnerable {
+			builder.WriteString(fmt.Sprintf("  ✗ %s\n", vuln))
+		}
+		for _, vuln := range result.Fixed {
+			builder.WriteString(fmt.Sprintf("  ✓ %s\n", vuln))
+		}
+		if result.PreviousCNAME != "" && result.PreviousCNAME != result.CurrentCNAME {
+			builder.WriteString(fmt.Sprintf("  CNAME changed: %s -> %s\n", result.PreviousCNAME, result.CurrentCNAME))
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
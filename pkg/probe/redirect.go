@@ -0,0 +1,47 @@
+package probe
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redirectCanary is the external host the open redirect payloads point to
+const redirectCanary = "evil.com"
+
+// redirectPayloads are the values tried for a redirect parameter, written
+// as they appear in the raw query with {host} standing for the probed host.
+// Filters often block the plain URL while letting an encoded or
+// browser-normalized form of it through.
+var redirectPayloads = []string{
+	"https://" + redirectCanary,
+	"//" + redirectCanary,                   // protocol-relative
+	"/%5C" + redirectCanary,                 // backslash, read as // by browsers
+	"https%253A%252F%252F" + redirectCanary, // double-encoded
+	"https://{host}@" + redirectCanary,      // allowed host as userinfo
+}
+
+// redirectsToCanary reports whether a Location header sent in response to
+// requestURL sends a browser to the canary host. The location is resolved the
+// way browsers do: relative to the request, with backslashes read as slashes
+// and leading whitespace ignored, so only a real change of host counts.
+func redirectsToCanary(location string, requestURL *url.URL) bool {
+	location = strings.TrimLeft(location, " \t\r\n")
+	if location == "" {
+		return false
+	}
+	location = strings.ReplaceAll(location, "\\", "/")
+
+	target, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	if requestURL != nil {
+		target = requestURL.ResolveReference(target)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return false
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(target.Hostname()), ".")
+	return host == redirectCanary || strings.HasSuffix(host, "."+redirectCanary)
+}
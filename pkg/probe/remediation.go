@@ -0,0 +1,108 @@
+package probe
+
+import "strings"
+
+// Remediation is the guidance for fixing a vulnerability, for the asset owner
+// receiving the report
+type Remediation struct {
+	Summary    string
+	References []string
+}
+
+// Reference links shared by several remediations
+const (
+	referenceTakeover      = "https://owasp.org/www-project-web-security-testing-guide/latest/4-Web_Application_Security_Testing/02-Configuration_and_Deployment_Management_Testing/10-Test_for_Subdomain_Takeover"
+	referenceCanITakeOver  = "https://github.com/EdOverflow/can-i-take-over-xyz"
+	referenceS3BlockPublic = "https://docs.aws.amazon.com/AmazonS3/latest/userguide/access-control-block-public-access.html"
+	referenceExposedFiles  = "https://owasp.org/www-project-web-security-testing-guide/latest/4-Web_Application_Security_Testing/02-Configuration_and_Deployment_Management_Testing/04-Review_Old_Backup_and_Unreferenced_Files_for_Sensitive_Information"
+)
+
+// secretFileRemediation applies to exposed files holding credentials
+var secretFileRemediation = Remediation{
+	Summary:    "Block the file at the web server and move it out of the web root. Treat every credential, key and token it contained as compromised and rotate them.",
+	References: []string{referenceExposedFiles},
+}
+
+// fileRemediations are the remediations of exposed files, by the description
+// the sensitive files check reports them with
+var fileRemediations = map[string]Remediation{
+	"Environment Variables File": secretFileRemediation,
+	"Configuration File":         secretFileRemediation,
+	"WordPress Config":           secretFileRemediation,
+	"Git Config File": {
+		Summary:    "Deny access to /.git at the web server and deploy without the repository directory. Assume the source code and its history, including any secrets ever committed, have been downloaded.",
+		References: []string{referenceExposedFiles},
+	},
+	"Apache Status Page": {
+		Summary:    "Restrict mod_status to localhost or trusted addresses with Require ip, or disable it; it reveals client addresses and requested URLs.",
+		References: []string{"https://httpd.apache.org/docs/2.4/mod/mod_status.html"},
+	},
+	"PHP Info": {
+		Summary:    "Remove phpinfo() pages from production; they reveal versions, paths and environment variables useful to attackers.",
+		References: []string{"https://www.php.net/manual/en/function.phpinfo.php"},
+	},
+	"Robots.txt File": {
+		Summary: "Usually meant to be public. Review it for paths that reveal hidden or internal areas, and protect those areas with authentication instead.",
+	},
+	"Sitemap": {
+		Summary: "Usually meant to be public. Review it for pages that shouldn't be listed.",
+	},
+	"Security Policy": {
+		Summary:    "Meant to be public; keep its contacts and Expires date current.",
+		References: []string{"https://www.rfc-editor.org/rfc/rfc9116"},
+	},
+}
+
+// VulnerabilityRemediation returns the remediation of a vulnerability, false
+// for vulnerabilities without guidance
+func VulnerabilityRemediation(vuln string) (Remediation, bool) {
+	switch {
+	case strings.HasPrefix(vuln, "Subdomain Takeover"):
+		return Remediation{
+			Summary:    "Remove the DNS record pointing at the unclaimed resource, or claim the resource at the provider again, before someone else does. Remove DNS records before deprovisioning the services they point at.",
+			References: []string{referenceTakeover, referenceCanITakeOver},
+		}, true
+	case vuln == "Unclaimed S3 Bucket":
+		return Remediation{
+			Summary:    "Create the bucket in an account you control to hold its name, or remove the DNS record pointing at it.",
+			References: []string{referenceTakeover, referenceCanITakeOver},
+		}, true
+	case vuln == "Public S3 Bucket":
+		return Remediation{
+			Summary:    "Turn on S3 Block Public Access for the bucket and remove ACL and bucket policy grants to everyone. Serve public content through CloudFront with origin access control instead of a listable bucket.",
+			References: []string{referenceS3BlockPublic},
+		}, true
+	case vuln == "Open Redirect":
+		return Remediation{
+			Summary:    "Only redirect to relative paths or to an allowlist of hosts, and reject absolute URLs in redirect parameters.",
+			References: []string{"https://cheatsheetseries.owasp.org/cheatsheets/Unvalidated_Redirects_and_Forwards_Cheat_Sheet.html"},
+		}, true
+	case strings.HasPrefix(vuln, "Zone Transfer"):
+		return Remediation{
+			Summary:    "Only allow zone transfers to the secondary nameservers, by address or TSIG key (allow-transfer in BIND, allow-axfr-ips in PowerDNS). The zone lists every host, including internal ones, to anyone asking.",
+			References: []string{"https://www.cisa.gov/news-events/alerts/2015/04/13/dns-zone-transfer-axfr-requests-may-leak-domain-information"},
+		}, true
+	case strings.HasPrefix(vuln, "Exposed "):
+		remediation, ok := fileRemediations[strings.TrimPrefix(vuln, "Exposed ")]
+		return remediation, ok
+	}
+	return Remediation{}, false
+}
+
+// groupRemediations returns the distinct remediations of a group's
+// vulnerabilities, in the order they first appear
+func groupRemediations(group FindingGroup) []Remediation {
+	var remediations []Remediation
+	seen := make(map[string]bool)
+	for _, entry := range group.Entries {
+		for _, vuln := range entry.Vulnerabilities {
+			remediation, ok := VulnerabilityRemediation(vuln)
+			if !ok || seen[remediation.Summary] {
+				continue
+			}
+			seen[remediation.Summary] = true
+			remediations = append(remediations, remediation)
+		}
+	}
+	return remediations
+}
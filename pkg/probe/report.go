@@ -0,0 +1,86 @@
+package probe
+
+import (
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// NewReport builds a versioned report document from probe results
+func NewReport(results []ProbeResult, target string) model.Report {
+	report := model.NewReport(model.KindProbe, target)
+	for _, result := range results {
+		report.Hosts = append(report.Hosts, ReportHost(result))
+		report.Findings = append(report.Findings, result.Findings...)
+	}
+	report.Scan.HostCount = len(report.Hosts)
+	return report
+}
+
+// ReportHost converts a probe result into a report host
+func ReportHost(result ProbeResult) model.Host {
+	return model.Host{
+		Domain:          result.Domain,
+		Status:          result.HTTPStatus,
+		ContentLength:   result.ContentLength,
+		CNAME:           result.CNAME,
+		IPs:             result.IPs,
+		Tags:            result.Tags,
+		FinalURL:        result.FinalURL,
+		RedirectChain:   result.RedirectChain,
+		URLs:            result.URLs,
+		Sources:         result.Sources,
+		IsTakeover:      result.IsTakeover,
+		S3Public:        result.S3Public,
+		S3Private:       result.S3Private,
+		ExposedFiles:    result.ExposedFiles,
+		OpenRedirect:    result.OpenRedirect,
+		RedirectURL:     result.RedirectURL,
+		Vulnerabilities: result.Vulnerabilities,
+		ProbedAt:        result.ProbedAt,
+		Error:           result.Error,
+		Skipped:         result.Skipped,
+		Owner:           result.Owner,
+		Team:            result.Team,
+		Notes:           result.Notes,
+	}
+}
+
+// FromReport converts the hosts of a report back into probe results, along
+// with their findings
+func FromReport(report model.Report) []ProbeResult {
+	findings := report.FindingsByHost()
+	results := make([]ProbeResult, 0, len(report.Hosts))
+	for _, host := range report.Hosts {
+		result := ProbeResult{
+			Domain:          host.Domain,
+			CNAME:           host.CNAME,
+			IPs:             host.IPs,
+			HTTPStatus:      host.Status,
+			ContentLength:   host.ContentLength,
+			IsTakeover:      host.IsTakeover,
+			S3Public:        host.S3Public,
+			S3Private:       host.S3Private,
+			ExposedFiles:    host.ExposedFiles,
+			RedirectURL:     host.RedirectURL,
+			OpenRedirect:    host.OpenRedirect,
+			RedirectChain:   host.RedirectChain,
+			URLs:            host.URLs,
+			Sources:         host.Sources,
+			FinalURL:        host.FinalURL,
+			Vulnerabilities: host.Vulnerabilities,
+			Findings:        findings[host.Domain],
+			Tags:            host.Tags,
+			Owner:           host.Owner,
+			Team:            host.Team,
+			Notes:           host.Notes,
+			ProbedAt:        host.ProbedAt,
+			Error:           host.Error,
+			Skipped:         host.Skipped,
+		}
+		// Reports without a findings section still get fingerprinted findings
+		if len(report.Findings) == 0 {
+			result.Findings = BuildFindings(result)
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,176 @@
+package probe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how urgent a vulnerability is
+type Severity int
+
+// Severities from least to most urgent
+const (
+	SeverityNone Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// String returns the severity's name
+func (s Severity) String() string {
+	switch s {
+	case SeverityCritical:
+		return "critical"
+	case SeverityHigh:
+		return "high"
+	case SeverityMedium:
+		return "medium"
+	case SeverityLow:
+		return "low"
+	default:
+		return "none"
+	}
+}
+
+// VulnerabilityCheck returns the check that reports a vulnerability, or "" for
+// vulnerabilities no check is known for
+func VulnerabilityCheck(vuln string) string {
+	switch {
+	case strings.HasPrefix(vuln, "Subdomain Takeover"):
+		return CheckTakeover
+	case strings.Contains(vuln, "S3 Bucket"):
+		return CheckS3
+	case strings.HasPrefix(vuln, "Exposed "):
+		return CheckSensitiveFiles
+	case vuln == "Open Redirect":
+		return CheckOpenRedirect
+	case strings.HasPrefix(vuln, "Zone Transfer"):
+		return CheckZoneTransfer
+	default:
+		return ""
+	}
+}
+
+// VulnerabilitySeverity returns the severity of a vulnerability. Takeovers and
+// unclaimed buckets let anyone serve content on the host, so they rank highest.
+func VulnerabilitySeverity(vuln string) Severity {
+	switch VulnerabilityCheck(vuln) {
+	case CheckTakeover:
+		return SeverityCritical
+	case CheckS3:
+		if vuln == "Unclaimed S3 Bucket" {
+			return SeverityCritical
+		}
+		return SeverityHigh
+	case CheckSensitiveFiles, CheckZoneTransfer:
+		return SeverityHigh
+	case CheckOpenRedirect:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// ResultSeverity returns the highest severity of a result's vulnerabilities
+func ResultSeverity(result ProbeResult) Severity {
+	severity := SeverityNone
+	for _, vuln := range result.Vulnerabilities {
+		if s := VulnerabilitySeverity(vuln); s > severity {
+			severity = s
+		}
+	}
+	return severity
+}
+
+// SortBySeverity sorts results by severity in descending order, then by
+// number of vulnerabilities and domain
+func SortBySeverity(results []ProbeResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		si, sj := ResultSeverity(results[i]), ResultSeverity(results[j])
+		if si != sj {
+			return si > sj
+		}
+		if len(results[i].Vulnerabilities) != len(results[j].Vulnerabilities) {
+			return len(results[i].Vulnerabilities) > len(results[j].Vulnerabilities)
+		}
+		return results[i].Domain < results[j].Domain
+	})
+}
+
+// FindingGroup is the hosts with vulnerabilities reported by one check
+type FindingGroup struct {
+	// Check is the check's name, or "" for vulnerabilities of no known check
+	Check   string
+	Title   string
+	Entries []FindingEntry
+	// Remediations are the distinct remediations of the group's vulnerabilities
+	Remediations []Remediation
+}
+
+// FindingEntry is a host in a finding group with its vulnerabilities of the
+// group's check
+type FindingEntry struct {
+	Result          ProbeResult
+	Vulnerabilities []string
+	Severity        Severity
+}
+
+// groupTitles are the report section titles of the checks
+var groupTitles = map[string]string{
+	CheckTakeover:       "Subdomain Takeovers",
+	CheckS3:             "S3 Buckets",
+	CheckSensitiveFiles: "Exposed Files",
+	CheckOpenRedirect:   "Open Redirects",
+	CheckZoneTransfer:   "Zone Transfers",
+	"":                  "Other Findings",
+}
+
+// GroupFindings groups the vulnerable results by check, in check order, with
+// the hosts of each group sorted by severity. A result with vulnerabilities
+// of several checks is in each of their groups.
+func GroupFindings(results []ProbeResult) []FindingGroup {
+	sorted := make([]ProbeResult, len(results))
+	copy(sorted, results)
+	SortBySeverity(sorted)
+
+	var groups []FindingGroup
+	for _, check := range append(append([]string{}, AllChecks...), "") {
+		group := FindingGroup{Check: check, Title: groupTitles[check]}
+		for _, result := range sorted {
+			entry := FindingEntry{Result: result}
+			for _, vuln := range result.Vulnerabilities {
+				if VulnerabilityCheck(vuln) != check {
+					continue
+				}
+				entry.Vulnerabilities = append(entry.Vulnerabilities, vuln)
+				if s := VulnerabilitySeverity(vuln); s > entry.Severity {
+					entry.Severity = s
+				}
+			}
+			if len(entry.Vulnerabilities) > 0 {
+				group.Entries = append(group.Entries, entry)
+			}
+		}
+		if len(group.Entries) == 0 {
+			continue
+		}
+		sort.SliceStable(group.Entries, func(i, j int) bool {
+			return group.Entries[i].Severity > group.Entries[j].Severity
+		})
+		group.Remediations = groupRemediations(group)
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// ParseSeverity returns the severity with the given name
+func ParseSeverity(name string) (Severity, error) {
+	for s := SeverityNone; s <= SeverityCritical; s++ {
+		if strings.EqualFold(strings.TrimSpace(name), s.String()) {
+			return s, nil
+		}
+	}
+	return SeverityNone, fmt.Errorf("unknown severity %q (available: low, medium, high, critical)", name)
+}
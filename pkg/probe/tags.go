@@ -0,0 +1,14 @@
+package probe
+
+import (
+	"fmt"
+
+	"github.com/omerimzali/subscan/pkg/tags"
+)
+
+func init() {
+	for provider := range takeoversignatures {
+		tags.Register(tags.Tag{Name: provider, Category: tags.CategoryTakeover, Severity: tags.SeverityInfo,
+			Description: fmt.Sprintf("Provider of a takeover candidate: the CNAME points at %s", provider)})
+	}
+}
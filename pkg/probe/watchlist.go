@@ -0,0 +1,217 @@
+package probe
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/resolver"
+)
+
+// WatchEntry is a host with a dangling or takeover-prone CNAME kept under watch
+type WatchEntry struct {
+	Host string `json:"host"`
+	// CNAME is the target recorded when the host was flagged, if known
+	CNAME string `json:"cname,omitempty"`
+}
+
+// TakeoverStatus is the outcome of re-checking a watched host
+type TakeoverStatus struct {
+	Host      string `json:"host"`
+	CNAME     string `json:"cname,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	Claimable bool   `json:"claimable"`
+	Reason    string `json:"reason,omitempty"`
+	// Error is set when the host could not be checked; its last state is kept
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// TakeoverWatchOptions contains configuration for takeover watchlist monitoring
+type TakeoverWatchOptions struct {
+	// Interval is the time between two checks of the watchlist
+	Interval time.Duration
+	// Nameservers are queried for CNAME chains; empty uses the system's
+	Nameservers []string
+	Timeout     time.Duration
+	Concurrency int
+	UserAgent   string
+	Verbose     bool
+}
+
+// DefaultTakeoverWatchOptions returns the default takeover watch options
+func DefaultTakeoverWatchOptions() TakeoverWatchOptions {
+	return TakeoverWatchOptions{
+		Interval:    10 * time.Minute,
+		Timeout:     10 * time.Second,
+		Concurrency: 10,
+		UserAgent:   "Subscan/1.0",
+	}
+}
+
+// BuildWatchlist picks the hosts of a probe report that were flagged as takeover
+// candidates or whose CNAME points at a provider known to allow takeovers
+func BuildWatchlist(results []ProbeResult) []WatchEntry {
+	var entries []WatchEntry
+	for _, result := range results {
+		if result.CNAME == "" {
+			continue
+		}
+		if result.IsTakeover || hasTag(result, "UNCLAIMED-S3") || len(takeoverProviders(result.CNAME)) > 0 {
+			entries = append(entries, WatchEntry{Host: result.Domain, CNAME: result.CNAME})
+		}
+	}
+	return entries
+}
+
+// hasTag reports whether a probe result carries the tag
+func hasTag(result ProbeResult, tag string) bool {
+	for _, t := range result.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// RecheckTakeover re-resolves a watched host and reports whether its CNAME target
+// can currently be claimed: either the target no longer exists or the provider
+// serves its unclaimed-resource page
+func RecheckTakeover(entry WatchEntry, options TakeoverWatchOptions) TakeoverStatus {
+	status := TakeoverStatus{Host: entry.Host, CNAME: entry.CNAME, CheckedAt: time.Now().UTC()}
+
+	chain, err := resolver.LookupChain(entry.Host, options.Nameservers, options.Timeout)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if len(chain.Targets) == 0 {
+		if chain.NXDOMAIN {
+			status.Reason = "host no longer exists"
+		} else {
+			status.Reason = "CNAME removed"
+		}
+		return status
+	}
+
+	status.CNAME = chain.Targets[0]
+	targets := strings.Join(chain.Targets, " ")
+	if providers := takeoverProviders(targets); len(providers) > 0 {
+		status.Provider = providers[0]
+	}
+
+	if chain.Dangling() {
+		status.Claimable = true
+		status.Reason = fmt.Sprintf("CNAME target %s does not exist", chain.Targets[len(chain.Targets)-1])
+		return status
+	}
+	if status.Provider == "" {
+		status.Reason = "CNAME target resolves"
+		return status
+	}
+
+	body, err := fetchPage(entry.Host, options)
+	if err != nil {
+		status.Reason = fmt.Sprintf("CNAME target resolves, page unavailable: %v", err)
+		return status
+	}
+	if matches := takeoverMatches(targets, body); len(matches) > 0 {
+		status.Claimable = true
+		status.Provider = matches[0]
+		status.Reason = fmt.Sprintf("%s serves its unclaimed resource page", matches[0])
+		return status
+	}
+
+	status.Reason = "resource is claimed"
+	return status
+}
+
+// fetchPage returns the start of a host's page over HTTPS, falling back to HTTP
+func fetchPage(host string, options TakeoverWatchOptions) (string, error) {
+	client := httpclient.New(httpclient.Options{
+		Timeout:           options.Timeout,
+		DisableKeepAlives: true,
+		UserAgent:         options.UserAgent,
+	})
+
+	var lastErr error
+	for _, scheme := range []string{"https", "http"} {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s://%s", scheme, host), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", options.UserAgent)
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, _ := httpclient.ReadBody(resp, 10*1024)
+		resp.Body.Close()
+		return string(body), nil
+	}
+	return "", lastErr
+}
+
+// TakeoverWatcher re-checks a watchlist and remembers which hosts were
+// claimable, so only changes are reported
+type TakeoverWatcher struct {
+	entries   []WatchEntry
+	options   TakeoverWatchOptions
+	claimable map[string]bool
+}
+
+// NewTakeoverWatcher creates a watcher for the given entries
+func NewTakeoverWatcher(entries []WatchEntry, options TakeoverWatchOptions) *TakeoverWatcher {
+	return &TakeoverWatcher{entries: entries, options: options, claimable: make(map[string]bool)}
+}
+
+// Check re-checks every entry and returns the statuses of hosts whose
+// claimability changed since the previous check: hosts that became claimable,
+// and previously claimable hosts that no longer are. Hosts that could not be
+// checked keep their previous state.
+func (w *TakeoverWatcher) Check() []TakeoverStatus {
+	var statuses []TakeoverStatus
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	concurrency := w.options.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+
+	for _, entry := range w.entries {
+		wg.Add(1)
+		go func(entry WatchEntry) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			status := RecheckTakeover(entry, w.options)
+
+			if status.Error != "" {
+				if w.options.Verbose {
+					logger.Warnf("could not check %s: %s", entry.Host, status.Error)
+				}
+				return
+			}
+
+			mu.Lock()
+			if status.Claimable != w.claimable[entry.Host] {
+				statuses = append(statuses, status)
+			}
+			w.claimable[entry.Host] = status.Claimable
+			mu.Unlock()
+		}(entry)
+	}
+
+	wg.Wait()
+
+	return statuses
+}
@@ -0,0 +1,38 @@
+package probe
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// TagZoneTransfer marks nameservers that hand out the zone over AXFR
+const TagZoneTransfer = "ZONE-TRANSFER"
+
+// ZoneTransferResults returns a result for each nameserver that handed out
+// the zone of domain, so open zone transfers are reported and tracked like
+// the findings of the probed hosts. It returns nothing when the zone-transfer
+// check is disabled.
+func ZoneTransferResults(domain string, nameservers []model.Nameserver, options ProbeOptions) []ProbeResult {
+	if !options.checkEnabled(CheckZoneTransfer) {
+		return nil
+	}
+
+	var results []ProbeResult
+	for _, ns := range nameservers {
+		if !ns.ZoneTransfer {
+			continue
+		}
+		result := ProbeResult{
+			Domain:          ns.Name,
+			IPs:             ns.Addresses,
+			Vulnerabilities: []string{fmt.Sprintf("Zone Transfer (%s)", domain)},
+			Tags:            []string{TagZoneTransfer},
+			ProbedAt:        time.Now().UTC().Format(time.RFC3339),
+		}
+		result.Findings = BuildFindings(result)
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,103 @@
+// Package progress reports how far a stage is through its items, with rate
+// and ETA, as a single updating bar on terminals or as periodic log lines
+// when standard error is redirected.
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+const (
+	// barInterval is how often the bar is redrawn
+	barInterval = 200 * time.Millisecond
+	// textInterval is how often a progress line is logged without a terminal
+	textInterval = 5 * time.Second
+	// barWidth is the number of cells of the bar
+	barWidth = 25
+)
+
+// Tracker counts the finished items of a stage and reports its progress until
+// Finish is called
+type Tracker struct {
+	label string
+	total int64
+	done  atomic.Int64
+	start time.Time
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// Start starts reporting the progress of a stage of total items. Nothing is
+// reported below the info level.
+func Start(label string, total int) *Tracker {
+	t := &Tracker{label: label, total: int64(total), start: time.Now()}
+	if total <= 0 || !logger.Enabled(logger.LevelInfo) {
+		return t
+	}
+
+	interval := textInterval
+	if logger.Interactive() {
+		interval = barInterval
+	}
+	t.stop = make(chan struct{})
+	t.wg.Add(1)
+	go t.run(interval)
+	return t
+}
+
+// Increment records a finished item
+func (t *Tracker) Increment() {
+	t.done.Add(1)
+}
+
+// Finish stops reporting and erases the bar
+func (t *Tracker) Finish() {
+	if t.stop == nil {
+		return
+	}
+	close(t.stop)
+	t.wg.Wait()
+	logger.Status("")
+}
+
+// run reports the progress every interval until the tracker is finished
+func (t *Tracker) run(interval time.Duration) {
+	defer t.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.report()
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// report draws the bar, or logs a progress line without a terminal
+func (t *Tracker) report() {
+	done := t.done.Load()
+	percent := float64(done) / float64(t.total) * 100
+	rate := float64(done) / time.Since(t.start).Seconds()
+	eta := "?"
+	if rate > 0 {
+		remaining := time.Duration(float64(t.total-done) / rate * float64(time.Second))
+		eta = remaining.Round(time.Second).String()
+	}
+
+	if !logger.Interactive() {
+		logger.Infof("%s: %d/%d (%.1f%%), %.1f/s, ETA %s", t.label, done, t.total, percent, rate, eta)
+		return
+	}
+	filled := int(done * barWidth / t.total)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled)
+	logger.Status(fmt.Sprintf("%s [%s] %d/%d %3.0f%% %.1f/s ETA %s", t.label, bar, done, t.total, percent, rate, eta))
+}
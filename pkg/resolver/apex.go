@@ -0,0 +1,170 @@
+package resolver
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// ApexRecords are the DNS records of a target domain itself
+type ApexRecords = model.ApexRecords
+
+// SRVServices are the well-known service names looked up under the apex
+var SRVServices = []string{
+	"_autodiscover._tcp", "_caldav._tcp", "_caldavs._tcp", "_carddav._tcp",
+	"_carddavs._tcp", "_collab-edge._tls", "_ftp._tcp", "_gc._tcp",
+	"_h323cs._tcp", "_imap._tcp", "_imaps._tcp", "_jabber._tcp",
+	"_kerberos._tcp", "_kerberos._udp", "_kpasswd._tcp", "_ldap._tcp",
+	"_ldaps._tcp", "_matrix._tcp", "_minecraft._tcp", "_pop3._tcp",
+	"_pop3s._tcp", "_sip._tcp", "_sip._tls", "_sip._udp", "_sipfederationtls._tcp",
+	"_sips._tcp", "_smtp._tcp", "_submission._tcp", "_submissions._tcp",
+	"_stun._udp", "_turn._udp", "_vlmcs._tcp", "_xmpp-client._tcp",
+	"_xmpp-server._tcp",
+}
+
+// LookupApex asks the servers, as host:port, for the NS, MX, TXT and SOA
+// records of domain, the DMARC policy under _dmarc and the SRV records of
+// the well-known services. Record types that fail to resolve are left empty.
+func LookupApex(domain string, servers []string, timeout time.Duration) ApexRecords {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if len(servers) == 0 {
+		servers = systemNameservers()
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	client := &dns.Client{Timeout: timeout}
+	query := func(name string, qtype uint16) []dns.RR {
+		return queryServers(client, servers, name, qtype)
+	}
+
+	records := ApexRecords{Domain: domain}
+	for _, rr := range query(domain, dns.TypeNS) {
+		if ns, ok := rr.(*dns.NS); ok {
+			records.NS = append(records.NS, trimName(ns.Ns))
+		}
+	}
+	sort.Strings(records.NS)
+
+	for _, rr := range query(domain, dns.TypeMX) {
+		if mx, ok := rr.(*dns.MX); ok && mx.Mx != "." {
+			records.MX = append(records.MX, model.MXRecord{Host: trimName(mx.Mx), Preference: mx.Preference})
+		}
+	}
+	sort.Slice(records.MX, func(i, j int) bool {
+		if records.MX[i].Preference != records.MX[j].Preference {
+			return records.MX[i].Preference < records.MX[j].Preference
+		}
+		return records.MX[i].Host < records.MX[j].Host
+	})
+
+	for _, rr := range query(domain, dns.TypeTXT) {
+		if txt, ok := rr.(*dns.TXT); ok {
+			records.TXT = append(records.TXT, strings.Join(txt.Txt, ""))
+		}
+	}
+	sort.Strings(records.TXT)
+
+	for _, rr := range query(domain, dns.TypeSOA) {
+		if soa, ok := rr.(*dns.SOA); ok {
+			records.SOA = &model.SOARecord{
+				PrimaryNS: trimName(soa.Ns),
+				Mailbox:   trimName(soa.Mbox),
+				Serial:    soa.Serial,
+				Refresh:   soa.Refresh,
+				Retry:     soa.Retry,
+				Expire:    soa.Expire,
+				MinTTL:    soa.Minttl,
+			}
+			break
+		}
+	}
+
+	for _, rr := range query("_dmarc."+domain, dns.TypeTXT) {
+		if txt, ok := rr.(*dns.TXT); ok {
+			value := strings.Join(txt.Txt, "")
+			if strings.HasPrefix(strings.ToLower(value), "v=dmarc1") {
+				records.DMARC = value
+				break
+			}
+		}
+	}
+
+	for _, service := range SRVServices {
+		for _, rr := range query(service+"."+domain, dns.TypeSRV) {
+			if srv, ok := rr.(*dns.SRV); ok && srv.Target != "." {
+				records.SRV = append(records.SRV, model.SRVRecord{
+					Service:  service,
+					Target:   trimName(srv.Target),
+					Port:     srv.Port,
+					Priority: srv.Priority,
+					Weight:   srv.Weight,
+				})
+			}
+		}
+	}
+	return records
+}
+
+// ApexCandidates returns the hosts within the domain that its apex records
+// name: mail exchangers, SRV targets and the hosts of the SPF record's
+// include, a, mx, exists and redirect mechanisms. They are sorted and unique.
+func ApexCandidates(records ApexRecords) []string {
+	domain := records.Domain
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		name = strings.ToLower(trimName(name))
+		if name == domain || !strings.HasSuffix(name, "."+domain) || strings.ContainsAny(name, "%/ ") || seen[name] {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	for _, mx := range records.MX {
+		add(mx.Host)
+	}
+	for _, srv := range records.SRV {
+		add(srv.Target)
+	}
+	for _, term := range strings.Fields(records.SPF()) {
+		term = strings.TrimLeft(strings.ToLower(term), "+-~?")
+		for _, prefix := range []string{"include:", "a:", "mx:", "exists:", "redirect="} {
+			if strings.HasPrefix(term, prefix) {
+				add(strings.TrimPrefix(term, prefix))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// queryServers asks the servers in turn for the records of name, returning
+// the answer of the first one that replies
+func queryServers(client *dns.Client, servers []string, name string, qtype uint16) []dns.RR {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.RecursionDesired = true
+	for _, server := range servers {
+		reply, _, err := client.Exchange(msg, server)
+		if err != nil {
+			logger.Debugf("%s query for %s failed on %s: %v", dns.TypeToString[qtype], name, server, err)
+			continue
+		}
+		if reply.Rcode != dns.RcodeSuccess && reply.Rcode != dns.RcodeNameError {
+			continue
+		}
+		return reply.Answer
+	}
+	return nil
+}
+
+// trimName lowercases a DNS name and drops its trailing dot
+func trimName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,84 @@
+package resolver
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+// TransferZone attempts a zone transfer (AXFR) of domain from every address
+// of each nameserver. It returns the names in the zone, sorted, along with
+// the nameservers that handed it out, marked ZoneTransfer. Misconfigured
+// nameservers give the whole zone to anyone asking.
+func TransferZone(domain string, nameservers []Nameserver, timeout time.Duration) ([]string, []Nameserver) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	names := make(map[string]bool)
+	var open []Nameserver
+	for _, ns := range nameservers {
+		transferred := false
+		for _, address := range ns.Addresses {
+			zone, err := transfer(domain, net.JoinHostPort(address, "53"), timeout)
+			if err != nil {
+				logger.Debugf("Zone transfer of %s refused by %s (%s): %v", domain, ns.Name, address, err)
+				continue
+			}
+			transferred = true
+			for _, name := range zone {
+				names[name] = true
+			}
+		}
+		if transferred {
+			ns.ZoneTransfer = true
+			open = append(open, ns)
+		}
+	}
+
+	zone := make([]string, 0, len(names))
+	for name := range names {
+		zone = append(zone, name)
+	}
+	sort.Strings(zone)
+	return zone, open
+}
+
+// transfer runs one AXFR and returns the owner names within domain
+func transfer(domain string, server string, timeout time.Duration) ([]string, error) {
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(domain))
+	client := &dns.Transfer{DialTimeout: timeout, ReadTimeout: timeout, WriteTimeout: timeout}
+	envelopes, err := client.In(msg, server)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	records := 0
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			err = envelope.Error
+			continue
+		}
+		for _, rr := range envelope.RR {
+			records++
+			name := strings.ToLower(strings.TrimSuffix(rr.Header().Name, "."))
+			if name != domain && strings.HasSuffix(name, "."+domain) && !strings.HasPrefix(name, "*.") {
+				names = append(names, name)
+			}
+		}
+	}
+	if records == 0 {
+		if err == nil {
+			err = dns.ErrSoa
+		}
+		return nil, err
+	}
+	return names, nil
+}
@@ -0,0 +1,86 @@
+package resolver
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// CNAMEChain describes where a name's CNAME records lead
+type CNAMEChain struct {
+	Name string
+	// Targets lists the CNAME targets in the order they are followed
+	Targets []string
+	// Addresses holds the A records at the end of the chain
+	Addresses []string
+	// NXDOMAIN is set when the name, or the end of its CNAME chain, does not exist
+	NXDOMAIN bool
+}
+
+// Dangling reports whether the name is a CNAME to a target that does not exist,
+// the classic sign of a resource that can be claimed by someone else
+func (c CNAMEChain) Dangling() bool {
+	return c.NXDOMAIN && len(c.Targets) > 0
+}
+
+// LookupChain asks for a name's A records with a raw DNS query and returns the
+// CNAME chain from the answer. Unlike the stdlib resolver it tells a CNAME to
+// a missing target apart from a name that no longer exists. Without
+// nameservers the system's configured ones are used.
+func LookupChain(name string, nameservers []string, timeout time.Duration) (CNAMEChain, error) {
+	chain := CNAMEChain{Name: name}
+	if len(nameservers) == 0 {
+		nameservers = systemNameservers()
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeA)
+	client := &dns.Client{Timeout: timeout}
+
+	var lastErr error
+	for _, server := range nameservers {
+		reply, _, err := client.Exchange(msg, server)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if reply.Rcode != dns.RcodeSuccess && reply.Rcode != dns.RcodeNameError {
+			lastErr = fmt.Errorf("%s answered %s", server, dns.RcodeToString[reply.Rcode])
+			continue
+		}
+
+		chain.NXDOMAIN = reply.Rcode == dns.RcodeNameError
+		for _, answer := range reply.Answer {
+			switch rr := answer.(type) {
+			case *dns.CNAME:
+				chain.Targets = append(chain.Targets, strings.TrimSuffix(rr.Target, "."))
+			case *dns.A:
+				chain.Addresses = append(chain.Addresses, rr.A.String())
+			}
+		}
+		return chain, nil
+	}
+
+	return chain, fmt.Errorf("lookup %s failed: %v", name, lastErr)
+}
+
+// systemNameservers returns the nameservers from /etc/resolv.conf, falling back
+// to the fast engine's public resolvers where that file is unavailable
+func systemNameservers() []string {
+	config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil || len(config.Servers) == 0 {
+		return DefaultFastResolvers
+	}
+
+	servers := make([]string, 0, len(config.Servers))
+	for _, server := range config.Servers {
+		servers = append(servers, net.JoinHostPort(server, config.Port))
+	}
+	return servers
+}
@@ -0,0 +1,176 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DoH endpoints addressed by IP so they work without a bootstrap DNS lookup
+const (
+	CloudflareDoH = "https://1.1.1.1/dns-query"
+	GoogleDoH     = "https://8.8.8.8/dns-query"
+)
+
+// DefaultDoTServer is used when DoT is enabled without explicit servers
+const DefaultDoTServer = "1.1.1.1:853"
+
+// DoHURL expands the cloudflare/google aliases into DoH endpoint URLs
+func DoHURL(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "", "cloudflare":
+		return CloudflareDoH, nil
+	case "google":
+		return GoogleDoH, nil
+	}
+	if !strings.HasPrefix(value, "https://") {
+		return "", fmt.Errorf("invalid DoH endpoint %q (expected https:// URL, cloudflare or google)", value)
+	}
+	return value, nil
+}
+
+// ParseDoTServers normalizes DoT server addresses, defaulting to port 853
+func ParseDoTServers(values []string) []string {
+	var servers []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(value); err != nil {
+			value = net.JoinHostPort(value, "853")
+		}
+		servers = append(servers, value)
+	}
+	if len(servers) == 0 {
+		servers = []string{DefaultDoTServer}
+	}
+	return servers
+}
+
+// newDoTResolver returns a resolver that sends every query over TLS, rotating across servers
+func newDoTResolver(servers []string, timeout time.Duration) *net.Resolver {
+	var next uint32
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			server := servers[atomic.AddUint32(&next, 1)%uint32(len(servers))]
+			noteServer(ctx, server)
+			host, _, _ := net.SplitHostPort(server)
+			dialer := &tls.Dialer{
+				NetDialer: &net.Dialer{Timeout: timeout},
+				Config:    &tls.Config{ServerName: host},
+			}
+			// Not a PacketConn, so the Go resolver uses TCP framing as DoT expects
+			return dialer.DialContext(ctx, "tcp", server)
+		},
+	}
+}
+
+// newDoHResolver returns a resolver that posts every query to a DoH endpoint
+func newDoHResolver(endpoint string, timeout time.Duration) *net.Resolver {
+	client := &http.Client{Timeout: timeout}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			noteServer(ctx, endpoint)
+			return &dohConn{ctx: ctx, client: client, endpoint: endpoint}, nil
+		},
+	}
+}
+
+// dohConn adapts DNS-over-HTTPS to the stream connection the Go resolver dials.
+// Queries arrive length-prefixed as over TCP; each one is sent as an
+// application/dns-message POST and the answer is framed the same way for reading.
+type dohConn struct {
+	ctx      context.Context
+	client   *http.Client
+	endpoint string
+	deadline time.Time
+	pending  bytes.Buffer
+	answer   bytes.Buffer
+}
+
+// Write buffers query bytes and performs the DoH request once a full message is in
+func (c *dohConn) Write(b []byte) (int, error) {
+	c.pending.Write(b)
+	for c.pending.Len() >= 2 {
+		size := int(binary.BigEndian.Uint16(c.pending.Bytes()[:2]))
+		if c.pending.Len() < 2+size {
+			break
+		}
+		c.pending.Next(2)
+		query := make([]byte, size)
+		c.pending.Read(query)
+		if err := c.exchange(query); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// exchange sends one DNS message to the DoH endpoint and queues the framed answer
+func (c *dohConn) exchange(query []byte) error {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(query))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("DoH endpoint returned status %d", resp.StatusCode)
+	}
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, 65535))
+	if err != nil {
+		return err
+	}
+
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(answer)))
+	c.answer.Write(length[:])
+	c.answer.Write(answer)
+	return nil
+}
+
+// Read returns buffered answer bytes
+func (c *dohConn) Read(b []byte) (int, error) {
+	if c.answer.Len() == 0 {
+		return 0, io.EOF
+	}
+	return c.answer.Read(b)
+}
+
+func (c *dohConn) Close() error                       { return nil }
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr(c.endpoint) }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr(c.endpoint) }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { c.deadline = t; return nil }
+
+// dohAddr names a DoH endpoint as a net.Addr
+type dohAddr string
+
+func (a dohAddr) Network() string { return "https" }
+func (a dohAddr) String() string  { return string(a) }
@@ -0,0 +1,39 @@
+package resolver
+
+import (
+	"strings"
+)
+
+// InternalSuffixes are suffixes that are never delegated in public DNS: special
+// use names (RFC 6761, 6762, 8375), the ICANN reserved .internal, and suffixes
+// commonly used for corporate networks
+var InternalSuffixes = []string{
+	"local", "internal", "home.arpa", "localdomain", "localhost", "test", "invalid",
+	"lan", "corp", "home", "intranet", "private",
+}
+
+// IsInternalDomain reports whether a domain can only exist on a private
+// network: a single label, or a name under one of InternalSuffixes or the
+// extra suffixes given
+func IsInternalDomain(domain string, extra []string) bool {
+	domain = strings.ToLower(strings.Trim(domain, "."))
+	if domain == "" {
+		return false
+	}
+	if !strings.Contains(domain, ".") {
+		return true
+	}
+	for _, suffix := range append(append([]string{}, InternalSuffixes...), extra...) {
+		suffix = strings.ToLower(strings.Trim(suffix, "."))
+		if suffix != "" && (domain == suffix || strings.HasSuffix(domain, "."+suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemNameservers returns the nameservers configured for this machine, which
+// on an internal network are the ones that know its private zones
+func SystemNameservers() []string {
+	return systemNameservers()
+}
@@ -0,0 +1,89 @@
+package resolver
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// Nameserver is an authoritative nameserver of a domain
+type Nameserver = model.Nameserver
+
+// LookupNameservers returns the authoritative nameservers of a domain with
+// their addresses, skipping the ones that don't resolve
+func LookupNameservers(domain string) ([]Nameserver, error) {
+	records, err := net.LookupNS(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var nameservers []Nameserver
+	for _, ns := range records {
+		host := strings.TrimSuffix(ns.Host, ".")
+		ips, err := net.LookupHost(host)
+		if err != nil {
+			logger.Warnf("could not resolve nameserver %s: %v", host, err)
+			continue
+		}
+		nameservers = append(nameservers, Nameserver{Name: host, Addresses: ips})
+	}
+	if len(nameservers) == 0 {
+		return nil, fmt.Errorf("no usable authoritative nameservers found for %s", domain)
+	}
+
+	sort.Slice(nameservers, func(i, j int) bool { return nameservers[i].Name < nameservers[j].Name })
+	return nameservers, nil
+}
+
+// FingerprintNameservers looks up the authoritative nameservers of a domain
+// and asks each for its software version and hostname
+func FingerprintNameservers(domain string, timeout time.Duration) ([]Nameserver, error) {
+	nameservers, err := LookupNameservers(domain)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	var wg sync.WaitGroup
+	for i := range nameservers {
+		wg.Add(1)
+		go func(ns *Nameserver) {
+			defer wg.Done()
+			ns.Version = chaosTXT(ns.Addresses, "version.bind", timeout)
+			ns.Hostname = chaosTXT(ns.Addresses, "hostname.bind", timeout)
+		}(&nameservers[i])
+	}
+	wg.Wait()
+	return nameservers, nil
+}
+
+// chaosTXT asks the addresses in turn for a CHAOS-class TXT record and returns
+// the first answer, or "" when none of them answers
+func chaosTXT(addresses []string, name string, timeout time.Duration) string {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeTXT)
+	msg.Question[0].Qclass = dns.ClassCHAOS
+	client := &dns.Client{Timeout: timeout}
+
+	for _, address := range addresses {
+		reply, _, err := client.Exchange(msg, net.JoinHostPort(address, "53"))
+		if err != nil || reply.Rcode != dns.RcodeSuccess {
+			continue
+		}
+		for _, answer := range reply.Answer {
+			if txt, ok := answer.(*dns.TXT); ok && len(txt.Txt) > 0 {
+				return strings.Join(txt.Txt, " ")
+			}
+		}
+	}
+	return ""
+}
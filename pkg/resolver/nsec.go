@@ -0,0 +1,280 @@
+package resolver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+// DefaultWalkQueries caps the queries of a zone walk by default
+const DefaultWalkQueries = 10000
+
+// nsec3Stale is how many queries in a row may turn up no new NSEC3 hash
+// before collection stops short of closing the chain
+const nsec3Stale = 200
+
+// WalkOptions configures a DNSSEC zone walk
+type WalkOptions struct {
+	// MaxQueries caps the queries sent to the nameservers
+	MaxQueries int
+	Timeout    time.Duration
+}
+
+// DefaultWalkOptions returns the default zone walk options
+func DefaultWalkOptions() WalkOptions {
+	return WalkOptions{
+		MaxQueries: DefaultWalkQueries,
+		Timeout:    DefaultTimeout,
+	}
+}
+
+// ZoneWalk is what walking a signed zone found. NSEC zones give up their
+// names directly; NSEC3 zones only give up hashes of them, with the
+// parameters needed to test names against the hashes or crack them offline.
+type ZoneWalk struct {
+	Domain string
+	Names  []string
+	// NSEC3 is set when the zone uses hashed denial of existence, filling
+	// Hashes, Salt (in hex) and Iterations instead of Names
+	NSEC3      bool
+	Hashes     []string
+	Salt       string
+	Iterations uint16
+	Queries    int
+	// Complete is set when the walk went all the way around the zone's chain
+	Complete bool
+}
+
+// WalkZone enumerates a DNSSEC-signed zone through the NSEC or NSEC3 records
+// its nameservers return to prove names don't exist. An NSEC chain links each
+// name to the next, so following it lists the whole zone without guessing.
+// It returns an error when the zone isn't signed or the nameservers don't
+// answer.
+func WalkZone(ctx context.Context, domain string, nameservers []Nameserver, options WalkOptions) (ZoneWalk, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.MaxQueries <= 0 {
+		options.MaxQueries = DefaultWalkQueries
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	walker := &zoneWalker{
+		client:     &dns.Client{Timeout: options.Timeout},
+		maxQueries: options.MaxQueries,
+	}
+	for _, ns := range nameservers {
+		for _, address := range ns.Addresses {
+			walker.servers = append(walker.servers, net.JoinHostPort(address, "53"))
+		}
+	}
+	if len(walker.servers) == 0 {
+		return ZoneWalk{Domain: domain}, fmt.Errorf("no nameserver addresses for %s", domain)
+	}
+
+	// A name that doesn't exist shows which kind of denial the zone uses
+	reply, err := walker.query(randomLabel()+"."+domain, dns.TypeA)
+	if err != nil {
+		return ZoneWalk{Domain: domain}, err
+	}
+	for _, rr := range reply.Ns {
+		switch rr.(type) {
+		case *dns.NSEC:
+			return walker.walkNSEC(ctx, domain), nil
+		case *dns.NSEC3:
+			return walker.collectNSEC3(ctx, domain, reply), nil
+		}
+	}
+	return ZoneWalk{Domain: domain, Queries: walker.queries}, fmt.Errorf("%s is not DNSSEC-signed", domain)
+}
+
+// Crack returns the names whose NSEC3 hash is in the zone, which exist
+// whether or not they resolve to an address
+func (w ZoneWalk) Crack(names []string) []string {
+	hashes := make(map[string]bool, len(w.Hashes))
+	for _, hash := range w.Hashes {
+		hashes[hash] = true
+	}
+	var found []string
+	for _, name := range names {
+		if hashes[strings.ToLower(dns.HashName(dns.Fqdn(name), dns.SHA1, w.Iterations, w.Salt))] {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
+// HashcatLines returns the NSEC3 hashes in the format of hashcat mode 8300
+func (w ZoneWalk) HashcatLines() []string {
+	lines := make([]string, 0, len(w.Hashes))
+	for _, hash := range w.Hashes {
+		lines = append(lines, fmt.Sprintf("%s:.%s:%s:%d", hash, w.Domain, w.Salt, w.Iterations))
+	}
+	return lines
+}
+
+// zoneWalker sends the queries of a walk, round robin over the servers
+type zoneWalker struct {
+	client     *dns.Client
+	servers    []string
+	queries    int
+	maxQueries int
+}
+
+// exhausted reports whether the walk used up its queries
+func (z *zoneWalker) exhausted() bool {
+	return z.queries >= z.maxQueries
+}
+
+// query asks the servers in turn for name with the DNSSEC records, over TCP
+// when the answer doesn't fit in UDP
+func (z *zoneWalker) query(name string, qtype uint16) (*dns.Msg, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.RecursionDesired = false
+	msg.SetEdns0(4096, true)
+
+	var lastErr error
+	for i := range z.servers {
+		if z.exhausted() {
+			return nil, fmt.Errorf("query limit of %d reached", z.maxQueries)
+		}
+		server := z.servers[(z.queries+i)%len(z.servers)]
+		z.queries++
+		reply, _, err := z.client.Exchange(msg, server)
+		if err == nil && reply.Truncated {
+			tcp := &dns.Client{Net: "tcp", Timeout: z.client.Timeout}
+			reply, _, err = tcp.Exchange(msg, server)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if reply.Rcode != dns.RcodeSuccess && reply.Rcode != dns.RcodeNameError {
+			lastErr = fmt.Errorf("%s answered %s", server, dns.RcodeToString[reply.Rcode])
+			continue
+		}
+		return reply, nil
+	}
+	return nil, lastErr
+}
+
+// walkNSEC follows the NSEC chain from the apex until it comes back around
+func (z *zoneWalker) walkNSEC(ctx context.Context, domain string) ZoneWalk {
+	walk := ZoneWalk{Domain: domain}
+	seen := map[string]bool{domain: true}
+	current := domain
+	for ctx.Err() == nil && !z.exhausted() {
+		next, ok := z.nextName(current)
+		if !ok {
+			logger.Debugf("NSEC walk of %s stopped at %s", domain, current)
+			break
+		}
+		if next == domain {
+			walk.Complete = true
+			break
+		}
+		// Servers signing on the fly answer with minimally covering records
+		// pointing just past the name asked about, which lead nowhere
+		if strings.HasPrefix(next, "\\000.") || !strings.HasSuffix(next, "."+domain) || seen[next] {
+			logger.Debugf("NSEC walk of %s stopped at %s: the zone answers with minimal NSEC records", domain, current)
+			break
+		}
+		seen[next] = true
+		if !strings.HasPrefix(next, "*.") {
+			walk.Names = append(walk.Names, next)
+		}
+		current = next
+	}
+	sort.Strings(walk.Names)
+	walk.Queries = z.queries
+	return walk
+}
+
+// nextName returns the name after current in the NSEC chain, from its own
+// NSEC record or, when the server won't return that, from the record proving
+// a name just below current doesn't exist
+func (z *zoneWalker) nextName(current string) (string, bool) {
+	for _, question := range []struct {
+		name  string
+		qtype uint16
+	}{{current, dns.TypeNSEC}, {"\\000." + current, dns.TypeA}} {
+		reply, err := z.query(question.name, question.qtype)
+		if err != nil {
+			return "", false
+		}
+		for _, rr := range append(reply.Answer, reply.Ns...) {
+			if nsec, ok := rr.(*dns.NSEC); ok && strings.EqualFold(strings.TrimSuffix(nsec.Hdr.Name, "."), current) {
+				return strings.ToLower(strings.TrimSuffix(nsec.NextDomain, ".")), true
+			}
+		}
+	}
+	return "", false
+}
+
+// collectNSEC3 gathers the zone's NSEC3 hashes from the records proving
+// random names don't exist, until the hashes link up into a closed chain
+func (z *zoneWalker) collectNSEC3(ctx context.Context, domain string, first *dns.Msg) ZoneWalk {
+	walk := ZoneWalk{Domain: domain, NSEC3: true}
+	next := make(map[string]string)
+	collect := func(reply *dns.Msg) bool {
+		added := false
+		for _, rr := range reply.Ns {
+			nsec3, ok := rr.(*dns.NSEC3)
+			if !ok {
+				continue
+			}
+			walk.Salt, walk.Iterations = nsec3.Salt, nsec3.Iterations
+			owner := strings.ToLower(strings.SplitN(nsec3.Hdr.Name, ".", 2)[0])
+			if _, known := next[owner]; !known {
+				next[owner] = strings.ToLower(nsec3.NextDomain)
+				added = true
+			}
+		}
+		return added
+	}
+	closed := func() bool {
+		for _, hash := range next {
+			if _, known := next[hash]; !known {
+				return false
+			}
+		}
+		return true
+	}
+
+	collect(first)
+	stale := 0
+	for ctx.Err() == nil && !z.exhausted() && !closed() && stale < nsec3Stale {
+		reply, err := z.query(randomLabel()+"."+domain, dns.TypeA)
+		if err != nil {
+			break
+		}
+		if collect(reply) {
+			stale = 0
+		} else {
+			stale++
+		}
+	}
+
+	walk.Complete = len(next) > 0 && closed()
+	for hash := range next {
+		walk.Hashes = append(walk.Hashes, hash)
+	}
+	sort.Strings(walk.Hashes)
+	walk.Queries = z.queries
+	return walk
+}
+
+// randomLabel returns a label no zone is likely to hold
+func randomLabel() string {
+	label := make([]byte, 8)
+	rand.Read(label)
+	return "subscan-" + hex.EncodeToString(label)
+}
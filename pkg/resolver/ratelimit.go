@@ -0,0 +1,60 @@
+package resolver
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket limits DNS queries to a steady rate while allowing short bursts
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a limiter for the given queries per second, or nil for no limit
+func newTokenBucket(rate float64) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Wait blocks until n tokens are available and takes them. A nil bucket never blocks.
+func (b *tokenBucket) Wait(n int) {
+	if b == nil {
+		return
+	}
+	for i := 0; i < n; i++ {
+		b.take()
+	}
+}
+
+// take reserves a single token and sleeps until it is due
+func (b *tokenBucket) take() {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		// Tokens are reserved ahead of time, so concurrent callers queue up fairly
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
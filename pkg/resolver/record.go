@@ -0,0 +1,170 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/input"
+)
+
+// DNSRecord holds the DNS data gathered while resolving a subdomain, so later
+// stages can reuse it instead of querying again
+type DNSRecord struct {
+	Name     string        `json:"name"`
+	A        []string      `json:"a,omitempty"`
+	AAAA     []string      `json:"aaaa,omitempty"`
+	CNAME    string        `json:"cname,omitempty"`
+	TXT      []string      `json:"txt,omitempty"`
+	Resolver string        `json:"resolver"`
+	RTT      time.Duration `json:"rtt_ns"`
+	// Sources are the passive sources and stages that found the name
+	Sources []string `json:"sources,omitempty"`
+}
+
+// IPs returns the record's IPv4 and IPv6 addresses
+func (r DNSRecord) IPs() []string {
+	return append(append([]string{}, r.A...), r.AAAA...)
+}
+
+// IsRecordList reports whether data holds DNS records as written by the resolve
+// stage, rather than a host list or another report
+func IsRecordList(data []byte) bool {
+	fields := input.Fields(data)
+	return input.HasField(fields, "name") && input.HasField(fields, "resolver")
+}
+
+// ParseRecords reads DNS records from a JSON array or JSON Lines
+func ParseRecords(data []byte) ([]DNSRecord, error) {
+	items, err := input.Objects(data)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]DNSRecord, 0, len(items))
+	for i, item := range items {
+		var record DNSRecord
+		if err := json.Unmarshal(item, &record); err != nil {
+			return nil, fmt.Errorf("record %d: %v", i+1, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Names returns the subdomain names of the given records
+func Names(records []DNSRecord) []string {
+	names := make([]string, 0, len(records))
+	for _, record := range records {
+		names = append(names, record.Name)
+	}
+	return names
+}
+
+// RecordMap indexes records by subdomain name
+func RecordMap(records []DNSRecord) map[string]DNSRecord {
+	byName := make(map[string]DNSRecord, len(records))
+	for _, record := range records {
+		byName[record.Name] = record
+	}
+	return byName
+}
+
+// systemResolverName is reported as the resolver for lookups through the system resolver
+const systemResolverName = "system"
+
+// dialedKey carries a *dialedServer through a lookup's context
+type dialedKey struct{}
+
+// dialedServer remembers which server a lookup was sent to
+type dialedServer struct {
+	mu     sync.Mutex
+	server string
+}
+
+// noteServer records the server dialed for the lookup owning ctx
+func noteServer(ctx context.Context, server string) {
+	if dialed, ok := ctx.Value(dialedKey{}).(*dialedServer); ok {
+		dialed.mu.Lock()
+		dialed.server = server
+		dialed.mu.Unlock()
+	}
+}
+
+// lookupRecord resolves a subdomain and reports whether it is alive along with
+// its A/AAAA/CNAME/TXT records, the resolver that answered and the round trip time.
+// The limiter is charged one token per DNS query sent. Canceling ctx aborts
+// the queries in flight. It returns errUnanswered when the address lookup
+// timed out or failed on the server, so the name may still exist.
+func lookupRecord(ctx context.Context, dnsResolver *net.Resolver, subdomain string, timeout time.Duration, limiter *tokenBucket) (DNSRecord, bool, error) {
+	record := DNSRecord{Name: subdomain, Resolver: systemResolverName}
+	dialed := &dialedServer{}
+	base := context.WithValue(ctx, dialedKey{}, dialed)
+
+	// query waits for rate limiter tokens, then bounds the lookup by the timeout
+	query := func(queries int) (context.Context, context.CancelFunc) {
+		limiter.Wait(queries)
+		return context.WithTimeout(base, timeout)
+	}
+
+	queryCtx, cancel := query(2) // A and AAAA
+	start := time.Now()
+	ips, err := dnsResolver.LookupHost(queryCtx, subdomain)
+	record.RTT = time.Since(start)
+	cancel()
+
+	// The resolver reported is the one the RTT was measured against, not the
+	// one a later CNAME or TXT query rotated to
+	dialed.mu.Lock()
+	if dialed.server != "" {
+		record.Resolver = dialed.server
+	}
+	dialed.mu.Unlock()
+	alive := err == nil && len(ips) > 0
+
+	if !alive && dnsResolver == net.DefaultResolver && ctx.Err() == nil {
+		// Simple LookupHost as fallback
+		limiter.Wait(2)
+		ips, err = net.LookupHost(subdomain)
+		alive = err == nil && len(ips) > 0
+	}
+
+	// Authoritative servers don't recurse, so a CNAME pointing outside their
+	// zone comes back without addresses but still proves the name exists
+	if alive || dnsResolver != net.DefaultResolver {
+		queryCtx, cancel = query(1)
+		if cname, err := dnsResolver.LookupCNAME(queryCtx, subdomain); err == nil {
+			if cname = strings.TrimSuffix(cname, "."); cname != subdomain {
+				record.CNAME = cname
+			}
+		}
+		cancel()
+	}
+	if !alive && record.CNAME == "" {
+		var dnsErr *net.DNSError
+		if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+			return record, false, errUnanswered
+		}
+		return record, false, nil
+	}
+
+	for _, ip := range ips {
+		if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+			record.AAAA = append(record.AAAA, ip)
+		} else {
+			record.A = append(record.A, ip)
+		}
+	}
+
+	queryCtx, cancel = query(1)
+	if txt, err := dnsResolver.LookupTXT(queryCtx, subdomain); err == nil {
+		record.TXT = txt
+	}
+	cancel()
+
+	return record, true, nil
+}
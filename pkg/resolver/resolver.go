@@ -2,61 +2,190 @@ package resolver
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/omerimzali/subscan/pkg/coverage"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/progress"
+	"github.com/omerimzali/subscan/pkg/schedule"
 )
 
+// errUnanswered reports a lookup no resolver answered, leaving it unknown
+// whether the name exists
+var errUnanswered = errors.New("no answer from the resolvers")
+
 const (
-	maxWorkers = 50
+	// DefaultConcurrency is the number of resolution workers used when none is configured
+	DefaultConcurrency = 50
+	// DefaultTimeout bounds each subdomain's lookups when no timeout is configured
+	DefaultTimeout = 5 * time.Second
 )
 
-// ResolveSubdomains performs DNS resolution on a list of subdomains to determine which ones are alive
-func ResolveSubdomains(subdomains []string) []string {
-	var aliveSubdomains []string
+// ResolveOptions contains configuration for subdomain resolution
+type ResolveOptions struct {
+	// Nameservers are queried directly (host:port) instead of the system resolver
+	Nameservers []string
+	// DoHURL sends every query to this DNS-over-HTTPS endpoint when set
+	DoHURL string
+	// DoTServers sends every query over DNS-over-TLS to these servers (host:port) when set
+	DoTServers []string
+	// Fast uses the raw UDP engine for very large candidate lists (A records only)
+	Fast bool
+	// Concurrency is the number of resolution workers
+	Concurrency int
+	// Rate caps DNS queries per second across all workers; 0 means unlimited
+	Rate float64
+	// Timeout bounds the lookups of a single subdomain
+	Timeout time.Duration
+	// OnResolved is called from the workers with each alive subdomain as soon as it resolves
+	OnResolved func(DNSRecord)
+	// OnAttempted is called from the workers after each subdomain's lookups,
+	// with its record when alive and nil otherwise
+	OnAttempted func(name string, record *DNSRecord)
+	// Windows restricts work to scan windows, pausing between jobs outside them
+	Windows schedule.Windows
+	// Stats records how the nameservers perform and leaves out the ones that
+	// performed poorly in past runs; it is saved once resolution ends
+	Stats *Stats
+}
+
+// DefaultResolveOptions returns a default set of resolve options using the system resolver
+func DefaultResolveOptions() ResolveOptions {
+	return ResolveOptions{
+		Concurrency: DefaultConcurrency,
+		Timeout:     DefaultTimeout,
+	}
+}
+
+// AuthoritativeNameservers looks up the NS records of a domain and returns the
+// addresses of its authoritative nameservers, ready to be used as ResolveOptions.Nameservers
+func AuthoritativeNameservers(domain string) ([]string, error) {
+	records, err := LookupNameservers(domain)
+	if err != nil {
+		return nil, err
+	}
+	
+	var nameservers []string
+	for _, ns := range records {
+		for _, ip := range ns.Addresses {
+			nameservers = append(nameservers, net.JoinHostPort(ip, "53"))
+		}
+	}
+	
+	return nameservers, nil
+}
+
+// ResolveSubdomains performs DNS resolution on a list of subdomains and returns
+// the records of the ones that are alive. Canceling ctx stops the resolution
+// and returns the records found so far.
+func ResolveSubdomains(ctx context.Context, subdomains []string, options ResolveOptions) []DNSRecord {
+	var aliveSubdomains []DNSRecord
 	var mu sync.Mutex
 	var wg sync.WaitGroup
+	var attempted int32
+	var unanswered int32
 	
 	// Track progress
-	var processed int32
 	total := len(subdomains)
 	
+	workers := options.Concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	limiter := newTokenBucket(options.Rate)
+	
 	// Print initial status
-	fmt.Printf("Starting resolution of %d subdomains with %d concurrent workers\n", total, maxWorkers)
+	logger.Infof("Starting resolution of %d subdomains with %d concurrent workers", total, workers)
+	if options.Rate > 0 {
+		logger.Infof("Rate limited to %.0f queries/sec", options.Rate)
+	}
+	var dnsResolver *net.Resolver
+	var engine *fastEngine
+	switch {
+	case options.Fast:
+		engine = newFastEngine(options.Nameservers, timeout, limiter, options.Stats)
+		logger.Infof("Using fast DNS engine with resolvers: %s", strings.Join(engine.servers, ", "))
+	case options.DoHURL != "":
+		logger.Infof("Resolving over DNS-over-HTTPS: %s", options.DoHURL)
+		dnsResolver = newDoHResolver(options.DoHURL, timeout)
+	case len(options.DoTServers) > 0:
+		logger.Infof("Resolving over DNS-over-TLS: %s", strings.Join(options.DoTServers, ", "))
+		dnsResolver = newDoTResolver(options.DoTServers, timeout)
+	default:
+		if len(options.Nameservers) > 0 {
+			logger.Infof("Querying nameservers directly: %s", strings.Join(options.Nameservers, ", "))
+		}
+		dnsResolver = newResolver(options.Nameservers, timeout, options.Stats)
+	}
 	
 	// Create a channel for jobs
 	jobs := make(chan string, len(subdomains))
 	
-	// Start progress reporting in the background
-	stopProgress := make(chan bool)
-	go func() {
-		ticker := time.NewTicker(2 * time.Second)
-		defer ticker.Stop()
-		
-		for {
-			select {
-			case <-ticker.C:
-				current := atomic.LoadInt32(&processed)
-				percent := float64(current) / float64(total) * 100
-				fmt.Printf("Progress: %d/%d (%.1f%%)\n", current, total, percent)
-			case <-stopProgress:
-				return
-			}
-		}
-	}()
+	// Report progress in the background
+	tracker := progress.Start("Resolving", total)
 
 	// Create workers
-	for i := 0; i < maxWorkers; i++ {
+	for i := 0; i < workers; i++ {
 		go func() {
+			lookup := func(subdomain string) (DNSRecord, bool, error) {
+				return lookupRecord(ctx, dnsResolver, subdomain, timeout, limiter)
+			}
+			if engine != nil {
+				worker := engine.newWorker()
+				defer worker.close()
+				lookup = func(subdomain string) (DNSRecord, bool, error) {
+					return worker.lookup(ctx, subdomain)
+				}
+			}
+			
 			for subdomain := range jobs {
-				if isAlive(subdomain) {
+				if ctx.Err() != nil {
+					wg.Done()
+					continue
+				}
+				if options.Windows.Wait(ctx) != nil {
+					wg.Done()
+					continue
+				}
+				record, ok, err := lookup(subdomain)
+				if !ok && ctx.Err() != nil {
+					// The lookup was cut short, so the name isn't known to be dead
+					wg.Done()
+					continue
+				}
+				if err != nil {
+					logger.Debugf("No answer for %s", subdomain)
+					atomic.AddInt32(&unanswered, 1)
+				}
+				if ok {
+					if record.CNAME != "" && len(record.A)+len(record.AAAA) == 0 {
+						logger.Debugf("Resolved %s (CNAME %s)", subdomain, record.CNAME)
+					} else {
+						logger.Debugf("Resolved %s", subdomain)
+					}
 					mu.Lock()
-					aliveSubdomains = append(aliveSubdomains, subdomain)
+					aliveSubdomains = append(aliveSubdomains, record)
 					mu.Unlock()
+					if options.OnResolved != nil {
+						options.OnResolved(record)
+					}
+					if options.OnAttempted != nil {
+						options.OnAttempted(subdomain, &record)
+					}
+				} else if options.OnAttempted != nil {
+					options.OnAttempted(subdomain, nil)
 				}
-				atomic.AddInt32(&processed, 1)
+				atomic.AddInt32(&attempted, 1)
+				tracker.Increment()
 				wg.Done()
 			}
 		}()
@@ -71,32 +200,60 @@ func ResolveSubdomains(subdomains []string) []string {
 	// Wait for all jobs to complete
 	wg.Wait()
 	close(jobs)
-	stopProgress <- true
+	tracker.Finish()
+	if err := options.Stats.Save(); err != nil {
+		logger.Warnf("could not save resolver statistics: %v", err)
+	}
 	
-	fmt.Printf("Resolution complete: %d alive out of %d total subdomains\n", len(aliveSubdomains), total)
+	if unanswered > 0 {
+		logger.Warnf("%d of %d subdomains got no answer from the resolvers (timeouts or server failures)", unanswered, total)
+		coverage.Note(ctx, "%d of %d names got no answer from the resolvers (timeouts or server failures) and may exist", unanswered, total)
+	}
+	if ctx.Err() != nil {
+		logger.Warnf("resolution interrupted after %d of %d subdomains: %d alive", attempted, total, len(aliveSubdomains))
+		coverage.Note(ctx, "resolution interrupted after %d of %d names", attempted, total)
+		return aliveSubdomains
+	}
+	logger.Infof("Resolution complete: %d alive out of %d total subdomains", len(aliveSubdomains), total)
 
 	return aliveSubdomains
 }
 
-// isAlive checks if a subdomain is alive by attempting DNS resolution
-func isAlive(subdomain string) bool {
-	// Set a timeout for the lookup
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// Try method 1: LookupHost with context
-	ips, err := net.DefaultResolver.LookupHost(ctx, subdomain)
-	if err == nil && len(ips) > 0 {
-		fmt.Printf("Resolved %s\n", subdomain)
-		return true
+// newResolver returns the system resolver, or a pure Go resolver that rotates
+// across the given nameservers when any are configured, leaving out the ones
+// that performed poorly in past runs
+func newResolver(nameservers []string, timeout time.Duration, stats *Stats) *net.Resolver {
+	if len(nameservers) == 0 {
+		return net.DefaultResolver
 	}
-
-	// Try method 2: Simple LookupHost as fallback
-	ips2, err := net.LookupHost(subdomain)
-	if err == nil && len(ips2) > 0 {
-		fmt.Printf("Resolved %s (fallback)\n", subdomain)
-		return true
+	nameservers = healthyServers(nameservers, stats)
+	
+	var next uint32
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			server := nameservers[atomic.AddUint32(&next, 1)%uint32(len(nameservers))]
+			noteServer(ctx, server)
+			dialer := net.Dialer{Timeout: timeout}
+			conn, err := dialer.DialContext(ctx, network, server)
+			if err != nil {
+				stats.Record(server, 0, false)
+				return nil, err
+			}
+			if stats == nil {
+				return conn, nil
+			}
+			return wrapStatsConn(conn, stats, server), nil
+		},
 	}
+}
 
-	return false
-} 
\ No newline at end of file
+// healthyServers drops the servers that performed poorly in past runs,
+// logging each the first time it is skipped
+func healthyServers(servers []string, stats *Stats) []string {
+	healthy, skipped := stats.Healthy(servers)
+	if skipped = stats.newlySkipped(skipped); len(skipped) > 0 {
+		logger.Infof("Skipping %d resolvers that performed poorly in past runs: %s", len(skipped), strings.Join(skipped, ", "))
+	}
+	return healthy
+}
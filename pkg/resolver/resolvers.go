@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// ParseResolvers turns resolver flag values into host:port nameserver addresses.
+// Each value is either a resolver address (1.1.1.1, 8.8.8.8:53, [2606:4700::1111]:53)
+// or the path to a file listing one resolver per line, with # comments.
+func ParseResolvers(values []string) ([]string, error) {
+	var nameservers []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if _, err := os.Stat(value); err == nil {
+			fromFile, err := readResolversFile(value)
+			if err != nil {
+				return nil, err
+			}
+			nameservers = append(nameservers, fromFile...)
+			continue
+		}
+
+		nameserver, err := normalizeResolver(value)
+		if err != nil {
+			return nil, err
+		}
+		nameservers = append(nameservers, nameserver)
+	}
+	return nameservers, nil
+}
+
+// readResolversFile reads resolver addresses from a file, one per line
+func readResolversFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var nameservers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		nameserver, err := normalizeResolver(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		nameservers = append(nameservers, nameserver)
+	}
+	return nameservers, scanner.Err()
+}
+
+// normalizeResolver validates a resolver address and adds the default DNS port
+func normalizeResolver(value string) (string, error) {
+	if ip := net.ParseIP(value); ip != nil {
+		return net.JoinHostPort(value, "53"), nil
+	}
+	host, port, err := net.SplitHostPort(value)
+	if err != nil || net.ParseIP(host) == nil || port == "" {
+		return "", fmt.Errorf("invalid resolver %q (expected IP or IP:port)", value)
+	}
+	return net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,41 @@
+package resolver
+
+import (
+	"context"
+	"time"
+)
+
+// Reverify resolves alive subdomains a second time, after waiting delay, and
+// returns the records of those that resolve again along with the names that
+// don't. A single answer from a flaky or misbehaving resolver is then not
+// enough to send a host on to the more expensive stages. Canceling ctx
+// returns every record unverified.
+func Reverify(ctx context.Context, records []DNSRecord, delay time.Duration, options ResolveOptions) ([]DNSRecord, []string) {
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return records, nil
+		}
+	}
+
+	options.OnResolved = nil
+	options.OnAttempted = nil
+	again := RecordMap(ResolveSubdomains(ctx, Names(records), options))
+	if ctx.Err() != nil {
+		return records, nil
+	}
+
+	var confirmed []DNSRecord
+	var dropped []string
+	for _, record := range records {
+		if _, ok := again[record.Name]; ok {
+			confirmed = append(confirmed, record)
+		} else {
+			dropped = append(dropped, record.Name)
+		}
+	}
+	return confirmed, dropped
+}
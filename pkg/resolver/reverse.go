@@ -0,0 +1,134 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/progress"
+)
+
+// SweepRanges returns the unique /24 networks of the IPv4 addresses in
+// records, e.g. 192.0.2.0/24, the ranges holding the most addresses first so
+// a capped sweep covers the address space the target uses most
+func SweepRanges(records []DNSRecord) []string {
+	counts := make(map[string]int)
+	var ranges []string
+	for _, record := range records {
+		for _, address := range record.A {
+			ip := net.ParseIP(address).To4()
+			if ip == nil {
+				continue
+			}
+			network := (&net.IPNet{IP: ip.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+			if counts[network] == 0 {
+				ranges = append(ranges, network)
+			}
+			counts[network]++
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		if counts[ranges[i]] != counts[ranges[j]] {
+			return counts[ranges[i]] > counts[ranges[j]]
+		}
+		return ranges[i] < ranges[j]
+	})
+	return ranges
+}
+
+// ReverseSweep looks up the PTR records of every address in the given /24
+// ranges and returns the hostnames within domain they point to, sorted.
+// Hosts of a target often share address space that only reverse DNS names.
+// Canceling ctx returns the names found so far.
+func ReverseSweep(ctx context.Context, ranges []string, domain string, options ResolveOptions) []string {
+	workers := options.Concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	limiter := newTokenBucket(options.Rate)
+
+	var dnsResolver *net.Resolver
+	switch {
+	case options.DoHURL != "":
+		dnsResolver = newDoHResolver(options.DoHURL, timeout)
+	case len(options.DoTServers) > 0:
+		dnsResolver = newDoTResolver(options.DoTServers, timeout)
+	default:
+		// The fast engine only asks for A records, so PTRs go through the
+		// nameservers it would use
+		dnsResolver = newResolver(options.Nameservers, timeout, options.Stats)
+	}
+
+	var addresses []string
+	for _, network := range ranges {
+		_, ipNet, err := net.ParseCIDR(network)
+		if err != nil || ipNet.IP.To4() == nil {
+			continue
+		}
+		base := ipNet.IP.To4()
+		for host := 1; host < 255; host++ {
+			addresses = append(addresses, net.IPv4(base[0], base[1], base[2], byte(host)).String())
+		}
+	}
+	logger.Infof("Sweeping PTR records of %d addresses in %d ranges", len(addresses), len(ranges))
+
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	found := make(map[string]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	tracker := progress.Start("Reverse sweep", len(addresses))
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for address := range jobs {
+				if options.Windows.Wait(ctx) != nil {
+					continue
+				}
+				limiter.Wait(1)
+				queryCtx, cancel := context.WithTimeout(ctx, timeout)
+				names, err := dnsResolver.LookupAddr(queryCtx, address)
+				cancel()
+				tracker.Increment()
+				if err != nil {
+					continue
+				}
+				for _, name := range names {
+					name = strings.ToLower(strings.TrimSuffix(name, "."))
+					if name != domain && !strings.HasSuffix(name, "."+domain) {
+						continue
+					}
+					logger.Debugf("PTR %s -> %s", address, name)
+					mu.Lock()
+					found[name] = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, address := range addresses {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- address
+	}
+	close(jobs)
+	wg.Wait()
+	tracker.Finish()
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,301 @@
+package resolver
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultStatsFile is where resolver statistics are kept, under the home directory
+const DefaultStatsFile = ".subscan/resolver-stats.json"
+
+const (
+	// statsMinQueries is how many queries a resolver needs before its history is judged
+	statsMinQueries = 50
+	// statsMaxFailureRate is the share of failed queries past which a resolver is skipped
+	statsMaxFailureRate = 0.3
+	// statsSlowFactor skips resolvers answering this many times slower than
+	// the median of the configured ones
+	statsSlowFactor = 4
+	// statsRetryAfter is how old the history of a skipped resolver gets before
+	// it is given another chance
+	statsRetryAfter = 7 * 24 * time.Hour
+	// statsWindow halves a resolver's counts once it has seen this many
+	// queries, so recent runs outweigh old ones
+	statsWindow = 10000
+)
+
+// ServerStats is the history of queries sent to one resolver
+type ServerStats struct {
+	Queries int64 `json:"queries"`
+	// Failures counts the queries that timed out or couldn't be sent
+	Failures int64 `json:"failures"`
+	// RTT is the total round trip time of the answered queries
+	RTT     time.Duration `json:"rtt_ns"`
+	Updated time.Time     `json:"updated"`
+}
+
+// FailureRate returns the share of the resolver's queries that failed
+func (s ServerStats) FailureRate() float64 {
+	if s.Queries == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Queries)
+}
+
+// AverageRTT returns the mean round trip time of the answered queries
+func (s ServerStats) AverageRTT() time.Duration {
+	answered := s.Queries - s.Failures
+	if answered <= 0 {
+		return 0
+	}
+	return s.RTT / time.Duration(answered)
+}
+
+// Stats tracks how each resolver performs across runs, so the ones that
+// historically time out or lag on this network can be left out of rotation.
+// A nil *Stats records nothing.
+type Stats struct {
+	path    string
+	mu      sync.Mutex
+	Servers map[string]*ServerStats `json:"servers"`
+	// reported holds the skipped servers already logged this run
+	reported map[string]bool
+}
+
+// DefaultStatsPath returns the path of the statistics file in the home directory
+func DefaultStatsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, DefaultStatsFile)
+}
+
+// LoadStats reads resolver statistics from path, starting empty if the file doesn't exist
+func LoadStats(path string) (*Stats, error) {
+	stats := &Stats{path: path, Servers: make(map[string]*ServerStats), reported: make(map[string]bool)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, err
+	}
+	if stats.Servers == nil {
+		stats.Servers = make(map[string]*ServerStats)
+	}
+	return stats, nil
+}
+
+// Record adds the outcome of one query sent to server
+func (s *Stats) Record(server string, rtt time.Duration, ok bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats, found := s.Servers[server]
+	if !found {
+		stats = &ServerStats{}
+		s.Servers[server] = stats
+	}
+	if stats.Queries >= statsWindow {
+		stats.Queries /= 2
+		stats.Failures /= 2
+		stats.RTT /= 2
+	}
+	stats.Queries++
+	if ok {
+		stats.RTT += rtt
+	} else {
+		stats.Failures++
+	}
+	stats.Updated = time.Now().UTC()
+}
+
+// Healthy splits servers into the ones to query, in their given order, and
+// the ones skipped for failing too often or answering much slower than the
+// rest in past runs. At least one server is always kept.
+func (s *Stats) Healthy(servers []string) ([]string, []string) {
+	if s == nil || len(servers) < 2 {
+		return servers, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	judged := make(map[string]ServerStats)
+	var averages []time.Duration
+	for _, server := range servers {
+		stats, ok := s.Servers[server]
+		if !ok || stats.Queries < statsMinQueries || time.Since(stats.Updated) > statsRetryAfter {
+			continue
+		}
+		judged[server] = *stats
+		if average := stats.AverageRTT(); average > 0 {
+			averages = append(averages, average)
+		}
+	}
+	var median time.Duration
+	if len(averages) > 0 {
+		sort.Slice(averages, func(i, j int) bool { return averages[i] < averages[j] })
+		median = averages[len(averages)/2]
+	}
+
+	var healthy, skipped []string
+	for _, server := range servers {
+		stats, ok := judged[server]
+		poor := ok && (stats.FailureRate() > statsMaxFailureRate ||
+			(median > 0 && stats.AverageRTT() > statsSlowFactor*median))
+		if poor {
+			skipped = append(skipped, server)
+		} else {
+			healthy = append(healthy, server)
+		}
+	}
+	if len(healthy) == 0 {
+		// Keep the least bad one rather than resolving with nothing
+		best := 0
+		for i, server := range skipped {
+			if judged[server].FailureRate() < judged[skipped[best]].FailureRate() {
+				best = i
+			}
+		}
+		healthy = []string{skipped[best]}
+		skipped = append(skipped[:best:best], skipped[best+1:]...)
+	}
+	return healthy, skipped
+}
+
+// newlySkipped returns the skipped servers not reported yet this run
+func (s *Stats) newlySkipped(skipped []string) []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var fresh []string
+	for _, server := range skipped {
+		if !s.reported[server] {
+			s.reported[server] = true
+			fresh = append(fresh, server)
+		}
+	}
+	return fresh
+}
+
+// Save writes the statistics back to their file, creating its directory when
+// needed. The file is replaced atomically, so runs saving at the same time
+// never leave it half written.
+func (s *Stats) Save() error {
+	if s == nil || s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// wrapStatsConn wraps a connection to server so its queries are recorded.
+// UDP connections stay packet connections, which the Go resolver relies on
+// to tell datagram from stream framing.
+func wrapStatsConn(conn net.Conn, stats *Stats, server string) net.Conn {
+	wrapped := &statsConn{Conn: conn, stats: stats, server: server}
+	if packet, ok := conn.(net.PacketConn); ok {
+		return &statsPacketConn{statsConn: wrapped, packet: packet}
+	}
+	return wrapped
+}
+
+// statsConn records the outcome of the queries sent over a connection to a
+// resolver: answered once a reply is read, failed when closed without one
+type statsConn struct {
+	net.Conn
+	stats  *Stats
+	server string
+
+	mu       sync.Mutex
+	sent     time.Time
+	answered bool
+}
+
+// Write notes when the first query went out
+func (c *statsConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	if c.sent.IsZero() {
+		c.sent = time.Now()
+	}
+	c.mu.Unlock()
+	return c.Conn.Write(b)
+}
+
+// Read records the query as answered on its first reply
+func (c *statsConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.mu.Lock()
+		if !c.answered && !c.sent.IsZero() {
+			c.answered = true
+			c.stats.Record(c.server, time.Since(c.sent), true)
+		}
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+// Close records the query as failed when no reply came
+func (c *statsConn) Close() error {
+	c.mu.Lock()
+	if !c.answered && !c.sent.IsZero() {
+		c.answered = true
+		c.stats.Record(c.server, 0, false)
+	}
+	c.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// statsPacketConn is a statsConn over UDP
+type statsPacketConn struct {
+	*statsConn
+	packet net.PacketConn
+}
+
+// ReadFrom reads a datagram from the underlying connection
+func (c *statsPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	return c.packet.ReadFrom(b)
+}
+
+// WriteTo writes a datagram to the underlying connection
+func (c *statsPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	return c.packet.WriteTo(b, addr)
+}
@@ -0,0 +1,168 @@
+package robots
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Rules holds the allow/disallow rules of a robots.txt that apply to our user agent
+type Rules struct {
+	allow    []string
+	disallow []string
+}
+
+// group is a set of rules for one or more user agents
+type group struct {
+	agents   []string
+	allow    []string
+	disallow []string
+}
+
+// cache keeps fetched rules per base URL so each host's robots.txt is fetched once
+var cache = struct {
+	sync.Mutex
+	rules map[string]*Rules
+}{rules: make(map[string]*Rules)}
+
+// Fetch downloads and parses robots.txt for a base URL such as https://example.com.
+// A missing or unreadable robots.txt allows everything.
+func Fetch(client *http.Client, baseURL string, userAgent string) *Rules {
+	cache.Lock()
+	if rules, ok := cache.rules[baseURL]; ok {
+		cache.Unlock()
+		return rules
+	}
+	cache.Unlock()
+
+	rules := &Rules{}
+	resp, err := client.Get(strings.TrimSuffix(baseURL, "/") + "/robots.txt")
+	if err == nil {
+		if resp.StatusCode == http.StatusOK {
+			rules = Parse(io.LimitReader(resp.Body, 512*1024), userAgent)
+		}
+		resp.Body.Close()
+	}
+
+	cache.Lock()
+	cache.rules[baseURL] = rules
+	cache.Unlock()
+
+	return rules
+}
+
+// Parse reads robots.txt content and returns the rules for the given user agent,
+// falling back to the wildcard group when no group names the agent
+func Parse(r io.Reader, userAgent string) *Rules {
+	var groups []*group
+	var current *group
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastWasAgent {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+		case "allow", "disallow":
+			lastWasAgent = false
+			if current == nil || value == "" {
+				continue
+			}
+			if key == "allow" {
+				current.allow = append(current.allow, value)
+			} else {
+				current.disallow = append(current.disallow, value)
+			}
+		default:
+			lastWasAgent = false
+		}
+	}
+
+	// The product token is the part of the User-Agent before the version
+	token := strings.ToLower(strings.SplitN(userAgent, "/", 2)[0])
+
+	var wildcard *group
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if wildcard == nil {
+					wildcard = g
+				}
+			} else if token != "" && strings.Contains(token, agent) {
+				return &Rules{allow: g.allow, disallow: g.disallow}
+			}
+		}
+	}
+	if wildcard != nil {
+		return &Rules{allow: wildcard.allow, disallow: wildcard.disallow}
+	}
+	return &Rules{}
+}
+
+// Allowed reports whether a path may be requested; the longest matching rule wins
+// and allow wins ties, as in RFC 9309
+func (r *Rules) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	longestAllow := longestMatch(r.allow, path)
+	longestDisallow := longestMatch(r.disallow, path)
+	return longestDisallow < 0 || longestAllow >= longestDisallow
+}
+
+// longestMatch returns the length of the longest rule matching the path, or -1
+func longestMatch(rules []string, path string) int {
+	longest := -1
+	for _, rule := range rules {
+		if matchRule(rule, path) && len(rule) > longest {
+			longest = len(rule)
+		}
+	}
+	return longest
+}
+
+// matchRule matches a robots.txt path rule supporting * wildcards and a trailing $ anchor
+func matchRule(rule string, path string) bool {
+	anchored := strings.HasSuffix(rule, "$")
+	rule = strings.TrimSuffix(rule, "$")
+
+	parts := strings.Split(rule, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+
+	if anchored {
+		return rest == "" || (len(parts) > 1 && strings.HasSuffix(path, parts[len(parts)-1]))
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package scan
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/enumeration"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// Option configures a Scanner
+type Option func(*Options)
+
+// WithOptions replaces every option, for callers building Options themselves
+func WithOptions(options Options) Option {
+	return func(o *Options) {
+		*o = options
+	}
+}
+
+// WithSources queries only the given passive sources
+func WithSources(sources ...enumeration.Source) Option {
+	return func(o *Options) {
+		o.Sources = sources
+	}
+}
+
+// WithoutPassive skips the passive sources and only brute forces the wordlists
+func WithoutPassive() Option {
+	return func(o *Options) {
+		o.SkipPassive = true
+	}
+}
+
+// WithRecursion enumerates the subdomains found by the passive sources again,
+// within the bounds of options
+func WithRecursion(options enumeration.RecursionOptions) Option {
+	return func(o *Options) {
+		o.Recursion = &options
+	}
+}
+
+// WithoutZoneTransfer skips the zone transfer attempt
+func WithoutZoneTransfer() Option {
+	return func(o *Options) {
+		o.ZoneTransfer = false
+	}
+}
+
+// WithScope drops the names the scope filter excludes from every stage (see
+// enumeration.NewScopeFilter)
+func WithScope(scope *enumeration.ScopeFilter) Option {
+	return func(o *Options) {
+		o.Scope = scope
+	}
+}
+
+// WithWordlist brute forces the wordlist at path, placing its words as new
+// leading labels
+func WithWordlist(path string) Option {
+	return WithWordlists(enumeration.Wordlist{Path: path, Mode: enumeration.WordlistPrefix})
+}
+
+// WithWordlists brute forces the wordlists in addition to those already set
+func WithWordlists(wordlists ...enumeration.Wordlist) Option {
+	return func(o *Options) {
+		o.Wordlists = append(o.Wordlists, wordlists...)
+	}
+}
+
+// WithMaxDepth limits generated names to depth labels below the domain
+func WithMaxDepth(depth int) Option {
+	return func(o *Options) {
+		o.MaxDepth = depth
+	}
+}
+
+// WithResolvers queries the nameservers (host:port) instead of the system resolver
+func WithResolvers(nameservers ...string) Option {
+	return func(o *Options) {
+		o.Resolve.Nameservers = nameservers
+	}
+}
+
+// WithConcurrency sets the number of workers of every stage
+func WithConcurrency(workers int) Option {
+	return func(o *Options) {
+		o.Resolve.Concurrency = workers
+		o.Analysis.Concurrency = workers
+		o.ProbeOptions.Concurrency = workers
+	}
+}
+
+// WithTimeout sets the timeout of a single lookup or HTTP request in every stage
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.Resolve.Timeout = timeout
+		o.Analysis.Timeout = timeout
+		o.ProbeOptions.Timeout = timeout
+	}
+}
+
+// WithProxy sends the scoring and probing requests through proxy (see
+// httpclient.ParseProxy); passive sources use enumeration.SetProxy
+func WithProxy(proxy *url.URL) Option {
+	return func(o *Options) {
+		o.Analysis.Proxy = proxy
+		o.ProbeOptions.Proxy = proxy
+	}
+}
+
+// WithResolveOptions sets the resolution options
+func WithResolveOptions(options resolver.ResolveOptions) Option {
+	return func(o *Options) {
+		o.Resolve = options
+	}
+}
+
+// WithReverify resolves the alive subdomains again after delay and drops
+// those that no longer resolve
+func WithReverify(delay time.Duration) Option {
+	return func(o *Options) {
+		o.Reverify = true
+		o.ReverifyDelay = delay
+	}
+}
+
+// WithSANHarvest resolves and scores the subdomains named by the certificates
+// of the scored ones, for up to rounds rounds; 0 turns harvesting off
+func WithSANHarvest(rounds int) Option {
+	return func(o *Options) {
+		o.SANRounds = rounds
+	}
+}
+
+// WithScoring scores the alive subdomains
+func WithScoring() Option {
+	return func(o *Options) {
+		o.Score = true
+	}
+}
+
+// WithAnalysisOptions scores the alive subdomains with the given options
+func WithAnalysisOptions(options scorer.AnalysisOptions) Option {
+	return func(o *Options) {
+		o.Score = true
+		o.Analysis = options
+	}
+}
+
+// WithProbing probes the alive subdomains for misconfigurations
+func WithProbing() Option {
+	return func(o *Options) {
+		o.Probe = true
+	}
+}
+
+// WithChecks probes the alive subdomains running only the named checks (see
+// probe.SelectChecks)
+func WithChecks(checks ...string) Option {
+	return func(o *Options) {
+		o.Probe = true
+		o.ProbeOptions.Checks = append([]string{}, checks...)
+	}
+}
+
+// WithProbeOptions probes the alive subdomains with the given options
+func WithProbeOptions(options probe.ProbeOptions) Option {
+	return func(o *Options) {
+		o.Probe = true
+		o.ProbeOptions = options
+	}
+}
@@ -0,0 +1,299 @@
+// Package scan runs subdomain scans from Go programs: enumeration, resolution
+// and then scoring or probing, with the results streamed back to the caller
+// as they are produced instead of printed.
+package scan
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/enumeration"
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+	"github.com/omerimzali/subscan/pkg/sink"
+)
+
+// DefaultSANRounds bounds how many times the certificates of hosts harvested
+// from SANs are harvested in turn
+const DefaultSANRounds = 3
+
+// resultBuffer is how many results may wait for the caller before the scan
+// workers block
+const resultBuffer = 64
+
+// Options contains configuration for a scan
+type Options struct {
+	// Sources are the passive sources queried; nil queries every registered source
+	Sources []enumeration.Source
+	// SkipPassive only brute forces the wordlists
+	SkipPassive bool
+	// Recursion enumerates the subdomains found by the passive sources again when set
+	Recursion *enumeration.RecursionOptions
+	// ZoneTransfer asks the domain's nameservers for a zone transfer
+	ZoneTransfer bool
+	// Scope drops the names the scope rules exclude; nil keeps every name
+	Scope *enumeration.ScopeFilter
+	// Wordlists are brute forced in addition to the passive results
+	Wordlists []enumeration.Wordlist
+	// MaxDepth limits generated names to this many labels below the domain; 0 means unlimited
+	MaxDepth int
+	Resolve  resolver.ResolveOptions
+	// Reverify resolves the alive subdomains again after ReverifyDelay and
+	// drops those that no longer resolve
+	Reverify      bool
+	ReverifyDelay time.Duration
+	// Score analyzes and scores the alive subdomains
+	Score    bool
+	Analysis scorer.AnalysisOptions
+	// SANRounds resolves and scores the subdomains named by the certificates
+	// of the scored ones, and then those named by theirs, up to this many rounds
+	SANRounds int
+	// Probe probes the alive subdomains for misconfigurations instead of scoring them
+	Probe        bool
+	ProbeOptions probe.ProbeOptions
+}
+
+// DefaultOptions returns the default scan options, matching the CLI's: every
+// passive source, a zone transfer attempt, resolution only and, once scoring,
+// SAN harvesting
+func DefaultOptions() Options {
+	return Options{
+		ZoneTransfer: true,
+		Resolve:      resolver.DefaultResolveOptions(),
+		Analysis:     scorer.DefaultOptions(),
+		SANRounds:    DefaultSANRounds,
+		ProbeOptions: probe.DefaultProbeOptions(),
+	}
+}
+
+// Scanner scans the subdomains of a domain. Each stage is available on its
+// own, returning its results, or chained by Results.
+type Scanner struct {
+	domain  string
+	options Options
+
+	// mu guards what enumeration found besides the candidates
+	mu sync.Mutex
+	// ports are the open ports the passive sources reported per host
+	ports map[string][]int
+	// openNameservers handed out the zone
+	openNameservers []resolver.Nameserver
+}
+
+// New returns a scanner for the domain, configured by the options applied in
+// order over DefaultOptions
+func New(domain string, opts ...Option) *Scanner {
+	options := DefaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &Scanner{domain: strings.ToLower(strings.TrimSuffix(domain, ".")), options: options}
+}
+
+// Domain returns the scanned domain
+func (s *Scanner) Domain() string {
+	return s.domain
+}
+
+// Enumerate returns the unique candidate subdomains found by the passive
+// sources, the wordlists and a zone transfer, within the scope rules
+func (s *Scanner) Enumerate(ctx context.Context) []string {
+	var passive []string
+	if !s.options.SkipPassive {
+		var ports map[string][]int
+		passive, _, ports = EnumeratePassive(ctx, s.domain, s.options.Sources, s.options.Recursion)
+		s.mu.Lock()
+		s.ports = ports
+		s.mu.Unlock()
+	}
+
+	var generated []string
+	for _, wordlist := range s.options.Wordlists {
+		generated = append(generated, enumeration.BruteForceWordlist(s.domain, wordlist, passive)...)
+	}
+	generated, _ = enumeration.ScopeCandidates(generated, s.domain, s.options.MaxDepth)
+	candidates := append(passive, generated...)
+
+	if s.options.ZoneTransfer && ctx.Err() == nil {
+		zone, open, err := TransferZone(s.domain, s.options.Resolve.Timeout)
+		if err == nil {
+			candidates = append(candidates, zone...)
+			s.mu.Lock()
+			s.openNameservers = open
+			s.mu.Unlock()
+		}
+	}
+
+	candidates, _ = Dedupe(candidates)
+	candidates, _ = s.options.Scope.Filter(candidates)
+	return candidates
+}
+
+// Resolve returns the DNS records of the alive subdomains among names,
+// re-verified when Reverify is set
+func (s *Scanner) Resolve(ctx context.Context, names []string) []resolver.DNSRecord {
+	records := resolver.ResolveSubdomains(ctx, names, s.options.Resolve)
+	return s.reverify(ctx, records)
+}
+
+// Score analyzes and scores the resolved subdomains, highest score first,
+// along with the subdomains harvested from their certificates when SANRounds
+// is set
+func (s *Scanner) Score(ctx context.Context, records []resolver.DNSRecord) []scorer.SubdomainInfo {
+	options := s.analysisOptions(ctx, records)
+	results := scorer.AnalyzeSubdomains(ctx, resolver.Names(records), options)
+	harvested, _ := s.harvestSANs(ctx, records, results, options)
+	results = append(results, harvested...)
+	scorer.SortByScore(results)
+	return results
+}
+
+// Probe probes the resolved subdomains for misconfigurations, and reports the
+// nameservers that handed out the zone to Enumerate
+func (s *Scanner) Probe(ctx context.Context, records []resolver.DNSRecord) []probe.ProbeResult {
+	options := s.probeOptions(records)
+	results := probe.RunProbes(ctx, resolver.Names(records), options)
+	return append(results, probe.ZoneTransferResults(s.domain, s.zoneTransfers(), options)...)
+}
+
+// Results runs every stage in the background and returns a channel receiving
+// each result of the last stage as soon as it is produced: DNS records when
+// only resolving, scored subdomains with scoring and probe results with
+// probing. The channel is closed when the scan is done. Canceling ctx stops
+// the scan; callers that stop reading early must cancel it so the scan's
+// workers exit.
+func (s *Scanner) Results(ctx context.Context) <-chan sink.Result {
+	results := make(chan sink.Result, resultBuffer)
+	go func() {
+		defer close(results)
+		s.run(ctx, func(result sink.Result) {
+			result.Target = s.domain
+			select {
+			case results <- result:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return results
+}
+
+// run scans the domain and hands each result of the last stage to emit
+func (s *Scanner) run(ctx context.Context, emit func(sink.Result)) {
+	candidates := s.Enumerate(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Records are only final once re-verified, so they are emitted after it
+	if !s.options.Score && !s.options.Probe && !s.options.Reverify {
+		resolveOptions := s.options.Resolve
+		resolveOptions.OnResolved = func(record resolver.DNSRecord) {
+			emit(sink.Result{Record: &record})
+		}
+		resolver.ResolveSubdomains(ctx, candidates, resolveOptions)
+		return
+	}
+	records := s.Resolve(ctx, candidates)
+	if ctx.Err() != nil {
+		return
+	}
+	if !s.options.Score && !s.options.Probe {
+		for i := range records {
+			emit(sink.Result{Record: &records[i]})
+		}
+		return
+	}
+
+	if s.options.Probe {
+		options := s.probeOptions(records)
+		options.DiscardResults = true
+		options.OnResult = func(result probe.ProbeResult) {
+			emit(sink.Result{Probe: &result})
+		}
+		probe.RunProbes(ctx, resolver.Names(records), options)
+		for _, result := range probe.ZoneTransferResults(s.domain, s.zoneTransfers(), options) {
+			options.OnResult(result)
+		}
+		return
+	}
+
+	// The certificates of the streamed results seed the SAN harvest
+	var mu sync.Mutex
+	var sans []scorer.SubdomainInfo
+	options := s.analysisOptions(ctx, records)
+	options.DiscardResults = true
+	options.OnResult = func(info scorer.SubdomainInfo) {
+		if s.options.SANRounds > 0 && len(info.SANs) > 0 {
+			mu.Lock()
+			sans = append(sans, scorer.SubdomainInfo{SANs: info.SANs})
+			mu.Unlock()
+		}
+		emit(sink.Result{Subdomain: &info})
+	}
+	scorer.AnalyzeSubdomains(ctx, resolver.Names(records), options)
+	mu.Lock()
+	seeds := sans
+	mu.Unlock()
+	s.harvestSANs(ctx, records, seeds, options)
+}
+
+// reverify resolves the records again when Reverify is set, dropping the
+// names that no longer resolve
+func (s *Scanner) reverify(ctx context.Context, records []resolver.DNSRecord) []resolver.DNSRecord {
+	if !s.options.Reverify || len(records) == 0 || ctx.Err() != nil {
+		return records
+	}
+	confirmed, _ := resolver.Reverify(ctx, records, s.options.ReverifyDelay, s.options.Resolve)
+	return confirmed
+}
+
+// harvestSANs resolves and scores the subdomains named by the certificates of
+// results when SANRounds is set, skipping the names already resolved
+func (s *Scanner) harvestSANs(ctx context.Context, records []resolver.DNSRecord, results []scorer.SubdomainInfo, options scorer.AnalysisOptions) ([]scorer.SubdomainInfo, []resolver.DNSRecord) {
+	if s.options.SANRounds <= 0 {
+		return nil, nil
+	}
+	known := make(map[string]bool, len(records))
+	for _, record := range records {
+		known[record.Name] = true
+	}
+	sanOptions := SANOptions{Rounds: s.options.SANRounds, Scope: s.options.Scope}
+	return HarvestSANs(ctx, s.domain, results, known, s.options.Resolve, options, sanOptions)
+}
+
+// zoneTransfers returns the nameservers that handed out the zone to Enumerate
+func (s *Scanner) zoneTransfers() []resolver.Nameserver {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.openNameservers
+}
+
+// analysisOptions returns the scoring options reusing the records, with the
+// apex and www baselines profiled
+func (s *Scanner) analysisOptions(ctx context.Context, records []resolver.DNSRecord) scorer.AnalysisOptions {
+	options := s.options.Analysis
+	options.Records = resolver.RecordMap(records)
+	if options.KnownPorts == nil {
+		s.mu.Lock()
+		options.KnownPorts = s.ports
+		s.mu.Unlock()
+	}
+	if options.Baselines == nil {
+		options.Baselines = scorer.ProfileBaselines(ctx, s.domain, options)
+	}
+	return options
+}
+
+// probeOptions returns the probe options reusing the records, scoped to the
+// domain
+func (s *Scanner) probeOptions(records []resolver.DNSRecord) probe.ProbeOptions {
+	options := s.options.ProbeOptions
+	options.Records = resolver.RecordMap(records)
+	if options.Scope == "" {
+		options.Scope = s.domain
+	}
+	return options
+}
@@ -0,0 +1,143 @@
+package scan
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/attribution"
+	"github.com/omerimzali/subscan/pkg/enumeration"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// Dedupe lowercases and de-duplicates names, keeping their first-seen order,
+// and returns the set of names kept
+func Dedupe(names []string) ([]string, map[string]bool) {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique, seen
+}
+
+// EnumeratePassive queries the passive sources for the domain, and again for
+// the subdomains found when recursion is set. It returns the results inside
+// the domain, how many were dropped outside it and the open ports the
+// sources reported under the domain. A nil source list queries every
+// registered source.
+func EnumeratePassive(ctx context.Context, domain string, sources []enumeration.Source, recursion *enumeration.RecursionOptions) ([]string, int, map[string][]int) {
+	if sources == nil {
+		sources = enumeration.Sources()
+	}
+
+	var results []string
+	if recursion != nil {
+		results = enumeration.FetchPassiveRecursive(ctx, domain, sources, *recursion)
+	} else {
+		results = enumeration.FetchPassive(ctx, domain, sources)
+	}
+	results, dropped := enumeration.ScopeCandidates(results, domain, 0)
+	return results, dropped, enumeration.CollectPorts(sources, domain)
+}
+
+// TransferZone attempts a zone transfer of the domain from each of its
+// authoritative nameservers, returning the names in the zone and the
+// nameservers that handed it out
+func TransferZone(domain string, timeout time.Duration) ([]string, []resolver.Nameserver, error) {
+	nameservers, err := resolver.LookupNameservers(domain)
+	if err != nil {
+		return nil, nil, err
+	}
+	zone, open := resolver.TransferZone(domain, nameservers, timeout)
+	return zone, open, nil
+}
+
+// SANOptions configures HarvestSANs
+type SANOptions struct {
+	// Rounds is how many times the certificates of the hosts found are
+	// harvested in turn
+	Rounds int
+	// Scope drops the names the scope rules exclude; nil keeps every name
+	Scope *enumeration.ScopeFilter
+	// Pending keeps the names still to be scored, such as those not scored
+	// before an interruption; nil keeps every name
+	Pending func(names []string) []string
+}
+
+// HarvestSANs resolves and scores the subdomains named by the certificates of
+// results that are not in known, and then those named by their certificates in
+// turn, for up to options.Rounds rounds. known gains every name tried. It
+// returns the results and records of the alive names harvested, which carry
+// the tls-san provenance. When analysis discards its results, the
+// certificates of the streamed ones seed the next round.
+func HarvestSANs(ctx context.Context, domain string, results []scorer.SubdomainInfo, known map[string]bool, resolve resolver.ResolveOptions, analysis scorer.AnalysisOptions, options SANOptions) ([]scorer.SubdomainInfo, []resolver.DNSRecord) {
+	if analysis.Records == nil {
+		analysis.Records = make(map[string]resolver.DNSRecord)
+	}
+
+	// Streamed results only keep their SANs, collected as they arrive
+	var mu sync.Mutex
+	var streamed []scorer.SubdomainInfo
+	if onResult := analysis.OnResult; onResult != nil {
+		analysis.OnResult = func(info scorer.SubdomainInfo) {
+			info.Provenance = scorer.ProvenanceTLSSAN
+			if analysis.DiscardResults && len(info.SANs) > 0 {
+				mu.Lock()
+				streamed = append(streamed, scorer.SubdomainInfo{SANs: info.SANs})
+				mu.Unlock()
+			}
+			onResult(info)
+		}
+	}
+
+	attributed := attribution.From(ctx)
+	sources := results
+	var harvested []scorer.SubdomainInfo
+	var records []resolver.DNSRecord
+	for round := 1; round <= options.Rounds && ctx.Err() == nil; round++ {
+		sans := scorer.SANCandidates(sources, domain, known)
+		sans, _ = options.Scope.Filter(sans)
+		for _, san := range sans {
+			known[san] = true
+		}
+		if options.Pending != nil {
+			sans = options.Pending(sans)
+		}
+		if len(sans) == 0 {
+			break
+		}
+		logger.Infof("🔏 Resolving %d new subdomains found in certificate SANs...", len(sans))
+		attributed.Add(attribution.TLSSAN, sans...)
+		sanRecords := resolver.ResolveSubdomains(ctx, sans, resolve)
+		for i := range sanRecords {
+			sanRecords[i].Sources = attributed.Of(sanRecords[i].Name)
+			analysis.Records[sanRecords[i].Name] = sanRecords[i]
+		}
+		records = append(records, sanRecords...)
+
+		mu.Lock()
+		seen := len(streamed)
+		mu.Unlock()
+		sanResults := scorer.AnalyzeSubdomains(ctx, resolver.Names(sanRecords), analysis)
+		for i := range sanResults {
+			sanResults[i].Provenance = scorer.ProvenanceTLSSAN
+		}
+		harvested = append(harvested, sanResults...)
+
+		sources = sanResults
+		if analysis.DiscardResults {
+			mu.Lock()
+			sources = append([]scorer.SubdomainInfo(nil), streamed[seen:]...)
+			mu.Unlock()
+		}
+	}
+	return harvested, records
+}
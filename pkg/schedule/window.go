@@ -0,0 +1,168 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/logger"
+)
+
+// Window is a daily time range in local time, e.g. 01:00-05:00. Windows whose
+// end is before their start wrap around midnight.
+type Window struct {
+	Start time.Duration // offset from local midnight
+	End   time.Duration
+}
+
+// Windows is a set of scan windows; an empty set means scanning is always allowed
+type Windows []Window
+
+// pauseState makes sure concurrent workers announce a pause only once
+var pauseState struct {
+	sync.Mutex
+	paused bool
+}
+
+// ParseWindow parses a window in HH:MM-HH:MM form
+func ParseWindow(value string) (Window, error) {
+	startText, endText, found := strings.Cut(strings.TrimSpace(value), "-")
+	if !found {
+		return Window{}, fmt.Errorf("invalid scan window '%s', expected HH:MM-HH:MM", value)
+	}
+
+	start, err := parseClock(startText)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid scan window '%s': %v", value, err)
+	}
+	end, err := parseClock(endText)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid scan window '%s': %v", value, err)
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("invalid scan window '%s': start equals end", value)
+	}
+
+	return Window{Start: start, End: end}, nil
+}
+
+// ParseWindows parses a list of windows in HH:MM-HH:MM form
+func ParseWindows(values []string) (Windows, error) {
+	var windows Windows
+	for _, value := range values {
+		window, err := ParseWindow(value)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// parseClock parses HH:MM into an offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s'", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// String formats the window as HH:MM-HH:MM
+func (w Window) String() string {
+	return fmt.Sprintf("%s-%s", formatClock(w.Start), formatClock(w.End))
+}
+
+// formatClock formats an offset from midnight as HH:MM
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// Contains reports whether t falls inside the window
+func (w Window) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	// Wraps around midnight
+	return offset >= w.Start || offset < w.End
+}
+
+// Open reports whether scanning is allowed at t
+func (ws Windows) Open(t time.Time) bool {
+	if len(ws) == 0 {
+		return true
+	}
+	for _, w := range ws {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the next time at or after t when a window opens
+func (ws Windows) NextOpen(t time.Time) time.Time {
+	if ws.Open(t) {
+		return t
+	}
+
+	midnight := t.Add(-sinceMidnight(t))
+	var next time.Time
+	for _, w := range ws {
+		candidate := midnight.Add(w.Start)
+		if !candidate.After(t) {
+			candidate = candidate.AddDate(0, 0, 1)
+		}
+		if next.IsZero() || candidate.Before(next) {
+			next = candidate
+		}
+	}
+	return next
+}
+
+// Wait blocks while the current time is outside every window, so pipelines
+// pause between jobs and resume automatically once a window opens. It returns
+// ctx's error as soon as ctx is canceled.
+func (ws Windows) Wait(ctx context.Context) error {
+	for !ws.Open(time.Now()) {
+		next := ws.NextOpen(time.Now())
+
+		pauseState.Lock()
+		if !pauseState.paused {
+			pauseState.paused = true
+			logger.Infof("⏸  Outside scan window, pausing until %s", next.Format("15:04"))
+		}
+		pauseState.Unlock()
+
+		// Re-check at least every minute to follow clock changes
+		sleep := time.Until(next)
+		if sleep > time.Minute {
+			sleep = time.Minute
+		}
+		if sleep < time.Second {
+			sleep = time.Second
+		}
+		select {
+		case <-time.After(sleep):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	pauseState.Lock()
+	if pauseState.paused {
+		pauseState.paused = false
+		logger.Infof("▶  Scan window open, resuming")
+	}
+	pauseState.Unlock()
+	return nil
+}
+
+// sinceMidnight returns the time elapsed since local midnight of t's day
+func sinceMidnight(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+}
@@ -0,0 +1,153 @@
+package scorer
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/omerimzali/subscan/pkg/fingerprint"
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// baselineSimilarity is the similarity above which a host's page is taken for
+// the same page a baseline host serves
+const baselineSimilarity = 0.9
+
+// Baseline is the profile of the apex or www host, kept with its page so the
+// responses of other hosts can be compared with it
+type Baseline struct {
+	Profile model.Profile
+	// Label names the host in tags, "APEX" or "WWW"
+	Label string
+	body  string
+}
+
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// maxTitle caps the length of page titles kept for reports
+const maxTitle = 200
+
+// ProfileBaselines profiles the apex and www hosts of a domain. Wildcard DNS
+// and catch-all virtual hosts serve one of their pages for any name, so other
+// hosts answering the same page are likely not separate services.
+func ProfileBaselines(ctx context.Context, domain string, options AnalysisOptions) []Baseline {
+	hosts := []struct{ host, label string }{{domain, "APEX"}, {"www." + domain, "WWW"}}
+	baselines := make([]Baseline, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string, label string) {
+			defer wg.Done()
+			baselines[i] = profileHost(ctx, host, options)
+			baselines[i].Label = label
+		}(i, host.host, host.label)
+	}
+	wg.Wait()
+	return baselines
+}
+
+// Profiles returns the profiles of the baselines
+func Profiles(baselines []Baseline) []model.Profile {
+	profiles := make([]model.Profile, len(baselines))
+	for i, baseline := range baselines {
+		profiles[i] = baseline.Profile
+	}
+	return profiles
+}
+
+// profileHost fetches a host's page over HTTPS, or HTTP when HTTPS fails, and
+// records its status, title, technologies and certificate
+func profileHost(ctx context.Context, host string, options AnalysisOptions) Baseline {
+	client := httpclient.New(httpclient.Options{
+		Timeout:         options.Timeout,
+		FollowRedirects: options.FollowRedirects,
+		MaxRedirects:    options.MaxRedirects,
+		UserAgent:       options.UserAgent,
+		RequestDelay:    options.RequestDelay,
+		AcceptEncoding:  options.AcceptEncoding,
+		Context:         ctx,
+		Proxy:           options.Proxy,
+	})
+
+	baseline := Baseline{Profile: model.Profile{Host: host}}
+	var resp *http.Response
+	var err error
+	for _, scheme := range []string{"https", "http"} {
+		baseline.Profile.URL = fmt.Sprintf("%s://%s", scheme, host)
+		if resp, err = client.Get(baseline.Profile.URL); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		baseline.Profile.URL = ""
+		baseline.Profile.Error = "no HTTP response"
+		return baseline
+	}
+	defer resp.Body.Close()
+
+	body, _ := httpclient.ReadBody(resp, 10*1024)
+	baseline.body = string(body)
+	baseline.Profile.Status = resp.StatusCode
+	baseline.Profile.Title = pageTitle(body)
+	baseline.Profile.Technologies = fingerprint.Detect(resp.Header, body)
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		cert := resp.TLS.PeerCertificates[0]
+		baseline.Profile.CertIssuer = certName(cert.Issuer)
+		baseline.Profile.CertSubject = certName(cert.Subject)
+		baseline.Profile.CertExpires = cert.NotAfter.UTC().Format("2006-01-02")
+	}
+	return baseline
+}
+
+// pageTitle returns the text of the page's title element, cut to maxTitle
+// characters
+func pageTitle(body []byte) string {
+	match := titlePattern.FindSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	title := strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+	if runes := []rune(title); len(runes) > maxTitle {
+		title = string(runes[:maxTitle]) + "…"
+	}
+	return title
+}
+
+// certName returns a certificate name's common name, or its organization for
+// certificates without one
+func certName(name pkix.Name) string {
+	if name.CommonName != "" || len(name.Organization) == 0 {
+		return name.CommonName
+	}
+	return name.Organization[0]
+}
+
+// matchBaseline returns the label of the baseline serving the same page as a
+// host, or "" when it serves its own. The baseline hosts themselves aren't
+// compared, since www commonly serves the apex's page.
+func matchBaseline(host string, status int, body []byte, baselines []Baseline) string {
+	if status == 0 || len(body) == 0 {
+		return ""
+	}
+	for _, baseline := range baselines {
+		if baseline.Profile.Host == host {
+			return ""
+		}
+	}
+	for _, baseline := range baselines {
+		if baseline.Profile.Status != status || baseline.body == "" {
+			continue
+		}
+		if httpclient.Similarity(baseline.body, string(body)) >= baselineSimilarity {
+			return baseline.Label
+		}
+	}
+	return ""
+}
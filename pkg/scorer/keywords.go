@@ -0,0 +1,141 @@
+package scorer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// keywordBoostCap caps the score a name earns from its keywords, so names
+// stacking several don't drown out what the host actually serves
+const keywordBoostCap = 3.0
+
+// Keyword boosts the score of subdomains whose name contains Word as a part,
+// e.g. jenkins in jenkins-ci.example.com, and tags them with Tag
+type Keyword struct {
+	Word  string
+	Score float64
+	Tag   string
+}
+
+// DefaultKeywords returns the built-in keywords of interesting names
+func DefaultKeywords() []Keyword {
+	return []Keyword{
+		{"admin", 1.5, "ADMIN"},
+		{"administrator", 1.5, "ADMIN"},
+		{"panel", 1.0, "ADMIN"},
+		{"manage", 1.0, "ADMIN"},
+		{"vpn", 1.5, "VPN"},
+		{"remote", 1.0, "VPN"},
+		{"citrix", 1.2, "VPN"},
+		{"jenkins", 1.5, "CI"},
+		{"ci", 1.0, "CI"},
+		{"build", 0.8, "CI"},
+		{"gitlab", 1.5, "SCM"},
+		{"git", 1.2, "SCM"},
+		{"svn", 1.2, "SCM"},
+		{"bitbucket", 1.2, "SCM"},
+		{"staging", 1.0, "STAGING"},
+		{"stage", 1.0, "STAGING"},
+		{"stg", 1.0, "STAGING"},
+		{"preprod", 1.0, "STAGING"},
+		{"uat", 1.0, "STAGING"},
+		{"dev", 1.0, "DEV"},
+		{"test", 0.8, "DEV"},
+		{"qa", 0.8, "DEV"},
+		{"sandbox", 0.8, "DEV"},
+		{"internal", 1.5, "INTERNAL"},
+		{"intranet", 1.5, "INTERNAL"},
+		{"corp", 1.0, "INTERNAL"},
+		{"api", 0.7, "API"},
+		{"graphql", 0.8, "API"},
+		{"grafana", 1.2, "MONITORING"},
+		{"kibana", 1.2, "MONITORING"},
+		{"prometheus", 1.2, "MONITORING"},
+		{"monitor", 0.8, "MONITORING"},
+		{"s3", 1.0, "STORAGE"},
+		{"storage", 0.8, "STORAGE"},
+		{"backup", 1.2, "BACKUP"},
+		{"backups", 1.2, "BACKUP"},
+		{"db", 1.2, "DATABASE"},
+		{"database", 1.2, "DATABASE"},
+		{"mysql", 1.2, "DATABASE"},
+		{"sso", 0.8, "AUTH"},
+		{"auth", 0.8, "AUTH"},
+		{"login", 0.8, "AUTH"},
+		{"jira", 0.7, "COLLAB"},
+		{"confluence", 0.7, "COLLAB"},
+		{"wiki", 0.7, "COLLAB"},
+	}
+}
+
+// LoadKeywords reads keywords from a file of "word score [tag]" lines and
+// returns the built-in keywords with them added; a listed built-in word takes
+// the file's score and tag, and a score of 0 turns it off
+func LoadKeywords(path string) ([]Keyword, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open score keywords: %v", err)
+	}
+	defer file.Close()
+
+	keywords := DefaultKeywords()
+	index := make(map[string]int)
+	for i, keyword := range keywords {
+		index[keyword.Word] = i
+	}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("%s:%d: expected \"word score [tag]\"", path, line)
+		}
+		score, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid score %q", path, line, fields[1])
+		}
+		keyword := Keyword{Word: strings.ToLower(fields[0]), Score: score, Tag: strings.ToUpper(fields[0])}
+		if len(fields) == 3 {
+			keyword.Tag = strings.ToUpper(fields[2])
+		}
+		if i, ok := index[keyword.Word]; ok {
+			keywords[i] = keyword
+		} else {
+			index[keyword.Word] = len(keywords)
+			keywords = append(keywords, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	registerKeywordTags(keywords)
+	return keywords, nil
+}
+
+// scoreKeywords boosts and tags a subdomain by the keywords in its name
+func scoreKeywords(info *SubdomainInfo, keywords []Keyword, scope string) {
+	if keywords == nil {
+		keywords = DefaultKeywords()
+	}
+	boost := 0.0
+	for _, keyword := range keywords {
+		if keyword.Score == 0 || !hasKeyword(info.Subdomain, scope, []string{keyword.Word}) {
+			continue
+		}
+		boost += keyword.Score
+		if keyword.Tag != "" && !hasTag(info.Tags, keyword.Tag) {
+			info.Tags = append(info.Tags, keyword.Tag)
+		}
+	}
+	if boost > keywordBoostCap {
+		boost = keywordBoostCap
+	}
+	info.Score += boost
+}
@@ -0,0 +1,44 @@
+package scorer
+
+import (
+	"context"
+	"net"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/portscan"
+)
+
+// scanOpenPorts port-scans the addresses of the subdomains and returns the
+// known ports with the open ones found added. The scan connects directly, so
+// it is skipped when a proxy routes the scan.
+func scanOpenPorts(ctx context.Context, subdomains []string, options AnalysisOptions) map[string][]int {
+	if options.Proxy != nil && options.Proxy != httpclient.Direct {
+		logger.Warnf("Skipping the port scan: it connects directly and a proxy is set")
+		return options.KnownPorts
+	}
+
+	hosts := make(map[string][]string, len(subdomains))
+	for _, subdomain := range subdomains {
+		if record, ok := options.Records[subdomain]; ok {
+			hosts[subdomain] = record.IPs()
+			continue
+		}
+		lookupCtx, cancel := context.WithTimeout(ctx, options.Timeout)
+		if ips, err := net.DefaultResolver.LookupHost(lookupCtx, subdomain); err == nil {
+			hosts[subdomain] = ips
+		}
+		cancel()
+	}
+	logger.Infof("🔌 Scanning %d ports on %d hosts...", len(options.PortScan.Ports), len(hosts))
+
+	found := portscan.Scan(ctx, hosts, *options.PortScan)
+	known := make(map[string][]int, len(options.KnownPorts)+len(found))
+	for host, ports := range options.KnownPorts {
+		known[host] = ports
+	}
+	for host, ports := range found {
+		known[host] = portscan.Merge(known[host], ports)
+	}
+	return known
+}
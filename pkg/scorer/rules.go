@@ -0,0 +1,264 @@
+package scorer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TLS states a rule can match
+const (
+	// TLSValid hosts answered over HTTPS with a certificate inside its validity period
+	TLSValid = "valid"
+	// TLSInvalid hosts answered over HTTPS with an expired or not yet valid certificate
+	TLSInvalid = "invalid"
+	// TLSNone hosts didn't answer over HTTPS
+	TLSNone = "none"
+)
+
+// Rule adjusts the score of the analyzed hosts matching all of its conditions
+// and adds its tags to them. Unset conditions match any host.
+type Rule struct {
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Status is a comma-separated list of codes and ranges, e.g. "200-299,401"
+	// or "5xx"
+	Status string `yaml:"status,omitempty" json:"status,omitempty"`
+	// Cloud is the cloud provider behind the host's CNAME, a glob such as
+	// "AWS-*"; "*" matches any provider
+	Cloud string `yaml:"cloud,omitempty" json:"cloud,omitempty"`
+	// Keywords match dot, dash or underscore separated parts of the subdomain
+	// below the target, ignoring numbers, so admin matches admin01.example.com
+	Keywords []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`
+	// TLS is TLSValid, TLSInvalid or TLSNone
+	TLS string `yaml:"tls,omitempty" json:"tls,omitempty"`
+	// HasTags are globs the host must all have a tag for, e.g. "SAME-AS-*"
+	HasTags []string `yaml:"has_tags,omitempty" json:"has_tags,omitempty"`
+
+	// Score is added to the host's score; negative values push it down
+	Score float64  `yaml:"score,omitempty" json:"score,omitempty"`
+	Tags  []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// rulesFile is the layout of a rules file
+type rulesFile struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// DefaultRules returns the built-in scoring rules
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "success", Status: "200-299", Score: 1.0},
+		{Name: "redirect", Status: "300-399", Score: 0.5},
+		{Name: "forbidden", Status: "403", Score: 0.7}, // Might hide something interesting
+		{Name: "client error", Status: "400-402,404-499", Score: 0.2},
+		{Name: "server error", Status: "500-599", Score: 0.3},
+		{Name: "valid certificate", TLS: TLSValid, Score: 0.5},
+		{Name: "invalid certificate", TLS: TLSInvalid, Score: -0.3},
+		// Self-signed certificates and weak TLS point at internal or neglected hosts
+		{Name: "self-signed certificate", HasTags: []string{"SELF-SIGNED"}, Score: 0.5},
+		{Name: "expiring certificate", HasTags: []string{"CERT-EXPIRING-SOON"}, Score: 0.2},
+		{Name: "weak protocol", HasTags: []string{"WEAK-TLS"}, Score: 0.3},
+		{Name: "weak cipher", HasTags: []string{"WEAK-CIPHER"}, Score: 0.3},
+		{Name: "cloud endpoint", Cloud: "*", Score: 1.0},
+		// Redirects leaving scope can hint at takeovers or third-party hosting
+		{Name: "off-scope redirect", HasTags: []string{"OFF-SCOPE-REDIRECT"}, Score: 0.3},
+		// Likely wildcard or catch-all answers
+		{Name: "same as baseline", HasTags: []string{"SAME-AS-*"}, Score: -1.0},
+		{Name: "parked", HasTags: []string{"PARKED"}, Score: -1.5},
+		{Name: "login panel", HasTags: []string{"*-LOGIN"}, Score: 0.5},
+		{Name: "large response", HasTags: []string{"LARGE"}, Score: 0.2},
+	}
+}
+
+// LoadRules reads scoring rules from a YAML or JSON file holding a rules list;
+// they replace the built-in rules
+func LoadRules(filename string) ([]Rule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read score rules: %v", err)
+	}
+	var file rulesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse score rules %s: %v", filename, err)
+	}
+	for i, rule := range file.Rules {
+		if err := rule.validate(); err != nil {
+			name := rule.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return nil, fmt.Errorf("score rule %s in %s: %v", name, filename, err)
+		}
+	}
+	registerRuleTags(file.Rules)
+	return file.Rules, nil
+}
+
+// validate reports conditions that could never be evaluated
+func (r Rule) validate() error {
+	if _, err := parseStatusRanges(r.Status); err != nil {
+		return err
+	}
+	switch r.TLS {
+	case "", TLSValid, TLSInvalid, TLSNone:
+	default:
+		return fmt.Errorf("unknown tls state %q (available: valid, invalid, none)", r.TLS)
+	}
+	for _, pattern := range append([]string{r.Cloud}, r.HasTags...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// applyRules adds the score and tags of every rule the host matches, in
+// order, so a rule can match the tags of the rules before it
+func applyRules(info *SubdomainInfo, rules []Rule, scope string) {
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	for _, rule := range rules {
+		if !rule.matches(info, scope) {
+			continue
+		}
+		info.Score += rule.Score
+		for _, tag := range rule.Tags {
+			if !hasTag(info.Tags, tag) {
+				info.Tags = append(info.Tags, tag)
+			}
+		}
+	}
+}
+
+// matches reports whether the host meets every condition of the rule
+func (r Rule) matches(info *SubdomainInfo, scope string) bool {
+	if r.Status != "" {
+		ranges, err := parseStatusRanges(r.Status)
+		if err != nil || !inRanges(info.HTTPStatus, ranges) {
+			return false
+		}
+	}
+	if r.Cloud != "" {
+		if matched, _ := path.Match(r.Cloud, info.CloudProvider); info.CloudProvider == "" || !matched {
+			return false
+		}
+	}
+	if r.TLS != "" && r.TLS != tlsState(info) {
+		return false
+	}
+	for _, pattern := range r.HasTags {
+		if !hasTagMatching(info.Tags, pattern) {
+			return false
+		}
+	}
+	if len(r.Keywords) > 0 && !hasKeyword(info.Subdomain, scope, r.Keywords) {
+		return false
+	}
+	return true
+}
+
+// tlsState returns whether the host answered over HTTPS and with a valid certificate
+func tlsState(info *SubdomainInfo) string {
+	switch {
+	case !info.IsTLS:
+		return TLSNone
+	case hasTag(info.Tags, "CERT-INVALID"):
+		return TLSInvalid
+	default:
+		return TLSValid
+	}
+}
+
+// parseStatusRanges parses a list of status codes and ranges such as
+// "200-299,401,5xx"
+func parseStatusRanges(spec string) ([][2]int, error) {
+	var ranges [][2]int
+	if spec == "" {
+		return nil, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if len(part) == 3 && strings.HasSuffix(part, "xx") {
+			class, err := strconv.Atoi(part[:1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid status %q", part)
+			}
+			ranges = append(ranges, [2]int{class * 100, class*100 + 99})
+			continue
+		}
+		low, high, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(low))
+		if err != nil {
+			return nil, fmt.Errorf("invalid status %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(strings.TrimSpace(high)); err != nil || to < from {
+				return nil, fmt.Errorf("invalid status range %q", part)
+			}
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+	return ranges, nil
+}
+
+// inRanges reports whether status falls in one of the ranges
+func inRanges(status int, ranges [][2]int) bool {
+	for _, r := range ranges {
+		if status >= r[0] && status <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasKeyword reports whether a part of the subdomain below scope is one of
+// the keywords, ignoring the numbers around the part
+func hasKeyword(subdomain string, scope string, keywords []string) bool {
+	for _, part := range nameParts(subdomain, scope) {
+		for _, keyword := range keywords {
+			keyword = strings.ToLower(keyword)
+			if part == keyword || strings.Trim(part, "0123456789") == keyword {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nameParts returns the dot, dash and underscore separated parts of the
+// subdomain below scope
+func nameParts(subdomain string, scope string) []string {
+	name := strings.ToLower(subdomain)
+	if scope != "" {
+		name = strings.TrimSuffix(name, "."+strings.ToLower(scope))
+	}
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return r == '.' || r == '-' || r == '_'
+	})
+}
+
+// hasTag reports whether tags contains tag
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTagMatching reports whether a tag matches the glob pattern
+func hasTagMatching(tags []string, pattern string) bool {
+	for _, tag := range tags {
+		if matched, _ := path.Match(pattern, tag); matched {
+			return true
+		}
+	}
+	return false
+}
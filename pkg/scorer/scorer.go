@@ -1,15 +1,30 @@
 package scorer
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
+	"io"
+	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/omerimzali/subscan/pkg/coverage"
+	"github.com/omerimzali/subscan/pkg/fingerprint"
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/logger"
+	"github.com/omerimzali/subscan/pkg/model"
+	"github.com/omerimzali/subscan/pkg/portscan"
+	"github.com/omerimzali/subscan/pkg/progress"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/robots"
+	"github.com/omerimzali/subscan/pkg/schedule"
 )
 
 // Cloud provider CNAME patterns
@@ -37,6 +52,81 @@ var cloudCnamePatterns = map[string]string{
 	`\.appspot\.com`:                                   "Google-AppEngine",
 }
 
+// CloudProvider returns the cloud provider a CNAME target belongs to, or ""
+// when it matches no known provider
+func CloudProvider(cname string) string {
+	for pattern, provider := range cloudCnamePatterns {
+		if matched, _ := regexp.MatchString(pattern, cname); matched {
+			return provider
+		}
+	}
+	return ""
+}
+
+// Parked/for-sale page signatures from parking services and registrar templates
+var parkedSignatures = []string{
+	"sedoparking.com",
+	"This domain may be for sale",
+	"This domain is for sale",
+	"is for sale!",
+	"buy this domain",
+	"parkingcrew.net",
+	"parking.godaddy.com",
+	"img1.wsimg.com/parking-lander",
+	"This Web page is parked",
+	"domain is parked",
+	"bodis.com",
+	"above.com/marketplace",
+	"dan.com/buy-domain",
+	"afternic.com/forsale",
+	"hugedomains.com",
+	"namebrightstatic.com",
+	"This domain has been registered via",
+}
+
+// Ports that commonly expose admin panels, databases or dev services
+var interestingPorts = map[int]bool{
+	21: true, 22: true, 23: true, 445: true, 1433: true, 2375: true, 3000: true,
+	3306: true, 3389: true, 5432: true, 5601: true, 5900: true, 6379: true,
+	8000: true, 8080: true, 8443: true, 8888: true, 9000: true, 9090: true,
+	9200: true, 11211: true, 27017: true,
+}
+
+// SaaS tenant signatures: CNAME targets and landing page markers of third-party services
+var saasSignatures = []struct {
+	name    string
+	cnames  []string
+	markers []string
+}{
+	{"Okta", []string{"okta.com", "oktapreview.com", "okta-emea.com"}, []string{"okta-sign-in", "/okta-signin-widget/"}},
+	{"Auth0", []string{"auth0.com"}, []string{"cdn.auth0.com"}},
+	{"Atlassian", []string{"atlassian.net"}, []string{"atlassian.net", "ajs-atlassian"}},
+	{"Zendesk", []string{"zendesk.com"}, []string{"zdassets.com/hc/"}},
+	{"Freshdesk", []string{"freshdesk.com"}, []string{"assets.freshdesk.com"}},
+	{"Statuspage", []string{"stspg-customer.com", "statuspage.io"}, []string{"statuspage.io", "Powered by Atlassian Statuspage"}},
+	{"Salesforce", []string{"force.com", "salesforce.com"}, []string{"force.com/"}},
+	{"ServiceNow", []string{"service-now.com"}, []string{"service-now.com"}},
+	{"HubSpot", []string{"hubspot.net", "hs-sites.com"}, []string{"hs-sites.com"}},
+	{"Intercom", []string{"intercom.help", "intercom.io"}, []string{"intercom.help"}},
+	{"Shopify", []string{"myshopify.com"}, []string{"cdn.shopify.com"}},
+	{"Helpscout", []string{"helpscoutdocs.com"}, []string{"helpscoutdocs.com"}},
+}
+
+// CMS signatures: body markers identifying the CMS and the login page that confirms an exposed panel
+var cmsLoginSignatures = []struct {
+	name      string
+	markers   []string
+	loginPath string
+	loginSigs []string
+}{
+	{"WORDPRESS", []string{"wp-content/", "wp-includes/", "content=\"WordPress"}, "/wp-login.php", []string{"user_login", "wp-submit"}},
+	{"JOOMLA", []string{"content=\"Joomla", "/media/jui/", "/media/system/js/"}, "/administrator/", []string{"com_login", "mod-login-username"}},
+	{"DRUPAL", []string{"content=\"Drupal", "drupal-settings-json", "Drupal.settings", "/sites/default/files/"}, "/user/login", []string{"user-login-form", "user-login"}},
+}
+
+// htmlLangPattern extracts the lang attribute of the html element
+var htmlLangPattern = regexp.MustCompile(`(?i)<html[^>]*\slang=["']?([a-zA-Z]{2,3})(?:[-_][a-zA-Z0-9]+)?`)
+
 // SubdomainInfo represents analysis results for a subdomain
 type SubdomainInfo struct {
 	Subdomain     string
@@ -47,9 +137,40 @@ type SubdomainInfo struct {
 	TLSIssuer     string
 	SANs          []string
 	CNAMEs        []string
+	IPs           []string
 	CloudProvider string
 	Score         float64
 	Tags          []string
+	RedirectChain []string
+	FinalURL      string
+	Language      string
+	SaaSProvider  string
+	OpenPorts     []int
+	// Title is the text of the page's title element
+	Title string
+	// Server and PoweredBy are the Server and X-Powered-By response headers
+	Server    string
+	PoweredBy string
+	// Technologies are the server software, frameworks and CMSs the host's
+	// response reveals, e.g. "nginx 1.18.0"
+	Technologies []string
+	// FaviconHash is the Shodan-compatible hash of the host's /favicon.ico,
+	// zero when it has none
+	FaviconHash int32
+	// URLs are the base URLs of the web services that answered, e.g. https://host:8443
+	URLs []string
+	// Screenshots are the paths of the screenshots of the web services, when captured
+	Screenshots []string
+	// TLS holds the certificate and protocol details of HTTPS hosts
+	TLS *model.TLSInfo
+	// Provenance records how the subdomain was found when not by enumeration, e.g. "tls-san"
+	Provenance string
+	// Sources are the passive sources and stages that found the subdomain
+	Sources []string
+	// Ownership annotations
+	Owner string
+	Team  string
+	Notes string
 }
 
 // AnalysisOptions holds configuration for analysis
@@ -58,6 +179,55 @@ type AnalysisOptions struct {
 	Timeout        time.Duration
 	VerboseOutput  bool
 	ExcludeHeaders bool
+	// FollowRedirects enables bounded redirect following, recording the chain
+	FollowRedirects bool
+	MaxRedirects    int
+	// Scope is the target domain; redirects ending outside it are flagged
+	Scope string
+	// KnownPorts holds open ports per subdomain reported by passive sources
+	KnownPorts map[string][]int
+	// ProbeOpenPorts tries HTTP(S) on each known open port, adding the web
+	// services that answer to URLs
+	ProbeOpenPorts bool
+	// HTTPPorts are tried for HTTP(S) besides 80 and 443, adding the web
+	// services that answer to URLs and their ports to OpenPorts
+	HTTPPorts []int
+	// UserAgent is sent with every request when set
+	UserAgent string
+	// RespectRobots skips path checks disallowed by the host's robots.txt
+	RespectRobots bool
+	// RequestDelay is the minimum time between two requests to the same host
+	RequestDelay time.Duration
+	// AcceptEncoding is advertised on requests; responses are decoded before matching
+	AcceptEncoding string
+	// Records holds DNS data from resolution, reused instead of querying again
+	Records map[string]resolver.DNSRecord
+	// OnResult is called from the workers with each result as soon as it is analyzed
+	OnResult func(SubdomainInfo)
+	// DiscardResults only hands results to OnResult instead of also collecting
+	// them, for scans too large to hold in memory
+	DiscardResults bool
+	// Windows restricts work to scan windows, pausing between jobs outside them
+	Windows schedule.Windows
+	// Baselines are the apex and www profiles; hosts serving the same page
+	// are tagged SAME-AS-APEX or SAME-AS-WWW and scored down
+	Baselines []Baseline
+	// Proxy routes every request; the environment's proxy when nil
+	Proxy *url.URL
+	// Rules weigh the analyzed hosts' status, certificate, cloud provider,
+	// name and tags; DefaultRules when nil
+	Rules []Rule
+	// Keywords boost hosts by the words in their names; DefaultKeywords when nil
+	Keywords []Keyword
+	// CertExpiryWarning is how close to expiry certificates are tagged
+	// CERT-EXPIRING-SOON; DefaultCertExpiryWarning when 0
+	CertExpiryWarning time.Duration
+	// WeakTLSChecks opens extra handshakes to HTTPS hosts to detect deprecated
+	// protocol versions and insecure cipher suites
+	WeakTLSChecks bool
+	// PortScan connects to the hosts' TCP ports before the analysis, adding
+	// the open ones to KnownPorts; off when nil
+	PortScan *portscan.Options
 }
 
 // DefaultOptions returns a default set of analysis options
@@ -67,36 +237,64 @@ func DefaultOptions() AnalysisOptions {
 		Timeout:        5 * time.Second,
 		VerboseOutput:  false,
 		ExcludeHeaders: true,
+		MaxRedirects:   httpclient.DefaultMaxRedirects,
 	}
 }
 
-// AnalyzeSubdomains performs comprehensive analysis on a list of subdomains
-func AnalyzeSubdomains(subdomains []string, options AnalysisOptions) []SubdomainInfo {
+// AnalyzeSubdomains performs comprehensive analysis on a list of subdomains.
+// Canceling ctx stops the analysis and returns the hosts finished so far.
+func AnalyzeSubdomains(ctx context.Context, subdomains []string, options AnalysisOptions) []SubdomainInfo {
 	var results []SubdomainInfo
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 	
+	// Hosts are port-scanned together up front so shared addresses are
+	// scanned once
+	if options.PortScan != nil && len(subdomains) > 0 {
+		options.KnownPorts = scanOpenPorts(ctx, subdomains, options)
+	}
+	
 	// Create a channel for jobs
 	jobs := make(chan string, len(subdomains))
+	tracker := progress.Start("Scoring", len(subdomains))
 	
 	// Launch worker goroutines
 	for i := 0; i < options.Concurrency; i++ {
 		go func() {
 			for subdomain := range jobs {
-				info := analyzeSubdomain(subdomain, options)
+				if ctx.Err() != nil {
+					wg.Done()
+					continue
+				}
+				if options.Windows.Wait(ctx) != nil {
+					wg.Done()
+					continue
+				}
+				info := analyzeSubdomain(ctx, subdomain, options)
+				if ctx.Err() != nil {
+					// Requests cut short leave the analysis incomplete
+					wg.Done()
+					continue
+				}
 				
-				mu.Lock()
-				results = append(results, info)
-				mu.Unlock()
+				if !options.DiscardResults {
+					mu.Lock()
+					results = append(results, info)
+					mu.Unlock()
+				}
+				if options.OnResult != nil {
+					options.OnResult(info)
+				}
 				
 				if options.VerboseOutput {
 					tags := ""
 					if len(info.Tags) > 0 {
 						tags = "[" + strings.Join(info.Tags, "][") + "]"
 					}
-					fmt.Printf("%s %s (Score: %.1f)\n", tags, info.Subdomain, info.Score)
+					logger.Infof("%s %s (Score: %.1f)", tags, info.Subdomain, info.Score)
 				}
 				
+				tracker.Increment()
 				wg.Done()
 			}
 		}()
@@ -111,15 +309,20 @@ func AnalyzeSubdomains(subdomains []string, options AnalysisOptions) []Subdomain
 	// Wait for all jobs to complete
 	wg.Wait()
 	close(jobs)
+	tracker.Finish()
 	
 	// Sort results by score
-	sortByScore(results)
+	SortByScore(results)
+	if ctx.Err() != nil {
+		logger.Warnf("scoring interrupted after %d of %d subdomains", len(results), len(subdomains))
+		coverage.Note(ctx, "scoring interrupted after %d of %d hosts", len(results), len(subdomains))
+	}
 	
 	return results
 }
 
 // analyzeSubdomain performs comprehensive analysis on a single subdomain
-func analyzeSubdomain(subdomain string, options AnalysisOptions) SubdomainInfo {
+func analyzeSubdomain(ctx context.Context, subdomain string, options AnalysisOptions) SubdomainInfo {
 	info := SubdomainInfo{
 		Subdomain: subdomain,
 		Headers:   make(map[string]string),
@@ -127,28 +330,40 @@ func analyzeSubdomain(subdomain string, options AnalysisOptions) SubdomainInfo {
 		Tags:      []string{},
 	}
 
+	// Names hint at what a host runs even when it doesn't answer
+	scoreKeywords(&info, options.Keywords, options.Scope)
+
 	// HTTP probing
-	httpClient := &http.Client{
-		Timeout: options.Timeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, // Skip certificate validation for analysis
-			},
-		},
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse // Don't follow redirects
-		},
-	}
+	httpClient := httpclient.New(httpclient.Options{
+		Timeout:         options.Timeout,
+		FollowRedirects: options.FollowRedirects,
+		MaxRedirects:    options.MaxRedirects,
+		UserAgent:       options.UserAgent,
+		RequestDelay:    options.RequestDelay,
+		AcceptEncoding:  options.AcceptEncoding,
+		Context:         ctx,
+		Proxy:           options.Proxy,
+	})
 
+	// Response body (limited to 10KB) used for content signatures
+	var body []byte
+	
 	// Try HTTPS first
 	httpsURL := fmt.Sprintf("https://%s", subdomain)
 	httpsResp, err := httpClient.Get(httpsURL)
 	
 	if err == nil {
 		defer httpsResp.Body.Close()
+		body, info.ContentLength = httpclient.ReadBody(httpsResp, 10*1024)
+		info.Language = detectLanguage(httpsResp, body)
+		info.Technologies = fingerprint.Detect(httpsResp.Header, body)
+		info.Title = pageTitle(body)
+		info.Server = httpsResp.Header.Get("Server")
+		info.PoweredBy = httpsResp.Header.Get("X-Powered-By")
 		info.IsTLS = true
 		info.HTTPStatus = httpsResp.StatusCode
-		info.ContentLength = httpsResp.ContentLength
+		info.URLs = append(info.URLs, httpsURL)
+		recordRedirects(&info, httpsResp, options)
 		
 		// Extract headers
 		if !options.ExcludeHeaders {
@@ -169,12 +384,12 @@ func analyzeSubdomain(subdomain string, options AnalysisOptions) SubdomainInfo {
 				}
 			}
 			
-			// Add score for valid cert
-			if time.Now().Before(cert.NotAfter) && time.Now().After(cert.NotBefore) {
-				info.Score += 0.5
-			} else {
+			if time.Now().After(cert.NotAfter) || time.Now().Before(cert.NotBefore) {
 				info.Tags = append(info.Tags, "CERT-INVALID")
-				info.Score -= 0.3
+			}
+			inspectTLS(&info, httpsResp.TLS, options)
+			if options.WeakTLSChecks {
+				checkWeakTLS(ctx, &info, options)
 			}
 		}
 	} else {
@@ -184,8 +399,15 @@ func analyzeSubdomain(subdomain string, options AnalysisOptions) SubdomainInfo {
 		
 		if err == nil {
 			defer httpResp.Body.Close()
+			body, info.ContentLength = httpclient.ReadBody(httpResp, 10*1024)
+			info.Language = detectLanguage(httpResp, body)
+			info.Technologies = fingerprint.Detect(httpResp.Header, body)
+			info.Title = pageTitle(body)
+			info.Server = httpResp.Header.Get("Server")
+			info.PoweredBy = httpResp.Header.Get("X-Powered-By")
 			info.HTTPStatus = httpResp.StatusCode
-			info.ContentLength = httpResp.ContentLength
+			info.URLs = append(info.URLs, httpURL)
+			recordRedirects(&info, httpResp, options)
 			
 			// Extract headers
 			if !options.ExcludeHeaders {
@@ -199,9 +421,19 @@ func analyzeSubdomain(subdomain string, options AnalysisOptions) SubdomainInfo {
 		}
 	}
 
-	// DNS CNAME lookup
-	cnames, err := lookupCNAME(subdomain)
-	if err == nil {
+	// DNS CNAME lookup, reusing the resolution stage's records when available
+	var cnames []string
+	var cnameErr error
+	if record, ok := options.Records[subdomain]; ok {
+		info.IPs = record.IPs()
+		info.Sources = record.Sources
+		if record.CNAME != "" {
+			cnames = []string{record.CNAME}
+		}
+	} else {
+		cnames, cnameErr = lookupCNAME(subdomain)
+	}
+	if cnameErr == nil {
 		info.CNAMEs = cnames
 		
 		// Check for cloud provider patterns
@@ -211,31 +443,76 @@ func analyzeSubdomain(subdomain string, options AnalysisOptions) SubdomainInfo {
 				if matched {
 					info.CloudProvider = provider
 					info.Tags = append(info.Tags, provider)
-					info.Score += 1.0 // Higher score for cloud endpoints
 					break
 				}
 			}
 		}
 	}
 
-	// Add tags based on HTTP status
-	switch {
-	case info.HTTPStatus >= 200 && info.HTTPStatus < 300:
-		info.Tags = append(info.Tags, fmt.Sprintf("%d", info.HTTPStatus))
-		info.Score += 1.0 // Higher score for 2xx responses
-	case info.HTTPStatus >= 300 && info.HTTPStatus < 400:
+	// Open ports reported by passive sources or the port scan
+	var servicePorts []int
+	if ports, ok := options.KnownPorts[subdomain]; ok {
+		info.OpenPorts = ports
+		if options.ProbeOpenPorts {
+			servicePorts = ports
+		}
+	}
+	
+	// Web services on the extra HTTP ports, whose ports are open too
+	servicePorts = portscan.Merge(servicePorts, options.HTTPPorts)
+	for _, url := range webServices(httpClient, subdomain, servicePorts) {
+		info.URLs = append(info.URLs, url)
+		if port := httpclient.URLPort(url); port != 0 {
+			info.OpenPorts = portscan.Merge(info.OpenPorts, []int{port})
+		}
+	}
+	if len(info.OpenPorts) > 0 {
+		scorePorts(&info)
+	}
+
+	// Third-party SaaS tenants, identified by CNAME first and landing page second
+	if provider := detectSaaS(info.CNAMEs, body); provider != "" {
+		info.SaaSProvider = provider
+		info.Tags = append(info.Tags, "SAAS-"+strings.ToUpper(provider))
+	}
+
+	// Tag the HTTP status
+	if info.HTTPStatus >= 200 {
 		info.Tags = append(info.Tags, fmt.Sprintf("%d", info.HTTPStatus))
+	}
+	if info.HTTPStatus >= 300 && info.HTTPStatus < 400 {
 		info.Tags = append(info.Tags, "REDIRECT")
-		info.Score += 0.5 // Medium score for redirects
-	case info.HTTPStatus == 403:
-		info.Tags = append(info.Tags, "403")
-		info.Score += 0.7 // Slightly higher score for 403 (might be interesting)
-	case info.HTTPStatus >= 400 && info.HTTPStatus < 500:
-		info.Tags = append(info.Tags, fmt.Sprintf("%d", info.HTTPStatus))
-		info.Score += 0.2 // Lower score for 4xx responses
-	case info.HTTPStatus >= 500:
-		info.Tags = append(info.Tags, fmt.Sprintf("%d", info.HTTPStatus))
-		info.Score += 0.3 // Lower score for 5xx responses
+	}
+
+	// Hosts serving the apex or www page are likely wildcard or catch-all answers
+	if label := matchBaseline(subdomain, info.HTTPStatus, body, options.Baselines); label != "" {
+		info.Tags = append(info.Tags, "SAME-AS-"+label)
+	}
+
+	// Page language helps route findings to regional owners
+	if info.Language != "" {
+		info.Tags = append(info.Tags, "LANG-"+strings.ToUpper(info.Language))
+	}
+
+	// Parked pages pollute results for large old domains
+	if isParked(body) {
+		info.Tags = append(info.Tags, "PARKED")
+	}
+
+	// CMS detection and exposed login panels
+	baseURL := fmt.Sprintf("http://%s", subdomain)
+	if info.IsTLS {
+		baseURL = httpsURL
+	}
+	var rules *robots.Rules
+	if options.RespectRobots {
+		rules = robots.Fetch(httpClient, baseURL, options.UserAgent)
+	}
+	info.Tags = append(info.Tags, detectCMSLogin(httpClient, baseURL, body, rules)...)
+
+	// Favicon hashes identify products and related hosts across the internet
+	if info.HTTPStatus > 0 && rules.Allowed("/favicon.ico") {
+		info.FaviconHash, _ = fingerprint.FetchFavicon(httpClient, baseURL)
 	}
 
 	// Add tag for content size
@@ -243,15 +520,212 @@ func analyzeSubdomain(subdomain string, options AnalysisOptions) SubdomainInfo {
 		sizeKB := info.ContentLength / 1024
 		if sizeKB > 100 {
 			info.Tags = append(info.Tags, "LARGE")
-			info.Score += 0.2 // Higher score for larger responses
 		} else {
 			info.Tags = append(info.Tags, fmt.Sprintf("%dKB", sizeKB))
 		}
 	}
 
+	// Weigh what was found by the scoring rules
+	applyRules(&info, options.Rules, options.Scope)
+
 	return info
 }
 
+// recordRedirects stores the redirect chain followed for a response and flags
+// chains that end outside the scanned scope
+func recordRedirects(info *SubdomainInfo, resp *http.Response, options AnalysisOptions) {
+	if !options.FollowRedirects {
+		return
+	}
+
+	chain := httpclient.RedirectChain(resp)
+	if len(chain) < 2 {
+		return
+	}
+
+	info.RedirectChain = chain
+	info.FinalURL = chain[len(chain)-1]
+
+	// A final 3xx (redirect budget exhausted) is tagged with its status
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		info.Tags = append(info.Tags, "REDIRECT")
+	}
+
+	if !httpclient.InScope(httpclient.FinalHost(resp), options.Scope) {
+		info.Tags = append(info.Tags, "OFF-SCOPE-REDIRECT")
+	}
+}
+
+// webServices returns the base URLs of the web services answering on a host's
+// open ports, skipping 80 and 443 which the main request already covers
+func webServices(client *http.Client, host string, ports []int) []string {
+	var urls []string
+	for _, port := range ports {
+		if port == 80 || port == 443 {
+			continue
+		}
+		if url := httpclient.DetectWebService(client, host, port); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// scorePorts tags and boosts hosts exposing interesting ports
+func scorePorts(info *SubdomainInfo) {
+	boost := 0.0
+	for _, port := range info.OpenPorts {
+		if interestingPorts[port] {
+			info.Tags = append(info.Tags, fmt.Sprintf("PORT-%d", port))
+			boost += 0.3
+		}
+	}
+	
+	// Cap the boost so port-heavy hosts don't drown out everything else
+	if boost > 1.5 {
+		boost = 1.5
+	}
+	info.Score += boost
+}
+
+// detectSaaS returns the SaaS provider hosting a subdomain, if any
+func detectSaaS(cnames []string, body []byte) string {
+	for _, saas := range saasSignatures {
+		for _, cname := range cnames {
+			cname = strings.TrimSuffix(strings.ToLower(cname), ".")
+			for _, pattern := range saas.cnames {
+				// Match whole labels, so notokta.com isn't Okta
+				if cname == pattern || strings.HasSuffix(cname, "."+pattern) {
+					return saas.name
+				}
+			}
+		}
+	}
+	
+	content := strings.ToLower(string(body))
+	for _, saas := range saasSignatures {
+		for _, marker := range saas.markers {
+			if content != "" && strings.Contains(content, strings.ToLower(marker)) {
+				return saas.name
+			}
+		}
+	}
+	
+	return ""
+}
+
+// ProvenanceTLSSAN marks subdomains discovered in another host's certificate SANs
+const ProvenanceTLSSAN = "tls-san"
+
+// SANCandidates returns the certificate SANs seen while analyzing that fall
+// inside domain and aren't already known, ready for a supplementary resolution round
+func SANCandidates(results []SubdomainInfo, domain string, known map[string]bool) []string {
+	domain = strings.ToLower(domain)
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, info := range results {
+		for _, san := range info.SANs {
+			san = strings.TrimPrefix(strings.ToLower(strings.TrimSuffix(san, ".")), "*.")
+			if seen[san] || known[san] || !strings.HasSuffix(san, "."+domain) {
+				continue
+			}
+			seen[san] = true
+			candidates = append(candidates, san)
+		}
+	}
+	return candidates
+}
+
+// SaaSInventory groups subdomains by the SaaS provider hosting them
+func SaaSInventory(results []SubdomainInfo) map[string][]string {
+	inventory := make(map[string][]string)
+	for _, info := range results {
+		if info.SaaSProvider != "" {
+			inventory[info.SaaSProvider] = append(inventory[info.SaaSProvider], info.Subdomain)
+		}
+	}
+	return inventory
+}
+
+// detectLanguage returns the primary language of a page from its html lang
+// attribute, falling back to the Content-Language header
+func detectLanguage(resp *http.Response, body []byte) string {
+	if match := htmlLangPattern.FindSubmatch(body); match != nil {
+		return strings.ToLower(string(match[1]))
+	}
+	
+	header := resp.Header.Get("Content-Language")
+	if header == "" {
+		return ""
+	}
+	
+	// Use the first listed language without its region, e.g. "de-DE, en" -> "de"
+	lang := strings.TrimSpace(strings.Split(header, ",")[0])
+	lang = strings.Split(strings.Split(lang, "-")[0], "_")[0]
+	return strings.ToLower(lang)
+}
+
+// detectCMSLogin identifies common CMSs from the page body and checks whether
+// their login page is reachable, returning tags such as WORDPRESS and WORDPRESS-LOGIN
+func detectCMSLogin(client *http.Client, baseURL string, body []byte, rules *robots.Rules) []string {
+	var tags []string
+	content := string(body)
+	
+	for _, cms := range cmsLoginSignatures {
+		detected := false
+		for _, marker := range cms.markers {
+			if strings.Contains(content, marker) {
+				detected = true
+				break
+			}
+		}
+		if !detected {
+			continue
+		}
+		
+		tags = append(tags, cms.name)
+		
+		if !rules.Allowed(cms.loginPath) {
+			continue
+		}
+		
+		resp, err := client.Get(baseURL + cms.loginPath)
+		if err != nil {
+			continue
+		}
+		loginBody, _ := io.ReadAll(io.LimitReader(resp.Body, 10*1024))
+		resp.Body.Close()
+		
+		if resp.StatusCode != http.StatusOK {
+			continue
+		}
+		for _, sig := range cms.loginSigs {
+			if strings.Contains(string(loginBody), sig) {
+				tags = append(tags, cms.name+"-LOGIN")
+				break
+			}
+		}
+	}
+	
+	return tags
+}
+
+// isParked checks a response body for parking service and for-sale signatures
+func isParked(body []byte) bool {
+	if len(body) == 0 {
+		return false
+	}
+	
+	content := strings.ToLower(string(body))
+	for _, signature := range parkedSignatures {
+		if strings.Contains(content, strings.ToLower(signature)) {
+			return true
+		}
+	}
+	
+	return false
+}
+
 // lookupCNAME performs a DNS CNAME lookup for a subdomain
 func lookupCNAME(subdomain string) ([]string, error) {
 	var cnames []string
@@ -274,8 +748,8 @@ func lookupCNAME(subdomain string) ([]string, error) {
 	return cnames, nil
 }
 
-// sortByScore sorts the results by their score in descending order
-func sortByScore(results []SubdomainInfo) {
+// SortByScore sorts the results by their score in descending order
+func SortByScore(results []SubdomainInfo) {
 	for i := 0; i < len(results); i++ {
 		for j := i + 1; j < len(results); j++ {
 			if results[i].Score < results[j].Score {
@@ -285,6 +759,20 @@ func sortByScore(results []SubdomainInfo) {
 	}
 }
 
+// SortKey returns a key that orders results like SortByScore, highest score
+// first and then by name, when keys are compared as strings
+func SortKey(info SubdomainInfo) string {
+	// Flip the float's bits so that unsigned order matches numeric order,
+	// then invert them for descending order
+	bits := math.Float64bits(info.Score)
+	if info.Score >= 0 {
+		bits ^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	return fmt.Sprintf("%016x %s", ^bits, info.Subdomain)
+}
+
 // FormatResults returns a formatted string representation of the analysis results
 func FormatResults(results []SubdomainInfo) string {
 	var output strings.Builder
@@ -315,6 +803,9 @@ func FormatResults(results []SubdomainInfo) string {
 		
 		// Format additional information
 		additional := ""
+		if info.Title != "" {
+			additional += fmt.Sprintf(" [Title: %q]", info.Title)
+		}
 		if info.CloudProvider != "" {
 			additional += fmt.Sprintf(" [Cloud: %s]", info.CloudProvider)
 		}
@@ -326,5 +817,30 @@ func FormatResults(results []SubdomainInfo) string {
 		output.WriteString(line)
 	}
 	
+	output.WriteString(FormatSaaSInventory(results))
+	
+	return output.String()
+}
+
+// FormatSaaSInventory returns the SaaS inventory section for terminal output, or
+// an empty string when no tenants were detected
+func FormatSaaSInventory(results []SubdomainInfo) string {
+	inventory := SaaSInventory(results)
+	if len(inventory) == 0 {
+		return ""
+	}
+	
+	var providers []string
+	for provider := range inventory {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	
+	var output strings.Builder
+	output.WriteString("\n=== SaaS Inventory ===\n")
+	for _, provider := range providers {
+		output.WriteString(fmt.Sprintf("%s: %s\n", provider, strings.Join(inventory[provider], ", ")))
+	}
+	
 	return output.String()
 } 
\ No newline at end of file
@@ -0,0 +1,52 @@
+package scorer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/omerimzali/subscan/pkg/tags"
+)
+
+func init() {
+	for _, provider := range cloudCnamePatterns {
+		tags.Register(tags.Tag{Name: provider, Category: tags.CategoryCloud, Severity: tags.SeverityInfo,
+			Description: fmt.Sprintf("The CNAME points at %s", provider)})
+	}
+	for _, cms := range cmsLoginSignatures {
+		tags.Register(tags.Tag{Name: cms.name, Category: tags.CategoryCMS, Severity: tags.SeverityInfo,
+			Description: fmt.Sprintf("The page is served by %s", cms.name[:1]+strings.ToLower(cms.name[1:]))})
+	}
+	registerKeywordTags(DefaultKeywords())
+}
+
+// registerKeywordTags describes the tags of keywords by the words adding them
+func registerKeywordTags(keywords []Keyword) {
+	var order []string
+	words := make(map[string][]string)
+	for _, keyword := range keywords {
+		if keyword.Tag == "" || keyword.Score == 0 {
+			continue
+		}
+		if _, ok := words[keyword.Tag]; !ok {
+			order = append(order, keyword.Tag)
+		}
+		words[keyword.Tag] = append(words[keyword.Tag], keyword.Word)
+	}
+	for _, tag := range order {
+		tags.Register(tags.Tag{Name: tag, Category: tags.CategoryKeyword, Severity: tags.SeverityInfo,
+			Description: fmt.Sprintf("The name contains %s", strings.Join(words[tag], ", "))})
+	}
+}
+
+// registerRuleTags describes the tags added by scoring rules
+func registerRuleTags(rules []Rule) {
+	for _, rule := range rules {
+		description := "Added by a score rule"
+		if rule.Name != "" {
+			description = fmt.Sprintf("Added by the score rule %q", rule.Name)
+		}
+		for _, tag := range rule.Tags {
+			tags.Register(tags.Tag{Name: tag, Category: tags.CategoryRule, Severity: tags.SeverityInfo, Description: description})
+		}
+	}
+}
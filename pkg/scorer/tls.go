@@ -0,0 +1,138 @@
+package scorer
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/model"
+)
+
+// DefaultCertExpiryWarning is how close to expiry a certificate is tagged
+// CERT-EXPIRING-SOON
+const DefaultCertExpiryWarning = 30 * 24 * time.Hour
+
+// tlsVersions names the protocol versions
+var tlsVersions = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+// weakVersions are the deprecated protocol versions hosts are checked for
+var weakVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11}
+
+// inspectTLS records the certificate and negotiated parameters of a
+// connection and tags self-signed, wildcard and expiring certificates
+func inspectTLS(info *SubdomainInfo, state *tls.ConnectionState, options AnalysisOptions) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return
+	}
+	cert := state.PeerCertificates[0]
+	fingerprint := sha256.Sum256(cert.Raw)
+	now := time.Now()
+
+	details := &model.TLSInfo{
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		Subject:     cert.Subject.CommonName,
+		Issuer:      cert.Issuer.CommonName,
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+		SelfSigned:  selfSigned(cert),
+		Expired:     now.After(cert.NotAfter),
+		Wildcard:    wildcard(cert),
+		Version:     tlsVersions[state.Version],
+		Cipher:      tls.CipherSuiteName(state.CipherSuite),
+	}
+	info.TLS = details
+
+	if details.SelfSigned {
+		info.Tags = append(info.Tags, "SELF-SIGNED")
+	}
+	if details.Wildcard {
+		info.Tags = append(info.Tags, "WILDCARD-CERT")
+	}
+	warning := options.CertExpiryWarning
+	if warning <= 0 {
+		warning = DefaultCertExpiryWarning
+	}
+	if !details.Expired && cert.NotAfter.Sub(now) < warning {
+		info.Tags = append(info.Tags, "CERT-EXPIRING-SOON")
+	}
+}
+
+// selfSigned reports whether a certificate is signed by its own key
+func selfSigned(cert *x509.Certificate) bool {
+	if cert.Subject.String() != cert.Issuer.String() {
+		return false
+	}
+	return cert.CheckSignatureFrom(cert) == nil
+}
+
+// wildcard reports whether a certificate covers a wildcard name
+func wildcard(cert *x509.Certificate) bool {
+	if strings.HasPrefix(cert.Subject.CommonName, "*.") {
+		return true
+	}
+	for _, name := range cert.DNSNames {
+		if strings.HasPrefix(name, "*.") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWeakTLS opens extra handshakes offering only deprecated protocol
+// versions and only insecure cipher suites, recording and tagging what the
+// host accepts. The handshakes connect directly, so they are skipped when a
+// proxy routes the scan.
+func checkWeakTLS(ctx context.Context, info *SubdomainInfo, options AnalysisOptions) {
+	if info.TLS == nil || (options.Proxy != nil && options.Proxy != httpclient.Direct) {
+		return
+	}
+	address := net.JoinHostPort(info.Subdomain, "443")
+
+	for _, version := range weakVersions {
+		if handshake(ctx, address, info.Subdomain, options.Timeout, &tls.Config{MinVersion: version, MaxVersion: version}) != nil {
+			info.TLS.WeakProtocols = append(info.TLS.WeakProtocols, tlsVersions[version])
+		}
+	}
+	if len(info.TLS.WeakProtocols) > 0 {
+		info.Tags = append(info.Tags, "WEAK-TLS")
+	}
+
+	var insecure []uint16
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure = append(insecure, suite.ID)
+	}
+	// TLS 1.3 suites can't be configured, so the offer is capped at TLS 1.2
+	config := &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS12, CipherSuites: insecure}
+	if state := handshake(ctx, address, info.Subdomain, options.Timeout, config); state != nil {
+		info.TLS.WeakCipher = tls.CipherSuiteName(state.CipherSuite)
+		info.Tags = append(info.Tags, "WEAK-CIPHER")
+	}
+}
+
+// handshake completes a TLS handshake with config, returning the connection
+// state or nil when the host refuses it
+func handshake(ctx context.Context, address string, serverName string, timeout time.Duration, config *tls.Config) *tls.ConnectionState {
+	config.ServerName = serverName
+	config.InsecureSkipVerify = true // Only the protocol is checked
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: timeout}, Config: config}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+	state := conn.(*tls.Conn).ConnectionState()
+	return &state
+}
@@ -0,0 +1,255 @@
+// Package screenshot renders web pages in headless Chrome or Chromium and
+// saves screenshots of them with thumbnails, so the web services found can be
+// triaged visually.
+package screenshot
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures screenshot capture
+type Options struct {
+	// Browser is the Chrome or Chromium executable; looked up on the PATH
+	// when empty
+	Browser string
+	// Dir is where the screenshots and thumbnails are saved
+	Dir         string
+	Timeout     time.Duration
+	Concurrency int
+	// Width and Height are the size of the browser window
+	Width  int
+	Height int
+	// ThumbnailWidth is the width thumbnails are scaled down to
+	ThumbnailWidth int
+	UserAgent      string
+	// Proxy routes the browser's requests; direct when nil
+	Proxy *url.URL
+}
+
+// DefaultOptions returns the default screenshot options
+func DefaultOptions() Options {
+	return Options{
+		Dir:            "screenshots",
+		Timeout:        30 * time.Second,
+		Concurrency:    4,
+		Width:          1280,
+		Height:         800,
+		ThumbnailWidth: 320,
+	}
+}
+
+// Shot is a captured page
+type Shot struct {
+	URL string
+	// Path is the full-size screenshot and Thumbnail its scaled-down copy
+	Path      string
+	Thumbnail string
+}
+
+// browsers are the executable names Chrome and Chromium install as
+var browsers = []string{
+	"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome", "headless_shell",
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+}
+
+// ErrNoBrowser is returned when no Chrome or Chromium executable is found
+var ErrNoBrowser = errors.New("no Chrome or Chromium executable found; install one or set its path")
+
+// FindBrowser returns the path of an installed Chrome or Chromium
+func FindBrowser() (string, error) {
+	for _, name := range browsers {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrNoBrowser
+}
+
+// unsafeChars are the characters replaced in screenshot file names
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// FileName returns the screenshot file name of a URL, e.g.
+// https_app.example.com_8443.png
+func FileName(pageURL string) string {
+	name := strings.Replace(pageURL, "://", "_", 1)
+	return strings.Trim(unsafeChars.ReplaceAllString(name, "_"), "_") + ".png"
+}
+
+// CaptureAll screenshots the pages at urls, returning the shots by URL. Pages
+// that fail to render are left out.
+func CaptureAll(ctx context.Context, urls []string, options Options) (map[string]Shot, error) {
+	browser := options.Browser
+	if browser == "" {
+		var err error
+		if browser, err = FindBrowser(); err != nil {
+			return nil, err
+		}
+	} else if _, err := exec.LookPath(browser); err != nil {
+		return nil, fmt.Errorf("browser %s: %v", browser, err)
+	}
+	if err := os.MkdirAll(options.Dir, 0755); err != nil {
+		return nil, err
+	}
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	shots := make(map[string]Shot)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+	for _, pageURL := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(pageURL string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			shot, err := Capture(ctx, browser, pageURL, options)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			shots[pageURL] = shot
+			mu.Unlock()
+		}(pageURL)
+	}
+	wg.Wait()
+	return shots, nil
+}
+
+// Capture screenshots one page with browser and saves it with its thumbnail
+func Capture(ctx context.Context, browser string, pageURL string, options Options) (Shot, error) {
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	// Each browser gets its own profile so concurrent captures don't collide
+	profile, err := os.MkdirTemp("", "subscan-chrome-")
+	if err != nil {
+		return Shot{}, err
+	}
+	defer os.RemoveAll(profile)
+
+	path := filepath.Join(options.Dir, FileName(pageURL))
+	args := []string{
+		"--headless",
+		"--disable-gpu",
+		"--no-sandbox",
+		"--no-first-run",
+		"--hide-scrollbars",
+		"--mute-audio",
+		"--ignore-certificate-errors",
+		"--user-data-dir=" + profile,
+		fmt.Sprintf("--window-size=%d,%d", options.Width, options.Height),
+		"--screenshot=" + path,
+	}
+	if options.UserAgent != "" {
+		args = append(args, "--user-agent="+options.UserAgent)
+	}
+	if options.Proxy != nil {
+		args = append(args, "--proxy-server="+options.Proxy.Scheme+"://"+options.Proxy.Host)
+	}
+	args = append(args, pageURL)
+
+	os.Remove(path)
+	if output, err := exec.CommandContext(ctx, browser, args...).CombinedOutput(); err != nil {
+		return Shot{}, fmt.Errorf("could not render %s: %v: %s", pageURL, err, bytes.TrimSpace(output))
+	}
+	if _, err := os.Stat(path); err != nil {
+		return Shot{}, fmt.Errorf("could not render %s: no screenshot written", pageURL)
+	}
+
+	thumbnail := ThumbnailPath(path)
+	if err := writeThumbnail(path, thumbnail, options.ThumbnailWidth); err != nil {
+		return Shot{}, err
+	}
+	return Shot{URL: pageURL, Path: path, Thumbnail: thumbnail}, nil
+}
+
+// ThumbnailPath returns where the thumbnail of the screenshot at path is saved
+func ThumbnailPath(path string) string {
+	return strings.TrimSuffix(path, ".png") + ".thumb.png"
+}
+
+// writeThumbnail scales the screenshot at path down to width pixels wide
+func writeThumbnail(path string, thumbnail string, width int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return fmt.Errorf("could not read screenshot %s: %v", path, err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scale(img, width)); err != nil {
+		return err
+	}
+	return os.WriteFile(thumbnail, buf.Bytes(), 0644)
+}
+
+// scale shrinks img to width pixels wide, keeping its aspect ratio, by
+// averaging the pixels each thumbnail pixel covers
+func scale(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	if width <= 0 || bounds.Dx() <= width {
+		return img
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			thumb.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return thumb
+}
+
+// DataURI returns the image at path as a data: URI for embedding in HTML
+func DataURI(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
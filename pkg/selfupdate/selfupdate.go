@@ -0,0 +1,481 @@
+// Package selfupdate replaces the running Subscan binary with the latest
+// release for the current platform, after verifying the release checksums and,
+// when a release signing key is configured, their signature.
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+)
+
+// Options configures the update
+type Options struct {
+	// Repository is the GitHub repository releases are published to
+	Repository string
+	// APIURL is the GitHub API the latest release is looked up on
+	APIURL  string
+	Timeout time.Duration
+	// PublicKey is the base64 ed25519 key the checksums file is signed with.
+	// When set, releases without a valid signature are refused.
+	PublicKey string
+	// AllowUnsigned installs releases verified against their checksums only
+	// when no PublicKey is set. A checksums file taken from the same release
+	// proves the download is intact, not that it is authentic.
+	AllowUnsigned bool
+	// OS and Arch select the release asset; the running platform when empty
+	OS   string
+	Arch string
+	// Proxy routes the requests; the environment's proxy when nil
+	Proxy *url.URL
+}
+
+// DefaultOptions returns the default update options
+func DefaultOptions() Options {
+	return Options{
+		Repository: "omerimzali/subscan",
+		APIURL:     "https://api.github.com",
+		Timeout:    2 * time.Minute,
+	}
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Release is a published release
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	URL    string  `json:"html_url"`
+	Assets []Asset `json:"assets"`
+}
+
+// Version is the release's version without its leading "v"
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// checksumFiles are the names releases publish their SHA-256 sums under
+var checksumFiles = []string{"checksums.txt", "SHA256SUMS", "sha256sums.txt"}
+
+// ErrNoAsset is returned when a release has no binary for the platform
+var ErrNoAsset = errors.New("the release has no binary for this platform")
+
+// ErrUnsigned is returned when no release signing key is configured and
+// unsigned releases aren't allowed
+var ErrUnsigned = errors.New("no release signing key is configured; refusing to install a binary verified against its checksums only")
+
+const (
+	// maxMetadataSize caps the release metadata and an asset of unknown size
+	maxMetadataSize = 10 << 20
+	// maxBinarySize caps a download and the executable extracted from an archive
+	maxBinarySize = 512 << 20
+)
+
+// Latest looks up the latest release
+func Latest(ctx context.Context, options Options) (*Release, error) {
+	endpoint := strings.TrimSuffix(options.APIURL, "/") + "/repos/" + options.Repository + "/releases/latest"
+	body, err := fetch(ctx, options, endpoint, "application/vnd.github+json", maxMetadataSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not look up the latest release: %v", err)
+	}
+	var release Release
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("could not read the latest release: %v", err)
+	}
+	if release.Tag == "" {
+		return nil, errors.New("could not read the latest release: no tag")
+	}
+	return &release, nil
+}
+
+// Newer reports whether latest is a newer version than current. Development
+// builds, whose version can't be compared, are always older.
+func Newer(current string, latest string) bool {
+	a, okA := parseVersion(current)
+	b, okB := parseVersion(latest)
+	if !okB {
+		return false
+	}
+	if !okA {
+		return true
+	}
+	for i := range b {
+		if a[i] != b[i] {
+			return b[i] > a[i]
+		}
+	}
+	return false
+}
+
+// parseVersion reads the major, minor and patch numbers of a version such as
+// v1.4.2 or 1.4.2-rc1
+func parseVersion(version string) ([3]int, bool) {
+	var numbers [3]int
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return numbers, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return numbers, false
+		}
+		numbers[i] = n
+	}
+	return numbers, true
+}
+
+// archAliases are the other names release archives use for an architecture
+var archAliases = map[string][]string{
+	"amd64": {"amd64", "x86_64", "x64"},
+	"386":   {"386", "i386", "x86"},
+	"arm64": {"arm64", "aarch64"},
+	"arm":   {"arm", "armv7", "armv6"},
+}
+
+// osAliases are the other names release archives use for an operating system
+var osAliases = map[string][]string{
+	"darwin":  {"darwin", "macos"},
+	"windows": {"windows", "win"},
+}
+
+// amd64Spellings rewrites the spellings of x86_64 to amd64
+var amd64Spellings = strings.NewReplacer("x86_64", "amd64", "x86-64", "amd64")
+
+// FindAsset returns the release's binary or archive for the platform
+func FindAsset(release *Release, goos string, goarch string) (Asset, error) {
+	osNames := osAliases[goos]
+	if osNames == nil {
+		osNames = []string{goos}
+	}
+	archNames := archAliases[goarch]
+	if archNames == nil {
+		archNames = []string{goarch}
+	}
+
+	for _, asset := range release.Assets {
+		name := strings.ToLower(asset.Name)
+		if isChecksumFile(asset.Name) || strings.HasSuffix(name, ".sig") || strings.HasSuffix(name, ".pem") {
+			continue
+		}
+		// x86_64 would otherwise split into x86 and 64 like any other separator
+		base := amd64Spellings.Replace(strings.TrimSuffix(strings.TrimSuffix(name, ".exe"), archiveExt(name)))
+		fields := strings.FieldsFunc(base, func(r rune) bool {
+			return r == '_' || r == '-' || r == '.'
+		})
+		if containsAny(fields, osNames) && containsAny(fields, archNames) {
+			return asset, nil
+		}
+	}
+	return Asset{}, fmt.Errorf("%w (%s/%s)", ErrNoAsset, goos, goarch)
+}
+
+// containsAny reports whether any of names is one of fields
+func containsAny(fields []string, names []string) bool {
+	for _, field := range fields {
+		for _, name := range names {
+			if field == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isChecksumFile reports whether an asset is the release's checksums file
+func isChecksumFile(name string) bool {
+	for _, checksums := range checksumFiles {
+		if strings.EqualFold(name, checksums) || strings.HasSuffix(strings.ToLower(name), "_"+strings.ToLower(checksums)) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveExt returns the archive extension of an asset name, if any
+func archiveExt(name string) string {
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// Download fetches the platform's binary from a release and verifies it
+// against the release's checksums, returning the executable's contents
+func Download(ctx context.Context, release *Release, options Options) ([]byte, error) {
+	goos, goarch := options.OS, options.Arch
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	if options.PublicKey == "" && !options.AllowUnsigned {
+		return nil, ErrUnsigned
+	}
+	asset, err := FindAsset(release, goos, goarch)
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := checksums(ctx, release, options)
+	if err != nil {
+		return nil, err
+	}
+	want, ok := sums[asset.Name]
+	if !ok {
+		return nil, fmt.Errorf("the release checksums don't cover %s", asset.Name)
+	}
+
+	data, err := fetch(ctx, options, asset.URL, "application/octet-stream", assetLimit(asset, maxBinarySize))
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %v", asset.Name, err)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %s, want %s", asset.Name, got, want)
+	}
+
+	binary := "subscan"
+	if goos == "windows" {
+		binary += ".exe"
+	}
+	return extract(asset.Name, data, binary)
+}
+
+// checksums downloads the release's checksums file, verifies its signature
+// when a public key is configured and returns the sums by file name
+func checksums(ctx context.Context, release *Release, options Options) (map[string]string, error) {
+	var file *Asset
+	for i := range release.Assets {
+		if isChecksumFile(release.Assets[i].Name) {
+			file = &release.Assets[i]
+			break
+		}
+	}
+	if file == nil {
+		return nil, errors.New("the release has no checksums file; refusing to install an unverified binary")
+	}
+	data, err := fetch(ctx, options, file.URL, "application/octet-stream", assetLimit(*file, maxMetadataSize))
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %v", file.Name, err)
+	}
+
+	if options.PublicKey != "" {
+		if err := verifySignature(ctx, release, file.Name, data, options); err != nil {
+			return nil, err
+		}
+	}
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return sums, nil
+}
+
+// verifySignature checks the ed25519 signature published next to the
+// checksums file as <name>.sig
+func verifySignature(ctx context.Context, release *Release, name string, data []byte, options Options) error {
+	key, err := base64.StdEncoding.DecodeString(options.PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid release public key: want a base64 ed25519 key")
+	}
+	var signatureAsset *Asset
+	for i := range release.Assets {
+		if release.Assets[i].Name == name+".sig" {
+			signatureAsset = &release.Assets[i]
+		}
+	}
+	if signatureAsset == nil {
+		return fmt.Errorf("the release has no signature for %s; refusing to install an unverified binary", name)
+	}
+	signature, err := fetch(ctx, options, signatureAsset.URL, "application/octet-stream", assetLimit(*signatureAsset, maxMetadataSize))
+	if err != nil {
+		return fmt.Errorf("could not download the signature of %s: %v", name, err)
+	}
+	// Signatures are published raw or base64-encoded
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil {
+		signature = decoded
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), data, signature) {
+		return fmt.Errorf("the signature of %s doesn't match the release key", name)
+	}
+	return nil
+}
+
+// extract returns binary from an archive asset, or the asset itself when it
+// is a bare executable
+func extract(name string, data []byte, binary string) ([]byte, error) {
+	switch archiveExt(strings.ToLower(name)) {
+	case ".tar.gz", ".tgz":
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %v", name, err)
+		}
+		archive := tar.NewReader(gz)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("could not read %s: %v", name, err)
+			}
+			if header.Typeflag == tar.TypeReg && path.Base(header.Name) == binary {
+				return readLimited(archive, maxBinarySize)
+			}
+		}
+	case ".zip":
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %v", name, err)
+		}
+		for _, file := range archive.File {
+			if path.Base(file.Name) != binary {
+				continue
+			}
+			reader, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer reader.Close()
+			return readLimited(reader, maxBinarySize)
+		}
+	default:
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s has no %s executable", name, binary)
+}
+
+// Replace swaps the executable at target for binary. The new binary is
+// written next to it and renamed over it, so an interrupted update leaves
+// the old binary in place.
+func Replace(target string, binary []byte) error {
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(target)
+	temp, err := os.CreateTemp(dir, ".subscan-update-")
+	if err != nil {
+		return fmt.Errorf("could not write to %s: %v", dir, err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(binary); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), info.Mode().Perm()|0111); err != nil {
+		return err
+	}
+
+	// Windows can't replace a running executable, but it can rename it
+	if runtime.GOOS == "windows" {
+		old := target + ".old"
+		os.Remove(old)
+		if err := os.Rename(target, old); err != nil {
+			return err
+		}
+		if err := os.Rename(temp.Name(), target); err != nil {
+			os.Rename(old, target)
+			return err
+		}
+		return nil
+	}
+	return os.Rename(temp.Name(), target)
+}
+
+// assetLimit returns the most bytes to read for an asset: its published size,
+// capped at max, or max when the release doesn't give one
+func assetLimit(asset Asset, max int64) int64 {
+	if asset.Size > 0 && asset.Size < max {
+		return asset.Size
+	}
+	return max
+}
+
+// readLimited reads r to the end, failing when it holds more than limit bytes
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("larger than the expected %d bytes", limit)
+	}
+	return data, nil
+}
+
+// fetch GETs a URL and returns its body, reading at most limit bytes
+func fetch(ctx context.Context, options Options, address string, accept string, limit int64) ([]byte, error) {
+	// Unlike the scanning clients, certificates are verified: the binary
+	// is only as trustworthy as the connection it's downloaded over
+	client := &http.Client{
+		Timeout:   options.Timeout,
+		Transport: &http.Transport{Proxy: httpclient.ProxyFunc(options.Proxy)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", "subscan-self-update")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: HTTP %d", address, resp.StatusCode)
+	}
+	data, err := readLimited(resp.Body, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", address, err)
+	}
+	return data, nil
+}
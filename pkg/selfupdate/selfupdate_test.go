@@ -0,0 +1,250 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"1.2.0", "v1.3.0", true},
+		{"v1.2.0", "1.2.1", true},
+		{"1.2.0", "2.0.0", true},
+		{"1.10.0", "1.9.9", false},
+		{"1.2.0", "1.2.0", false},
+		{"1.2.0", "v1.2.0-rc1", false},
+		{"dev", "1.0.0", true},
+		{"1.0.0", "nightly", false},
+		{"1.0.0", "1.0.0.1", false},
+	}
+	for _, test := range tests {
+		if got := Newer(test.current, test.latest); got != test.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", test.current, test.latest, got, test.want)
+		}
+	}
+}
+
+func TestFindAsset(t *testing.T) {
+	release := &Release{Assets: []Asset{
+		{Name: "checksums.txt"},
+		{Name: "checksums.txt.sig"},
+		{Name: "subscan_1.2.0_linux_x86_64.tar.gz"},
+		{Name: "subscan_1.2.0_linux_arm64.tar.gz"},
+		{Name: "subscan_1.2.0_macos_arm64.zip"},
+		{Name: "subscan-windows-amd64.exe"},
+	}}
+	tests := []struct {
+		goos, goarch, want string
+	}{
+		{"linux", "amd64", "subscan_1.2.0_linux_x86_64.tar.gz"},
+		{"linux", "arm64", "subscan_1.2.0_linux_arm64.tar.gz"},
+		{"darwin", "arm64", "subscan_1.2.0_macos_arm64.zip"},
+		{"windows", "amd64", "subscan-windows-amd64.exe"},
+	}
+	for _, test := range tests {
+		asset, err := FindAsset(release, test.goos, test.goarch)
+		if err != nil || asset.Name != test.want {
+			t.Errorf("FindAsset(%s/%s) = %q, %v, want %q", test.goos, test.goarch, asset.Name, err, test.want)
+		}
+	}
+	if _, err := FindAsset(release, "freebsd", "amd64"); !errors.Is(err, ErrNoAsset) {
+		t.Errorf("FindAsset(freebsd/amd64) error = %v, want ErrNoAsset", err)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho subscan\n")
+
+	var tarball bytes.Buffer
+	gz := gzip.NewWriter(&tarball)
+	archive := tar.NewWriter(gz)
+	for _, name := range []string{"subscan_1.2.0/README.md", "subscan_1.2.0/subscan"} {
+		archive.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+		archive.Write(binary)
+	}
+	archive.Close()
+	gz.Close()
+
+	var zipped bytes.Buffer
+	zipArchive := zip.NewWriter(&zipped)
+	w, _ := zipArchive.Create("subscan.exe")
+	w.Write(binary)
+	zipArchive.Close()
+
+	tests := []struct {
+		name, binary string
+		data         []byte
+	}{
+		{"subscan_linux_amd64.tar.gz", "subscan", tarball.Bytes()},
+		{"subscan_windows_amd64.zip", "subscan.exe", zipped.Bytes()},
+		{"subscan_linux_amd64", "subscan", binary},
+	}
+	for _, test := range tests {
+		got, err := extract(test.name, test.data, test.binary)
+		if err != nil || !bytes.Equal(got, binary) {
+			t.Errorf("extract(%s) = %q, %v, want %q", test.name, got, err, binary)
+		}
+	}
+	if _, err := extract("subscan_windows_amd64.zip", zipped.Bytes(), "subscan"); err == nil {
+		t.Error("extract found a binary missing from the archive")
+	}
+}
+
+// testRelease serves a release whose binary, checksums and signature can be
+// tampered with
+type testRelease struct {
+	binary    []byte
+	checksums string
+	signature []byte
+	// sizes overrides the published asset sizes
+	sizes map[string]int64
+}
+
+func (r *testRelease) serve(t *testing.T) (*Release, func()) {
+	files := map[string][]byte{
+		"subscan_linux_amd64": r.binary,
+		"checksums.txt":       []byte(r.checksums),
+	}
+	if r.signature != nil {
+		files["checksums.txt.sig"] = r.signature
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, ok := files[strings.TrimPrefix(req.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(data)
+	}))
+
+	release := &Release{Tag: "v1.2.0"}
+	for name, data := range files {
+		size := int64(len(data))
+		if override, ok := r.sizes[name]; ok {
+			size = override
+		}
+		release.Assets = append(release.Assets, Asset{Name: name, URL: server.URL + "/" + name, Size: size})
+	}
+	return release, server.Close
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownload(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPublic, _, _ := ed25519.GenerateKey(rand.Reader)
+	key := base64.StdEncoding.EncodeToString(public)
+
+	binary := []byte("subscan binary")
+	checksums := fmt.Sprintf("%s  subscan_linux_amd64\n", sha256Hex(binary))
+	signature := ed25519.Sign(private, []byte(checksums))
+
+	tests := []struct {
+		name      string
+		release   testRelease
+		publicKey string
+		unsigned  bool
+		wantErr   string
+	}{
+		{
+			name:      "signed",
+			release:   testRelease{binary: binary, checksums: checksums, signature: signature},
+			publicKey: key,
+		},
+		{
+			name:      "base64 signature",
+			release:   testRelease{binary: binary, checksums: checksums, signature: []byte(base64.StdEncoding.EncodeToString(signature))},
+			publicKey: key,
+		},
+		{
+			name:     "unsigned allowed",
+			release:  testRelease{binary: binary, checksums: checksums},
+			unsigned: true,
+		},
+		{
+			name:    "unsigned refused",
+			release: testRelease{binary: binary, checksums: checksums},
+			wantErr: ErrUnsigned.Error(),
+		},
+		{
+			name:      "missing signature",
+			release:   testRelease{binary: binary, checksums: checksums},
+			publicKey: key,
+			wantErr:   "no signature",
+		},
+		{
+			name:      "wrong key",
+			release:   testRelease{binary: binary, checksums: checksums, signature: signature},
+			publicKey: base64.StdEncoding.EncodeToString(otherPublic),
+			wantErr:   "doesn't match",
+		},
+		{
+			name:      "tampered checksums",
+			release:   testRelease{binary: []byte("evil"), checksums: fmt.Sprintf("%s  subscan_linux_amd64\n", sha256Hex([]byte("evil"))), signature: signature},
+			publicKey: key,
+			wantErr:   "doesn't match",
+		},
+		{
+			name:      "tampered binary",
+			release:   testRelease{binary: []byte("evil binary"), checksums: checksums, signature: signature},
+			publicKey: key,
+			wantErr:   "checksum mismatch",
+		},
+		{
+			name:      "binary larger than published",
+			release:   testRelease{binary: binary, checksums: checksums, signature: signature, sizes: map[string]int64{"subscan_linux_amd64": 4}},
+			publicKey: key,
+			wantErr:   "larger than",
+		},
+		{
+			name:      "invalid key",
+			release:   testRelease{binary: binary, checksums: checksums, signature: signature},
+			publicKey: "not a key",
+			wantErr:   "invalid release public key",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			release, stop := test.release.serve(t)
+			defer stop()
+
+			options := DefaultOptions()
+			options.OS, options.Arch = "linux", "amd64"
+			options.PublicKey = test.publicKey
+			options.AllowUnsigned = test.unsigned
+			got, err := Download(context.Background(), release, options)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Download() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, binary) {
+				t.Fatalf("Download() = %q, %v, want %q", got, err, binary)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package sink delivers the results of a scan to their destinations as soon
+// as they are produced, so one run can stream to a file, standard output and
+// a webhook at the same time.
+package sink
+
+import (
+	"github.com/omerimzali/subscan/pkg/probe"
+	"github.com/omerimzali/subscan/pkg/resolver"
+	"github.com/omerimzali/subscan/pkg/scorer"
+)
+
+// Result kinds, telling which stage produced a result
+const (
+	KindRecord = "record"
+	KindScore  = "score"
+	KindProbe  = "probe"
+)
+
+// Result is one result of a scan. Exactly one of Record, Subdomain and Probe
+// is set, depending on the last stage the scan runs.
+type Result struct {
+	// Target is the scanned apex domain, empty for scans of a host list
+	Target    string
+	Record    *resolver.DNSRecord
+	Subdomain *scorer.SubdomainInfo
+	Probe     *probe.ProbeResult
+}
+
+// Kind returns the kind of the result
+func (r Result) Kind() string {
+	switch {
+	case r.Subdomain != nil:
+		return KindScore
+	case r.Probe != nil:
+		return KindProbe
+	default:
+		return KindRecord
+	}
+}
+
+// OutputSink receives the results of a run
+type OutputSink interface {
+	// Start prepares the destination before the first result
+	Start() error
+	// WriteResult delivers a result. It is called from the stage workers and
+	// must be safe for concurrent use.
+	WriteResult(result Result) error
+	// Close flushes and releases the destination once the run is done
+	Close() error
+}
+
+// Multi sends every result to each of its sinks
+type Multi []OutputSink
+
+// Start starts every sink, stopping at the first failure
+func (m Multi) Start() error {
+	for _, s := range m {
+		if err := s.Start(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteResult writes the result to every sink, even after one fails, and
+// returns the first error
+func (m Multi) WriteResult(result Result) error {
+	var first error
+	for _, s := range m {
+		if err := s.WriteResult(result); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close closes every sink and returns the first error
+func (m Multi) Close() error {
+	var first error
+	for _, s := range m {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Filter passes on to its sink only the results Keep accepts
+type Filter struct {
+	Sink OutputSink
+	Keep func(Result) bool
+}
+
+// Start starts the sink
+func (f Filter) Start() error {
+	return f.Sink.Start()
+}
+
+// WriteResult sends the result to the sink when it is kept
+func (f Filter) WriteResult(result Result) error {
+	if !f.Keep(result) {
+		return nil
+	}
+	return f.Sink.WriteResult(result)
+}
+
+// Close closes the sink
+func (f Filter) Close() error {
+	return f.Sink.Close()
+}
@@ -0,0 +1,65 @@
+package sink
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/omerimzali/subscan/pkg/formatter"
+)
+
+// Stream writes each result as a line in an output format: human-readable
+// lines for plain, URLs for urls and JSON Lines otherwise
+type Stream struct {
+	path   string
+	format string
+	out    io.Writer
+	file   *os.File
+	writer *formatter.StreamWriter
+}
+
+// NewStream returns a sink writing to w
+func NewStream(w io.Writer, format string) *Stream {
+	return &Stream{out: w, format: format}
+}
+
+// NewFile returns a sink writing to the file at path, created when the sink
+// starts
+func NewFile(path string, format string) *Stream {
+	return &Stream{path: path, format: format}
+}
+
+// Start creates the output file of file sinks
+func (s *Stream) Start() error {
+	if s.path != "" {
+		file, err := os.Create(s.path)
+		if err != nil {
+			return fmt.Errorf("could not create output file: %v", err)
+		}
+		s.file = file
+		s.out = file
+	}
+	s.writer = formatter.NewStreamWriter(s.out, s.format)
+	return nil
+}
+
+// WriteResult writes the result as a line
+func (s *Stream) WriteResult(result Result) error {
+	switch {
+	case result.Subdomain != nil:
+		return s.writer.WriteSubdomain(*result.Subdomain)
+	case result.Probe != nil:
+		return s.writer.WriteProbeResult(*result.Probe)
+	case result.Record != nil:
+		return s.writer.WriteRecord(*result.Record)
+	}
+	return nil
+}
+
+// Close closes the output file of file sinks
+func (s *Stream) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
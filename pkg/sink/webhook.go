@@ -0,0 +1,70 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/formatter"
+)
+
+// Webhook posts each result as JSON to a URL, wrapped with its target and
+// kind. The result has the shape of a JSON Lines output line.
+type Webhook struct {
+	URL    string
+	client *http.Client
+}
+
+// webhookPayload is the body of a result posted to a webhook
+type webhookPayload struct {
+	Target string          `json:"target,omitempty"`
+	Kind   string          `json:"kind"`
+	Result json.RawMessage `json:"result"`
+}
+
+// NewWebhook returns a sink posting to url
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Start does nothing; results are posted independently
+func (w *Webhook) Start() error {
+	return nil
+}
+
+// WriteResult posts the result, failing on non-2xx answers
+func (w *Webhook) WriteResult(result Result) error {
+	var line bytes.Buffer
+	stream := NewStream(&line, formatter.FormatJSONL)
+	if err := stream.Start(); err != nil {
+		return err
+	}
+	if err := stream.WriteResult(result); err != nil {
+		return err
+	}
+	data, err := json.Marshal(webhookPayload{
+		Target: result.Target,
+		Kind:   result.Kind(),
+		Result: bytes.TrimSpace(line.Bytes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("webhook output failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook output failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// Close does nothing; every result is posted by the time the run is done
+func (w *Webhook) Close() error {
+	return nil
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// spillBucket holds every value of a spill store
+var spillBucket = []byte("results")
+
+// Spill is a temporary on-disk store for scan results, so scans of millions of
+// hosts don't keep every result in memory. Values are JSON encoded and read
+// back in the order of their sort keys. It is safe for concurrent use.
+type Spill struct {
+	db    *bolt.DB
+	path  string
+	count int64
+	seq   uint64
+}
+
+// Open creates a spill store in a new temporary file inside dir, or inside
+// the system temporary directory when dir is empty
+func Open(dir string) (*Spill, error) {
+	file, err := os.CreateTemp(dir, "subscan-spill-*.db")
+	if err != nil {
+		return nil, fmt.Errorf("error creating spill file: %v", err)
+	}
+	path := file.Name()
+	file.Close()
+
+	// The data only lives for one scan, so durability is traded for speed
+	db, err := bolt.Open(path, 0600, &bolt.Options{NoSync: true, NoFreelistSync: true})
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("error opening spill file: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(spillBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		os.Remove(path)
+		return nil, err
+	}
+
+	return &Spill{db: db, path: path}, nil
+}
+
+// Put stores a value under a sort key. Values with equal keys are all kept,
+// in insertion order. Concurrent calls are batched into shared transactions.
+func (s *Spill) Put(sortKey string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	key := make([]byte, len(sortKey)+9)
+	copy(key, sortKey)
+	binary.BigEndian.PutUint64(key[len(sortKey)+1:], atomic.AddUint64(&s.seq, 1))
+
+	err = s.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(spillBucket).Put(key, data)
+	})
+	if err == nil {
+		atomic.AddInt64(&s.count, 1)
+	}
+	return err
+}
+
+// Each calls fn with every stored value in sort key order, stopping at the first error
+func (s *Spill) Each(fn func(data []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(spillBucket).ForEach(func(_, data []byte) error {
+			return fn(data)
+		})
+	})
+}
+
+// Len returns the number of stored values
+func (s *Spill) Len() int {
+	return int(atomic.LoadInt64(&s.count))
+}
+
+// Close closes the store and deletes its file
+func (s *Spill) Close() error {
+	err := s.db.Close()
+	if removeErr := os.Remove(s.path); err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,180 @@
+// Package tags is the registry of the tags Subscan attaches to hosts, with a
+// category, a severity and a description for each, so reports can explain
+// them and automation can act on them without hard-coding their names.
+package tags
+
+import (
+	"path"
+	"sort"
+	"sync"
+)
+
+// Severity ranks how urgent a host carrying a tag is
+type Severity string
+
+// Tag severities, from least to most urgent
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// rank orders severities for sorting
+func (s Severity) rank() int {
+	switch s {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	}
+	return 0
+}
+
+// Tag categories
+const (
+	CategoryStatus   = "status"
+	CategoryContent  = "content"
+	CategoryTLS      = "tls"
+	CategoryRedirect = "redirect"
+	CategoryCloud    = "cloud"
+	CategorySaaS     = "saas"
+	CategoryCMS      = "cms"
+	CategoryPort     = "port"
+	CategoryName     = "name"
+	CategoryTakeover = "takeover"
+	CategoryStorage  = "storage"
+	CategoryExposure = "exposure"
+	CategoryProbe    = "probe"
+	CategoryRule     = "rule"
+	CategoryKeyword  = "keyword"
+)
+
+// Tag describes a tag. Name is the tag itself or, for families of tags such
+// as PORT-8080 and PORT-9200, a glob matching them all, e.g. "PORT-*".
+type Tag struct {
+	Name        string   `json:"name"`
+	Category    string   `json:"category"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+}
+
+var (
+	mu       sync.RWMutex
+	registry = builtin()
+)
+
+// builtin returns the tags Subscan attaches itself. Provider, CMS and keyword
+// tags are registered by the packages that detect them.
+func builtin() []Tag {
+	return []Tag{
+		// Scoring
+		{"[1-5][0-9][0-9]", CategoryStatus, SeverityInfo, "HTTP status code of the host's response"},
+		{"NO-HTTP", CategoryStatus, SeverityInfo, "Neither HTTPS nor HTTP answered"},
+		{"REDIRECT", CategoryRedirect, SeverityInfo, "The host answered with or followed a redirect"},
+		{"OFF-SCOPE-REDIRECT", CategoryRedirect, SeverityLow, "Redirects end outside the scanned domain, hinting at third-party hosting or a takeover"},
+		{"CERT-INVALID", CategoryTLS, SeverityLow, "The TLS certificate is expired or not yet valid"},
+		{"CERT-EXPIRING-SOON", CategoryTLS, SeverityLow, "The TLS certificate expires within the warning period, 30 days by default"},
+		{"SELF-SIGNED", CategoryTLS, SeverityMedium, "The TLS certificate is signed by its own key, typical of internal, development and appliance hosts"},
+		{"WILDCARD-CERT", CategoryTLS, SeverityInfo, "The TLS certificate covers a wildcard name, so its key is likely shared by other hosts"},
+		{"WEAK-TLS", CategoryTLS, SeverityMedium, "Accepts the deprecated TLS 1.0 or 1.1 protocol"},
+		{"WEAK-CIPHER", CategoryTLS, SeverityMedium, "Agrees to an insecure cipher suite such as RC4 or 3DES"},
+		{"LARGE", CategoryContent, SeverityInfo, "The response body is larger than 100 KB"},
+		{"[0-9]*KB", CategoryContent, SeverityInfo, "Size of the response body in kilobytes"},
+		{"PARKED", CategoryContent, SeverityInfo, "A parking or domain-for-sale page"},
+		{"SAME-AS-*", CategoryContent, SeverityInfo, "Serves the same page as the apex or www host, likely a wildcard or catch-all answer"},
+		{"LANG-*", CategoryContent, SeverityInfo, "Language of the page, from its lang attribute or Content-Language header"},
+		{"SAAS-*", CategorySaaS, SeverityInfo, "A tenant of a third-party SaaS provider"},
+		{"*-LOGIN", CategoryCMS, SeverityMedium, "The CMS's login page is reachable"},
+		{"PORT-*", CategoryPort, SeverityLow, "An interesting port is open, as reported by passive sources, the port scan or a web service answering on --http-ports"},
+
+		// Probing
+		{"TAKEOVER-CANDIDATE", CategoryTakeover, SeverityCritical, "The CNAME points at a provider showing its unclaimed-resource page; the subdomain can likely be taken over"},
+		{"PUBLIC-S3", CategoryStorage, SeverityHigh, "An S3 bucket whose contents anyone can list"},
+		{"UNCLAIMED-S3", CategoryStorage, SeverityCritical, "Points at an S3 bucket that doesn't exist and can be registered by anyone"},
+		{"PRIVATE-S3", CategoryStorage, SeverityInfo, "An S3 bucket denying anonymous access"},
+		{"EXPOSED-*", CategoryExposure, SeverityHigh, "A sensitive file, such as .env or .git/config, is publicly readable"},
+		{"OPEN-REDIRECT", CategoryProbe, SeverityMedium, "Redirects to any URL passed in a parameter"},
+		{"ZONE-TRANSFER", CategoryExposure, SeverityHigh, "An authoritative nameserver hands out the whole zone over AXFR to anyone"},
+		{"BLOCKED", CategoryProbe, SeverityInfo, "The host started blocking the probes; its checks are partial"},
+	}
+}
+
+// Register adds a tag to the registry. Tags already registered keep their
+// first description.
+func Register(tag Tag) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, registered := range registry {
+		if registered.Name == tag.Name {
+			return
+		}
+	}
+	if tag.Severity == "" {
+		tag.Severity = SeverityInfo
+	}
+	registry = append(registry, tag)
+}
+
+// All returns the registered tags, most severe first, then by category and name
+func All() []Tag {
+	mu.RLock()
+	all := append([]Tag(nil), registry...)
+	mu.RUnlock()
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Severity.rank() != all[j].Severity.rank() {
+			return all[i].Severity.rank() > all[j].Severity.rank()
+		}
+		if all[i].Category != all[j].Category {
+			return all[i].Category < all[j].Category
+		}
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// Lookup returns the description of a tag, matching exact names before globs
+func Lookup(name string) (Tag, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, tag := range registry {
+		if tag.Name == name {
+			return tag, true
+		}
+	}
+	for _, tag := range registry {
+		if matched, _ := path.Match(tag.Name, name); matched {
+			return tag, true
+		}
+	}
+	return Tag{}, false
+}
+
+// Legend describes each distinct tag in names that the registry knows, under
+// the tag's own name, most severe first
+func Legend(names []string) []Tag {
+	var legend []Tag
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if tag, ok := Lookup(name); ok {
+			tag.Name = name
+			legend = append(legend, tag)
+		}
+	}
+	sort.SliceStable(legend, func(i, j int) bool {
+		if legend[i].Severity.rank() != legend[j].Severity.rank() {
+			return legend[i].Severity.rank() > legend[j].Severity.rank()
+		}
+		return legend[i].Name < legend[j].Name
+	})
+	return legend
+}
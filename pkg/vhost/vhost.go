@@ -0,0 +1,271 @@
+// Package vhost discovers virtual hosts by requesting candidate Host headers
+// from a server directly and comparing the responses with the server's
+// answer for a name it doesn't host. Shared hosting and CDN origins often
+// serve names that have no DNS records, so resolution never finds them.
+package vhost
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omerimzali/subscan/pkg/httpclient"
+	"github.com/omerimzali/subscan/pkg/progress"
+)
+
+// maxBody caps how much of each response is read for the comparison
+const maxBody = 1024 * 1024
+
+// titlePattern extracts the text of the title element
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// Options configures virtual host discovery
+type Options struct {
+	// Scheme is https or http
+	Scheme string
+	// Port is the port requested; the scheme's default when 0
+	Port        int
+	Timeout     time.Duration
+	Concurrency int
+	UserAgent   string
+	// Tolerance is how many bytes a response may differ from the baseline and
+	// still count as the same page, absorbing dynamic content
+	Tolerance int
+	// Proxy tunnels the connections to the server through this proxy; the
+	// environment's proxy when nil (see httpclient.ProxyFunc)
+	Proxy *url.URL
+}
+
+// DefaultOptions returns the default virtual host discovery options
+func DefaultOptions() Options {
+	return Options{
+		Scheme:      "https",
+		Timeout:     10 * time.Second,
+		Concurrency: 20,
+		UserAgent:   "Subscan/1.0",
+		Tolerance:   32,
+	}
+}
+
+// Host is a virtual host the server answered differently for
+type Host struct {
+	Name          string `json:"name"`
+	Address       string `json:"address"`
+	URL           string `json:"url"`
+	Status        int    `json:"status"`
+	ContentLength int    `json:"content_length"`
+	Title         string `json:"title,omitempty"`
+	// InDNS is set when the name also resolves, so only the others are new
+	InDNS bool `json:"in_dns"`
+}
+
+// response is what the comparison looks at
+type response struct {
+	status int
+	// length is the size of the body with the requested name taken out, so
+	// pages echoing the Host header still compare equal
+	length   int
+	title    string
+	location string
+}
+
+// Discover requests each candidate name from the server at address and
+// returns the names it answers differently for than for names it doesn't
+// host. Canceling ctx returns the hosts found so far.
+func Discover(ctx context.Context, address string, candidates []string, options Options) ([]Host, error) {
+	if options.Scheme != "https" && options.Scheme != "http" {
+		return nil, fmt.Errorf("unsupported scheme %q (use https or http)", options.Scheme)
+	}
+	port := options.Port
+	if port == 0 {
+		port = 443
+		if options.Scheme == "http" {
+			port = 80
+		}
+	}
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	client, err := pinnedClient(net.JoinHostPort(address, strconv.Itoa(port)), options)
+	if err != nil {
+		return nil, err
+	}
+
+	// The server's answers for a random name and for its bare address are
+	// what every default or catch-all page looks like
+	names := []string{randomName(candidates)}
+	if !strings.Contains(address, ":") {
+		names = append(names, address)
+	}
+	var baselines []response
+	for _, name := range names {
+		if baseline, err := fetch(ctx, client, name, port, options); err == nil {
+			baselines = append(baselines, baseline)
+		}
+	}
+	if len(baselines) == 0 {
+		return nil, fmt.Errorf("%s did not answer on %s port %d", address, options.Scheme, port)
+	}
+
+	var hosts []Host
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	tracker := progress.Start("Virtual hosts", len(candidates))
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				resp, err := fetch(ctx, client, name, port, options)
+				tracker.Increment()
+				if err != nil || matchesAny(resp, baselines, options.Tolerance) {
+					continue
+				}
+				mu.Lock()
+				hosts = append(hosts, Host{
+					Name:          name,
+					Address:       address,
+					URL:           httpclient.BaseURL(options.Scheme, name, port),
+					Status:        resp.status,
+					ContentLength: resp.length,
+					Title:         resp.title,
+					InDNS:         resolves(ctx, name, options.Timeout),
+				})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, name := range candidates {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+	tracker.Finish()
+	return hosts, nil
+}
+
+// pinnedClient returns an HTTP client sending every request to address,
+// whatever its URL, so the name requested is used as both the Host header
+// and the TLS server name. Through a proxy, the connection is tunneled to
+// address, since a proxy given the URL would resolve the name itself.
+func pinnedClient(address string, options Options) (*http.Client, error) {
+	var proxy *url.URL
+	if selectProxy := httpclient.ProxyFunc(options.Proxy); selectProxy != nil {
+		var err error
+		proxy, err = selectProxy(&http.Request{URL: &url.URL{Scheme: options.Scheme, Host: address}})
+		if err != nil {
+			return nil, err
+		}
+	}
+	dialer := &net.Dialer{Timeout: options.Timeout}
+	return &http.Client{
+		Timeout: options.Timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				if proxy != nil {
+					return httpclient.DialProxy(ctx, dialer, proxy, address)
+				}
+				return dialer.DialContext(ctx, network, address)
+			},
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true}, // Names are tested, not certificates
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}, nil
+}
+
+// fetch requests the root page of name and summarizes the response
+func fetch(ctx context.Context, client *http.Client, name string, port int, options Options) (response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", httpclient.BaseURL(options.Scheme, name, port)+"/", nil)
+	if err != nil {
+		return response{}, err
+	}
+	if options.UserAgent != "" {
+		req.Header.Set("User-Agent", options.UserAgent)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+
+	body = bytes.ReplaceAll(body, []byte(name), nil)
+	location := strings.ReplaceAll(resp.Header.Get("Location"), name, "")
+	return response{
+		status:   resp.StatusCode,
+		length:   len(body),
+		title:    pageTitle(body),
+		location: location,
+	}, nil
+}
+
+// matchesAny reports whether resp looks like one of the baselines
+func matchesAny(resp response, baselines []response, tolerance int) bool {
+	for _, baseline := range baselines {
+		if resp.status != baseline.status || resp.title != baseline.title || resp.location != baseline.location {
+			continue
+		}
+		diff := resp.length - baseline.length
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= tolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// randomName returns a name no server hosts, under the domain of the first
+// candidate so servers matching on the domain answer as they would for an
+// unknown subdomain
+func randomName(candidates []string) string {
+	label := make([]byte, 8)
+	rand.Read(label)
+	name := "subscan-" + hex.EncodeToString(label)
+	if len(candidates) > 0 {
+		if parts := strings.SplitN(candidates[0], ".", 2); len(parts) == 2 {
+			return name + "." + parts[1]
+		}
+	}
+	return name + ".invalid"
+}
+
+// resolves reports whether name has DNS records
+func resolves(ctx context.Context, name string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	addresses, err := net.DefaultResolver.LookupHost(ctx, name)
+	return err == nil && len(addresses) > 0
+}
+
+// pageTitle returns the text of the page's title element
+func pageTitle(body []byte) string {
+	match := titlePattern.FindSubmatch(body)
+	if match == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+}